@@ -2,27 +2,81 @@ package onvif
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/0x524a/onvif-go/internal/soap"
+)
+
+// Version is the version of this library
+const Version = "1.0.0"
+
+// DefaultUserAgent is the User-Agent sent with every request unless
+// WithUserAgent overrides it
+const DefaultUserAgent = "onvif-go/" + Version
+
+// PasswordMode selects how the WS-Security UsernameToken carries the password
+type PasswordMode int
+
+const (
+	// PasswordDigest sends Base64(SHA1(nonce + created + password)). This is
+	// the default and what ONVIF requires.
+	PasswordDigest PasswordMode = iota
+
+	// PasswordText sends the password in plain text, for firmware that never
+	// implemented digests correctly. Use it only on trusted networks or over HTTPS.
+	PasswordText
 )
 
 // Client represents an ONVIF client for communicating with IP cameras
 type Client struct {
-	endpoint   string
-	username   string
-	password   string
-	httpClient *http.Client
-	mu         sync.RWMutex
-	
+	endpoint     string
+	username     string
+	password     string
+	userAgent    string
+	passwordMode PasswordMode
+	noAuth       bool                     // Never send credentials, even if set
+	addressing   bool                     // Send WS-Addressing headers with every call
+	opTimeouts   map[string]time.Duration // Per-operation timeouts replacing the HTTP client timeout
+	rewriteXAddr func(xaddr string) string
+	metrics      MetricsRecorder
+	requestIDHdr string // HTTP header carrying the context's request ID, if set
+	sortProfiles bool   // Sort GetProfiles results by token
+	validateMove bool   // Check AbsoluteMove and RelativeMove vectors against the node's ranges
+	validateEnc  bool   // Check SetVideoEncoderConfiguration against fetched encoder options
+	dialContext  func(ctx context.Context, network, addr string) (net.Conn, error)
+	responseHook func(op string, err error)
+	httpClient   *http.Client
+	mu           sync.RWMutex
+	clockSkew    time.Duration // Device clock minus local clock, learned after a NotAuthorized fault
+	soap         *soap.Client  // Shared by all calls, built on first use and reset when credentials change
+	closed       bool          // Set by Close; calls then fail with ErrClientClosed
+
 	// Service endpoints
-	mediaEndpoint   string
-	ptzEndpoint     string
-	imagingEndpoint string
-	eventEndpoint   string
+	mediaEndpoint     string
+	media2Endpoint    string // Looked up on first use under mu, see media2ServiceEndpoint
+	ptzEndpoint       string
+	imagingEndpoint   string
+	eventEndpoint     string
+	analyticsEndpoint string
+	deviceIOEndpoint  string
+	recordingEndpoint string
+	searchEndpoint    string
+	replayEndpoint    string
+
+	streamingCapabilities *StreamingCapabilities // Reported with the media service by Initialize
+	moveNodes             map[string]*PTZNode    // PTZ node of each profile, looked up for move validation under mu
+	features              Features               // Set by Initialize, Bootstrap and DetectFeatures under mu
+
+	// Options fetched with GetVideoEncoderConfigurationOptions per
+	// configuration token, for encoder validation under mu
+	encoderOptions map[string]*VideoEncoderConfigurationOptions
 }
 
 // ClientOption is a functional option for configuring the Client
@@ -42,6 +96,16 @@ func WithHTTPClient(httpClient *http.Client) ClientOption {
 	}
 }
 
+// WithContextDialer sets how the client opens TCP connections, e.g. through
+// an SSH tunnel or a SOCKS proxy. It replaces the DialContext of a copy of
+// the HTTP client's transport, which must be an *http.Transport (or nil for
+// the default), so a client passed to WithHTTPClient is left unchanged.
+func WithContextDialer(dial func(ctx context.Context, network, addr string) (net.Conn, error)) ClientOption {
+	return func(c *Client) {
+		c.dialContext = dial
+	}
+}
+
 // WithCredentials sets the authentication credentials
 func WithCredentials(username, password string) ClientOption {
 	return func(c *Client) {
@@ -50,6 +114,175 @@ func WithCredentials(username, password string) ClientOption {
 	}
 }
 
+// WithUserAgent sets the User-Agent header sent with every request. Some
+// firmware treats unknown agents differently, so this can be used to present
+// the agent of a client the device is known to work with.
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithPasswordMode selects how credentials are sent in the WS-Security header
+func WithPasswordMode(mode PasswordMode) ClientOption {
+	return func(c *Client) {
+		c.passwordMode = mode
+	}
+}
+
+// WithoutAuthentication disables authentication for cameras that have it
+// turned off and fault on an unexpected WS-Security header. No credentials are
+// sent even if they are set. Without this option, the Security header is only
+// sent when both a username and a password are set.
+func WithoutAuthentication() ClientOption {
+	return func(c *Client) {
+		c.noAuth = true
+	}
+}
+
+// WithEndpointRewrite sets a function applied by Initialize to every service
+// address the device reports. Use it when the device is reached through NAT
+// or a port forward and advertises addresses that are not reachable from the
+// client, for example by replacing the host with the one given to NewClient.
+// It replaces the default, which only rewrites addresses whose host is
+// unspecified (0.0.0.0) or loopback to the host given to NewClient, keeping
+// the reported port.
+func WithEndpointRewrite(rewrite func(xaddr string) string) ClientOption {
+	return func(c *Client) {
+		c.rewriteXAddr = rewrite
+	}
+}
+
+// WithOperationTimeouts sets timeouts for individual operations, keyed by
+// operation name (e.g. "SystemReboot"). They replace the client timeout for
+// those operations, which can then take longer or shorter; every other
+// operation keeps the client timeout.
+func WithOperationTimeouts(timeouts map[string]time.Duration) ClientOption {
+	return func(c *Client) {
+		c.opTimeouts = make(map[string]time.Duration, len(timeouts))
+		for op, d := range timeouts {
+			c.opTimeouts[op] = d
+		}
+	}
+}
+
+// WithAddressing adds WS-Addressing MessageID, ReplyTo, To and Action headers
+// to every request, for devices that reject requests without them. Event
+// service requests always carry them.
+func WithAddressing() ClientOption {
+	return func(c *Client) {
+		c.addressing = true
+	}
+}
+
+// MetricsRecorder receives the outcome of every ONVIF operation, for example to
+// export request counts, latency and error rates
+type MetricsRecorder interface {
+	// ObserveCall is called after each operation with its name (e.g.
+	// "GetProfiles"), how long it took including any retry, and its error
+	ObserveCall(op string, d time.Duration, err error)
+}
+
+// WithMetrics reports every SOAP operation of the client to m. Use one
+// recorder per client, or one that knows the camera, to get per-camera metrics.
+func WithMetrics(m MetricsRecorder) ClientOption {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
+// ContextMetricsRecorder is a MetricsRecorder that also receives the context
+// of each operation, for example to read its request ID with
+// RequestIDFromContext or to end a tracing span. ObserveCallContext is called
+// instead of ObserveCall.
+type ContextMetricsRecorder interface {
+	MetricsRecorder
+	ObserveCallContext(ctx context.Context, op string, d time.Duration, err error)
+}
+
+// observeCall reports an operation to the client's metrics recorder
+func (c *Client) observeCall(ctx context.Context, op string, d time.Duration, err error) {
+	if m, ok := c.metrics.(ContextMetricsRecorder); ok {
+		m.ObserveCallContext(ctx, op, d, err)
+		return
+	}
+	c.metrics.ObserveCall(op, d, err)
+}
+
+// WithResponseHook calls hook for each part of a response that the client
+// left out because it could not be decoded, with the operation name and the
+// decode error. GetProfiles, for example, returns a profile with a malformed
+// section set to nil instead of failing. Without a hook such problems go
+// unreported.
+func WithResponseHook(hook func(op string, err error)) ClientOption {
+	return func(c *Client) {
+		c.responseHook = hook
+	}
+}
+
+// reportMalformed passes a decode error of part of a response to the hook
+// set by WithResponseHook
+func (c *Client) reportMalformed(op string, err error) {
+	if c.responseHook != nil {
+		c.responseHook(op, err)
+	}
+}
+
+// ContextWithRequestID returns a copy of ctx carrying a request ID, which
+// lets operations made with it be correlated in logs and metrics. See
+// ContextMetricsRecorder and WithRequestIDHeader.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return soap.WithRequestID(ctx, id)
+}
+
+// RequestIDFromContext returns the request ID set by ContextWithRequestID, or
+// an empty string
+func RequestIDFromContext(ctx context.Context) string {
+	return soap.RequestID(ctx)
+}
+
+// WithRequestIDHeader sends the request ID of each operation's context, set
+// by ContextWithRequestID, in the named HTTP header, e.g. "X-Request-ID", so
+// proxies and devices that log headers can be correlated too
+func WithRequestIDHeader(name string) ClientOption {
+	return func(c *Client) {
+		c.requestIDHdr = name
+	}
+}
+
+// WithStableProfileOrder sorts the profiles returned by GetProfiles by token,
+// so selecting the first profile gives the same result on every call.
+// Without it profiles keep the device's order, which is camera-dependent.
+func WithStableProfileOrder() ClientOption {
+	return func(c *Client) {
+		c.sortProfiles = true
+	}
+}
+
+// WithMoveValidation makes AbsoluteMove and RelativeMove check the vector
+// against the ranges of the profile's PTZ node and return ErrOutOfRange
+// instead of sending it, since cameras variously clamp or fault on values
+// outside them. The node is looked up with GetProfiles and GetNode on the
+// first move of each profile; if its ranges cannot be determined, moves are
+// sent unchecked.
+func WithMoveValidation() ClientOption {
+	return func(c *Client) {
+		c.validateMove = true
+	}
+}
+
+// WithEncoderValidation makes SetVideoEncoderConfiguration check the
+// resolution, quality, frame rate and bitrate against the options last
+// fetched with GetVideoEncoderConfigurationOptions for the configuration's
+// token, and return ErrInvalidEncoderConfig instead of sending a value the
+// device would reject. Configurations whose options were not fetched are
+// sent unchecked.
+func WithEncoderValidation() ClientOption {
+	return func(c *Client) {
+		c.validateEnc = true
+	}
+}
+
 // NewClient creates a new ONVIF client
 // The endpoint can be provided in multiple formats:
 //   - Full URL: "http://192.168.1.100/onvif/device_service"
@@ -63,7 +296,8 @@ func NewClient(endpoint string, opts ...ClientOption) (*Client, error) {
 	}
 
 	client := &Client{
-		endpoint: normalizedEndpoint,
+		endpoint:  normalizedEndpoint,
+		userAgent: DefaultUserAgent,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 			Transport: &http.Transport{
@@ -79,9 +313,35 @@ func NewClient(endpoint string, opts ...ClientOption) (*Client, error) {
 		opt(client)
 	}
 
+	if client.dialContext != nil {
+		if err := client.useDialer(); err != nil {
+			return nil, err
+		}
+	}
+
 	return client, nil
 }
 
+// useDialer switches the HTTP client to a copy whose transport dials with
+// the dialer set by WithContextDialer
+func (c *Client) useDialer() error {
+	var transport *http.Transport
+	switch t := c.httpClient.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return fmt.Errorf("%w: WithContextDialer needs an *http.Transport, got %T", ErrInvalidParameter, t)
+	}
+	transport.DialContext = c.dialContext
+
+	httpClient := *c.httpClient
+	httpClient.Transport = transport
+	c.httpClient = &httpClient
+	return nil
+}
+
 // normalizeEndpoint converts various endpoint formats to a full ONVIF URL
 func normalizeEndpoint(endpoint string) (string, error) {
 	// Check if endpoint starts with a scheme
@@ -108,7 +368,7 @@ func normalizeEndpoint(endpoint string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("invalid IP address or hostname: %w", err)
 	}
-	
+
 	if parsedURL.Host == "" {
 		return "", fmt.Errorf("invalid endpoint format")
 	}
@@ -124,21 +384,147 @@ func (c *Client) Initialize(ctx context.Context) error {
 		return fmt.Errorf("failed to get capabilities: %w", err)
 	}
 
-	// Extract service endpoints
+	c.applyCapabilities(capabilities)
+
+	return nil
+}
+
+// applyCapabilities stores the service addresses listed in capabilities and
+// the features they report
+func (c *Client) applyCapabilities(capabilities *Capabilities) {
+	c.mu.Lock()
+	c.features = capabilityFeatures(capabilities)
+	if capabilities.Media != nil && capabilities.Media.XAddr != "" {
+		c.streamingCapabilities = capabilities.Media.StreamingCapabilities
+	}
+	c.mu.Unlock()
+
 	if capabilities.Media != nil && capabilities.Media.XAddr != "" {
-		c.mediaEndpoint = capabilities.Media.XAddr
+		c.mediaEndpoint = c.serviceXAddr(capabilities.Media.XAddr)
 	}
 	if capabilities.PTZ != nil && capabilities.PTZ.XAddr != "" {
-		c.ptzEndpoint = capabilities.PTZ.XAddr
+		c.ptzEndpoint = c.serviceXAddr(capabilities.PTZ.XAddr)
 	}
 	if capabilities.Imaging != nil && capabilities.Imaging.XAddr != "" {
-		c.imagingEndpoint = capabilities.Imaging.XAddr
+		c.imagingEndpoint = c.serviceXAddr(capabilities.Imaging.XAddr)
 	}
 	if capabilities.Events != nil && capabilities.Events.XAddr != "" {
-		c.eventEndpoint = capabilities.Events.XAddr
+		c.eventEndpoint = c.serviceXAddr(capabilities.Events.XAddr)
+	}
+	if capabilities.Analytics != nil && capabilities.Analytics.XAddr != "" {
+		c.analyticsEndpoint = c.serviceXAddr(capabilities.Analytics.XAddr)
 	}
 
-	return nil
+	// Extension-only services
+	if ext := capabilities.Extension; ext != nil {
+		if ext.DeviceIO != nil && ext.DeviceIO.XAddr != "" {
+			c.deviceIOEndpoint = c.serviceXAddr(ext.DeviceIO.XAddr)
+		}
+		if ext.Recording != nil && ext.Recording.XAddr != "" {
+			c.recordingEndpoint = c.serviceXAddr(ext.Recording.XAddr)
+		}
+		if ext.Search != nil && ext.Search.XAddr != "" {
+			c.searchEndpoint = c.serviceXAddr(ext.Search.XAddr)
+		}
+		if ext.Replay != nil && ext.Replay.XAddr != "" {
+			c.replayEndpoint = c.serviceXAddr(ext.Replay.XAddr)
+		}
+	}
+}
+
+// ServiceEndpoints lists the service addresses found by Initialize. Services
+// the device did not report have an empty address.
+type ServiceEndpoints struct {
+	Media     string `json:"media,omitempty"`
+	PTZ       string `json:"ptz,omitempty"`
+	Imaging   string `json:"imaging,omitempty"`
+	Events    string `json:"events,omitempty"`
+	Analytics string `json:"analytics,omitempty"`
+	DeviceIO  string `json:"device_io,omitempty"`
+	Recording string `json:"recording,omitempty"`
+	Search    string `json:"search,omitempty"`
+	Replay    string `json:"replay,omitempty"`
+}
+
+// BootstrapInfo is what Bootstrap learned about a device. Parts whose request
+// failed are nil.
+type BootstrapInfo struct {
+	DeviceInfo   *DeviceInformation `json:"device_info,omitempty"`
+	Capabilities *Capabilities      `json:"capabilities,omitempty"`
+	Endpoints    ServiceEndpoints   `json:"endpoints"`
+	Profiles     []*Profile         `json:"profiles,omitempty"`
+}
+
+// Bootstrap gets everything needed to start streaming from a device in one
+// call: it runs GetDeviceInformation alongside Initialize, then GetProfiles.
+// The returned info is never nil and holds whatever succeeded; the error
+// joins the failures of the individual requests, if any.
+func (c *Client) Bootstrap(ctx context.Context) (*BootstrapInfo, error) {
+	info := &BootstrapInfo{}
+
+	var deviceErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		info.DeviceInfo, deviceErr = c.GetDeviceInformation(ctx)
+	}()
+
+	var capabilitiesErr, profilesErr error
+	info.Capabilities, capabilitiesErr = c.GetCapabilities(ctx)
+	if capabilitiesErr == nil {
+		c.applyCapabilities(info.Capabilities)
+	}
+	info.Endpoints = ServiceEndpoints{
+		Media:     c.mediaEndpoint,
+		PTZ:       c.ptzEndpoint,
+		Imaging:   c.imagingEndpoint,
+		Events:    c.eventEndpoint,
+		Analytics: c.analyticsEndpoint,
+		DeviceIO:  c.deviceIOEndpoint,
+		Recording: c.recordingEndpoint,
+		Search:    c.searchEndpoint,
+		Replay:    c.replayEndpoint,
+	}
+
+	// Devices that failed GetCapabilities may still serve media on the
+	// device endpoint, which GetProfiles falls back to
+	info.Profiles, profilesErr = c.GetProfiles(ctx)
+
+	<-done
+	if err := errors.Join(deviceErr, capabilitiesErr, profilesErr); err != nil {
+		return info, fmt.Errorf("Bootstrap incomplete: %w", err)
+	}
+	return info, nil
+}
+
+// serviceXAddr applies the endpoint rewrite to a service address reported by
+// the device
+func (c *Client) serviceXAddr(xaddr string) string {
+	if c.rewriteXAddr != nil {
+		return c.rewriteXAddr(xaddr)
+	}
+
+	// Addresses the device cannot mean literally are taken to be on the host
+	// we connected to
+	u, err := url.Parse(xaddr)
+	if err != nil {
+		return xaddr
+	}
+	host := u.Hostname()
+	ip := net.ParseIP(host)
+	if host != "localhost" && (ip == nil || !ip.IsUnspecified() && !ip.IsLoopback()) {
+		return xaddr
+	}
+	device, err := url.Parse(c.endpoint)
+	if err != nil {
+		return xaddr
+	}
+	if port := u.Port(); port != "" {
+		u.Host = net.JoinHostPort(device.Hostname(), port)
+	} else {
+		u.Host = device.Hostname()
+	}
+	return u.String()
 }
 
 // Endpoint returns the device endpoint
@@ -146,12 +532,166 @@ func (c *Client) Endpoint() string {
 	return c.endpoint
 }
 
-// SetCredentials updates the authentication credentials
+// MediaEndpoint returns the media service address found by Initialize, or an
+// empty string if the device did not report one
+func (c *Client) MediaEndpoint() string {
+	return c.mediaEndpoint
+}
+
+// PTZEndpoint returns the PTZ service address found by Initialize, or an
+// empty string if the device did not report one
+func (c *Client) PTZEndpoint() string {
+	return c.ptzEndpoint
+}
+
+// ImagingEndpoint returns the imaging service address found by Initialize, or
+// an empty string if the device did not report one
+func (c *Client) ImagingEndpoint() string {
+	return c.imagingEndpoint
+}
+
+// EventsEndpoint returns the event service address found by Initialize, or an
+// empty string if the device did not report one
+func (c *Client) EventsEndpoint() string {
+	return c.eventEndpoint
+}
+
+// SetCredentials updates the authentication credentials. It is safe to call
+// while other calls are in flight; calls started afterwards use the new
+// credentials, and the service endpoints found by Initialize are kept.
 func (c *Client) SetCredentials(username, password string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.username = username
 	c.password = password
+	c.soap = nil
+}
+
+// soapClient returns the SOAP client shared by all calls, creating it from the
+// current credentials and request settings on first use
+func (c *Client) soapClient() *soap.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.soap != nil {
+		return c.soap
+	}
+
+	username, password := c.username, c.password
+	if c.noAuth {
+		username, password = "", ""
+	}
+	soapClient := soap.NewClient(c.transportClient(), username, password)
+	soapClient.SetUserAgent(c.userAgent)
+	soapClient.SetPlaintextPassword(c.passwordMode == PasswordText)
+	soapClient.SetClockOffset(c.clockSkew)
+	soapClient.SetClockResync(c.syncClock)
+	soapClient.SetAddressing(c.addressing)
+	soapClient.SetOperationTimeouts(c.opTimeouts)
+	soapClient.SetRequestIDHeader(c.requestIDHdr)
+	if c.metrics != nil {
+		soapClient.SetObserver(c.observeCall)
+	}
+	c.soap = soapClient
+	return soapClient
+}
+
+// closedHTTPClient is used for requests after Close
+var closedHTTPClient = &http.Client{Transport: closedTransport{}}
+
+// closedTransport fails every request with ErrClientClosed
+type closedTransport struct{}
+
+// RoundTrip implements http.RoundTripper
+func (closedTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, ErrClientClosed
+}
+
+// transportClient returns the HTTP client requests are sent with, which
+// fails them once the client is closed. The caller must hold mu.
+func (c *Client) transportClient() *http.Client {
+	if c.closed {
+		return closedHTTPClient
+	}
+	return c.httpClient
+}
+
+// doHTTP sends a request that does not go through the SOAP client
+func (c *Client) doHTTP(req *http.Request) (*http.Response, error) {
+	c.mu.RLock()
+	httpClient := c.transportClient()
+	c.mu.RUnlock()
+
+	return httpClient.Do(req)
+}
+
+// Close releases the client's idle HTTP connections. The client is unusable
+// afterwards: every call fails with ErrClientClosed. Closing a client made
+// with WithHTTPClient also closes the idle connections of that HTTP client.
+// Close is safe to call more than once.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	c.soap = nil
+	c.httpClient.CloseIdleConnections()
+
+	return nil
+}
+
+// unauthenticatedSOAPClient creates a SOAP client that never sends
+// credentials, for the calls ONVIF allows before authentication
+func (c *Client) unauthenticatedSOAPClient() *soap.Client {
+	c.mu.RLock()
+	httpClient := c.transportClient()
+	c.mu.RUnlock()
+
+	soapClient := soap.NewClient(httpClient, "", "")
+	soapClient.SetUserAgent(c.userAgent)
+	soapClient.SetAddressing(c.addressing)
+	soapClient.SetOperationTimeouts(c.opTimeouts)
+	soapClient.SetRequestIDHeader(c.requestIDHdr)
+	if c.metrics != nil {
+		soapClient.SetObserver(c.observeCall)
+	}
+	return soapClient
+}
+
+// ClockSkew returns how far the device clock is ahead of the local clock
+// (negative if behind). It is learned when the device rejects credentials,
+// which may be caused by a Created timestamp outside the device's window, and
+// is zero until then.
+func (c *Client) ClockSkew() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.clockSkew
+}
+
+// syncClock measures the device clock against the local clock with an
+// unauthenticated GetSystemDateAndTime and stores the result as the clock skew
+func (c *Client) syncClock(ctx context.Context) (time.Duration, error) {
+	sent := time.Now()
+	sdt, err := c.getSystemDateAndTime(ctx, c.unauthenticatedSOAPClient())
+	if err != nil {
+		return 0, err
+	}
+	if sdt.UTCDateTime.IsZero() {
+		return 0, fmt.Errorf("%w: device did not report UTC time", ErrInvalidResponse)
+	}
+
+	// Compare against the midpoint of the round trip
+	local := sent.Add(time.Since(sent) / 2)
+	skew := sdt.UTCDateTime.Sub(local).Round(time.Second)
+
+	c.mu.Lock()
+	c.clockSkew = skew
+	c.mu.Unlock()
+
+	return skew, nil
 }
 
 // GetCredentials returns the current credentials
@@ -160,3 +700,96 @@ func (c *Client) GetCredentials() (string, string) {
 	defer c.mu.RUnlock()
 	return c.username, c.password
 }
+
+// CallRaw sends an operation the library does not cover, such as a vendor
+// extension, with the client's credentials, HTTP client and fault handling.
+// The request is marshalled as the SOAP body and should name its element and
+// namespace the way the library's own requests do, e.g. with an
+// `xml:"vendor:GetThing"` XMLName and an `xml:"xmlns:vendor,attr"` field. The
+// body of the response is unmarshalled into response unless it is nil. An
+// empty endpoint sends the request to the device service.
+func (c *Client) CallRaw(ctx context.Context, endpoint string, request, response interface{}) error {
+	if endpoint == "" {
+		endpoint = c.endpoint
+	}
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", request, response); err != nil {
+		return fmt.Errorf("%s failed: %w", soap.OperationName(request), err)
+	}
+
+	return nil
+}
+
+// PingStatus classifies the outcome of Ping
+type PingStatus int
+
+const (
+	// PingUnreachable means no HTTP response was received
+	PingUnreachable PingStatus = iota
+
+	// PingNotONVIF means the endpoint answered, but not with an ONVIF response
+	PingNotONVIF
+
+	// PingAuthRequired means the device is ONVIF but refused the
+	// unauthenticated request
+	PingAuthRequired
+
+	// PingOK means the device answered GetSystemDateAndTime
+	PingOK
+)
+
+// String returns the name of the status
+func (s PingStatus) String() string {
+	switch s {
+	case PingUnreachable:
+		return "unreachable"
+	case PingNotONVIF:
+		return "not ONVIF"
+	case PingAuthRequired:
+		return "auth required"
+	case PingOK:
+		return "ok"
+	default:
+		return fmt.Sprintf("PingStatus(%d)", int(s))
+	}
+}
+
+// PingResult is the outcome of Ping
+type PingResult struct {
+	Status  PingStatus
+	Latency time.Duration // Round trip of the request, zero if unreachable
+}
+
+// Ping checks that the device is reachable and speaks ONVIF with an
+// unauthenticated GetSystemDateAndTime, which ONVIF requires devices to allow
+// without credentials. It does not check the configured credentials. The
+// error is nil only if the status is PingOK and otherwise carries the cause.
+func (c *Client) Ping(ctx context.Context) (PingResult, error) {
+	start := time.Now()
+	_, err := c.getSystemDateAndTime(ctx, c.unauthenticatedSOAPClient())
+	result := PingResult{Status: PingOK, Latency: time.Since(start)}
+	if err == nil {
+		return result, nil
+	}
+
+	var httpErr *soap.HTTPError
+	var urlErr *url.Error
+	switch {
+	case errors.Is(err, soap.ErrNotAuthorized):
+		result.Status = PingAuthRequired
+	case errors.As(err, &httpErr):
+		result.Status = PingNotONVIF
+		if httpErr.StatusCode == http.StatusUnauthorized || httpErr.StatusCode == http.StatusForbidden {
+			result.Status = PingAuthRequired
+		}
+	case ctx.Err() != nil, errors.As(err, &urlErr):
+		result = PingResult{Status: PingUnreachable}
+	default:
+		// A response arrived but could not be decoded
+		result.Status = PingNotONVIF
+	}
+
+	return result, fmt.Errorf("Ping failed: %w", err)
+}
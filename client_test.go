@@ -2,12 +2,22 @@ package onvif
 
 import (
 	"context"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/0x524a/onvif-go/internal/soap"
+	"github.com/0x524a/onvif-go/onviftest"
 )
 
 func TestNormalizeEndpoint(t *testing.T) {
@@ -414,6 +424,655 @@ func TestClientOptions(t *testing.T) {
 	})
 }
 
+func TestUserAgent(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:SetHostnameResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		opts []ClientOption
+		want string
+	}{
+		{"default", nil, "onvif-go/" + Version},
+		{"override", []ClientOption{WithUserAgent("VMS/2.0")}, "VMS/2.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(server.URL, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			if err := client.SetHostname(context.Background(), "camera"); err != nil {
+				t.Fatalf("SetHostname() error = %v", err)
+			}
+			if userAgent != tt.want {
+				t.Errorf("User-Agent = %q, want %q", userAgent, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordMode(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:SetHostnameResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	const (
+		digestType = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordDigest"
+		textType   = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordText"
+	)
+
+	tests := []struct {
+		name      string
+		opts      []ClientOption
+		wantType  string
+		plaintext bool
+	}{
+		{"default digest", nil, digestType, false},
+		{"text", []ClientOption{WithPasswordMode(PasswordText)}, textType, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]ClientOption{WithCredentials("admin", "s3cret")}, tt.opts...)
+			client, err := NewClient(server.URL, opts...)
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			if err := client.SetHostname(context.Background(), "camera"); err != nil {
+				t.Fatalf("SetHostname() error = %v", err)
+			}
+
+			if !strings.Contains(body, `Type="`+tt.wantType+`"`) {
+				t.Errorf("Expected password type %s, got: %s", tt.wantType, body)
+			}
+			if got := strings.Contains(body, `">s3cret</Password>`); got != tt.plaintext {
+				t.Errorf("plaintext password in envelope = %v, want %v: %s", got, tt.plaintext, body)
+			}
+		})
+	}
+}
+
+func TestWithoutAuthentication(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:SetHostnameResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		opts []ClientOption
+	}{
+		{"no credentials", nil},
+		{"username only", []ClientOption{WithCredentials("admin", "")}},
+		{"disabled", []ClientOption{WithCredentials("admin", "password"), WithoutAuthentication()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(server.URL, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			if err := client.SetHostname(context.Background(), "camera"); err != nil {
+				t.Fatalf("SetHostname() error = %v", err)
+			}
+			if strings.Contains(body, "Security") || strings.Contains(body, "UsernameToken") {
+				t.Errorf("Expected no WS-Security header, got: %s", body)
+			}
+		})
+	}
+}
+
+func TestSOAPClientReuse(t *testing.T) {
+	client, err := NewClient("http://192.168.1.100/onvif", WithCredentials("admin", "password"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	first := client.soapClient()
+	if client.soapClient() != first {
+		t.Error("Expected the SOAP client to be reused across calls")
+	}
+
+	client.SetCredentials("operator", "secret")
+	if client.soapClient() == first {
+		t.Error("Expected SetCredentials to replace the SOAP client")
+	}
+}
+
+func TestSetCredentialsRotation(t *testing.T) {
+	var mu sync.Mutex
+	var usernames []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		if m := regexp.MustCompile(`<Username>([^<]*)</Username>`).FindSubmatch(data); m != nil {
+			mu.Lock()
+			usernames = append(usernames, r.URL.Path+" "+string(m[1]))
+			mu.Unlock()
+		}
+
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>
+			</s:Body>
+		</s:Envelope>`
+		if strings.Contains(string(data), "GetCapabilities") {
+			response = `<?xml version="1.0" encoding="UTF-8"?>
+			<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+				<s:Body>
+					<tds:GetCapabilitiesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+						<tds:Capabilities>
+							<tt:Media><tt:XAddr>` + server.URL + `/onvif/media_service</tt:XAddr></tt:Media>
+						</tds:Capabilities>
+					</tds:GetCapabilitiesResponse>
+				</s:Body>
+			</s:Envelope>`
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithCredentials("admin", "old"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := client.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	client.SetCredentials("operator", "new")
+	if _, err := client.GetProfiles(ctx); err != nil {
+		t.Fatalf("GetProfiles() error = %v", err)
+	}
+
+	want := []string{"/onvif/device_service admin", "/onvif/media_service operator"}
+	if strings.Join(usernames, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected requests %v, got %v", want, usernames)
+	}
+}
+
+func TestServiceEndpointAccessors(t *testing.T) {
+	mock := NewMockONVIFServer()
+	defer mock.Close()
+
+	client, err := NewClient(mock.URL())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	if got := client.MediaEndpoint(); got != "" {
+		t.Errorf("MediaEndpoint() before Initialize = %q, want empty", got)
+	}
+
+	if err := client.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"MediaEndpoint", client.MediaEndpoint(), mock.URL() + "/onvif/media_service"},
+		{"PTZEndpoint", client.PTZEndpoint(), mock.URL() + "/onvif/ptz_service"},
+		{"ImagingEndpoint", client.ImagingEndpoint(), ""},
+		{"EventsEndpoint", client.EventsEndpoint(), ""},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s() = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestEndpointRewrite(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:GetCapabilitiesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<tds:Capabilities>
+						<tt:Media><tt:XAddr>http://0.0.0.0:8000/onvif/media_service</tt:XAddr></tt:Media>
+						<tt:PTZ><tt:XAddr>http://10.0.0.5/onvif/ptz_service</tt:XAddr></tt:PTZ>
+					</tds:Capabilities>
+				</tds:GetCapabilitiesResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if err := client.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if got := client.MediaEndpoint(); got != "http://127.0.0.1:8000/onvif/media_service" {
+		t.Errorf("Expected unspecified host to be replaced, got %q", got)
+	}
+	if got := client.PTZEndpoint(); got != "http://10.0.0.5/onvif/ptz_service" {
+		t.Errorf("Expected routable host to be kept, got %q", got)
+	}
+
+	client, err = NewClient(server.URL, WithEndpointRewrite(func(xaddr string) string {
+		return strings.Replace(xaddr, "10.0.0.5", "camera.example.com:8080", 1)
+	}))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if err := client.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if got := client.PTZEndpoint(); got != "http://camera.example.com:8080/onvif/ptz_service" {
+		t.Errorf("Expected rewritten PTZ endpoint, got %q", got)
+	}
+	if got := client.MediaEndpoint(); got != "http://0.0.0.0:8000/onvif/media_service" {
+		t.Errorf("Expected custom rewrite to replace the default, got %q", got)
+	}
+}
+
+func TestWithOperationTimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "SystemReboot") {
+			time.Sleep(150 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:SystemRebootResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+					<tds:Message>Rebooting</tds:Message>
+				</tds:SystemRebootResponse>
+			</s:Body>
+		</s:Envelope>`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	client, err := NewClient(server.URL,
+		WithTimeout(50*time.Millisecond),
+		WithOperationTimeouts(map[string]time.Duration{"SystemReboot": 2 * time.Second}))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if _, err := client.SystemReboot(ctx); err != nil {
+		t.Errorf("Expected SystemReboot to run under its own timeout, got %v", err)
+	}
+
+	client, err = NewClient(server.URL,
+		WithTimeout(2*time.Second),
+		WithOperationTimeouts(map[string]time.Duration{"SystemReboot": 50 * time.Millisecond}))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if _, err := client.SystemReboot(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected SystemReboot to time out, got %v", err)
+	}
+}
+
+func TestCallRaw(t *testing.T) {
+	type GetIRStatus struct {
+		XMLName xml.Name `xml:"vnd:GetIRStatus"`
+		Xmlns   string   `xml:"xmlns:vnd,attr"`
+		Channel int      `xml:"vnd:Channel"`
+	}
+
+	fault := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body := string(data)
+		if !strings.Contains(body, "<vnd:Channel>2</vnd:Channel>") || !strings.Contains(body, "UsernameToken") {
+			t.Errorf("Unexpected request body: %s", body)
+		}
+		if r.URL.Path != "/onvif/device_service" {
+			t.Errorf("Expected request to the device service, got %s", r.URL.Path)
+		}
+
+		if fault {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body><s:Fault><s:Code><s:Value>s:Receiver</s:Value></s:Code></s:Fault></s:Body></s:Envelope>`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<vnd:GetIRStatusResponse xmlns:vnd="http://vendor.example.com/wsdl">
+					<vnd:Enabled>true</vnd:Enabled>
+				</vnd:GetIRStatusResponse>
+			</s:Body>
+		</s:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithCredentials("admin", "password"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	req := GetIRStatus{Xmlns: "http://vendor.example.com/wsdl", Channel: 2}
+	var resp struct {
+		Enabled bool `xml:"Enabled"`
+	}
+	if err := client.CallRaw(context.Background(), "", req, &resp); err != nil {
+		t.Fatalf("CallRaw() error = %v", err)
+	}
+	if !resp.Enabled {
+		t.Error("Expected the response to be decoded")
+	}
+
+	fault = true
+	err = client.CallRaw(context.Background(), server.URL+"/onvif/device_service", req, nil)
+	var httpErr *soap.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected an HTTP error, got %v", err)
+	}
+	if err != nil && !strings.HasPrefix(err.Error(), "GetIRStatus failed") {
+		t.Errorf("Expected the operation name in the error, got %v", err)
+	}
+}
+
+func TestPing(t *testing.T) {
+	sdtResponse := `<?xml version="1.0" encoding="UTF-8"?>
+	<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+		<s:Body>
+			<tds:GetSystemDateAndTimeResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tds:SystemDateAndTime>
+					<tt:DateTimeType>NTP</tt:DateTimeType>
+				</tds:SystemDateAndTime>
+			</tds:GetSystemDateAndTimeResponse>
+		</s:Body>
+	</s:Envelope>`
+	faultResponse := `<?xml version="1.0" encoding="UTF-8"?>
+	<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:ter="http://www.onvif.org/ver10/error">
+		<s:Body>
+			<s:Fault>
+				<s:Code><s:Value>s:Sender</s:Value><s:Subcode><s:Value>ter:NotAuthorized</s:Value></s:Subcode></s:Code>
+				<s:Reason><s:Text xml:lang="en">Sender not authorized</s:Text></s:Reason>
+			</s:Fault>
+		</s:Body>
+	</s:Envelope>`
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    PingStatus
+	}{
+		{"ok", func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			if strings.Contains(string(body), "UsernameToken") {
+				t.Errorf("Ping must not send credentials")
+			}
+			_, _ = w.Write([]byte(sdtResponse))
+		}, PingOK},
+		{"not authorized fault", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(faultResponse))
+		}, PingAuthRequired},
+		{"HTTP 401", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}, PingAuthRequired},
+		{"web page", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("<html><body>Router login</body></html>"))
+		}, PingNotONVIF},
+		{"not found", http.NotFound, PingNotONVIF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			client, err := NewClient(server.URL, WithCredentials("admin", "password"))
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			result, err := client.Ping(context.Background())
+			if result.Status != tt.want {
+				t.Errorf("Ping() status = %v, want %v (error %v)", result.Status, tt.want, err)
+			}
+			if (err == nil) != (tt.want == PingOK) {
+				t.Errorf("Ping() error = %v", err)
+			}
+		})
+	}
+
+	t.Run("unreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+
+		client, err := NewClient(server.URL)
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+
+		result, err := client.Ping(context.Background())
+		if result.Status != PingUnreachable || err == nil {
+			t.Errorf("Ping() = %v, %v; want unreachable", result.Status, err)
+		}
+	})
+}
+
+// recordingMetrics is a MetricsRecorder that keeps every observation
+type recordingMetrics struct {
+	mu   sync.Mutex
+	ops  []string
+	errs []error
+}
+
+func (m *recordingMetrics) ObserveCall(op string, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ops = append(m.ops, op)
+	m.errs = append(m.errs, err)
+}
+
+func TestWithMetrics(t *testing.T) {
+	mock := NewMockONVIFServer()
+	defer mock.Close()
+
+	metrics := &recordingMetrics{}
+	client, err := NewClient(mock.URL(), WithMetrics(metrics))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := client.GetDeviceInformation(ctx); err != nil {
+		t.Fatalf("GetDeviceInformation() error = %v", err)
+	}
+	if _, err := client.GetHostname(ctx); err == nil {
+		t.Fatal("Expected the mock to fault on GetHostname")
+	}
+
+	if strings.Join(metrics.ops, ",") != "GetDeviceInformation,GetHostname" {
+		t.Errorf("Unexpected operations: %v", metrics.ops)
+	}
+	if len(metrics.errs) != 2 || metrics.errs[0] != nil || metrics.errs[1] == nil {
+		t.Errorf("Unexpected errors: %v", metrics.errs)
+	}
+}
+
+type contextMetrics struct {
+	recordingMetrics
+	ids []string
+}
+
+func (m *contextMetrics) ObserveCallContext(ctx context.Context, op string, d time.Duration, err error) {
+	m.ObserveCall(op, d, err)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ids = append(m.ids, RequestIDFromContext(ctx))
+}
+
+func TestRequestID(t *testing.T) {
+	var headers []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Get("X-Request-ID"))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>
+			<tds:GetHostnameResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+				<tds:HostnameInformation><tt:Name xmlns:tt="http://www.onvif.org/ver10/schema">camera</tt:Name></tds:HostnameInformation>
+			</tds:GetHostnameResponse>
+		</s:Body></s:Envelope>`))
+	}))
+	defer server.Close()
+
+	metrics := &contextMetrics{}
+	client, err := NewClient(server.URL, WithMetrics(metrics), WithRequestIDHeader("X-Request-ID"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	ctx := ContextWithRequestID(context.Background(), "req-7")
+	if got := RequestIDFromContext(ctx); got != "req-7" {
+		t.Errorf("RequestIDFromContext() = %q, want req-7", got)
+	}
+	if _, err := client.GetHostname(ctx); err != nil {
+		t.Fatalf("GetHostname() error = %v", err)
+	}
+	if _, err := client.GetHostname(context.Background()); err != nil {
+		t.Fatalf("GetHostname() error = %v", err)
+	}
+
+	if strings.Join(headers, ",") != "req-7," {
+		t.Errorf("Unexpected X-Request-ID headers: %q", headers)
+	}
+	if strings.Join(metrics.ids, ",") != "req-7," || len(metrics.ops) != 2 {
+		t.Errorf("Unexpected observations: %v %v", metrics.ops, metrics.ids)
+	}
+
+	req, err := client.NewMediaRequest(ctx, http.MethodGet, server.URL+"/snapshot.jpg")
+	if err != nil {
+		t.Fatalf("NewMediaRequest() error = %v", err)
+	}
+	if req.Header.Get("X-Request-ID") != "req-7" {
+		t.Errorf("Expected the request ID on media requests, got %q", req.Header.Get("X-Request-ID"))
+	}
+}
+
+func TestBootstrap(t *testing.T) {
+	mock := NewMockONVIFServer()
+	defer mock.Close()
+
+	client, err := NewClient(mock.URL())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	info, err := client.Bootstrap(context.Background())
+	if err != nil {
+		t.Fatalf("Bootstrap() error = %v", err)
+	}
+	if info.DeviceInfo == nil || info.DeviceInfo.Manufacturer != "Test Camera Inc" {
+		t.Errorf("Unexpected device info: %+v", info.DeviceInfo)
+	}
+	if info.Capabilities == nil || info.Endpoints.Media != mock.URL()+"/onvif/media_service" || info.Endpoints.PTZ != mock.URL()+"/onvif/ptz_service" {
+		t.Errorf("Unexpected endpoints: %+v", info.Endpoints)
+	}
+	if client.MediaEndpoint() != info.Endpoints.Media {
+		t.Errorf("Expected Bootstrap to initialize the client, media endpoint %q", client.MediaEndpoint())
+	}
+	if features := client.Features(); !features.Media || !features.PTZ {
+		t.Errorf("Expected Bootstrap to set the media and PTZ features, got %+v", features)
+	}
+	if len(info.Profiles) == 0 {
+		t.Error("Expected profiles")
+	}
+}
+
+func TestBootstrapPartialFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "GetProfiles") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>
+			<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Profiles token="Profile_1"><tt:Name>Main</tt:Name></trt:Profiles>
+			</trt:GetProfilesResponse>
+		</s:Body></s:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	info, err := client.Bootstrap(context.Background())
+	if err == nil {
+		t.Fatal("Expected an error when device information and capabilities fail")
+	}
+	for _, op := range []string{"GetDeviceInformation", "GetCapabilities"} {
+		if !strings.Contains(err.Error(), op) {
+			t.Errorf("Expected %s in error: %v", op, err)
+		}
+	}
+	var httpErr *soap.HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Errorf("Expected the HTTP error to be reachable, got %v", err)
+	}
+	if info == nil || info.DeviceInfo != nil || info.Capabilities != nil || info.Endpoints != (ServiceEndpoints{}) {
+		t.Fatalf("Unexpected partial info: %+v", info)
+	}
+	if len(info.Profiles) != 1 || info.Profiles[0].Token != "Profile_1" {
+		t.Errorf("Expected profiles from the device endpoint, got %+v", info.Profiles)
+	}
+}
+
 func TestClientEndpoint(t *testing.T) {
 	endpoint := "http://192.168.1.100/onvif"
 	client, err := NewClient(endpoint)
@@ -562,6 +1221,200 @@ func TestContextTimeout(t *testing.T) {
 	}
 }
 
+func TestCanceledContextSkipsRequest(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithCredentials("admin", "password"))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	client.mediaEndpoint = server.URL
+	client.ptzEndpoint = server.URL
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	velocity := &PTZSpeed{PanTilt: &Vector2D{X: 0.5}}
+	calls := []struct {
+		name string
+		call func() error
+	}{
+		{"GetDeviceInformation", func() error { _, err := client.GetDeviceInformation(ctx); return err }},
+		{"GetCapabilities", func() error { _, err := client.GetCapabilities(ctx); return err }},
+		{"GetProfiles", func() error { _, err := client.GetProfiles(ctx); return err }},
+		{"GetStreamURI", func() error { _, err := client.GetStreamURI(ctx, "Profile_1"); return err }},
+		{"ContinuousMove", func() error { return client.ContinuousMoveFor(ctx, "Profile_1", velocity, time.Second) }},
+		{"Stop", func() error { return client.Stop(ctx, "Profile_1", true, true) }},
+		{"GetStatus", func() error { _, err := client.GetStatus(ctx, "Profile_1"); return err }},
+	}
+
+	for _, tt := range calls {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, context.Canceled) {
+				t.Errorf("expected context.Canceled, got %v", err)
+			}
+		})
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Errorf("expected no requests to reach the server, got %d", n)
+	}
+}
+
+func TestClose(t *testing.T) {
+	var requests, closedConns int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+	<s:Body><tds:GetDeviceInformationResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/></s:Body>
+</s:Envelope>`))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			atomic.AddInt32(&closedConns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := client.GetDeviceInformation(ctx); err != nil {
+		t.Fatalf("GetDeviceInformation() failed: %v", err)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Errorf("second Close() failed: %v", err)
+	}
+
+	// The idle keep-alive connection is closed
+	for i := 0; i < 100 && atomic.LoadInt32(&closedConns) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if atomic.LoadInt32(&closedConns) == 0 {
+		t.Error("expected the idle connection to be closed")
+	}
+
+	if _, err := client.GetDeviceInformation(ctx); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("GetDeviceInformation() after Close error = %v, want ErrClientClosed", err)
+	}
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/snapshot.jpg", nil)
+	if err != nil {
+		t.Fatalf("NewRequest() failed: %v", err)
+	}
+	if _, err := client.DoMediaRequest(req); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("DoMediaRequest() after Close error = %v, want ErrClientClosed", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("expected only the request before Close to reach the server, got %d", n)
+	}
+}
+
+func TestClockSkewResync(t *testing.T) {
+	skew := 2 * time.Hour
+	var authorized, rejected int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		deviceNow := time.Now().Add(skew).UTC()
+
+		if strings.Contains(string(body), "GetSystemDateAndTime") {
+			if strings.Contains(string(body), "UsernameToken") {
+				t.Errorf("Expected unauthenticated GetSystemDateAndTime, got: %s", body)
+			}
+			fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+			<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+				<s:Body>
+					<tds:GetSystemDateAndTimeResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+						<tds:SystemDateAndTime>
+							<tt:DateTimeType>Manual</tt:DateTimeType>
+							<tt:UTCDateTime>
+								<tt:Time><tt:Hour>%d</tt:Hour><tt:Minute>%d</tt:Minute><tt:Second>%d</tt:Second></tt:Time>
+								<tt:Date><tt:Year>%d</tt:Year><tt:Month>%d</tt:Month><tt:Day>%d</tt:Day></tt:Date>
+							</tt:UTCDateTime>
+						</tds:SystemDateAndTime>
+					</tds:GetSystemDateAndTimeResponse>
+				</s:Body>
+			</s:Envelope>`, deviceNow.Hour(), deviceNow.Minute(), deviceNow.Second(),
+				deviceNow.Year(), int(deviceNow.Month()), deviceNow.Day())
+			return
+		}
+
+		// Accept only tokens created within a few seconds of the device clock
+		match := regexp.MustCompile(`Created[^>]*>([^<]+)<`).FindSubmatch(body)
+		if match == nil {
+			t.Errorf("Expected a Created timestamp, got: %s", body)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		created, err := time.Parse(time.RFC3339, string(match[1]))
+		if err != nil || created.Sub(deviceNow) > 5*time.Second || deviceNow.Sub(created) > 5*time.Second {
+			atomic.AddInt32(&rejected, 1)
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+			<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope" xmlns:ter="http://www.onvif.org/ver10/error">
+				<env:Body>
+					<env:Fault>
+						<env:Code>
+							<env:Value>env:Sender</env:Value>
+							<env:Subcode><env:Value>ter:NotAuthorized</env:Value></env:Subcode>
+						</env:Code>
+						<env:Reason><env:Text>Sender not Authorized</env:Text></env:Reason>
+					</env:Fault>
+				</env:Body>
+			</env:Envelope>`))
+			return
+		}
+
+		atomic.AddInt32(&authorized, 1)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:GetDeviceInformationResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+					<tds:Manufacturer>Acme</tds:Manufacturer>
+				</tds:GetDeviceInformationResponse>
+			</s:Body>
+		</s:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithCredentials("admin", "password"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	if _, err := client.GetDeviceInformation(context.Background()); err != nil {
+		t.Fatalf("GetDeviceInformation() error = %v", err)
+	}
+	if got := client.ClockSkew(); got < skew-2*time.Second || got > skew+2*time.Second {
+		t.Errorf("ClockSkew() = %v, want about %v", got, skew)
+	}
+
+	// The learned skew is used for later calls without another rejection
+	if _, err := client.GetDeviceInformation(context.Background()); err != nil {
+		t.Fatalf("second GetDeviceInformation() error = %v", err)
+	}
+	if n := atomic.LoadInt32(&rejected); n != 1 {
+		t.Errorf("expected 1 rejected request, got %d", n)
+	}
+	if n := atomic.LoadInt32(&authorized); n != 2 {
+		t.Errorf("expected 2 authorized requests, got %d", n)
+	}
+}
+
 func TestONVIFError(t *testing.T) {
 	err := NewONVIFError("Sender", "InvalidArgs", "Invalid parameter value")
 
@@ -639,3 +1492,52 @@ func ExampleClient_GetDeviceInformation() {
 	fmt.Printf("Camera: %s %s\n", info.Manufacturer, info.Model)
 	fmt.Printf("Firmware: %s\n", info.FirmwareVersion)
 }
+
+func TestWithContextDialer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+	<s:Body><tds:GetDeviceInformationResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"><tds:Model>Tunnelled</tds:Model></tds:GetDeviceInformationResponse></s:Body>
+</s:Envelope>`))
+	}))
+	defer server.Close()
+
+	// The camera's address is unreachable; every connection goes through the
+	// "tunnel" to the test server instead
+	var dialed []string
+	var mu sync.Mutex
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		mu.Lock()
+		dialed = append(dialed, addr)
+		mu.Unlock()
+		var d net.Dialer
+		return d.DialContext(ctx, network, server.Listener.Addr().String())
+	}
+
+	httpClient := &http.Client{Transport: &http.Transport{}}
+	client, err := NewClient("http://camera.invalid:8080/onvif/device_service",
+		WithHTTPClient(httpClient), WithContextDialer(dial))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	info, err := client.GetDeviceInformation(context.Background())
+	if err != nil {
+		t.Fatalf("GetDeviceInformation() failed: %v", err)
+	}
+	if info.Model != "Tunnelled" {
+		t.Errorf("Model = %q, want Tunnelled", info.Model)
+	}
+	if len(dialed) == 0 || dialed[0] != "camera.invalid:8080" {
+		t.Errorf("Dialed %v, want camera.invalid:8080", dialed)
+	}
+	if httpClient.Transport.(*http.Transport).DialContext != nil {
+		t.Error("The HTTP client passed to WithHTTPClient was modified")
+	}
+
+	mock := onviftest.NewMockTransport()
+	if _, err := NewClient("192.168.1.100", WithHTTPClient(mock.Client()), WithContextDialer(dial)); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("NewClient() with a custom round tripper error = %v, want ErrInvalidParameter", err)
+	}
+}
@@ -94,7 +94,8 @@ func (c *CLI) discoverCameras() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	devices, err := discovery.Discover(ctx, 5*time.Second)
+	// Stop listening once no new camera has answered for 2s
+	devices, err := discovery.Discover(ctx, 5*time.Second, discovery.WithEarlyExit(2*time.Second))
 	if err != nil {
 		fmt.Printf("❌ Discovery failed: %v\n", err)
 		return
@@ -402,7 +403,7 @@ func (c *CLI) getMediaProfiles(ctx context.Context) {
 			fmt.Printf("   Quality: %.1f\n", profile.VideoEncoderConfiguration.Quality)
 		}
 
-		if profile.PTZConfiguration != nil {
+		if profile.HasPTZ() {
 			fmt.Printf("   PTZ: Enabled\n")
 		}
 
@@ -586,7 +587,7 @@ func (c *CLI) getPTZProfileToken(ctx context.Context) (string, error) {
 
 	// Find a profile with PTZ configuration
 	for _, profile := range profiles {
-		if profile.PTZConfiguration != nil {
+		if profile.HasPTZ() {
 			return profile.Token, nil
 		}
 	}
@@ -641,17 +642,18 @@ func (c *CLI) continuousMove(ctx context.Context, profileToken string) {
 	pan, _ := strconv.ParseFloat(panStr, 64)
 	tilt, _ := strconv.ParseFloat(tiltStr, 64)
 	zoom, _ := strconv.ParseFloat(zoomStr, 64)
+	timeoutSecs, _ := strconv.ParseFloat(timeoutStr, 64)
 
 	velocity := &onvif.PTZSpeed{
 		PanTilt: &onvif.Vector2D{X: pan, Y: tilt},
 		Zoom:    &onvif.Vector1D{X: zoom},
 	}
 
-	timeout := fmt.Sprintf("PT%sS", timeoutStr)
+	timeout := time.Duration(timeoutSecs * float64(time.Second))
 
 	fmt.Println("⏳ Moving camera...")
 
-	err := c.client.ContinuousMove(ctx, profileToken, velocity, &timeout)
+	err := c.client.ContinuousMoveFor(ctx, profileToken, velocity, timeout)
 	if err != nil {
 		fmt.Printf("❌ Error: %v\n", err)
 		return
@@ -900,7 +902,7 @@ func (c *CLI) getImagingSettings(ctx context.Context, videoSourceToken string) {
 
 	if settings.Exposure != nil {
 		fmt.Printf("   Exposure Mode: %s\n", settings.Exposure.Mode)
-		if settings.Exposure.Mode == "MANUAL" {
+		if settings.Exposure.Mode == onvif.ExposureModeManual {
 			fmt.Printf("     Exposure Time: %.2f\n", settings.Exposure.ExposureTime)
 			fmt.Printf("     Gain: %.2f\n", settings.Exposure.Gain)
 		}
@@ -215,8 +215,7 @@ func ptzDemo() {
 	}
 
 	if velocity != nil {
-		timeout := "PT2S"
-		err = client.ContinuousMove(ctx, profileToken, velocity, &timeout)
+		err = client.ContinuousMoveFor(ctx, profileToken, velocity, 2*time.Second)
 		if err != nil {
 			fmt.Printf("❌ Error: %v\n", err)
 			return
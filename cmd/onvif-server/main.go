@@ -23,6 +23,7 @@ func main() {
 	port := flag.Int("port", 8080, "Server port")
 	username := flag.String("username", "admin", "Authentication username")
 	password := flag.String("password", "admin", "Authentication password")
+	requireAuth := flag.Bool("auth", true, "Require WS-Security authentication")
 	manufacturer := flag.String("manufacturer", "go-onvif", "Device manufacturer")
 	model := flag.String("model", "Virtual Multi-Lens Camera", "Device model")
 	firmware := flag.String("firmware", "1.0.0", "Firmware version")
@@ -31,6 +32,8 @@ func main() {
 	ptz := flag.Bool("ptz", true, "Enable PTZ support")
 	imaging := flag.Bool("imaging", true, "Enable Imaging support")
 	events := flag.Bool("events", false, "Enable Events support")
+	motion := flag.Duration("motion", 10*time.Second, "Simulated motion alarm interval when events are enabled (0 disables)")
+	rtsp := flag.Bool("rtsp", false, "Serve a test pattern on the advertised RTSP URIs")
 	info := flag.Bool("info", false, "Show server info and exit")
 	showVersion := flag.Bool("version", false, "Show version and exit")
 
@@ -64,8 +67,10 @@ func main() {
 	}
 
 	// Create server configuration
-	config := buildConfig(*host, *port, *username, *password, *manufacturer, *model,
+	config := buildConfig(*host, *port, *username, *password, *requireAuth, *manufacturer, *model,
 		*firmware, *serial, *profiles, *ptz, *imaging, *events)
+	config.EnableRTSP = *rtsp
+	config.SimulatedMotionInterval = *motion
 
 	// Create server
 	srv, err := server.New(config)
@@ -82,34 +87,38 @@ func main() {
 	// Print banner
 	printBanner()
 
-	// Create context that listens for interrupt signals
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
 	// Setup signal handler
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
 	// Start server in goroutine
+	errChan := make(chan error, 1)
 	go func() {
-		if err := srv.Start(ctx); err != nil {
-			log.Printf("Server error: %v", err)
-			cancel()
-		}
+		errChan <- srv.Start(context.Background())
 	}()
 
 	// Wait for interrupt signal
-	<-sigChan
-	fmt.Println("\n🛑 Received interrupt signal, shutting down...")
-	cancel()
+	select {
+	case <-sigChan:
+		fmt.Println("\n🛑 Received interrupt signal, shutting down...")
+	case err := <-errChan:
+		log.Fatalf("Server error: %v", err)
+	}
 
-	// Give the server a moment to shut down gracefully
-	time.Sleep(1 * time.Second)
+	// Drain in-flight requests before exiting
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Shutdown error: %v", err)
+	}
+	if err := <-errChan; err != nil {
+		log.Printf("Server error: %v", err)
+	}
 	fmt.Println("✅ Server stopped")
 }
 
 // buildConfig creates a server configuration from command-line arguments
-func buildConfig(host string, port int, username, password, manufacturer, model,
+func buildConfig(host string, port int, username, password string, requireAuth bool, manufacturer, model,
 	firmware, serial string, numProfiles int, ptz, imaging, events bool) *server.Config {
 
 	config := &server.Config{
@@ -126,6 +135,7 @@ func buildConfig(host string, port int, username, password, manufacturer, model,
 		},
 		Username:       username,
 		Password:       password,
+		RequireAuth:    requireAuth,
 		SupportPTZ:     ptz,
 		SupportImaging: imaging,
 		SupportEvents:  events,
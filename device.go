@@ -2,8 +2,19 @@ package onvif
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/0x524a/onvif-go/internal/soap"
 )
@@ -25,6 +36,9 @@ func (c *Client) GetDeviceInformation(ctx context.Context) (*DeviceInformation,
 		FirmwareVersion string   `xml:"FirmwareVersion"`
 		SerialNumber    string   `xml:"SerialNumber"`
 		HardwareID      string   `xml:"HardwareId"`
+
+		// Anything else, including an Extension element, is vendor specific
+		Extra []extensionFieldXML `xml:",any"`
 	}
 
 	req := GetDeviceInformation{
@@ -33,8 +47,7 @@ func (c *Client) GetDeviceInformation(ctx context.Context) (*DeviceInformation,
 
 	var resp GetDeviceInformationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetDeviceInformation failed: %w", err)
@@ -46,11 +59,60 @@ func (c *Client) GetDeviceInformation(ctx context.Context) (*DeviceInformation,
 		FirmwareVersion: resp.FirmwareVersion,
 		SerialNumber:    resp.SerialNumber,
 		HardwareID:      resp.HardwareID,
+		Extension:       extensionXML{Fields: resp.Extra}.toMap(),
 	}, nil
 }
 
+// Capability categories accepted by GetCapabilitiesFor
+const (
+	CapabilityCategoryAll       = "All"
+	CapabilityCategoryAnalytics = "Analytics"
+	CapabilityCategoryDevice    = "Device"
+	CapabilityCategoryEvents    = "Events"
+	CapabilityCategoryImaging   = "Imaging"
+	CapabilityCategoryMedia     = "Media"
+	CapabilityCategoryPTZ       = "PTZ"
+)
+
 // GetCapabilities retrieves device capabilities
 func (c *Client) GetCapabilities(ctx context.Context) (*Capabilities, error) {
+	capabilities, err := c.getCapabilities(ctx, []string{CapabilityCategoryAll})
+	if err != nil {
+		return nil, fmt.Errorf("GetCapabilities failed: %w", err)
+	}
+
+	return capabilities, nil
+}
+
+// GetCapabilitiesFor retrieves only the given capability categories, such as
+// CapabilityCategoryPTZ. Only the requested sections of the result are
+// populated, even if the device answers with more; the Extension section
+// needs CapabilityCategoryAll. Without categories it behaves like
+// GetCapabilities.
+func (c *Client) GetCapabilitiesFor(ctx context.Context, categories ...string) (*Capabilities, error) {
+	if len(categories) == 0 {
+		categories = []string{CapabilityCategoryAll}
+	}
+	for _, category := range categories {
+		switch category {
+		case CapabilityCategoryAll, CapabilityCategoryAnalytics, CapabilityCategoryDevice, CapabilityCategoryEvents,
+			CapabilityCategoryImaging, CapabilityCategoryMedia, CapabilityCategoryPTZ:
+		default:
+			return nil, fmt.Errorf("%w: unknown capability category %q", ErrInvalidParameter, category)
+		}
+	}
+
+	capabilities, err := c.getCapabilities(ctx, categories)
+	if err != nil {
+		return nil, fmt.Errorf("GetCapabilitiesFor failed: %w", err)
+	}
+
+	return capabilities, nil
+}
+
+// getCapabilities requests the given capability categories and maps the
+// sections of the response belonging to them
+func (c *Client) getCapabilities(ctx context.Context, categories []string) (*Capabilities, error) {
 	type GetCapabilities struct {
 		XMLName  xml.Name `xml:"tds:GetCapabilities"`
 		Xmlns    string   `xml:"xmlns:tds,attr"`
@@ -117,27 +179,56 @@ func (c *Client) GetCapabilities(ctx context.Context) (*Capabilities, error) {
 			PTZ *struct {
 				XAddr string `xml:"XAddr"`
 			} `xml:"PTZ"`
+			Extension *struct {
+				DeviceIO *struct {
+					XAddr        string `xml:"XAddr"`
+					VideoSources int    `xml:"VideoSources"`
+					VideoOutputs int    `xml:"VideoOutputs"`
+					AudioSources int    `xml:"AudioSources"`
+					AudioOutputs int    `xml:"AudioOutputs"`
+					RelayOutputs int    `xml:"RelayOutputs"`
+				} `xml:"DeviceIO"`
+				Recording *struct {
+					XAddr              string `xml:"XAddr"`
+					ReceiverSource     bool   `xml:"ReceiverSource"`
+					MediaProfileSource bool   `xml:"MediaProfileSource"`
+					DynamicRecordings  bool   `xml:"DynamicRecordings"`
+					DynamicTracks      bool   `xml:"DynamicTracks"`
+					MaxStringLength    int    `xml:"MaxStringLength"`
+				} `xml:"Recording"`
+				Search *struct {
+					XAddr          string `xml:"XAddr"`
+					MetadataSearch bool   `xml:"MetadataSearch"`
+				} `xml:"Search"`
+				Replay *struct {
+					XAddr string `xml:"XAddr"`
+				} `xml:"Replay"`
+			} `xml:"Extension"`
 		} `xml:"Capabilities"`
 	}
 
 	req := GetCapabilities{
 		Xmlns:    deviceNamespace,
-		Category: []string{"All"},
+		Category: categories,
 	}
 
 	var resp GetCapabilitiesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
-		return nil, fmt.Errorf("GetCapabilities failed: %w", err)
+		return nil, err
+	}
+
+	all := slices.Contains(categories, CapabilityCategoryAll)
+	wants := func(category string) bool {
+		return all || slices.Contains(categories, category)
 	}
 
 	capabilities := &Capabilities{}
 
 	// Map Analytics
-	if resp.Capabilities.Analytics != nil {
+	if resp.Capabilities.Analytics != nil && wants(CapabilityCategoryAnalytics) {
 		capabilities.Analytics = &AnalyticsCapabilities{
 			XAddr:                  resp.Capabilities.Analytics.XAddr,
 			RuleSupport:            resp.Capabilities.Analytics.RuleSupport,
@@ -146,7 +237,7 @@ func (c *Client) GetCapabilities(ctx context.Context) (*Capabilities, error) {
 	}
 
 	// Map Device
-	if resp.Capabilities.Device != nil {
+	if resp.Capabilities.Device != nil && wants(CapabilityCategoryDevice) {
 		capabilities.Device = &DeviceCapabilities{
 			XAddr: resp.Capabilities.Device.XAddr,
 		}
@@ -190,7 +281,7 @@ func (c *Client) GetCapabilities(ctx context.Context) (*Capabilities, error) {
 	}
 
 	// Map Events
-	if resp.Capabilities.Events != nil {
+	if resp.Capabilities.Events != nil && wants(CapabilityCategoryEvents) {
 		capabilities.Events = &EventCapabilities{
 			XAddr:                         resp.Capabilities.Events.XAddr,
 			WSSubscriptionPolicySupport:   resp.Capabilities.Events.WSSubscriptionPolicySupport,
@@ -200,14 +291,14 @@ func (c *Client) GetCapabilities(ctx context.Context) (*Capabilities, error) {
 	}
 
 	// Map Imaging
-	if resp.Capabilities.Imaging != nil {
+	if resp.Capabilities.Imaging != nil && wants(CapabilityCategoryImaging) {
 		capabilities.Imaging = &ImagingCapabilities{
 			XAddr: resp.Capabilities.Imaging.XAddr,
 		}
 	}
 
 	// Map Media
-	if resp.Capabilities.Media != nil {
+	if resp.Capabilities.Media != nil && wants(CapabilityCategoryMedia) {
 		capabilities.Media = &MediaCapabilities{
 			XAddr: resp.Capabilities.Media.XAddr,
 		}
@@ -221,15 +312,88 @@ func (c *Client) GetCapabilities(ctx context.Context) (*Capabilities, error) {
 	}
 
 	// Map PTZ
-	if resp.Capabilities.PTZ != nil {
+	if resp.Capabilities.PTZ != nil && wants(CapabilityCategoryPTZ) {
 		capabilities.PTZ = &PTZCapabilities{
 			XAddr: resp.Capabilities.PTZ.XAddr,
 		}
 	}
 
+	// Map Extension
+	if ext := resp.Capabilities.Extension; ext != nil && all {
+		capabilities.Extension = &CapabilitiesExtension{}
+		if ext.DeviceIO != nil {
+			capabilities.Extension.DeviceIO = &DeviceIOCapabilities{
+				XAddr:        ext.DeviceIO.XAddr,
+				VideoSources: ext.DeviceIO.VideoSources,
+				VideoOutputs: ext.DeviceIO.VideoOutputs,
+				AudioSources: ext.DeviceIO.AudioSources,
+				AudioOutputs: ext.DeviceIO.AudioOutputs,
+				RelayOutputs: ext.DeviceIO.RelayOutputs,
+			}
+		}
+		if ext.Recording != nil {
+			capabilities.Extension.Recording = &RecordingCapabilities{
+				XAddr:              ext.Recording.XAddr,
+				ReceiverSource:     ext.Recording.ReceiverSource,
+				MediaProfileSource: ext.Recording.MediaProfileSource,
+				DynamicRecordings:  ext.Recording.DynamicRecordings,
+				DynamicTracks:      ext.Recording.DynamicTracks,
+				MaxStringLength:    ext.Recording.MaxStringLength,
+			}
+		}
+		if ext.Search != nil {
+			capabilities.Extension.Search = &SearchCapabilities{
+				XAddr:          ext.Search.XAddr,
+				MetadataSearch: ext.Search.MetadataSearch,
+			}
+		}
+		if ext.Replay != nil {
+			capabilities.Extension.Replay = &ReplayCapabilities{
+				XAddr: ext.Replay.XAddr,
+			}
+		}
+	}
+
 	return capabilities, nil
 }
 
+// getServiceXAddrs retrieves the address of every service the device offers,
+// keyed by service namespace
+func (c *Client) getServiceXAddrs(ctx context.Context) (map[string]string, error) {
+	type GetServices struct {
+		XMLName           xml.Name `xml:"tds:GetServices"`
+		Xmlns             string   `xml:"xmlns:tds,attr"`
+		IncludeCapability bool     `xml:"tds:IncludeCapability"`
+	}
+
+	type GetServicesResponse struct {
+		XMLName xml.Name `xml:"GetServicesResponse"`
+		Service []struct {
+			Namespace string `xml:"Namespace"`
+			XAddr     string `xml:"XAddr"`
+		} `xml:"Service"`
+	}
+
+	req := GetServices{
+		Xmlns: deviceNamespace,
+	}
+
+	var resp GetServicesResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetServices failed: %w", err)
+	}
+
+	xaddrs := make(map[string]string, len(resp.Service))
+	for _, service := range resp.Service {
+		xaddrs[service.Namespace] = service.XAddr
+	}
+
+	return xaddrs, nil
+}
+
 // SystemReboot reboots the device
 func (c *Client) SystemReboot(ctx context.Context) (string, error) {
 	type SystemReboot struct {
@@ -248,37 +412,379 @@ func (c *Client) SystemReboot(ctx context.Context) (string, error) {
 
 	var resp SystemRebootResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
+		// Some devices reboot without a response body or drop the connection mid-response
+		if errors.Is(err, soap.ErrEmptyResponse) {
+			return "", nil
+		}
+		if isConnectionDropped(err) {
+			return "", ErrRebootInProgress
+		}
 		return "", fmt.Errorf("SystemReboot failed: %w", err)
 	}
 
 	return resp.Message, nil
 }
 
+// SetSystemFactoryDefault resets the device to its factory settings. A Soft
+// reset keeps network settings so the device stays reachable; a Hard reset
+// restores everything, including the IP address and credentials. The device
+// reboots afterwards and must be re-provisioned, and after a Hard reset
+// rediscovered. If it drops the connection before answering,
+// ErrRebootInProgress is returned.
+func (c *Client) SetSystemFactoryDefault(ctx context.Context, factoryDefault string) error {
+	if factoryDefault != "Soft" && factoryDefault != "Hard" {
+		return fmt.Errorf("%w: factory default must be Soft or Hard, got %q", ErrInvalidParameter, factoryDefault)
+	}
+
+	type SetSystemFactoryDefault struct {
+		XMLName        xml.Name `xml:"tds:SetSystemFactoryDefault"`
+		Xmlns          string   `xml:"xmlns:tds,attr"`
+		FactoryDefault string   `xml:"tds:FactoryDefault"`
+	}
+
+	req := SetSystemFactoryDefault{
+		Xmlns:          deviceNamespace,
+		FactoryDefault: factoryDefault,
+	}
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
+		// Like SystemReboot, devices may reset without a response body or drop
+		// the connection mid-response
+		if errors.Is(err, soap.ErrEmptyResponse) {
+			return nil
+		}
+		if isConnectionDropped(err) {
+			return ErrRebootInProgress
+		}
+		return fmt.Errorf("SetSystemFactoryDefault failed: %w", err)
+	}
+
+	return nil
+}
+
+// isConnectionDropped reports whether err is caused by the peer closing or
+// resetting the connection
+func isConnectionDropped(err error) bool {
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// GetSystemBackup retrieves the device configuration backup files, sent either
+// inline as base64 or as MTOM attachments
+func (c *Client) GetSystemBackup(ctx context.Context) ([]*BackupFile, error) {
+	type GetSystemBackup struct {
+		XMLName xml.Name `xml:"tds:GetSystemBackup"`
+		Xmlns   string   `xml:"xmlns:tds,attr"`
+	}
+
+	type GetSystemBackupResponse struct {
+		XMLName     xml.Name `xml:"GetSystemBackupResponse"`
+		BackupFiles []struct {
+			Name string `xml:"Name"`
+			Data struct {
+				Content string `xml:",chardata"`
+				Include *struct {
+					Href string `xml:"href,attr"`
+				} `xml:"Include"`
+			} `xml:"Data"`
+		} `xml:"BackupFiles"`
+	}
+
+	req := GetSystemBackup{
+		Xmlns: deviceNamespace,
+	}
+
+	var resp GetSystemBackupResponse
+
+	soapClient := c.soapClient()
+
+	attachments, err := soapClient.CallWithAttachments(ctx, c.endpoint, "", req, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("GetSystemBackup failed: %w", err)
+	}
+
+	files := make([]*BackupFile, len(resp.BackupFiles))
+	for i, f := range resp.BackupFiles {
+		if f.Data.Include != nil {
+			data, ok := attachments.Get(f.Data.Include.Href)
+			if !ok {
+				return nil, fmt.Errorf("GetSystemBackup failed: %w: missing MTOM attachment %s", ErrInvalidResponse, f.Data.Include.Href)
+			}
+			files[i] = &BackupFile{
+				Name: f.Name,
+				Data: data,
+			}
+			continue
+		}
+
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(f.Data.Content), ""))
+		if err != nil {
+			return nil, fmt.Errorf("GetSystemBackup failed: invalid backup data for %s: %w", f.Name, err)
+		}
+
+		files[i] = &BackupFile{
+			Name: f.Name,
+			Data: data,
+		}
+	}
+
+	return files, nil
+}
+
+// RestoreSystem restores the device configuration from a backup file obtained
+// with GetSystemBackup. The data is sent inline as base64.
+func (c *Client) RestoreSystem(ctx context.Context, backup []byte) error {
+	if len(backup) == 0 {
+		return fmt.Errorf("%w: backup data is empty", ErrInvalidParameter)
+	}
+
+	type RestoreSystem struct {
+		XMLName     xml.Name `xml:"tds:RestoreSystem"`
+		Xmlns       string   `xml:"xmlns:tds,attr"`
+		Xmlnst      string   `xml:"xmlns:tt,attr"`
+		BackupFiles struct {
+			Name string `xml:"tt:Name"`
+			Data string `xml:"tt:Data"`
+		} `xml:"tds:BackupFiles"`
+	}
+
+	req := RestoreSystem{
+		Xmlns:  deviceNamespace,
+		Xmlnst: "http://www.onvif.org/ver10/schema",
+	}
+	req.BackupFiles.Name = "backup"
+	req.BackupFiles.Data = base64.StdEncoding.EncodeToString(backup)
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("RestoreSystem failed: %w", err)
+	}
+
+	return nil
+}
+
+// StartFirmwareUpgrade prepares the device for a firmware upgrade and returns
+// the URI the firmware image must be uploaded to
+func (c *Client) StartFirmwareUpgrade(ctx context.Context) (*FirmwareUpgradeInfo, error) {
+	type StartFirmwareUpgrade struct {
+		XMLName xml.Name `xml:"tds:StartFirmwareUpgrade"`
+		Xmlns   string   `xml:"xmlns:tds,attr"`
+	}
+
+	type StartFirmwareUpgradeResponse struct {
+		XMLName          xml.Name `xml:"StartFirmwareUpgradeResponse"`
+		UploadUri        string   `xml:"UploadUri"`
+		UploadDelay      string   `xml:"UploadDelay"`
+		ExpectedDownTime string   `xml:"ExpectedDownTime"`
+	}
+
+	req := StartFirmwareUpgrade{
+		Xmlns: deviceNamespace,
+	}
+
+	var resp StartFirmwareUpgradeResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("StartFirmwareUpgrade failed: %w", err)
+	}
+
+	info := &FirmwareUpgradeInfo{
+		UploadURI: resp.UploadUri,
+	}
+
+	if resp.UploadDelay != "" {
+		delay, err := ParseDuration(resp.UploadDelay)
+		if err != nil {
+			return nil, fmt.Errorf("StartFirmwareUpgrade failed: invalid UploadDelay: %w", err)
+		}
+		info.UploadDelay = delay
+	}
+
+	if resp.ExpectedDownTime != "" {
+		downTime, err := ParseDuration(resp.ExpectedDownTime)
+		if err != nil {
+			return nil, fmt.Errorf("StartFirmwareUpgrade failed: invalid ExpectedDownTime: %w", err)
+		}
+		info.ExpectedDownTime = downTime
+	}
+
+	return info, nil
+}
+
+// UploadFirmware uploads a firmware image to the URI returned by StartFirmwareUpgrade
+// as a multipart HTTP POST. Callers should wait for UploadDelay before uploading.
+// Any 2xx status counts as success. The device reboots after a successful
+// upload; if it drops the connection before answering, ErrRebootInProgress is
+// returned.
+func (c *Client) UploadFirmware(ctx context.Context, uploadURI string, firmware io.Reader) error {
+	if uploadURI == "" {
+		return fmt.Errorf("%w: upload URI is required", ErrInvalidParameter)
+	}
+
+	// Stream the multipart body so large images are not buffered in memory
+	bodyReader, bodyWriter := io.Pipe()
+	multipartWriter := multipart.NewWriter(bodyWriter)
+
+	go func() {
+		part, err := multipartWriter.CreateFormFile("firmware", "firmware.bin")
+		if err == nil {
+			_, err = io.Copy(part, firmware)
+		}
+		if err == nil {
+			err = multipartWriter.Close()
+		}
+		_ = bodyWriter.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURI, bodyReader)
+	if err != nil {
+		_ = bodyReader.Close()
+		return fmt.Errorf("UploadFirmware failed: %w", err)
+	}
+	req.Header.Set("Content-Type", multipartWriter.FormDataContentType())
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	username, password := c.GetCredentials()
+	if username != "" && !c.noAuth {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := c.doHTTP(req)
+	if err != nil {
+		if isConnectionDropped(err) {
+			return ErrRebootInProgress
+		}
+		return fmt.Errorf("UploadFirmware failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("UploadFirmware failed: HTTP status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// extensionXML captures the children of an Extension element, whatever their
+// names, so vendor additions can be exposed without typed fields
+type extensionXML struct {
+	Fields []extensionFieldXML `xml:",any"`
+}
+
+// extensionFieldXML is a single element captured by extensionXML
+type extensionFieldXML struct {
+	XMLName xml.Name
+	Value   string              `xml:",chardata"`
+	Fields  []extensionFieldXML `xml:",any"`
+}
+
+// toMap returns the captured leaf elements keyed by local name, flattening any
+// nesting, or nil if there were none
+func (x extensionXML) toMap() map[string]string {
+	if len(x.Fields) == 0 {
+		return nil
+	}
+	fields := make(map[string]string)
+	var collect func([]extensionFieldXML)
+	collect = func(list []extensionFieldXML) {
+		for _, field := range list {
+			if len(field.Fields) > 0 {
+				collect(field.Fields)
+				continue
+			}
+			fields[field.XMLName.Local] = strings.TrimSpace(field.Value)
+		}
+	}
+	collect(x.Fields)
+	return fields
+}
+
+// dateTimeXML is the wire form of tt:DateTime
+type dateTimeXML struct {
+	Time struct {
+		Hour   int `xml:"Hour"`
+		Minute int `xml:"Minute"`
+		Second int `xml:"Second"`
+	} `xml:"Time"`
+	Date struct {
+		Year  int `xml:"Year"`
+		Month int `xml:"Month"`
+		Day   int `xml:"Day"`
+	} `xml:"Date"`
+}
+
+// toTime converts the wire form into a time.Time in UTC
+func (d *dateTimeXML) toTime() time.Time {
+	if d == nil {
+		return time.Time{}
+	}
+	return time.Date(d.Date.Year, time.Month(d.Date.Month), d.Date.Day,
+		d.Time.Hour, d.Time.Minute, d.Time.Second, 0, time.UTC)
+}
+
 // GetSystemDateAndTime retrieves the device's system date and time
-func (c *Client) GetSystemDateAndTime(ctx context.Context) (interface{}, error) {
+func (c *Client) GetSystemDateAndTime(ctx context.Context) (*SystemDateAndTime, error) {
+	return c.getSystemDateAndTime(ctx, c.soapClient())
+}
+
+// getSystemDateAndTime retrieves the device's system date and time with soapClient
+func (c *Client) getSystemDateAndTime(ctx context.Context, soapClient *soap.Client) (*SystemDateAndTime, error) {
 	type GetSystemDateAndTime struct {
 		XMLName xml.Name `xml:"tds:GetSystemDateAndTime"`
 		Xmlns   string   `xml:"xmlns:tds,attr"`
 	}
 
+	type GetSystemDateAndTimeResponse struct {
+		XMLName           xml.Name `xml:"GetSystemDateAndTimeResponse"`
+		SystemDateAndTime struct {
+			DateTimeType    string `xml:"DateTimeType"`
+			DaylightSavings bool   `xml:"DaylightSavings"`
+			TimeZone        *struct {
+				TZ string `xml:"TZ"`
+			} `xml:"TimeZone"`
+			UTCDateTime   *dateTimeXML `xml:"UTCDateTime"`
+			LocalDateTime *dateTimeXML `xml:"LocalDateTime"`
+		} `xml:"SystemDateAndTime"`
+	}
+
 	req := GetSystemDateAndTime{
 		Xmlns: deviceNamespace,
 	}
 
-	var resp interface{}
-
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	var resp GetSystemDateAndTimeResponse
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetSystemDateAndTime failed: %w", err)
 	}
 
-	return resp, nil
+	sdt := resp.SystemDateAndTime
+	result := &SystemDateAndTime{
+		DateTimeType:    sdt.DateTimeType,
+		DaylightSavings: sdt.DaylightSavings,
+		UTCDateTime:     sdt.UTCDateTime.toTime(),
+		LocalDateTime:   sdt.LocalDateTime.toTime(),
+	}
+
+	if sdt.TimeZone != nil {
+		result.TimeZone = &TimeZone{
+			TZ: sdt.TimeZone.TZ,
+		}
+	}
+
+	return result, nil
 }
 
 // GetHostname retrieves the device's hostname
@@ -302,8 +808,7 @@ func (c *Client) GetHostname(ctx context.Context) (*HostnameInformation, error)
 
 	var resp GetHostnameResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetHostname failed: %w", err)
@@ -328,8 +833,7 @@ func (c *Client) SetHostname(ctx context.Context, name string) error {
 		Name:  name,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetHostname failed: %w", err)
@@ -367,8 +871,7 @@ func (c *Client) GetDNS(ctx context.Context) (*DNSInformation, error) {
 
 	var resp GetDNSResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetDNS failed: %w", err)
@@ -426,8 +929,7 @@ func (c *Client) GetNTP(ctx context.Context) (*NTPInformation, error) {
 
 	var resp GetNTPResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetNTP failed: %w", err)
@@ -492,8 +994,7 @@ func (c *Client) GetNetworkInterfaces(ctx context.Context) ([]*NetworkInterface,
 
 	var resp GetNetworkInterfacesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetNetworkInterfaces failed: %w", err)
@@ -554,8 +1055,7 @@ func (c *Client) GetScopes(ctx context.Context) ([]*Scope, error) {
 
 	var resp GetScopesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetScopes failed: %w", err)
@@ -564,14 +1064,112 @@ func (c *Client) GetScopes(ctx context.Context) ([]*Scope, error) {
 	scopes := make([]*Scope, len(resp.Scopes))
 	for i, s := range resp.Scopes {
 		scopes[i] = &Scope{
-			ScopeDef:  s.ScopeDef,
-			ScopeItem: s.ScopeItem,
+			ScopeDef:  strings.TrimSpace(s.ScopeDef),
+			ScopeItem: strings.TrimSpace(s.ScopeItem),
+		}
+		// A scope the device does not qualify cannot be assumed editable
+		if scopes[i].ScopeDef == "" {
+			scopes[i].ScopeDef = ScopeDefFixed
 		}
 	}
 
 	return scopes, nil
 }
 
+// AddScopes adds new configurable scopes to the device
+func (c *Client) AddScopes(ctx context.Context, scopes []string) error {
+	if err := validateScopes(scopes); err != nil {
+		return err
+	}
+
+	type AddScopes struct {
+		XMLName   xml.Name `xml:"tds:AddScopes"`
+		Xmlns     string   `xml:"xmlns:tds,attr"`
+		ScopeItem []string `xml:"tds:ScopeItem"`
+	}
+
+	req := AddScopes{
+		Xmlns:     deviceNamespace,
+		ScopeItem: scopes,
+	}
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("AddScopes failed: %w", err)
+	}
+
+	return nil
+}
+
+// SetScopes replaces all configurable scopes of the device
+func (c *Client) SetScopes(ctx context.Context, scopes []string) error {
+	if err := validateScopes(scopes); err != nil {
+		return err
+	}
+
+	type SetScopes struct {
+		XMLName xml.Name `xml:"tds:SetScopes"`
+		Xmlns   string   `xml:"xmlns:tds,attr"`
+		Scopes  []string `xml:"tds:Scopes"`
+	}
+
+	req := SetScopes{
+		Xmlns:  deviceNamespace,
+		Scopes: scopes,
+	}
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("SetScopes failed: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveScopes removes configurable scopes from the device
+func (c *Client) RemoveScopes(ctx context.Context, scopes []string) error {
+	if err := validateScopes(scopes); err != nil {
+		return err
+	}
+
+	type RemoveScopes struct {
+		XMLName   xml.Name `xml:"tds:RemoveScopes"`
+		Xmlns     string   `xml:"xmlns:tds,attr"`
+		ScopeItem []string `xml:"tds:ScopeItem"`
+	}
+
+	req := RemoveScopes{
+		Xmlns:     deviceNamespace,
+		ScopeItem: scopes,
+	}
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("RemoveScopes failed: %w", err)
+	}
+
+	return nil
+}
+
+// validateScopes checks that every scope item is an absolute URI
+func validateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("%w: at least one scope is required", ErrInvalidParameter)
+	}
+
+	for _, scope := range scopes {
+		parsed, err := url.Parse(scope)
+		if err != nil || parsed.Scheme == "" || (parsed.Host == "" && parsed.Opaque == "") {
+			return fmt.Errorf("%w: scope %q is not a valid URI", ErrInvalidParameter, scope)
+		}
+	}
+
+	return nil
+}
+
 // GetUsers retrieves user accounts
 func (c *Client) GetUsers(ctx context.Context) ([]*User, error) {
 	type GetUsers struct {
@@ -582,8 +1180,9 @@ func (c *Client) GetUsers(ctx context.Context) ([]*User, error) {
 	type GetUsersResponse struct {
 		XMLName xml.Name `xml:"GetUsersResponse"`
 		User    []struct {
-			Username  string `xml:"Username"`
-			UserLevel string `xml:"UserLevel"`
+			Username  string       `xml:"Username"`
+			UserLevel string       `xml:"UserLevel"`
+			Extension extensionXML `xml:"Extension"`
 		} `xml:"User"`
 	}
 
@@ -593,8 +1192,7 @@ func (c *Client) GetUsers(ctx context.Context) ([]*User, error) {
 
 	var resp GetUsersResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetUsers failed: %w", err)
@@ -605,14 +1203,23 @@ func (c *Client) GetUsers(ctx context.Context) ([]*User, error) {
 		users[i] = &User{
 			Username:  u.Username,
 			UserLevel: u.UserLevel,
+			Extension: u.Extension.toMap(),
 		}
 	}
 
 	return users, nil
 }
 
-// CreateUsers creates new user accounts
+// CreateUsers creates new user accounts. Every user needs one of the
+// UserLevel constants; otherwise ErrInvalidUserLevel is returned and nothing
+// is sent.
 func (c *Client) CreateUsers(ctx context.Context, users []*User) error {
+	for _, user := range users {
+		if err := validateUserLevel(user.UserLevel); err != nil {
+			return fmt.Errorf("CreateUsers failed: %w", err)
+		}
+	}
+
 	type CreateUsers struct {
 		XMLName xml.Name `xml:"tds:CreateUsers"`
 		Xmlns   string   `xml:"xmlns:tds,attr"`
@@ -639,8 +1246,7 @@ func (c *Client) CreateUsers(ctx context.Context, users []*User) error {
 		})
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("CreateUsers failed: %w", err)
@@ -662,8 +1268,7 @@ func (c *Client) DeleteUsers(ctx context.Context, usernames []string) error {
 		Username: usernames,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("DeleteUsers failed: %w", err)
@@ -672,8 +1277,17 @@ func (c *Client) DeleteUsers(ctx context.Context, usernames []string) error {
 	return nil
 }
 
-// SetUser modifies an existing user account
+// SetUser modifies an existing user account. If it changes the password of
+// the account the client authenticates as, the device rejects the old
+// password from then on, so once the change succeeds the client's stored
+// password is replaced by the new one and later calls keep working. A failed
+// change leaves the credentials as they were. A user level other than the
+// UserLevel constants returns ErrInvalidUserLevel without sending anything.
 func (c *Client) SetUser(ctx context.Context, user *User) error {
+	if err := validateUserLevel(user.UserLevel); err != nil {
+		return fmt.Errorf("SetUser failed: %w", err)
+	}
+
 	type SetUser struct {
 		XMLName xml.Name `xml:"tds:SetUser"`
 		Xmlns   string   `xml:"xmlns:tds,attr"`
@@ -693,12 +1307,761 @@ func (c *Client) SetUser(ctx context.Context, user *User) error {
 	}
 	req.User.UserLevel = user.UserLevel
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetUser failed: %w", err)
 	}
 
+	if user.Password != "" {
+		c.mu.Lock()
+		if c.username == user.Username {
+			c.password = user.Password
+			c.soap = nil
+		}
+		c.mu.Unlock()
+	}
+
+	return nil
+}
+
+// validateUserLevel returns ErrInvalidUserLevel unless level is one of the
+// UserLevel constants
+func validateUserLevel(level string) error {
+	switch level {
+	case UserLevelAdministrator, UserLevelOperator, UserLevelUser, UserLevelAnonymous, UserLevelExtended:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrInvalidUserLevel, level)
+}
+
+// GetRemoteUser retrieves the user the device uses to authenticate against a
+// remote service such as a cloud relay. It returns nil if none is configured.
+// The password is never returned.
+func (c *Client) GetRemoteUser(ctx context.Context) (*RemoteUser, error) {
+	type GetRemoteUser struct {
+		XMLName xml.Name `xml:"tds:GetRemoteUser"`
+		Xmlns   string   `xml:"xmlns:tds,attr"`
+	}
+
+	type GetRemoteUserResponse struct {
+		XMLName    xml.Name `xml:"GetRemoteUserResponse"`
+		RemoteUser *struct {
+			Username           string `xml:"Username"`
+			UseDerivedPassword bool   `xml:"UseDerivedPassword"`
+		} `xml:"RemoteUser"`
+	}
+
+	req := GetRemoteUser{
+		Xmlns: deviceNamespace,
+	}
+
+	var resp GetRemoteUserResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetRemoteUser failed: %w", err)
+	}
+
+	if resp.RemoteUser == nil {
+		return nil, nil
+	}
+
+	return &RemoteUser{
+		Username:           resp.RemoteUser.Username,
+		UseDerivedPassword: resp.RemoteUser.UseDerivedPassword,
+	}, nil
+}
+
+// SetRemoteUser sets the user the device uses to authenticate against a remote
+// service. A nil user removes the remote user.
+func (c *Client) SetRemoteUser(ctx context.Context, user *RemoteUser) error {
+	type remoteUser struct {
+		Username           string  `xml:"tt:Username"`
+		Password           *string `xml:"tt:Password,omitempty"`
+		UseDerivedPassword bool    `xml:"tt:UseDerivedPassword"`
+	}
+
+	type SetRemoteUser struct {
+		XMLName    xml.Name    `xml:"tds:SetRemoteUser"`
+		Xmlns      string      `xml:"xmlns:tds,attr"`
+		Xmlnst     string      `xml:"xmlns:tt,attr"`
+		RemoteUser *remoteUser `xml:"tds:RemoteUser,omitempty"`
+	}
+
+	req := SetRemoteUser{
+		Xmlns:  deviceNamespace,
+		Xmlnst: "http://www.onvif.org/ver10/schema",
+	}
+	if user != nil {
+		req.RemoteUser = &remoteUser{
+			Username:           user.Username,
+			UseDerivedPassword: user.UseDerivedPassword,
+		}
+		if user.Password != "" {
+			req.RemoteUser.Password = &user.Password
+		}
+	}
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("SetRemoteUser failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetStorageConfigurations retrieves the storage targets the device records to
+func (c *Client) GetStorageConfigurations(ctx context.Context) ([]*StorageConfiguration, error) {
+	type GetStorageConfigurations struct {
+		XMLName xml.Name `xml:"tds:GetStorageConfigurations"`
+		Xmlns   string   `xml:"xmlns:tds,attr"`
+	}
+
+	type GetStorageConfigurationsResponse struct {
+		XMLName               xml.Name `xml:"GetStorageConfigurationsResponse"`
+		StorageConfigurations []struct {
+			Token string `xml:"token,attr"`
+			Data  struct {
+				Type       string `xml:"type,attr"`
+				LocalPath  string `xml:"LocalPath"`
+				StorageURI string `xml:"StorageUri"`
+				User       *struct {
+					UserName string `xml:"UserName"`
+					Password string `xml:"Password"`
+				} `xml:"User"`
+			} `xml:"Data"`
+		} `xml:"StorageConfigurations"`
+	}
+
+	req := GetStorageConfigurations{
+		Xmlns: deviceNamespace,
+	}
+
+	var resp GetStorageConfigurationsResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetStorageConfigurations failed: %w", err)
+	}
+
+	configs := make([]*StorageConfiguration, 0, len(resp.StorageConfigurations))
+	for _, sc := range resp.StorageConfigurations {
+		config := &StorageConfiguration{
+			Token:      sc.Token,
+			Type:       sc.Data.Type,
+			LocalPath:  strings.TrimSpace(sc.Data.LocalPath),
+			StorageURI: strings.TrimSpace(sc.Data.StorageURI),
+		}
+		if sc.Data.User != nil {
+			config.User = &StorageCredential{
+				Username: sc.Data.User.UserName,
+				Password: sc.Data.User.Password,
+			}
+		}
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
+// SetStorageConfiguration changes an existing storage target, identified by
+// its token
+func (c *Client) SetStorageConfiguration(ctx context.Context, config StorageConfiguration) error {
+	if config.Token == "" {
+		return fmt.Errorf("%w: storage configuration token is required", ErrInvalidParameter)
+	}
+	if config.Type == "" {
+		return fmt.Errorf("%w: storage type is required", ErrInvalidParameter)
+	}
+
+	type userCredential struct {
+		UserName string  `xml:"tds:UserName"`
+		Password *string `xml:"tds:Password,omitempty"`
+	}
+
+	type storageConfigurationData struct {
+		Type       string          `xml:"type,attr"`
+		LocalPath  string          `xml:"tds:LocalPath,omitempty"`
+		StorageURI string          `xml:"tds:StorageUri,omitempty"`
+		User       *userCredential `xml:"tds:User,omitempty"`
+	}
+
+	type SetStorageConfiguration struct {
+		XMLName              xml.Name `xml:"tds:SetStorageConfiguration"`
+		Xmlns                string   `xml:"xmlns:tds,attr"`
+		StorageConfiguration struct {
+			Token string                   `xml:"token,attr"`
+			Data  storageConfigurationData `xml:"tds:Data"`
+		} `xml:"tds:StorageConfiguration"`
+	}
+
+	req := SetStorageConfiguration{
+		Xmlns: deviceNamespace,
+	}
+	req.StorageConfiguration.Token = config.Token
+	req.StorageConfiguration.Data = storageConfigurationData{
+		Type:       config.Type,
+		LocalPath:  config.LocalPath,
+		StorageURI: config.StorageURI,
+	}
+	if config.User != nil {
+		req.StorageConfiguration.Data.User = &userCredential{UserName: config.User.Username}
+		if config.User.Password != "" {
+			req.StorageConfiguration.Data.User.Password = &config.User.Password
+		}
+	}
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("SetStorageConfiguration failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetDiscoveryMode retrieves the WS-Discovery mode of the device (Discoverable, NonDiscoverable)
+func (c *Client) GetDiscoveryMode(ctx context.Context) (string, error) {
+	type GetDiscoveryMode struct {
+		XMLName xml.Name `xml:"tds:GetDiscoveryMode"`
+		Xmlns   string   `xml:"xmlns:tds,attr"`
+	}
+
+	type GetDiscoveryModeResponse struct {
+		XMLName       xml.Name `xml:"GetDiscoveryModeResponse"`
+		DiscoveryMode string   `xml:"DiscoveryMode"`
+	}
+
+	req := GetDiscoveryMode{
+		Xmlns: deviceNamespace,
+	}
+
+	var resp GetDiscoveryModeResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
+		return "", fmt.Errorf("GetDiscoveryMode failed: %w", err)
+	}
+
+	return resp.DiscoveryMode, nil
+}
+
+// SetDiscoveryMode sets the WS-Discovery mode of the device (Discoverable, NonDiscoverable)
+func (c *Client) SetDiscoveryMode(ctx context.Context, mode string) error {
+	if mode != "Discoverable" && mode != "NonDiscoverable" {
+		return fmt.Errorf("%w: discovery mode must be Discoverable or NonDiscoverable, got %q", ErrInvalidParameter, mode)
+	}
+
+	type SetDiscoveryMode struct {
+		XMLName       xml.Name `xml:"tds:SetDiscoveryMode"`
+		Xmlns         string   `xml:"xmlns:tds,attr"`
+		DiscoveryMode string   `xml:"tds:DiscoveryMode"`
+	}
+
+	req := SetDiscoveryMode{
+		Xmlns:         deviceNamespace,
+		DiscoveryMode: mode,
+	}
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("SetDiscoveryMode failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetWsdlURL retrieves the URL of the device's WSDL and schema definitions
+func (c *Client) GetWsdlURL(ctx context.Context) (string, error) {
+	type GetWsdlUrl struct {
+		XMLName xml.Name `xml:"tds:GetWsdlUrl"`
+		Xmlns   string   `xml:"xmlns:tds,attr"`
+	}
+
+	type GetWsdlUrlResponse struct {
+		XMLName xml.Name `xml:"GetWsdlUrlResponse"`
+		WsdlUrl string   `xml:"WsdlUrl"`
+	}
+
+	req := GetWsdlUrl{
+		Xmlns: deviceNamespace,
+	}
+
+	var resp GetWsdlUrlResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
+		return "", fmt.Errorf("GetWsdlUrl failed: %w", err)
+	}
+
+	return resp.WsdlUrl, nil
+}
+
+// GetEndpointReference retrieves the device's EndpointReference GUID (urn:uuid),
+// which matches the EndpointRef reported by WS-Discovery
+func (c *Client) GetEndpointReference(ctx context.Context) (string, error) {
+	type GetEndpointReference struct {
+		XMLName xml.Name `xml:"tds:GetEndpointReference"`
+		Xmlns   string   `xml:"xmlns:tds,attr"`
+	}
+
+	type GetEndpointReferenceResponse struct {
+		XMLName xml.Name `xml:"GetEndpointReferenceResponse"`
+		GUID    string   `xml:"GUID"`
+	}
+
+	req := GetEndpointReference{
+		Xmlns: deviceNamespace,
+	}
+
+	var resp GetEndpointReferenceResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
+		return "", fmt.Errorf("GetEndpointReference failed: %w", err)
+	}
+
+	return strings.TrimSpace(resp.GUID), nil
+}
+
+// GetCertificates retrieves the device's NVT certificates used for HTTPS
+func (c *Client) GetCertificates(ctx context.Context) ([]*Certificate, error) {
+	type GetCertificates struct {
+		XMLName xml.Name `xml:"tds:GetCertificates"`
+		Xmlns   string   `xml:"xmlns:tds,attr"`
+	}
+
+	type GetCertificatesResponse struct {
+		XMLName        xml.Name `xml:"GetCertificatesResponse"`
+		NvtCertificate []struct {
+			CertificateID string `xml:"CertificateID"`
+			Certificate   struct {
+				Data string `xml:"Data"`
+			} `xml:"Certificate"`
+		} `xml:"NvtCertificate"`
+	}
+
+	req := GetCertificates{
+		Xmlns: deviceNamespace,
+	}
+
+	var resp GetCertificatesResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetCertificates failed: %w", err)
+	}
+
+	certificates := make([]*Certificate, len(resp.NvtCertificate))
+	for i, cert := range resp.NvtCertificate {
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(cert.Certificate.Data), ""))
+		if err != nil {
+			return nil, fmt.Errorf("GetCertificates failed: invalid data for certificate %s: %w", cert.CertificateID, err)
+		}
+
+		certificates[i] = &Certificate{
+			CertificateID: cert.CertificateID,
+			Data:          data,
+		}
+	}
+
+	return certificates, nil
+}
+
+// CreateCertificate creates a self-signed certificate with an on-board generated key pair.
+// The device must support OnboardKeyGeneration.
+func (c *Client) CreateCertificate(ctx context.Context, subject string) (*Certificate, error) {
+	type CreateCertificate struct {
+		XMLName xml.Name `xml:"tds:CreateCertificate"`
+		Xmlns   string   `xml:"xmlns:tds,attr"`
+		Subject string   `xml:"tds:Subject,omitempty"`
+	}
+
+	type CreateCertificateResponse struct {
+		XMLName        xml.Name `xml:"CreateCertificateResponse"`
+		NvtCertificate struct {
+			CertificateID string `xml:"CertificateID"`
+			Certificate   struct {
+				Data string `xml:"Data"`
+			} `xml:"Certificate"`
+		} `xml:"NvtCertificate"`
+	}
+
+	req := CreateCertificate{
+		Xmlns:   deviceNamespace,
+		Subject: subject,
+	}
+
+	var resp CreateCertificateResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("CreateCertificate failed: %w", err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(resp.NvtCertificate.Certificate.Data), ""))
+	if err != nil {
+		return nil, fmt.Errorf("CreateCertificate failed: invalid certificate data: %w", err)
+	}
+
+	return &Certificate{
+		CertificateID: resp.NvtCertificate.CertificateID,
+		Data:          data,
+	}, nil
+}
+
+// LoadCertificate uploads a DER encoded certificate to the device
+func (c *Client) LoadCertificate(ctx context.Context, certID string, cert []byte) error {
+	if len(cert) == 0 {
+		return fmt.Errorf("%w: certificate data is empty", ErrInvalidParameter)
+	}
+
+	type LoadCertificates struct {
+		XMLName        xml.Name `xml:"tds:LoadCertificates"`
+		Xmlns          string   `xml:"xmlns:tds,attr"`
+		Xmlnst         string   `xml:"xmlns:tt,attr"`
+		NVTCertificate struct {
+			CertificateID string `xml:"tt:CertificateID"`
+			Certificate   struct {
+				Data string `xml:"tt:Data"`
+			} `xml:"tt:Certificate"`
+		} `xml:"tds:NVTCertificate"`
+	}
+
+	req := LoadCertificates{
+		Xmlns:  deviceNamespace,
+		Xmlnst: "http://www.onvif.org/ver10/schema",
+	}
+	req.NVTCertificate.CertificateID = certID
+	req.NVTCertificate.Certificate.Data = base64.StdEncoding.EncodeToString(cert)
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("LoadCertificate failed: %w", err)
+	}
+
+	return nil
+}
+
+// SetCertificatesStatus enables or disables certificates on the device
+func (c *Client) SetCertificatesStatus(ctx context.Context, status []CertificateStatus) error {
+	type SetCertificatesStatus struct {
+		XMLName           xml.Name `xml:"tds:SetCertificatesStatus"`
+		Xmlns             string   `xml:"xmlns:tds,attr"`
+		Xmlnst            string   `xml:"xmlns:tt,attr"`
+		CertificateStatus []struct {
+			CertificateID string `xml:"tt:CertificateID"`
+			Status        bool   `xml:"tt:Status"`
+		} `xml:"tds:CertificateStatus"`
+	}
+
+	req := SetCertificatesStatus{
+		Xmlns:  deviceNamespace,
+		Xmlnst: "http://www.onvif.org/ver10/schema",
+	}
+
+	for _, st := range status {
+		req.CertificateStatus = append(req.CertificateStatus, struct {
+			CertificateID string `xml:"tt:CertificateID"`
+			Status        bool   `xml:"tt:Status"`
+		}{
+			CertificateID: st.CertificateID,
+			Status:        st.Status,
+		})
+	}
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("SetCertificatesStatus failed: %w", err)
+	}
+
+	return nil
+}
+
+// prefixedAddressXML is the wire form of tt:PrefixedIPv4Address and
+// tt:PrefixedIPv6Address in responses
+type prefixedAddressXML struct {
+	Address      string `xml:"Address"`
+	PrefixLength int    `xml:"PrefixLength"`
+}
+
+// prefixedAddressRequest is the request wire form of a prefixed address
+type prefixedAddressRequest struct {
+	Address      string `xml:"tt:Address"`
+	PrefixLength int    `xml:"tt:PrefixLength"`
+}
+
+// ipAddressFilterRequest is the request wire form of tt:IPAddressFilter
+type ipAddressFilterRequest struct {
+	Type        string                   `xml:"tt:Type"`
+	IPv4Address []prefixedAddressRequest `xml:"tt:IPv4Address,omitempty"`
+	IPv6Address []prefixedAddressRequest `xml:"tt:IPv6Address,omitempty"`
+}
+
+// newIPAddressFilterRequest validates filter and converts it into its request
+// wire form
+func newIPAddressFilterRequest(filter IPAddressFilter) (*ipAddressFilterRequest, error) {
+	if filter.Type != IPAddressFilterAllow && filter.Type != IPAddressFilterDeny {
+		return nil, fmt.Errorf("%w: IP address filter type must be Allow or Deny, got %q", ErrInvalidParameter, filter.Type)
+	}
+
+	req := &ipAddressFilterRequest{Type: filter.Type}
+	for _, a := range filter.IPv4Address {
+		if ip := net.ParseIP(a.Address); ip == nil || ip.To4() == nil || a.PrefixLength < 0 || a.PrefixLength > 32 {
+			return nil, fmt.Errorf("%w: invalid IPv4 filter address %s/%d", ErrInvalidParameter, a.Address, a.PrefixLength)
+		}
+		req.IPv4Address = append(req.IPv4Address, prefixedAddressRequest{Address: a.Address, PrefixLength: a.PrefixLength})
+	}
+	for _, a := range filter.IPv6Address {
+		if ip := net.ParseIP(a.Address); ip == nil || ip.To4() != nil || a.PrefixLength < 0 || a.PrefixLength > 128 {
+			return nil, fmt.Errorf("%w: invalid IPv6 filter address %s/%d", ErrInvalidParameter, a.Address, a.PrefixLength)
+		}
+		req.IPv6Address = append(req.IPv6Address, prefixedAddressRequest{Address: a.Address, PrefixLength: a.PrefixLength})
+	}
+
+	return req, nil
+}
+
+// GetIPAddressFilter retrieves the device's IP address filter
+func (c *Client) GetIPAddressFilter(ctx context.Context) (*IPAddressFilter, error) {
+	type GetIPAddressFilter struct {
+		XMLName xml.Name `xml:"tds:GetIPAddressFilter"`
+		Xmlns   string   `xml:"xmlns:tds,attr"`
+	}
+
+	type GetIPAddressFilterResponse struct {
+		XMLName         xml.Name `xml:"GetIPAddressFilterResponse"`
+		IPAddressFilter struct {
+			Type        string               `xml:"Type"`
+			IPv4Address []prefixedAddressXML `xml:"IPv4Address"`
+			IPv6Address []prefixedAddressXML `xml:"IPv6Address"`
+		} `xml:"IPAddressFilter"`
+	}
+
+	req := GetIPAddressFilter{
+		Xmlns: deviceNamespace,
+	}
+
+	var resp GetIPAddressFilterResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetIPAddressFilter failed: %w", err)
+	}
+
+	filter := &IPAddressFilter{
+		Type: resp.IPAddressFilter.Type,
+	}
+	for _, a := range resp.IPAddressFilter.IPv4Address {
+		filter.IPv4Address = append(filter.IPv4Address, PrefixedIPv4Address{Address: a.Address, PrefixLength: a.PrefixLength})
+	}
+	for _, a := range resp.IPAddressFilter.IPv6Address {
+		filter.IPv6Address = append(filter.IPv6Address, PrefixedIPv6Address{Address: a.Address, PrefixLength: a.PrefixLength})
+	}
+
+	return filter, nil
+}
+
+// SetIPAddressFilter replaces the device's IP address filter. An Allow filter
+// that does not include the client's own address locks the client out.
+func (c *Client) SetIPAddressFilter(ctx context.Context, filter IPAddressFilter) error {
+	filterReq, err := newIPAddressFilterRequest(filter)
+	if err != nil {
+		return err
+	}
+
+	type SetIPAddressFilter struct {
+		XMLName         xml.Name                `xml:"tds:SetIPAddressFilter"`
+		Xmlns           string                  `xml:"xmlns:tds,attr"`
+		Xmlnst          string                  `xml:"xmlns:tt,attr"`
+		IPAddressFilter *ipAddressFilterRequest `xml:"tds:IPAddressFilter"`
+	}
+
+	req := SetIPAddressFilter{
+		Xmlns:           deviceNamespace,
+		Xmlnst:          "http://www.onvif.org/ver10/schema",
+		IPAddressFilter: filterReq,
+	}
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("SetIPAddressFilter failed: %w", err)
+	}
+
+	return nil
+}
+
+// AddIPAddressFilter adds the addresses in filter to the device's IP address
+// filter. The filter type must match the one the device has.
+func (c *Client) AddIPAddressFilter(ctx context.Context, filter IPAddressFilter) error {
+	filterReq, err := newIPAddressFilterRequest(filter)
+	if err != nil {
+		return err
+	}
+
+	type AddIPAddressFilter struct {
+		XMLName         xml.Name                `xml:"tds:AddIPAddressFilter"`
+		Xmlns           string                  `xml:"xmlns:tds,attr"`
+		Xmlnst          string                  `xml:"xmlns:tt,attr"`
+		IPAddressFilter *ipAddressFilterRequest `xml:"tds:IPAddressFilter"`
+	}
+
+	req := AddIPAddressFilter{
+		Xmlns:           deviceNamespace,
+		Xmlnst:          "http://www.onvif.org/ver10/schema",
+		IPAddressFilter: filterReq,
+	}
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("AddIPAddressFilter failed: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveIPAddressFilter removes the addresses in filter from the device's IP
+// address filter. The filter type must match the one the device has.
+func (c *Client) RemoveIPAddressFilter(ctx context.Context, filter IPAddressFilter) error {
+	filterReq, err := newIPAddressFilterRequest(filter)
+	if err != nil {
+		return err
+	}
+
+	type RemoveIPAddressFilter struct {
+		XMLName         xml.Name                `xml:"tds:RemoveIPAddressFilter"`
+		Xmlns           string                  `xml:"xmlns:tds,attr"`
+		Xmlnst          string                  `xml:"xmlns:tt,attr"`
+		IPAddressFilter *ipAddressFilterRequest `xml:"tds:IPAddressFilter"`
+	}
+
+	req := RemoveIPAddressFilter{
+		Xmlns:           deviceNamespace,
+		Xmlnst:          "http://www.onvif.org/ver10/schema",
+		IPAddressFilter: filterReq,
+	}
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("RemoveIPAddressFilter failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetGeoLocation retrieves the configured geographic locations of the device
+func (c *Client) GetGeoLocation(ctx context.Context) ([]GeoLocation, error) {
+	type GetGeoLocation struct {
+		XMLName xml.Name `xml:"tds:GetGeoLocation"`
+		Xmlns   string   `xml:"xmlns:tds,attr"`
+	}
+
+	type GetGeoLocationResponse struct {
+		XMLName  xml.Name `xml:"GetGeoLocationResponse"`
+		Location []struct {
+			Entity      string `xml:"Entity,attr"`
+			Token       string `xml:"Token,attr"`
+			Fixed       bool   `xml:"Fixed,attr"`
+			GeoLocation *struct {
+				Lon       float64 `xml:"lon,attr"`
+				Lat       float64 `xml:"lat,attr"`
+				Elevation float64 `xml:"elevation,attr"`
+			} `xml:"GeoLocation"`
+		} `xml:"Location"`
+	}
+
+	req := GetGeoLocation{
+		Xmlns: deviceNamespace,
+	}
+
+	var resp GetGeoLocationResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetGeoLocation failed: %w", err)
+	}
+
+	locations := make([]GeoLocation, 0, len(resp.Location))
+	for _, l := range resp.Location {
+		location := GeoLocation{
+			Entity: l.Entity,
+			Token:  l.Token,
+			Fixed:  l.Fixed,
+		}
+		if l.GeoLocation != nil {
+			location.Lon = l.GeoLocation.Lon
+			location.Lat = l.GeoLocation.Lat
+			location.Elevation = l.GeoLocation.Elevation
+		}
+		locations = append(locations, location)
+	}
+
+	return locations, nil
+}
+
+// SetGeoLocation sets the geographic locations of the device
+func (c *Client) SetGeoLocation(ctx context.Context, locations []GeoLocation) error {
+	if len(locations) == 0 {
+		return fmt.Errorf("%w: at least one location is required", ErrInvalidParameter)
+	}
+
+	type locationEntity struct {
+		Entity      string `xml:"Entity,attr,omitempty"`
+		Token       string `xml:"Token,attr,omitempty"`
+		Fixed       bool   `xml:"Fixed,attr,omitempty"`
+		GeoLocation struct {
+			Lon       float64 `xml:"lon,attr"`
+			Lat       float64 `xml:"lat,attr"`
+			Elevation float64 `xml:"elevation,attr"`
+		} `xml:"tt:GeoLocation"`
+	}
+
+	type SetGeoLocation struct {
+		XMLName  xml.Name         `xml:"tds:SetGeoLocation"`
+		Xmlns    string           `xml:"xmlns:tds,attr"`
+		Xmlnst   string           `xml:"xmlns:tt,attr"`
+		Location []locationEntity `xml:"tds:Location"`
+	}
+
+	req := SetGeoLocation{
+		Xmlns:  deviceNamespace,
+		Xmlnst: "http://www.onvif.org/ver10/schema",
+	}
+
+	for _, l := range locations {
+		entity := locationEntity{
+			Entity: l.Entity,
+			Token:  l.Token,
+			Fixed:  l.Fixed,
+		}
+		entity.GeoLocation.Lon = l.Lon
+		entity.GeoLocation.Lat = l.Lat
+		entity.GeoLocation.Elevation = l.Elevation
+		req.Location = append(req.Location, entity)
+	}
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("SetGeoLocation failed: %w", err)
+	}
+
 	return nil
 }
@@ -2,10 +2,17 @@ package onvif
 
 import (
 	"context"
+	"encoding/json"
 	"encoding/xml"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/0x524a/onvif-go/onviftest"
 )
 
 func TestGetDeviceInformation(t *testing.T) {
@@ -82,6 +89,81 @@ func TestGetDeviceInformation(t *testing.T) {
 	}
 }
 
+func TestGetDeviceInformationExtension(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:GetDeviceInformationResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:vnd="http://vendor.example.com/onvif">
+					<tds:Manufacturer>Acme</tds:Manufacturer>
+					<tds:Model>X1</tds:Model>
+					<tds:FirmwareVersion>2.1</tds:FirmwareVersion>
+					<tds:SerialNumber>SN1</tds:SerialNumber>
+					<tds:HardwareId>HW1</tds:HardwareId>
+					<vnd:CountryCode>DE</vnd:CountryCode>
+					<tds:Extension>
+						<vnd:BuildDate> 2025-06-01 </vnd:BuildDate>
+					</tds:Extension>
+				</tds:GetDeviceInformationResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	info, err := client.GetDeviceInformation(context.Background())
+	if err != nil {
+		t.Fatalf("GetDeviceInformation() error = %v", err)
+	}
+
+	if info.Manufacturer != "Acme" || info.HardwareID != "HW1" {
+		t.Errorf("Unexpected typed fields: %+v", info)
+	}
+	if len(info.Extension) != 2 || info.Extension["CountryCode"] != "DE" || info.Extension["BuildDate"] != "2025-06-01" {
+		t.Errorf("Unexpected extension fields: %v", info.Extension)
+	}
+}
+
+func TestTypesJSON(t *testing.T) {
+	info := &DeviceInformation{Manufacturer: "Acme", HardwareID: "HW1"}
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"hardware_id":"HW1"`) || strings.Contains(string(data), "extension") {
+		t.Errorf("Unexpected DeviceInformation JSON: %s", data)
+	}
+
+	profile := &Profile{
+		Token: "Profile_1",
+		VideoEncoderConfiguration: &VideoEncoderConfiguration{
+			Encoding:   "H264",
+			Resolution: &VideoResolution{Width: 1920, Height: 1080},
+		},
+	}
+	data, err = json.Marshal(profile)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"video_encoder_configuration":{`) || strings.Contains(string(data), "ptz_configuration") {
+		t.Errorf("Unexpected Profile JSON: %s", data)
+	}
+
+	var decoded Profile
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if decoded.VideoEncoderConfiguration == nil || decoded.VideoEncoderConfiguration.Resolution.Width != 1920 {
+		t.Errorf("Profile did not survive a round trip: %+v", decoded)
+	}
+}
+
 func TestGetCapabilities(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		response := `<?xml version="1.0" encoding="UTF-8"?>
@@ -123,17 +205,37 @@ func TestGetCapabilities(t *testing.T) {
 	}
 }
 
-func TestGetHostname(t *testing.T) {
+func TestGetCapabilities_Extension(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		response := `<?xml version="1.0" encoding="UTF-8"?>
 		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
 			<s:Body>
-				<tds:GetHostnameResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
-					<tds:HostnameInformation>
-						<tt:FromDHCP>false</tt:FromDHCP>
-						<tt:Name>test-camera</tt:Name>
-					</tds:HostnameInformation>
-				</tds:GetHostnameResponse>
+				<tds:GetCapabilitiesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<tds:Capabilities>
+						<tt:Analytics>
+							<tt:XAddr>http://example.com/onvif/analytics_service</tt:XAddr>
+						</tt:Analytics>
+						<tt:Extension>
+							<tt:DeviceIO>
+								<tt:XAddr>http://example.com/onvif/deviceio_service</tt:XAddr>
+								<tt:VideoSources>1</tt:VideoSources>
+								<tt:RelayOutputs>2</tt:RelayOutputs>
+							</tt:DeviceIO>
+							<tt:Recording>
+								<tt:XAddr>http://example.com/onvif/recording_service</tt:XAddr>
+								<tt:DynamicRecordings>true</tt:DynamicRecordings>
+								<tt:MaxStringLength>64</tt:MaxStringLength>
+							</tt:Recording>
+							<tt:Search>
+								<tt:XAddr>http://example.com/onvif/search_service</tt:XAddr>
+								<tt:MetadataSearch>true</tt:MetadataSearch>
+							</tt:Search>
+							<tt:Replay>
+								<tt:XAddr>http://example.com/onvif/replay_service</tt:XAddr>
+							</tt:Replay>
+						</tt:Extension>
+					</tds:Capabilities>
+				</tds:GetCapabilitiesResponse>
 			</s:Body>
 		</s:Envelope>`
 		w.WriteHeader(http.StatusOK)
@@ -146,44 +248,276 @@ func TestGetHostname(t *testing.T) {
 		t.Fatalf("Failed to create client: %v", err)
 	}
 
-	hostname, err := client.GetHostname(context.Background())
+	capabilities, err := client.GetCapabilities(context.Background())
 	if err != nil {
-		t.Fatalf("GetHostname() error = %v", err)
+		t.Fatalf("GetCapabilities() error = %v", err)
 	}
 
-	if hostname == nil {
-		t.Fatal("Expected hostname information, got nil")
+	ext := capabilities.Extension
+	if ext == nil {
+		t.Fatal("Expected Extension capabilities, got nil")
+	}
+	if ext.DeviceIO == nil || ext.DeviceIO.VideoSources != 1 || ext.DeviceIO.RelayOutputs != 2 {
+		t.Errorf("Unexpected DeviceIO capabilities: %+v", ext.DeviceIO)
+	}
+	if ext.Recording == nil || !ext.Recording.DynamicRecordings || ext.Recording.MaxStringLength != 64 {
+		t.Errorf("Unexpected Recording capabilities: %+v", ext.Recording)
+	}
+	if ext.Search == nil || !ext.Search.MetadataSearch {
+		t.Errorf("Unexpected Search capabilities: %+v", ext.Search)
+	}
+	if ext.Replay == nil || ext.Replay.XAddr != "http://example.com/onvif/replay_service" {
+		t.Errorf("Unexpected Replay capabilities: %+v", ext.Replay)
 	}
 
-	if hostname.Name != "test-camera" {
-		t.Errorf("Expected hostname 'test-camera', got '%s'", hostname.Name)
+	if err := client.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if client.analyticsEndpoint != "http://example.com/onvif/analytics_service" {
+		t.Errorf("analyticsEndpoint = %q", client.analyticsEndpoint)
+	}
+	if client.deviceIOEndpoint != "http://example.com/onvif/deviceio_service" {
+		t.Errorf("deviceIOEndpoint = %q", client.deviceIOEndpoint)
+	}
+	if client.recordingEndpoint != "http://example.com/onvif/recording_service" {
+		t.Errorf("recordingEndpoint = %q", client.recordingEndpoint)
+	}
+	if client.searchEndpoint != "http://example.com/onvif/search_service" {
+		t.Errorf("searchEndpoint = %q", client.searchEndpoint)
+	}
+	if client.replayEndpoint != "http://example.com/onvif/replay_service" {
+		t.Errorf("replayEndpoint = %q", client.replayEndpoint)
 	}
 }
 
-func TestSetHostname(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Verify the request body contains the new hostname
-		var envelope struct {
-			Body struct {
-				SetHostname struct {
-					XMLName xml.Name `xml:"SetHostname"`
-					Name    string   `xml:"Name"`
-				} `xml:"SetHostname"`
-			} `xml:"Body"`
-		}
+func TestGetCapabilitiesFor(t *testing.T) {
+	mock := onviftest.NewMockTransport().
+		Handle("GetCapabilities", onviftest.Capabilities("http://camera.test"))
 
-		if err := xml.NewDecoder(r.Body).Decode(&envelope); err != nil {
-			t.Errorf("Failed to decode request: %v", err)
-		}
+	client, err := NewClient("http://camera.test/onvif/device_service", WithHTTPClient(mock.Client()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	ctx := context.Background()
 
-		if envelope.Body.SetHostname.Name != "new-hostname" {
-			t.Errorf("Expected hostname 'new-hostname', got '%s'", envelope.Body.SetHostname.Name)
-		}
+	// The mock answers with every section; only the requested ones are kept
+	capabilities, err := client.GetCapabilitiesFor(ctx, CapabilityCategoryPTZ, CapabilityCategoryMedia)
+	if err != nil {
+		t.Fatalf("GetCapabilitiesFor() error = %v", err)
+	}
+	if capabilities.PTZ == nil || capabilities.PTZ.XAddr != "http://camera.test/onvif/ptz_service" || capabilities.Media == nil {
+		t.Errorf("Expected PTZ and media capabilities, got %+v", capabilities)
+	}
+	if capabilities.Device != nil || capabilities.Events != nil || capabilities.Imaging != nil {
+		t.Errorf("Unrequested sections were populated: %+v", capabilities)
+	}
+
+	requests := mock.Requests()
+	body := requests[len(requests)-1].Body
+	if !strings.Contains(body, ">PTZ</tds:Category>") || !strings.Contains(body, ">Media</tds:Category>") || strings.Contains(body, ">All<") {
+		t.Errorf("Unexpected categories requested: %s", body)
+	}
+
+	if _, err := client.GetCapabilitiesFor(ctx, "Storage"); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("GetCapabilitiesFor() with an unknown category error = %v, want ErrInvalidParameter", err)
+	}
+	if calls := mock.Calls("GetCapabilities"); calls != 1 {
+		t.Errorf("Expected 1 GetCapabilities call, got %d", calls)
+	}
+}
+
+// TestResponseDecodingPrefixStyles decodes the same responses written the way
+// different vendors serialize them. Decoding must only depend on local names.
+func TestResponseDecodingPrefixStyles(t *testing.T) {
+	const envelope = `<?xml version="1.0" encoding="UTF-8"?>
+	<{env}Envelope {envDecl}>
+		<{env}Header/>
+		<{env}Body>{body}</{env}Body>
+	</{env}Envelope>`
+
+	const deviceInformation = `
+	<{dev}GetDeviceInformationResponse {devDecl}>
+		<{dev}Manufacturer>Acme</{dev}Manufacturer>
+		<{dev}Model>X1</{dev}Model>
+		<{dev}FirmwareVersion>2.1</{dev}FirmwareVersion>
+		<{dev}SerialNumber>SN1</{dev}SerialNumber>
+		<{dev}HardwareId>HW1</{dev}HardwareId>
+	</{dev}GetDeviceInformationResponse>`
+
+	const capabilities = `
+	<{dev}GetCapabilitiesResponse {devDecl}>
+		<{dev}Capabilities>
+			<{sch}Device {schDecl}>
+				<{sch}XAddr>http://example.com/onvif/device_service</{sch}XAddr>
+				<{sch}System>
+					<{sch}FirmwareUpgrade>true</{sch}FirmwareUpgrade>
+					<{sch}SupportedVersions><{sch}Major>2</{sch}Major><{sch}Minor>60</{sch}Minor></{sch}SupportedVersions>
+					<{sch}SupportedVersions><{sch}Major>2</{sch}Major><{sch}Minor>40</{sch}Minor></{sch}SupportedVersions>
+				</{sch}System>
+			</{sch}Device>
+			<{sch}Media {schDecl}>
+				<{sch}XAddr>http://example.com/onvif/media_service</{sch}XAddr>
+				<{sch}StreamingCapabilities>
+					<{sch}RTP_RTSP_TCP>true</{sch}RTP_RTSP_TCP>
+				</{sch}StreamingCapabilities>
+			</{sch}Media>
+			<{sch}Extension {schDecl}>
+				<{sch}Recording>
+					<{sch}XAddr>http://example.com/onvif/recording_service</{sch}XAddr>
+				</{sch}Recording>
+			</{sch}Extension>
+		</{dev}Capabilities>
+	</{dev}GetCapabilitiesResponse>`
+
+	styles := []struct {
+		name     string
+		replacer *strings.Replacer
+	}{
+		{
+			// Prefixes declared once on the envelope, as gSOAP-based firmware does
+			name: "gSOAP prefixes",
+			replacer: strings.NewReplacer(
+				"{env}", "SOAP-ENV:", "{dev}", "ns1:", "{sch}", "ns2:",
+				"{envDecl}", `xmlns:SOAP-ENV="http://www.w3.org/2003/05/soap-envelope" xmlns:ns1="http://www.onvif.org/ver10/device/wsdl" xmlns:ns2="http://www.onvif.org/ver10/schema"`,
+				"{devDecl}", "", "{schDecl}", "",
+			),
+		},
+		{
+			// Unprefixed elements with default namespaces redeclared per subtree
+			name: "default namespaces",
+			replacer: strings.NewReplacer(
+				"{env}", "", "{dev}", "", "{sch}", "",
+				"{envDecl}", `xmlns="http://www.w3.org/2003/05/soap-envelope"`,
+				"{devDecl}", `xmlns="http://www.onvif.org/ver10/device/wsdl"`,
+				"{schDecl}", `xmlns="http://www.onvif.org/ver10/schema"`,
+			),
+		},
+	}
+
+	for _, style := range styles {
+		t.Run(style.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				request, _ := io.ReadAll(r.Body)
+				body := deviceInformation
+				if strings.Contains(string(request), "GetCapabilities") {
+					body = capabilities
+				}
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(style.replacer.Replace(strings.Replace(envelope, "{body}", body, 1))))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(server.URL)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			ctx := context.Background()
+
+			info, err := client.GetDeviceInformation(ctx)
+			if err != nil {
+				t.Fatalf("GetDeviceInformation() error = %v", err)
+			}
+			if info.Manufacturer != "Acme" || info.Model != "X1" || info.HardwareID != "HW1" {
+				t.Errorf("Unexpected device information: %+v", info)
+			}
+
+			caps, err := client.GetCapabilities(ctx)
+			if err != nil {
+				t.Fatalf("GetCapabilities() error = %v", err)
+			}
+			if caps.Device == nil || caps.Device.System == nil || !caps.Device.System.FirmwareUpgrade ||
+				len(caps.Device.System.SupportedVersions) != 2 {
+				t.Errorf("Unexpected device capabilities: %+v", caps.Device)
+			}
+			if caps.Media == nil || caps.Media.StreamingCapabilities == nil || !caps.Media.StreamingCapabilities.RTP_RTSP_TCP {
+				t.Errorf("Unexpected media capabilities: %+v", caps.Media)
+			}
+			if caps.Extension == nil || caps.Extension.Recording == nil ||
+				caps.Extension.Recording.XAddr != "http://example.com/onvif/recording_service" {
+				t.Errorf("Unexpected extension capabilities: %+v", caps.Extension)
+			}
+		})
+	}
+}
+
+func TestSystemReboot(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     http.HandlerFunc
+		wantMessage string
+		wantErr     error
+	}{
+		{
+			name: "reboot with message",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				response := `<?xml version="1.0" encoding="UTF-8"?>
+				<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+					<s:Body>
+						<tds:SystemRebootResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+							<tds:Message>Rebooting in 30 seconds</tds:Message>
+						</tds:SystemRebootResponse>
+					</s:Body>
+				</s:Envelope>`
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(response))
+			},
+			wantMessage: "Rebooting in 30 seconds",
+		},
+		{
+			name: "empty response body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			},
+			wantMessage: "",
+		},
+		{
+			name: "connection dropped",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				hijacker, ok := w.(http.Hijacker)
+				if !ok {
+					t.Fatal("ResponseWriter does not support hijacking")
+				}
+				conn, _, err := hijacker.Hijack()
+				if err != nil {
+					t.Fatalf("Hijack failed: %v", err)
+				}
+				_ = conn.Close()
+			},
+			wantErr: ErrRebootInProgress,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			client, err := NewClient(server.URL)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			message, err := client.SystemReboot(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SystemReboot() error = %v, want %v", err, tt.wantErr)
+			}
 
+			if message != tt.wantMessage {
+				t.Errorf("Expected message '%s', got '%s'", tt.wantMessage, message)
+			}
+		})
+	}
+}
+
+func TestSetSystemFactoryDefault(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
 		response := `<?xml version="1.0" encoding="UTF-8"?>
 		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
 			<s:Body>
-				<tds:SetHostnameResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
+				<tds:SetSystemFactoryDefaultResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
 			</s:Body>
 		</s:Envelope>`
 		w.WriteHeader(http.StatusOK)
@@ -196,29 +530,63 @@ func TestSetHostname(t *testing.T) {
 		t.Fatalf("Failed to create client: %v", err)
 	}
 
-	err = client.SetHostname(context.Background(), "new-hostname")
+	if err := client.SetSystemFactoryDefault(context.Background(), "Soft"); err != nil {
+		t.Fatalf("SetSystemFactoryDefault() error = %v", err)
+	}
+	if !strings.Contains(body, "<tds:FactoryDefault>Soft</tds:FactoryDefault>") {
+		t.Errorf("Expected Soft factory default in request, got: %s", body)
+	}
+
+	if err := client.SetSystemFactoryDefault(context.Background(), "soft"); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for an unknown reset type, got %v", err)
+	}
+}
+
+func TestSetSystemFactoryDefaultConnectionDropped(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("Hijack failed: %v", err)
+			return
+		}
+		_ = conn.Close()
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
 	if err != nil {
-		t.Fatalf("SetHostname() error = %v", err)
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if err := client.SetSystemFactoryDefault(context.Background(), "Hard"); !errors.Is(err, ErrRebootInProgress) {
+		t.Errorf("Expected ErrRebootInProgress, got %v", err)
 	}
 }
 
-func TestGetDNS(t *testing.T) {
+func TestRemoteUser(t *testing.T) {
+	var body string
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
 		response := `<?xml version="1.0" encoding="UTF-8"?>
 		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
 			<s:Body>
-				<tds:GetDNSResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
-					<tds:DNSInformation>
-						<tt:FromDHCP>true</tt:FromDHCP>
-						<tt:SearchDomain>example.com</tt:SearchDomain>
-						<tt:DNSFromDHCP>
-							<tt:Type>IPv4</tt:Type>
-							<tt:IPv4Address>8.8.8.8</tt:IPv4Address>
-						</tt:DNSFromDHCP>
-					</tds:DNSInformation>
-				</tds:GetDNSResponse>
+				<tds:SetRemoteUserResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
 			</s:Body>
 		</s:Envelope>`
+		if strings.Contains(body, "GetRemoteUser") {
+			response = `<?xml version="1.0" encoding="UTF-8"?>
+			<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+				<s:Body>
+					<tds:GetRemoteUserResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+						<tds:RemoteUser>
+							<tt:Username>relay</tt:Username>
+							<tt:UseDerivedPassword>true</tt:UseDerivedPassword>
+						</tds:RemoteUser>
+					</tds:GetRemoteUserResponse>
+				</s:Body>
+			</s:Envelope>`
+		}
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte(response))
 	}))
@@ -228,68 +596,96 @@ func TestGetDNS(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create client: %v", err)
 	}
+	ctx := context.Background()
 
-	dns, err := client.GetDNS(context.Background())
+	user, err := client.GetRemoteUser(ctx)
 	if err != nil {
-		t.Fatalf("GetDNS() error = %v", err)
+		t.Fatalf("GetRemoteUser() error = %v", err)
+	}
+	if user == nil || user.Username != "relay" || !user.UseDerivedPassword {
+		t.Errorf("Unexpected remote user: %+v", user)
 	}
 
-	if dns == nil {
-		t.Fatal("Expected DNS information, got nil")
+	err = client.SetRemoteUser(ctx, &RemoteUser{Username: "relay", Password: "secret"})
+	if err != nil {
+		t.Fatalf("SetRemoteUser() error = %v", err)
+	}
+	if !strings.Contains(body, "<tt:Username>relay</tt:Username>") || !strings.Contains(body, "<tt:Password>secret</tt:Password>") {
+		t.Errorf("Expected remote user in request, got: %s", body)
 	}
 
-	if !dns.FromDHCP {
-		t.Error("Expected DNS from DHCP")
+	if err := client.SetRemoteUser(ctx, nil); err != nil {
+		t.Fatalf("SetRemoteUser(nil) error = %v", err)
+	}
+	if strings.Contains(body, "<tds:RemoteUser>") {
+		t.Errorf("Expected no RemoteUser element when removing, got: %s", body)
 	}
 }
 
-func TestGetUsers(t *testing.T) {
+func TestSetUserUpdatesOwnCredentials(t *testing.T) {
+	fail := false
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		response := `<?xml version="1.0" encoding="UTF-8"?>
-		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
-			<s:Body>
-				<tds:GetUsersResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
-					<tds:User>
-						<tt:Username>admin</tt:Username>
-						<tt:UserLevel>Administrator</tt:UserLevel>
-					</tds:User>
-					<tds:User>
-						<tt:Username>user</tt:Username>
-						<tt:UserLevel>User</tt:UserLevel>
-					</tds:User>
-				</tds:GetUsersResponse>
-			</s:Body>
-		</s:Envelope>`
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte(response))
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body><tds:SetUserResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/></s:Body>
+		</s:Envelope>`))
 	}))
 	defer server.Close()
 
-	client, err := NewClient(server.URL)
+	client, err := NewClient(server.URL, WithCredentials("admin", "old"))
 	if err != nil {
 		t.Fatalf("Failed to create client: %v", err)
 	}
+	ctx := context.Background()
 
-	users, err := client.GetUsers(context.Background())
-	if err != nil {
-		t.Fatalf("GetUsers() error = %v", err)
+	if err := client.SetUser(ctx, &User{Username: "operator", Password: "other", UserLevel: "Operator"}); err != nil {
+		t.Fatalf("SetUser() error = %v", err)
+	}
+	if _, password := client.GetCredentials(); password != "old" {
+		t.Errorf("Changing another user's password changed the client's to %q", password)
 	}
 
-	if len(users) != 2 {
-		t.Errorf("Expected 2 users, got %d", len(users))
+	if err := client.SetUser(ctx, &User{Username: "admin", UserLevel: "Administrator"}); err != nil {
+		t.Fatalf("SetUser() error = %v", err)
+	}
+	if _, password := client.GetCredentials(); password != "old" {
+		t.Errorf("Expected the password to be kept when none is set, got %q", password)
 	}
 
-	if users[0].Username != "admin" {
-		t.Errorf("Expected first user to be 'admin', got '%s'", users[0].Username)
+	fail = true
+	if err := client.SetUser(ctx, &User{Username: "admin", Password: "rejected", UserLevel: "Administrator"}); err == nil {
+		t.Fatal("Expected SetUser to fail")
+	}
+	if _, password := client.GetCredentials(); password != "old" {
+		t.Errorf("A failed change replaced the password with %q", password)
+	}
+
+	fail = false
+	if err := client.SetUser(ctx, &User{Username: "admin", Password: "new", UserLevel: "Administrator"}); err != nil {
+		t.Fatalf("SetUser() error = %v", err)
+	}
+	if username, password := client.GetCredentials(); username != "admin" || password != "new" {
+		t.Errorf("Expected the client to use the new password, got %q %q", username, password)
 	}
 }
 
-func TestCreateUsers(t *testing.T) {
+func TestGetSystemBackup(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		response := `<?xml version="1.0" encoding="UTF-8"?>
 		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
 			<s:Body>
-				<tds:CreateUsersResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
+				<tds:GetSystemBackupResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<tds:BackupFiles>
+						<tt:Name>config.bin</tt:Name>
+						<tt:Data>Y29uZmln
+						ZGF0YQ==</tt:Data>
+					</tds:BackupFiles>
+				</tds:GetSystemBackupResponse>
 			</s:Body>
 		</s:Envelope>`
 		w.WriteHeader(http.StatusOK)
@@ -302,26 +698,824 @@ func TestCreateUsers(t *testing.T) {
 		t.Fatalf("Failed to create client: %v", err)
 	}
 
-	users := []*User{
-		{
-			Username:  "newuser",
-			Password:  "password123",
-			UserLevel: "User",
+	files, err := client.GetSystemBackup(context.Background())
+	if err != nil {
+		t.Fatalf("GetSystemBackup() error = %v", err)
+	}
+
+	if len(files) != 1 {
+		t.Fatalf("Expected 1 backup file, got %d", len(files))
+	}
+
+	if files[0].Name != "config.bin" {
+		t.Errorf("Expected name 'config.bin', got '%s'", files[0].Name)
+	}
+
+	if string(files[0].Data) != "configdata" {
+		t.Errorf("Expected data 'configdata', got '%s'", string(files[0].Data))
+	}
+}
+
+func TestGetSystemBackupMTOM(t *testing.T) {
+	href := "cid:config"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", `multipart/related; type="application/xop+xml"; boundary="uuid:1234"; start="<envelope>"`)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("--uuid:1234\r\n" +
+			"Content-Type: application/xop+xml; charset=UTF-8\r\n" +
+			"Content-ID: <envelope>\r\n\r\n" +
+			`<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>
+				<tds:GetSystemBackupResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<tds:BackupFiles>
+						<tt:Name>config.bin</tt:Name>
+						<tt:Data><xop:Include xmlns:xop="http://www.w3.org/2004/08/xop/include" href="` + href + `"/></tt:Data>
+					</tds:BackupFiles>
+				</tds:GetSystemBackupResponse>
+			</s:Body></s:Envelope>` + "\r\n" +
+			"--uuid:1234\r\n" +
+			"Content-Type: application/octet-stream\r\n" +
+			"Content-ID: <config>\r\n\r\n" +
+			"configdata\r\n" +
+			"--uuid:1234--\r\n"))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	files, err := client.GetSystemBackup(context.Background())
+	if err != nil {
+		t.Fatalf("GetSystemBackup() error = %v", err)
+	}
+	if len(files) != 1 || files[0].Name != "config.bin" || string(files[0].Data) != "configdata" {
+		t.Errorf("Unexpected backup files: %+v", files)
+	}
+
+	href = "cid:missing"
+	_, err = client.GetSystemBackup(context.Background())
+	if !errors.Is(err, ErrInvalidResponse) || !strings.Contains(err.Error(), "cid:missing") {
+		t.Errorf("Expected ErrInvalidResponse for the missing attachment, got %v", err)
+	}
+}
+
+func TestRestoreSystem(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var envelope struct {
+			Body struct {
+				RestoreSystem struct {
+					BackupFiles struct {
+						Data string `xml:"Data"`
+					} `xml:"BackupFiles"`
+				} `xml:"RestoreSystem"`
+			} `xml:"Body"`
+		}
+
+		if err := xml.NewDecoder(r.Body).Decode(&envelope); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+
+		if envelope.Body.RestoreSystem.BackupFiles.Data != "Y29uZmlnZGF0YQ==" {
+			t.Errorf("Unexpected backup data '%s'", envelope.Body.RestoreSystem.BackupFiles.Data)
+		}
+
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:RestoreSystemResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if err := client.RestoreSystem(context.Background(), []byte("configdata")); err != nil {
+		t.Fatalf("RestoreSystem() error = %v", err)
+	}
+}
+
+func TestStartFirmwareUpgrade(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:StartFirmwareUpgradeResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+					<tds:UploadUri>http://192.168.1.100/firmware/upload</tds:UploadUri>
+					<tds:UploadDelay>PT5S</tds:UploadDelay>
+					<tds:ExpectedDownTime>PT2M30S</tds:ExpectedDownTime>
+				</tds:StartFirmwareUpgradeResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	info, err := client.StartFirmwareUpgrade(context.Background())
+	if err != nil {
+		t.Fatalf("StartFirmwareUpgrade() error = %v", err)
+	}
+
+	if info.UploadURI != "http://192.168.1.100/firmware/upload" {
+		t.Errorf("Unexpected upload URI '%s'", info.UploadURI)
+	}
+
+	if info.UploadDelay != 5*time.Second {
+		t.Errorf("Expected upload delay 5s, got %v", info.UploadDelay)
+	}
+
+	if info.ExpectedDownTime != 150*time.Second {
+		t.Errorf("Expected down time 2m30s, got %v", info.ExpectedDownTime)
+	}
+}
+
+func TestUploadFirmware(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("firmware")
+		if err != nil {
+			t.Errorf("Failed to read firmware part: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer func() { _ = file.Close() }()
+
+		data, _ := io.ReadAll(file)
+		if string(data) != "firmware-image" {
+			t.Errorf("Unexpected firmware data '%s'", string(data))
+		}
+
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	for _, status = range []int{http.StatusOK, http.StatusCreated, http.StatusAccepted, http.StatusNoContent} {
+		err = client.UploadFirmware(context.Background(), server.URL+"/firmware/upload", strings.NewReader("firmware-image"))
+		if err != nil {
+			t.Fatalf("UploadFirmware() with HTTP %d: error = %v", status, err)
+		}
+	}
+
+	status = http.StatusInternalServerError
+	err = client.UploadFirmware(context.Background(), server.URL+"/firmware/upload", strings.NewReader("firmware-image"))
+	if err == nil || !strings.Contains(err.Error(), "HTTP status 500") {
+		t.Errorf("Expected an HTTP status error, got %v", err)
+	}
+}
+
+func TestGetHostname(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:GetHostnameResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+					<tds:HostnameInformation>
+						<tt:FromDHCP>false</tt:FromDHCP>
+						<tt:Name>test-camera</tt:Name>
+					</tds:HostnameInformation>
+				</tds:GetHostnameResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	hostname, err := client.GetHostname(context.Background())
+	if err != nil {
+		t.Fatalf("GetHostname() error = %v", err)
+	}
+
+	if hostname == nil {
+		t.Fatal("Expected hostname information, got nil")
+	}
+
+	if hostname.Name != "test-camera" {
+		t.Errorf("Expected hostname 'test-camera', got '%s'", hostname.Name)
+	}
+}
+
+func TestSetHostname(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Verify the request body contains the new hostname
+		var envelope struct {
+			Body struct {
+				SetHostname struct {
+					XMLName xml.Name `xml:"SetHostname"`
+					Name    string   `xml:"Name"`
+				} `xml:"SetHostname"`
+			} `xml:"Body"`
+		}
+
+		if err := xml.NewDecoder(r.Body).Decode(&envelope); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+
+		if envelope.Body.SetHostname.Name != "new-hostname" {
+			t.Errorf("Expected hostname 'new-hostname', got '%s'", envelope.Body.SetHostname.Name)
+		}
+
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:SetHostnameResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	err = client.SetHostname(context.Background(), "new-hostname")
+	if err != nil {
+		t.Fatalf("SetHostname() error = %v", err)
+	}
+}
+
+func TestGetDNS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:GetDNSResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+					<tds:DNSInformation>
+						<tt:FromDHCP>true</tt:FromDHCP>
+						<tt:SearchDomain>example.com</tt:SearchDomain>
+						<tt:DNSFromDHCP>
+							<tt:Type>IPv4</tt:Type>
+							<tt:IPv4Address>8.8.8.8</tt:IPv4Address>
+						</tt:DNSFromDHCP>
+					</tds:DNSInformation>
+				</tds:GetDNSResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	dns, err := client.GetDNS(context.Background())
+	if err != nil {
+		t.Fatalf("GetDNS() error = %v", err)
+	}
+
+	if dns == nil {
+		t.Fatal("Expected DNS information, got nil")
+	}
+
+	if !dns.FromDHCP {
+		t.Error("Expected DNS from DHCP")
+	}
+}
+
+func TestGetUsers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:GetUsersResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+					<tds:User>
+						<tt:Username>admin</tt:Username>
+						<tt:UserLevel>Administrator</tt:UserLevel>
+					</tds:User>
+					<tds:User>
+						<tt:Username>user</tt:Username>
+						<tt:UserLevel>User</tt:UserLevel>
+					</tds:User>
+				</tds:GetUsersResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	users, err := client.GetUsers(context.Background())
+	if err != nil {
+		t.Fatalf("GetUsers() error = %v", err)
+	}
+
+	if len(users) != 2 {
+		t.Errorf("Expected 2 users, got %d", len(users))
+	}
+
+	if users[0].Username != "admin" {
+		t.Errorf("Expected first user to be 'admin', got '%s'", users[0].Username)
+	}
+}
+
+func TestGetUsersExtension(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<SOAP-ENV:Envelope xmlns:SOAP-ENV="http://www.w3.org/2003/05/soap-envelope" xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema" xmlns:vnd="http://vendor.example.com/onvif">
+			<SOAP-ENV:Body>
+				<tds:GetUsersResponse>
+					<tt:User>
+						<tt:Username>admin</tt:Username>
+						<tt:UserLevel>Administrator</tt:UserLevel>
+					</tt:User>
+					<tt:User>
+						<tt:Username>installer</tt:Username>
+						<tt:UserLevel>Extended</tt:UserLevel>
+						<tt:Extension>
+							<vnd:Role>Installer</vnd:Role>
+							<vnd:Expires>2027-01-01</vnd:Expires>
+						</tt:Extension>
+					</tt:User>
+				</tds:GetUsersResponse>
+			</SOAP-ENV:Body>
+		</SOAP-ENV:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	users, err := client.GetUsers(context.Background())
+	if err != nil {
+		t.Fatalf("GetUsers() error = %v", err)
+	}
+
+	if len(users) != 2 {
+		t.Fatalf("Expected 2 users, got %d", len(users))
+	}
+	if users[0].Username != "admin" || users[0].Extension != nil {
+		t.Errorf("Unexpected first user: %+v", users[0])
+	}
+	if users[1].UserLevel != "Extended" || users[1].Extension["Role"] != "Installer" || users[1].Extension["Expires"] != "2027-01-01" {
+		t.Errorf("Unexpected extended user: %+v", users[1])
+	}
+}
+
+func TestGetScopes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:GetScopesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<tds:Scopes>
+						<tt:ScopeDef>Fixed</tt:ScopeDef>
+						<tt:ScopeItem>onvif://www.onvif.org/Profile/Streaming</tt:ScopeItem>
+					</tds:Scopes>
+					<tds:Scopes>
+						<tt:ScopeDef> Configurable </tt:ScopeDef>
+						<tt:ScopeItem>
+							onvif://www.onvif.org/location/parking
+						</tt:ScopeItem>
+					</tds:Scopes>
+					<tds:Scopes>
+						<tt:ScopeItem>onvif://www.onvif.org/hardware/IPC</tt:ScopeItem>
+					</tds:Scopes>
+				</tds:GetScopesResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	scopes, err := client.GetScopes(context.Background())
+	if err != nil {
+		t.Fatalf("GetScopes() error = %v", err)
+	}
+	if len(scopes) != 3 {
+		t.Fatalf("Expected 3 scopes, got %d", len(scopes))
+	}
+
+	tests := []struct {
+		item         string
+		def          string
+		configurable bool
+	}{
+		{"onvif://www.onvif.org/Profile/Streaming", ScopeDefFixed, false},
+		{"onvif://www.onvif.org/location/parking", ScopeDefConfigurable, true},
+		{"onvif://www.onvif.org/hardware/IPC", ScopeDefFixed, false},
+	}
+	for i, tt := range tests {
+		scope := scopes[i]
+		if scope.ScopeItem != tt.item || scope.ScopeDef != tt.def || scope.IsConfigurable() != tt.configurable {
+			t.Errorf("Scope %d = %+v (configurable %v), want %s %s (configurable %v)",
+				i, scope, scope.IsConfigurable(), tt.item, tt.def, tt.configurable)
+		}
+	}
+}
+
+func TestIPAddressFilter(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body := string(data)
+		bodies = append(bodies, body)
+
+		var response string
+		switch {
+		case strings.Contains(body, "GetIPAddressFilter"):
+			response = `<tds:GetIPAddressFilterResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tds:IPAddressFilter>
+					<tt:Type>Allow</tt:Type>
+					<tt:IPv4Address>
+						<tt:Address>192.168.1.0</tt:Address>
+						<tt:PrefixLength>24</tt:PrefixLength>
+					</tt:IPv4Address>
+					<tt:IPv6Address>
+						<tt:Address>fd00::</tt:Address>
+						<tt:PrefixLength>64</tt:PrefixLength>
+					</tt:IPv6Address>
+				</tds:IPAddressFilter>
+			</tds:GetIPAddressFilterResponse>`
+		case strings.Contains(body, "SetIPAddressFilter"):
+			response = `<tds:SetIPAddressFilterResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>`
+		case strings.Contains(body, "AddIPAddressFilter"):
+			response = `<tds:AddIPAddressFilterResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>`
+		case strings.Contains(body, "RemoveIPAddressFilter"):
+			response = `<tds:RemoveIPAddressFilterResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>` + response + `</s:Body></s:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	filter, err := client.GetIPAddressFilter(ctx)
+	if err != nil {
+		t.Fatalf("GetIPAddressFilter() error = %v", err)
+	}
+	if filter.Type != IPAddressFilterAllow || len(filter.IPv4Address) != 1 || len(filter.IPv6Address) != 1 {
+		t.Fatalf("Unexpected filter: %+v", filter)
+	}
+	if filter.IPv4Address[0] != (PrefixedIPv4Address{Address: "192.168.1.0", PrefixLength: 24}) {
+		t.Errorf("Unexpected IPv4 address: %+v", filter.IPv4Address[0])
+	}
+	if filter.IPv6Address[0] != (PrefixedIPv6Address{Address: "fd00::", PrefixLength: 64}) {
+		t.Errorf("Unexpected IPv6 address: %+v", filter.IPv6Address[0])
+	}
+
+	deny := IPAddressFilter{
+		Type:        IPAddressFilterDeny,
+		IPv4Address: []PrefixedIPv4Address{{Address: "10.0.0.5", PrefixLength: 32}},
+	}
+	for name, call := range map[string]func(context.Context, IPAddressFilter) error{
+		"SetIPAddressFilter":    client.SetIPAddressFilter,
+		"AddIPAddressFilter":    client.AddIPAddressFilter,
+		"RemoveIPAddressFilter": client.RemoveIPAddressFilter,
+	} {
+		bodies = nil
+		if err := call(ctx, deny); err != nil {
+			t.Fatalf("%s() error = %v", name, err)
+		}
+		body := strings.Join(strings.Fields(bodies[0]), "")
+		for _, want := range []string{
+			"<tds:" + name,
+			"<tds:IPAddressFilter><tt:Type>Deny</tt:Type><tt:IPv4Address><tt:Address>10.0.0.5</tt:Address><tt:PrefixLength>32</tt:PrefixLength></tt:IPv4Address></tds:IPAddressFilter>",
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("Expected %s in %s request, got: %s", want, name, bodies[0])
+			}
+		}
+		if strings.Contains(body, "IPv6Address") {
+			t.Errorf("Unexpected IPv6 addresses in %s request: %s", name, bodies[0])
+		}
+
+		for _, invalid := range []IPAddressFilter{
+			{Type: "Block"},
+			{Type: IPAddressFilterAllow, IPv4Address: []PrefixedIPv4Address{{Address: "fd00::", PrefixLength: 8}}},
+			{Type: IPAddressFilterAllow, IPv4Address: []PrefixedIPv4Address{{Address: "10.0.0.0", PrefixLength: 33}}},
+			{Type: IPAddressFilterAllow, IPv6Address: []PrefixedIPv6Address{{Address: "10.0.0.0", PrefixLength: 8}}},
+		} {
+			if err := call(ctx, invalid); !errors.Is(err, ErrInvalidParameter) {
+				t.Errorf("%s(%+v) expected ErrInvalidParameter, got %v", name, invalid, err)
+			}
+		}
+	}
+}
+
+func TestStorageConfigurations(t *testing.T) {
+	var setBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body := string(data)
+
+		var response string
+		if strings.Contains(body, "GetStorageConfigurations") {
+			response = `<tds:GetStorageConfigurationsResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+				<tds:StorageConfigurations token="Storage_1">
+					<tds:Data type="NFS">
+						<tds:StorageUri>nfs://192.168.1.20/export/camera</tds:StorageUri>
+					</tds:Data>
+				</tds:StorageConfigurations>
+				<tds:StorageConfigurations token="Storage_2">
+					<tds:Data type="CIFS">
+						<tds:LocalPath>/mnt/nas</tds:LocalPath>
+						<tds:StorageUri>//nas/recordings</tds:StorageUri>
+						<tds:User>
+							<tds:UserName>recorder</tds:UserName>
+						</tds:User>
+					</tds:Data>
+				</tds:StorageConfigurations>
+			</tds:GetStorageConfigurationsResponse>`
+		} else {
+			setBody = body
+			response = `<tds:SetStorageConfigurationResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>` + response + `</s:Body></s:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	configs, err := client.GetStorageConfigurations(ctx)
+	if err != nil {
+		t.Fatalf("GetStorageConfigurations() error = %v", err)
+	}
+	if len(configs) != 2 {
+		t.Fatalf("Expected 2 storage configurations, got %d", len(configs))
+	}
+	if nfs := configs[0]; nfs.Token != "Storage_1" || nfs.Type != StorageTypeNFS || nfs.StorageURI != "nfs://192.168.1.20/export/camera" || nfs.User != nil {
+		t.Errorf("Unexpected NFS configuration: %+v", nfs)
+	}
+	cifs := configs[1]
+	if cifs.Type != StorageTypeCIFS || cifs.LocalPath != "/mnt/nas" || cifs.User == nil || cifs.User.Username != "recorder" {
+		t.Errorf("Unexpected CIFS configuration: %+v", cifs)
+	}
+
+	cifs.User.Password = "secret"
+	if err := client.SetStorageConfiguration(ctx, *cifs); err != nil {
+		t.Fatalf("SetStorageConfiguration() error = %v", err)
+	}
+	for _, want := range []string{
+		`<tds:StorageConfiguration token="Storage_2"><tds:Data type="CIFS">`,
+		`<tds:LocalPath>/mnt/nas</tds:LocalPath>`,
+		`<tds:StorageUri>//nas/recordings</tds:StorageUri>`,
+		`<tds:User><tds:UserName>recorder</tds:UserName><tds:Password>secret</tds:Password></tds:User>`,
+	} {
+		if !strings.Contains(strings.Join(strings.Fields(setBody), ""), strings.Join(strings.Fields(want), "")) {
+			t.Errorf("Expected %s in request, got: %s", want, setBody)
+		}
+	}
+
+	for _, invalid := range []StorageConfiguration{
+		{Type: StorageTypeNFS},
+		{Token: "Storage_1"},
+	} {
+		if err := client.SetStorageConfiguration(ctx, invalid); !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("Expected ErrInvalidParameter for %+v, got %v", invalid, err)
+		}
+	}
+}
+
+func TestCreateUsers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:CreateUsersResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	users := []*User{
+		{
+			Username:  "newuser",
+			Password:  "password123",
+			UserLevel: "User",
 		},
 	}
 
-	err = client.CreateUsers(context.Background(), users)
+	err = client.CreateUsers(context.Background(), users)
+	if err != nil {
+		t.Fatalf("CreateUsers() error = %v", err)
+	}
+}
+
+func TestUserLevelValidation(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	ctx := context.Background()
+
+	users := []*User{
+		{Username: "operator", Password: "secret", UserLevel: UserLevelOperator},
+		{Username: "guest", Password: "secret", UserLevel: "Guest"},
+	}
+	if err := client.CreateUsers(ctx, users); !errors.Is(err, ErrInvalidUserLevel) {
+		t.Errorf("CreateUsers() error = %v, want ErrInvalidUserLevel", err)
+	}
+	if err := client.SetUser(ctx, &User{Username: "operator", UserLevel: "administrator"}); !errors.Is(err, ErrInvalidUserLevel) {
+		t.Errorf("SetUser() error = %v, want ErrInvalidUserLevel", err)
+	}
+	if err := client.SetUser(ctx, &User{Username: "operator"}); !errors.Is(err, ErrInvalidUserLevel) {
+		t.Errorf("SetUser() without a level error = %v, want ErrInvalidUserLevel", err)
+	}
+	if requests != 0 {
+		t.Errorf("Invalid user levels were sent in %d requests", requests)
+	}
+}
+
+func TestDeleteUsers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:DeleteUsersResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	err = client.DeleteUsers(context.Background(), []string{"testuser"})
+	if err != nil {
+		t.Fatalf("DeleteUsers() error = %v", err)
+	}
+}
+
+func TestGetNetworkInterfaces(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:GetNetworkInterfacesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+					<tds:NetworkInterfaces token="eth0">
+						<tt:Enabled>true</tt:Enabled>
+						<tt:Info>
+							<tt:Name>eth0</tt:Name>
+							<tt:HwAddress>00:11:22:33:44:55</tt:HwAddress>
+							<tt:MTU>1500</tt:MTU>
+						</tt:Info>
+						<tt:IPv4>
+							<tt:Enabled>true</tt:Enabled>
+							<tt:Config>
+								<tt:DHCP>false</tt:DHCP>
+								<tt:Manual>
+									<tt:Address>192.168.1.100</tt:Address>
+									<tt:PrefixLength>24</tt:PrefixLength>
+								</tt:Manual>
+							</tt:Config>
+						</tt:IPv4>
+					</tds:NetworkInterfaces>
+				</tds:GetNetworkInterfacesResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	interfaces, err := client.GetNetworkInterfaces(context.Background())
+	if err != nil {
+		t.Fatalf("GetNetworkInterfaces() error = %v", err)
+	}
+
+	if len(interfaces) != 1 {
+		t.Errorf("Expected 1 interface, got %d", len(interfaces))
+	}
+
+	if interfaces[0].Info.Name != "eth0" {
+		t.Errorf("Expected interface name 'eth0', got '%s'", interfaces[0].Info.Name)
+	}
+}
+
+func TestGetDiscoveryMode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:GetDiscoveryModeResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+					<tds:DiscoveryMode>NonDiscoverable</tds:DiscoveryMode>
+				</tds:GetDiscoveryModeResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
 	if err != nil {
-		t.Fatalf("CreateUsers() error = %v", err)
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	mode, err := client.GetDiscoveryMode(context.Background())
+	if err != nil {
+		t.Fatalf("GetDiscoveryMode() error = %v", err)
+	}
+
+	if mode != "NonDiscoverable" {
+		t.Errorf("Expected mode 'NonDiscoverable', got '%s'", mode)
 	}
 }
 
-func TestDeleteUsers(t *testing.T) {
+func TestSetDiscoveryMode(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var envelope struct {
+			Body struct {
+				SetDiscoveryMode struct {
+					DiscoveryMode string `xml:"DiscoveryMode"`
+				} `xml:"SetDiscoveryMode"`
+			} `xml:"Body"`
+		}
+
+		if err := xml.NewDecoder(r.Body).Decode(&envelope); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+
+		if envelope.Body.SetDiscoveryMode.DiscoveryMode != "Discoverable" {
+			t.Errorf("Expected mode 'Discoverable', got '%s'", envelope.Body.SetDiscoveryMode.DiscoveryMode)
+		}
+
 		response := `<?xml version="1.0" encoding="UTF-8"?>
 		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
 			<s:Body>
-				<tds:DeleteUsersResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
+				<tds:SetDiscoveryModeResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
 			</s:Body>
 		</s:Envelope>`
 		w.WriteHeader(http.StatusOK)
@@ -334,37 +1528,112 @@ func TestDeleteUsers(t *testing.T) {
 		t.Fatalf("Failed to create client: %v", err)
 	}
 
-	err = client.DeleteUsers(context.Background(), []string{"testuser"})
+	if err := client.SetDiscoveryMode(context.Background(), "Discoverable"); err != nil {
+		t.Fatalf("SetDiscoveryMode() error = %v", err)
+	}
+
+	if err := client.SetDiscoveryMode(context.Background(), "Hidden"); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for invalid mode, got %v", err)
+	}
+}
+
+func TestAddScopes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var envelope struct {
+			Body struct {
+				AddScopes struct {
+					ScopeItem []string `xml:"ScopeItem"`
+				} `xml:"AddScopes"`
+			} `xml:"Body"`
+		}
+
+		if err := xml.NewDecoder(r.Body).Decode(&envelope); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+
+		if len(envelope.Body.AddScopes.ScopeItem) != 2 {
+			t.Errorf("Expected 2 scope items, got %d", len(envelope.Body.AddScopes.ScopeItem))
+		}
+
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:AddScopesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
 	if err != nil {
-		t.Fatalf("DeleteUsers() error = %v", err)
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	err = client.AddScopes(context.Background(), []string{
+		"onvif://www.onvif.org/location/building/1",
+		"onvif://www.onvif.org/name/Lobby",
+	})
+	if err != nil {
+		t.Fatalf("AddScopes() error = %v", err)
 	}
 }
 
-func TestGetNetworkInterfaces(t *testing.T) {
+func TestValidateScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []string
+		wantErr bool
+	}{
+		{
+			name:    "valid onvif scopes",
+			scopes:  []string{"onvif://www.onvif.org/location/Building1", "onvif://www.onvif.org/name/Camera"},
+			wantErr: false,
+		},
+		{
+			name:    "valid urn scope",
+			scopes:  []string{"urn:example:scope"},
+			wantErr: false,
+		},
+		{
+			name:    "missing scheme",
+			scopes:  []string{"www.onvif.org/location/Building1"},
+			wantErr: true,
+		},
+		{
+			name:    "plain text",
+			scopes:  []string{"Building 1"},
+			wantErr: true,
+		},
+		{
+			name:    "empty list",
+			scopes:  nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateScopes(tt.scopes)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateScopes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidParameter) {
+				t.Errorf("Expected ErrInvalidParameter, got %v", err)
+			}
+		})
+	}
+}
+
+func TestGetEndpointReference(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		response := `<?xml version="1.0" encoding="UTF-8"?>
 		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
 			<s:Body>
-				<tds:GetNetworkInterfacesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
-					<tds:NetworkInterfaces token="eth0">
-						<tt:Enabled>true</tt:Enabled>
-						<tt:Info>
-							<tt:Name>eth0</tt:Name>
-							<tt:HwAddress>00:11:22:33:44:55</tt:HwAddress>
-							<tt:MTU>1500</tt:MTU>
-						</tt:Info>
-						<tt:IPv4>
-							<tt:Enabled>true</tt:Enabled>
-							<tt:Config>
-								<tt:DHCP>false</tt:DHCP>
-								<tt:Manual>
-									<tt:Address>192.168.1.100</tt:Address>
-									<tt:PrefixLength>24</tt:PrefixLength>
-								</tt:Manual>
-							</tt:Config>
-						</tt:IPv4>
-					</tds:NetworkInterfaces>
-				</tds:GetNetworkInterfacesResponse>
+				<tds:GetEndpointReferenceResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+					<tds:GUID>urn:uuid:1419d68a-1dd2-11b2-a105-F0000000A1B2</tds:GUID>
+				</tds:GetEndpointReferenceResponse>
 			</s:Body>
 		</s:Envelope>`
 		w.WriteHeader(http.StatusOK)
@@ -377,17 +1646,102 @@ func TestGetNetworkInterfaces(t *testing.T) {
 		t.Fatalf("Failed to create client: %v", err)
 	}
 
-	interfaces, err := client.GetNetworkInterfaces(context.Background())
+	guid, err := client.GetEndpointReference(context.Background())
 	if err != nil {
-		t.Fatalf("GetNetworkInterfaces() error = %v", err)
+		t.Fatalf("GetEndpointReference() error = %v", err)
 	}
 
-	if len(interfaces) != 1 {
-		t.Errorf("Expected 1 interface, got %d", len(interfaces))
+	if guid != "urn:uuid:1419d68a-1dd2-11b2-a105-F0000000A1B2" {
+		t.Errorf("Unexpected GUID '%s'", guid)
 	}
+}
 
-	if interfaces[0].Info.Name != "eth0" {
-		t.Errorf("Expected interface name 'eth0', got '%s'", interfaces[0].Info.Name)
+func TestGetCertificates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:GetCertificatesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<tds:NvtCertificate>
+						<tt:CertificateID>cert1</tt:CertificateID>
+						<tt:Certificate>
+							<tt:Data>ZGVyLWRhdGE=</tt:Data>
+						</tt:Certificate>
+					</tds:NvtCertificate>
+				</tds:GetCertificatesResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	certificates, err := client.GetCertificates(context.Background())
+	if err != nil {
+		t.Fatalf("GetCertificates() error = %v", err)
+	}
+
+	if len(certificates) != 1 {
+		t.Fatalf("Expected 1 certificate, got %d", len(certificates))
+	}
+
+	if certificates[0].CertificateID != "cert1" {
+		t.Errorf("Expected certificate ID 'cert1', got '%s'", certificates[0].CertificateID)
+	}
+
+	if string(certificates[0].Data) != "der-data" {
+		t.Errorf("Unexpected certificate data '%s'", string(certificates[0].Data))
+	}
+}
+
+func TestSetCertificatesStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var envelope struct {
+			Body struct {
+				SetCertificatesStatus struct {
+					CertificateStatus []struct {
+						CertificateID string `xml:"CertificateID"`
+						Status        bool   `xml:"Status"`
+					} `xml:"CertificateStatus"`
+				} `xml:"SetCertificatesStatus"`
+			} `xml:"Body"`
+		}
+
+		if err := xml.NewDecoder(r.Body).Decode(&envelope); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+
+		status := envelope.Body.SetCertificatesStatus.CertificateStatus
+		if len(status) != 1 || status[0].CertificateID != "cert1" || !status[0].Status {
+			t.Errorf("Unexpected certificate status %+v", status)
+		}
+
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:SetCertificatesStatusResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	err = client.SetCertificatesStatus(context.Background(), []CertificateStatus{
+		{CertificateID: "cert1", Status: true},
+	})
+	if err != nil {
+		t.Fatalf("SetCertificatesStatus() error = %v", err)
 	}
 }
 
@@ -418,3 +1772,126 @@ func BenchmarkDeviceGetDeviceInformation(b *testing.B) {
 		_, _ = client.GetDeviceInformation(ctx)
 	}
 }
+
+func TestGetGeoLocation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:GetGeoLocationResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<tds:Location Fixed="true">
+						<tt:GeoLocation lon="-122.4194" lat="37.7749" elevation="16.5"/>
+					</tds:Location>
+				</tds:GetGeoLocationResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	locations, err := client.GetGeoLocation(context.Background())
+	if err != nil {
+		t.Fatalf("GetGeoLocation() error = %v", err)
+	}
+
+	if len(locations) != 1 {
+		t.Fatalf("Expected 1 location, got %d", len(locations))
+	}
+	l := locations[0]
+	if !l.Fixed || l.Lon != -122.4194 || l.Lat != 37.7749 || l.Elevation != 16.5 {
+		t.Errorf("Unexpected location: %+v", l)
+	}
+}
+
+func TestSetGeoLocation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `<tt:GeoLocation lon="2.3522" lat="48.8566" elevation="35">`) {
+			t.Errorf("Expected location in request, got: %s", body)
+		}
+
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:SetGeoLocationResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if err := client.SetGeoLocation(context.Background(), []GeoLocation{{Lon: 2.3522, Lat: 48.8566, Elevation: 35}}); err != nil {
+		t.Fatalf("SetGeoLocation() error = %v", err)
+	}
+
+	if err := client.SetGeoLocation(context.Background(), nil); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for empty locations, got %v", err)
+	}
+}
+
+func TestGetSystemDateAndTime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:GetSystemDateAndTimeResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<tds:SystemDateAndTime>
+						<tt:DateTimeType>NTP</tt:DateTimeType>
+						<tt:DaylightSavings>true</tt:DaylightSavings>
+						<tt:TimeZone><tt:TZ>PST8PDT,M3.2.0,M11.1.0</tt:TZ></tt:TimeZone>
+						<tt:UTCDateTime>
+							<tt:Time><tt:Hour>19</tt:Hour><tt:Minute>30</tt:Minute><tt:Second>15</tt:Second></tt:Time>
+							<tt:Date><tt:Year>2024</tt:Year><tt:Month>7</tt:Month><tt:Day>4</tt:Day></tt:Date>
+						</tt:UTCDateTime>
+						<tt:LocalDateTime>
+							<tt:Time><tt:Hour>12</tt:Hour><tt:Minute>30</tt:Minute><tt:Second>15</tt:Second></tt:Time>
+							<tt:Date><tt:Year>2024</tt:Year><tt:Month>7</tt:Month><tt:Day>4</tt:Day></tt:Date>
+						</tt:LocalDateTime>
+					</tds:SystemDateAndTime>
+				</tds:GetSystemDateAndTimeResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	sdt, err := client.GetSystemDateAndTime(context.Background())
+	if err != nil {
+		t.Fatalf("GetSystemDateAndTime() error = %v", err)
+	}
+
+	if sdt.DateTimeType != "NTP" || !sdt.DaylightSavings {
+		t.Errorf("Unexpected date and time settings: %+v", sdt)
+	}
+	want := time.Date(2024, 7, 4, 19, 30, 15, 0, time.UTC)
+	if !sdt.UTCDateTime.Equal(want) {
+		t.Errorf("UTCDateTime = %v, want %v", sdt.UTCDateTime, want)
+	}
+
+	loc, err := sdt.Location()
+	if err != nil {
+		t.Fatalf("Location() error = %v", err)
+	}
+	local := sdt.UTCDateTime.In(loc)
+	if local.Hour() != sdt.LocalDateTime.Hour() {
+		t.Errorf("Local hour = %d, want %d", local.Hour(), sdt.LocalDateTime.Hour())
+	}
+}
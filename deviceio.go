@@ -0,0 +1,76 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+)
+
+// DeviceIO service namespace
+const deviceIONamespace = "http://www.onvif.org/ver10/deviceIO/wsdl"
+
+// DeviceIOGetVideoSources retrieves the video source tokens from the DeviceIO service.
+// Some devices only list their physical sources here and leave the media
+// service's GetVideoSources empty.
+func (c *Client) DeviceIOGetVideoSources(ctx context.Context) ([]string, error) {
+	endpoint := c.deviceIOEndpoint
+	if endpoint == "" {
+		return nil, ErrServiceNotSupported
+	}
+
+	type GetVideoSources struct {
+		XMLName xml.Name `xml:"tmd:GetVideoSources"`
+		Xmlns   string   `xml:"xmlns:tmd,attr"`
+	}
+
+	type GetVideoSourcesResponse struct {
+		XMLName xml.Name `xml:"GetVideoSourcesResponse"`
+		Tokens  []string `xml:"Token"`
+	}
+
+	req := GetVideoSources{
+		Xmlns: deviceIONamespace,
+	}
+
+	var resp GetVideoSourcesResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("DeviceIOGetVideoSources failed: %w", err)
+	}
+
+	return resp.Tokens, nil
+}
+
+// DeviceIOGetAudioSources retrieves the audio source tokens from the DeviceIO service
+func (c *Client) DeviceIOGetAudioSources(ctx context.Context) ([]string, error) {
+	endpoint := c.deviceIOEndpoint
+	if endpoint == "" {
+		return nil, ErrServiceNotSupported
+	}
+
+	type GetAudioSources struct {
+		XMLName xml.Name `xml:"tmd:GetAudioSources"`
+		Xmlns   string   `xml:"xmlns:tmd,attr"`
+	}
+
+	type GetAudioSourcesResponse struct {
+		XMLName xml.Name `xml:"GetAudioSourcesResponse"`
+		Tokens  []string `xml:"Token"`
+	}
+
+	req := GetAudioSources{
+		Xmlns: deviceIONamespace,
+	}
+
+	var resp GetAudioSourcesResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("DeviceIOGetAudioSources failed: %w", err)
+	}
+
+	return resp.Tokens, nil
+}
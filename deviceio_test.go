@@ -0,0 +1,90 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDeviceIOGetVideoSources(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "GetVideoSources") || !strings.Contains(string(body), deviceIONamespace) {
+			t.Errorf("Unexpected request body: %s", body)
+		}
+
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tmd:GetVideoSourcesResponse xmlns:tmd="http://www.onvif.org/ver10/deviceIO/wsdl">
+					<tmd:Token>VideoSource_Visible</tmd:Token>
+					<tmd:Token>VideoSource_Thermal</tmd:Token>
+				</tmd:GetVideoSourcesResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.deviceIOEndpoint = server.URL
+
+	tokens, err := client.DeviceIOGetVideoSources(context.Background())
+	if err != nil {
+		t.Fatalf("DeviceIOGetVideoSources() error = %v", err)
+	}
+
+	if len(tokens) != 2 || tokens[0] != "VideoSource_Visible" || tokens[1] != "VideoSource_Thermal" {
+		t.Errorf("Unexpected tokens: %v", tokens)
+	}
+}
+
+func TestDeviceIOGetAudioSources(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tmd:GetAudioSourcesResponse xmlns:tmd="http://www.onvif.org/ver10/deviceIO/wsdl">
+					<tmd:Token>AudioSource_1</tmd:Token>
+				</tmd:GetAudioSourcesResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.deviceIOEndpoint = server.URL
+
+	tokens, err := client.DeviceIOGetAudioSources(context.Background())
+	if err != nil {
+		t.Fatalf("DeviceIOGetAudioSources() error = %v", err)
+	}
+
+	if len(tokens) != 1 || tokens[0] != "AudioSource_1" {
+		t.Errorf("Unexpected tokens: %v", tokens)
+	}
+}
+
+func TestDeviceIONotSupported(t *testing.T) {
+	client, err := NewClient("http://192.168.1.100/onvif/device_service")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if _, err := client.DeviceIOGetVideoSources(context.Background()); !errors.Is(err, ErrServiceNotSupported) {
+		t.Errorf("Expected ErrServiceNotSupported, got %v", err)
+	}
+}
@@ -12,6 +12,9 @@ import (
 const (
 	// WS-Discovery multicast address
 	multicastAddr = "239.255.255.250:3702"
+
+	// WS-Discovery UDP port
+	discoveryPort = "3702"
 	
 	// WS-Discovery probe message
 	probeTemplate = `<?xml version="1.0" encoding="UTF-8"?>
@@ -66,8 +69,54 @@ type ProbeMatches struct {
 	ProbeMatch  []ProbeMatch  `xml:"ProbeMatch"`
 }
 
+// DiscoverOption is a functional option for configuring discovery
+type DiscoverOption func(*discoverOptions)
+
+// discoverOptions holds the settings for a discovery run
+type discoverOptions struct {
+	probeCount    int
+	probeInterval time.Duration
+	quietPeriod   time.Duration
+}
+
+// Default probe settings; UDP probes are easily dropped, so send more than one
+const (
+	defaultProbeCount    = 3
+	defaultProbeInterval = 500 * time.Millisecond
+)
+
+// WithProbeCount sets how many times the probe is sent and the interval between
+// sends. All probes are sent within the overall discovery timeout and responses
+// are deduplicated by endpoint reference.
+func WithProbeCount(n int, interval time.Duration) DiscoverOption {
+	return func(o *discoverOptions) {
+		if n < 1 {
+			n = 1
+		}
+		o.probeCount = n
+		o.probeInterval = interval
+	}
+}
+
+// WithEarlyExit makes Discover return before its timeout once no new device
+// has answered for quietPeriod, counted from the first probe and then from
+// each newly discovered device. The timeout still bounds the whole run.
+func WithEarlyExit(quietPeriod time.Duration) DiscoverOption {
+	return func(o *discoverOptions) {
+		o.quietPeriod = quietPeriod
+	}
+}
+
 // Discover discovers ONVIF devices on the network
-func Discover(ctx context.Context, timeout time.Duration) ([]*Device, error) {
+func Discover(ctx context.Context, timeout time.Duration, opts ...DiscoverOption) ([]*Device, error) {
+	options := &discoverOptions{
+		probeCount:    defaultProbeCount,
+		probeInterval: defaultProbeInterval,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
 	// Create UDP connection for multicast
 	addr, err := net.ResolveUDPAddr("udp", multicastAddr)
 	if err != nil {
@@ -80,37 +129,59 @@ func Discover(ctx context.Context, timeout time.Duration) ([]*Device, error) {
 	}
 	defer func() { _ = conn.Close() }()
 
-	// Set read deadline
-	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
-		return nil, fmt.Errorf("failed to set read deadline: %w", err)
-	}
+	devices, err := probe(ctx, conn, addr, options, time.Now().Add(timeout))
+	return deviceMapToSlice(devices), err
+}
 
-	// Generate message ID
+// probe sends the probes to addr and collects the matches received on conn
+// until the deadline
+func probe(ctx context.Context, conn *net.UDPConn, addr *net.UDPAddr, options *discoverOptions, deadline time.Time) (map[string]*Device, error) {
+	// Generate message ID; retransmissions reuse it so devices can drop duplicates
 	messageID := generateUUID()
 
-	// Send probe message
-	probeMsg := fmt.Sprintf(probeTemplate, messageID)
-	if _, err := conn.WriteToUDP([]byte(probeMsg), addr); err != nil {
+	// Send the first probe message
+	probeMsg := []byte(fmt.Sprintf(probeTemplate, messageID))
+	if _, err := conn.WriteToUDP(probeMsg, addr); err != nil {
 		return nil, fmt.Errorf("failed to send probe message: %w", err)
 	}
 
-	// Collect responses
+	// Re-send the probe in the background while responses are collected
+	done := make(chan struct{})
+	defer close(done)
+	go resendProbes(conn, addr, probeMsg, options, deadline, done)
+
+	return collectProbeMatches(ctx, conn, deadline, options.quietPeriod)
+}
+
+// collectProbeMatches reads probe matches from conn until the deadline, the
+// context is cancelled or, with a positive quietPeriod, no new device has
+// answered for that long. Devices are deduplicated by endpoint reference.
+func collectProbeMatches(ctx context.Context, conn *net.UDPConn, deadline time.Time, quietPeriod time.Duration) (map[string]*Device, error) {
 	devices := make(map[string]*Device)
 	buffer := make([]byte, 8192)
+	lastNew := time.Now()
 
 	// Read responses until timeout or context cancellation
 	for {
 		select {
 		case <-ctx.Done():
-			return deviceMapToSlice(devices), ctx.Err()
+			return devices, ctx.Err()
 		default:
+			readDeadline := deadline
+			if quietPeriod > 0 && lastNew.Add(quietPeriod).Before(readDeadline) {
+				readDeadline = lastNew.Add(quietPeriod)
+			}
+			if err := conn.SetReadDeadline(readDeadline); err != nil {
+				return devices, fmt.Errorf("failed to set read deadline: %w", err)
+			}
+
 			n, _, err := conn.ReadFromUDP(buffer)
 			if err != nil {
 				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-					// Timeout reached, return collected devices
-					return deviceMapToSlice(devices), nil
+					// Timeout or quiet period reached, return collected devices
+					return devices, nil
 				}
-				return deviceMapToSlice(devices), fmt.Errorf("failed to read UDP response: %w", err)
+				return devices, fmt.Errorf("failed to read UDP response: %w", err)
 			}
 
 			// Parse response
@@ -122,12 +193,105 @@ func Discover(ctx context.Context, timeout time.Duration) ([]*Device, error) {
 
 			// Add to devices map (deduplicate by endpoint)
 			if device != nil && device.EndpointRef != "" {
+				if _, seen := devices[device.EndpointRef]; !seen {
+					lastNew = time.Now()
+				}
 				devices[device.EndpointRef] = device
 			}
 		}
 	}
 }
 
+// ProbeUnicast sends a WS-Discovery probe directly to a device's UDP port 3702
+// instead of the multicast group. This allows fetching the scopes, types and
+// XAddrs of a known device across routed networks where multicast is blocked.
+// The address may include a port; otherwise 3702 is used. Only a match whose
+// RelatesTo names this probe is returned.
+func ProbeUnicast(ctx context.Context, ip string, timeout time.Duration) (*Device, error) {
+	host := ip
+	if _, _, err := net.SplitHostPort(ip); err != nil {
+		host = net.JoinHostPort(ip, discoveryPort)
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve device address: %w", err)
+	}
+
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open UDP socket: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	// Honor the context deadline if it is earlier than the timeout
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("failed to set read deadline: %w", err)
+	}
+
+	// Send probe message
+	messageID := generateUUID()
+	probeMsg := fmt.Sprintf(probeTemplate, messageID)
+	if _, err := conn.WriteToUDP([]byte(probeMsg), addr); err != nil {
+		return nil, fmt.Errorf("failed to send probe message: %w", err)
+	}
+
+	buffer := make([]byte, 8192)
+
+	// Read until a probe match answering this probe arrives, the deadline
+	// passes or the context is cancelled
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+			n, _, err := conn.ReadFromUDP(buffer)
+			if err != nil {
+				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+					return nil, fmt.Errorf("no probe response from %s: %w", addr, err)
+				}
+				return nil, fmt.Errorf("failed to read UDP response: %w", err)
+			}
+
+			// Skip stale or unrelated matches that reach the socket
+			if probeRelatesTo(buffer[:n]) != "uuid:"+messageID {
+				continue
+			}
+
+			device, err := parseProbeResponse(buffer[:n])
+			if err != nil {
+				// Skip invalid responses
+				continue
+			}
+
+			return device, nil
+		}
+	}
+}
+
+// resendProbes sends the remaining probes spaced by the configured interval,
+// stopping early when the deadline passes or discovery finishes
+func resendProbes(conn *net.UDPConn, addr *net.UDPAddr, probeMsg []byte, options *discoverOptions, deadline time.Time, done <-chan struct{}) {
+	for i := 1; i < options.probeCount; i++ {
+		if time.Now().Add(options.probeInterval).After(deadline) {
+			return
+		}
+
+		select {
+		case <-done:
+			return
+		case <-time.After(options.probeInterval):
+		}
+
+		// Errors are ignored; the first probe already succeeded
+		_, _ = conn.WriteToUDP(probeMsg, addr)
+	}
+}
+
 // parseProbeResponse parses a WS-Discovery probe response
 func parseProbeResponse(data []byte) (*Device, error) {
 	var envelope struct {
@@ -158,6 +322,22 @@ func parseProbeResponse(data []byte) (*Device, error) {
 	return device, nil
 }
 
+// probeRelatesTo returns the MessageID of the probe a response answers, or an
+// empty string if it has none
+func probeRelatesTo(data []byte) string {
+	var envelope struct {
+		Header struct {
+			RelatesTo string `xml:"RelatesTo"`
+		} `xml:"Header"`
+	}
+
+	if err := xml.Unmarshal(data, &envelope); err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(envelope.Header.RelatesTo)
+}
+
 // parseSpaceSeparated parses a space-separated string into a slice
 func parseSpaceSeparated(s string) []string {
 	s = strings.TrimSpace(s)
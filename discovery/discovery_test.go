@@ -2,6 +2,8 @@ package discovery
 
 import (
 	"context"
+	"net"
+	"strings"
 	"testing"
 	"time"
 )
@@ -251,3 +253,252 @@ func BenchmarkDeviceGetDeviceEndpoint(b *testing.B) {
 		_ = device.GetDeviceEndpoint()
 	}
 }
+
+func TestWithProbeCount(t *testing.T) {
+	tests := []struct {
+		name         string
+		count        int
+		interval     time.Duration
+		wantCount    int
+		wantInterval time.Duration
+	}{
+		{
+			name:         "multiple probes",
+			count:        5,
+			interval:     200 * time.Millisecond,
+			wantCount:    5,
+			wantInterval: 200 * time.Millisecond,
+		},
+		{
+			name:         "zero count falls back to a single probe",
+			count:        0,
+			interval:     time.Second,
+			wantCount:    1,
+			wantInterval: time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &discoverOptions{
+				probeCount:    defaultProbeCount,
+				probeInterval: defaultProbeInterval,
+			}
+			WithProbeCount(tt.count, tt.interval)(options)
+
+			if options.probeCount != tt.wantCount {
+				t.Errorf("probeCount = %d, want %d", options.probeCount, tt.wantCount)
+			}
+			if options.probeInterval != tt.wantInterval {
+				t.Errorf("probeInterval = %v, want %v", options.probeInterval, tt.wantInterval)
+			}
+		})
+	}
+}
+
+func TestProbeResendsAndDeduplicates(t *testing.T) {
+	device, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer func() { _ = device.Close() }()
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	// The device answers every probe with the same match
+	received := make(chan time.Time, 10)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		buffer := make([]byte, 8192)
+		for {
+			n, remote, err := device.ReadFromUDP(buffer)
+			if err != nil {
+				return
+			}
+			if !strings.Contains(string(buffer[:n]), "Probe") {
+				continue
+			}
+			received <- time.Now()
+			response := `<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:d="http://schemas.xmlsoap.org/ws/2005/04/discovery" xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing">
+	<s:Body>
+		<d:ProbeMatches>
+			<d:ProbeMatch>
+				<a:EndpointReference><a:Address>urn:uuid:test-device</a:Address></a:EndpointReference>
+				<d:XAddrs>http://192.168.1.100/onvif/device_service</d:XAddrs>
+			</d:ProbeMatch>
+		</d:ProbeMatches>
+	</s:Body>
+</s:Envelope>`
+			_, _ = device.WriteToUDP([]byte(response), remote)
+		}
+	}()
+
+	const interval = 100 * time.Millisecond
+	options := &discoverOptions{}
+	WithProbeCount(3, interval)(options)
+
+	devices, err := probe(context.Background(), conn, device.LocalAddr().(*net.UDPAddr), options, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("probe() error = %v", err)
+	}
+	if len(devices) != 1 || devices["urn:uuid:test-device"] == nil {
+		t.Errorf("Discovered %v, want only urn:uuid:test-device", devices)
+	}
+
+	_ = device.Close()
+	<-stopped
+	close(received)
+	var times []time.Time
+	for at := range received {
+		times = append(times, at)
+	}
+	if len(times) != 3 {
+		t.Fatalf("Device received %d probes, want 3", len(times))
+	}
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < interval-10*time.Millisecond || gap > 3*interval {
+			t.Errorf("Probe %d sent %v after the previous one, want about %v", i+1, gap, interval)
+		}
+	}
+}
+
+func TestProbeUnicast(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	probeMatch := func(relatesTo, endpoint string) []byte {
+		return []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:d="http://schemas.xmlsoap.org/ws/2005/04/discovery" xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing">
+	<s:Header>
+		<a:RelatesTo>` + relatesTo + `</a:RelatesTo>
+	</s:Header>
+	<s:Body>
+		<d:ProbeMatches>
+			<d:ProbeMatch>
+				<a:EndpointReference><a:Address>` + endpoint + `</a:Address></a:EndpointReference>
+				<d:Types>dn:NetworkVideoTransmitter</d:Types>
+				<d:Scopes>onvif://www.onvif.org/name/TestCamera</d:Scopes>
+				<d:XAddrs>http://192.168.1.100/onvif/device_service</d:XAddrs>
+				<d:MetadataVersion>1</d:MetadataVersion>
+			</d:ProbeMatch>
+		</d:ProbeMatches>
+	</s:Body>
+</s:Envelope>`)
+	}
+
+	// Answer the probe after a stale match for an earlier probe
+	go func() {
+		buffer := make([]byte, 8192)
+		n, remote, err := conn.ReadFromUDP(buffer)
+		if err != nil {
+			return
+		}
+		request := string(buffer[:n])
+		start := strings.Index(request, "<a:MessageID>")
+		end := strings.Index(request, "</a:MessageID>")
+		if start < 0 || end < start {
+			return
+		}
+		messageID := request[start+len("<a:MessageID>") : end]
+
+		_, _ = conn.WriteToUDP(probeMatch("uuid:earlier-probe", "urn:uuid:stale-device"), remote)
+		_, _ = conn.WriteToUDP(probeMatch(messageID, "urn:uuid:test-device"), remote)
+	}()
+
+	device, err := ProbeUnicast(context.Background(), conn.LocalAddr().String(), 2*time.Second)
+	if err != nil {
+		t.Fatalf("ProbeUnicast() error = %v", err)
+	}
+
+	if device.EndpointRef != "urn:uuid:test-device" {
+		t.Errorf("EndpointRef = %q, want %q", device.EndpointRef, "urn:uuid:test-device")
+	}
+	if device.GetName() != "TestCamera" {
+		t.Errorf("GetName() = %q, want %q", device.GetName(), "TestCamera")
+	}
+	if device.GetDeviceEndpoint() != "http://192.168.1.100/onvif/device_service" {
+		t.Errorf("GetDeviceEndpoint() = %q", device.GetDeviceEndpoint())
+	}
+}
+
+func TestProbeUnicast_NoResponse(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	_, err = ProbeUnicast(context.Background(), conn.LocalAddr().String(), 200*time.Millisecond)
+	if err == nil {
+		t.Error("Expected error when device does not respond")
+	}
+}
+
+func TestCollectProbeMatches_EarlyExit(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	sender, err := net.DialUDP("udp", nil, conn.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer func() { _ = sender.Close() }()
+
+	probeMatch := func(endpoint string) []byte {
+		return []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:d="http://schemas.xmlsoap.org/ws/2005/04/discovery" xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing">
+	<s:Body>
+		<d:ProbeMatches>
+			<d:ProbeMatch>
+				<a:EndpointReference><a:Address>` + endpoint + `</a:Address></a:EndpointReference>
+				<d:XAddrs>http://192.168.1.100/onvif/device_service</d:XAddrs>
+			</d:ProbeMatch>
+		</d:ProbeMatches>
+	</s:Body>
+</s:Envelope>`)
+	}
+
+	// Two devices answer, then only a duplicate, which must not extend the wait
+	go func() {
+		_, _ = sender.Write(probeMatch("urn:uuid:camera-1"))
+		time.Sleep(100 * time.Millisecond)
+		_, _ = sender.Write(probeMatch("urn:uuid:camera-2"))
+		time.Sleep(200 * time.Millisecond)
+		_, _ = sender.Write(probeMatch("urn:uuid:camera-1"))
+	}()
+
+	start := time.Now()
+	devices, err := collectProbeMatches(context.Background(), conn, start.Add(5*time.Second), 300*time.Millisecond)
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("collectProbeMatches() error = %v", err)
+	}
+
+	if len(devices) != 2 {
+		t.Errorf("Discovered %d devices, want 2", len(devices))
+	}
+	if elapsed < 350*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("Returned after %v, want about 400ms", elapsed)
+	}
+}
+
+func TestWithEarlyExit(t *testing.T) {
+	options := &discoverOptions{}
+	WithEarlyExit(time.Second)(options)
+
+	if options.quietPeriod != time.Second {
+		t.Errorf("quietPeriod = %v, want %v", options.quietPeriod, time.Second)
+	}
+}
@@ -63,8 +63,7 @@
 //	velocity := &onvif.PTZSpeed{
 //	    PanTilt: &onvif.Vector2D{X: 0.5, Y: 0.0},
 //	}
-//	timeout := "PT2S"
-//	client.ContinuousMove(ctx, profileToken, velocity, &timeout)
+//	client.ContinuousMoveFor(ctx, profileToken, velocity, 2*time.Second)
 //
 //	// Go to preset
 //	presets, _ := client.GetPresets(ctx, profileToken)
@@ -0,0 +1,125 @@
+package onvif
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDuration parses an ISO8601 duration (e.g. "PT5S", "PT1M30S", "P1DT2H")
+// as used throughout ONVIF for timeouts. Fractional values such as "PT0.5S"
+// are accepted. Year and month designators are not supported because their
+// length is ambiguous.
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	negative := false
+	rest := s
+	if strings.HasPrefix(rest, "-") {
+		negative = true
+		rest = rest[1:]
+	}
+
+	if !strings.HasPrefix(rest, "P") {
+		return 0, fmt.Errorf("invalid duration %q: missing P designator", s)
+	}
+	rest = rest[1:]
+	if rest == "" {
+		return 0, fmt.Errorf("invalid duration %q: no components", s)
+	}
+
+	var total time.Duration
+	inTime := false
+	components := 0
+
+	for rest != "" {
+		if rest[0] == 'T' {
+			if inTime {
+				return 0, fmt.Errorf("invalid duration %q: repeated T designator", s)
+			}
+			inTime = true
+			rest = rest[1:]
+			continue
+		}
+
+		// Read the numeric part
+		end := 0
+		for end < len(rest) && (rest[end] >= '0' && rest[end] <= '9' || rest[end] == '.' || rest[end] == ',') {
+			end++
+		}
+		if end == 0 || end == len(rest) {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+
+		value, err := strconv.ParseFloat(strings.Replace(rest[:end], ",", ".", 1), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+		}
+
+		var unit time.Duration
+		switch designator := rest[end]; {
+		case !inTime && designator == 'W':
+			unit = 7 * 24 * time.Hour
+		case !inTime && designator == 'D':
+			unit = 24 * time.Hour
+		case inTime && designator == 'H':
+			unit = time.Hour
+		case inTime && designator == 'M':
+			unit = time.Minute
+		case inTime && designator == 'S':
+			unit = time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q: unsupported designator %q", s, designator)
+		}
+
+		total += time.Duration(value * float64(unit))
+		components++
+		rest = rest[end+1:]
+	}
+
+	if components == 0 {
+		return 0, fmt.Errorf("invalid duration %q: no components", s)
+	}
+
+	if negative {
+		total = -total
+	}
+
+	return total, nil
+}
+
+// FormatDuration formats a duration as an ISO8601 duration using hours,
+// minutes and (possibly fractional) seconds, e.g. "PT1M30S" or "PT0.5S".
+// Zero is "PT0S". The result round-trips through ParseDuration.
+func FormatDuration(d time.Duration) string {
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+		d = -d
+	}
+	b.WriteString("PT")
+
+	if d == 0 {
+		b.WriteString("0S")
+		return b.String()
+	}
+
+	if h := d / time.Hour; h > 0 {
+		fmt.Fprintf(&b, "%dH", h)
+		d -= h * time.Hour
+	}
+	if m := d / time.Minute; m > 0 {
+		fmt.Fprintf(&b, "%dM", m)
+		d -= m * time.Minute
+	}
+	if d > 0 {
+		b.WriteString(strconv.FormatFloat(d.Seconds(), 'f', -1, 64))
+		b.WriteByte('S')
+	}
+
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package onvif
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"PT5S", 5 * time.Second, false},
+		{"PT1M30S", 90 * time.Second, false},
+		{"PT0.5S", 500 * time.Millisecond, false},
+		{"P1DT2H", 26 * time.Hour, false},
+		{"-PT10S", -10 * time.Second, false},
+		{"PT0S", 0, false},
+		{"", 0, true},
+		{"5S", 0, true},
+		{"PT", 0, true},
+		{"P1M", 0, true},
+		{"PT5", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDuration(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDuration(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDuration(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		input time.Duration
+		want  string
+	}{
+		{0, "PT0S"},
+		{2 * time.Second, "PT2S"},
+		{90 * time.Second, "PT1M30S"},
+		{1500 * time.Millisecond, "PT1.5S"},
+		{time.Hour, "PT1H"},
+		{26*time.Hour + 5*time.Minute, "PT26H5M"},
+		{-10 * time.Second, "-PT10S"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := FormatDuration(tt.input)
+			if got != tt.want {
+				t.Errorf("FormatDuration(%v) = %q, want %q", tt.input, got, tt.want)
+			}
+
+			back, err := ParseDuration(got)
+			if err != nil || back != tt.input {
+				t.Errorf("ParseDuration(%q) = %v, %v; want %v", got, back, err, tt.input)
+			}
+		})
+	}
+}
@@ -32,6 +32,29 @@ var (
 
 	// ErrNotInitialized is returned when the client is not initialized
 	ErrNotInitialized = errors.New("client not initialized")
+
+	// ErrRebootInProgress is returned by SystemReboot, SetSystemFactoryDefault
+	// and UploadFirmware when the device dropped the connection before
+	// answering; the request was most likely accepted
+	ErrRebootInProgress = errors.New("reboot in progress")
+
+	// ErrPTZFault is returned by AbsoluteMoveAndWait when the PTZ status reports an error
+	ErrPTZFault = errors.New("PTZ fault")
+
+	// ErrOutOfRange is returned by AbsoluteMove and RelativeMove, when move
+	// validation is enabled, for a vector outside the PTZ node's ranges
+	ErrOutOfRange = errors.New("out of range")
+
+	// ErrInvalidUserLevel is returned by CreateUsers and SetUser, before
+	// sending the request, for a user level other than the UserLevel constants
+	ErrInvalidUserLevel = errors.New("invalid user level")
+
+	// ErrInvalidEncoderConfig is returned by SetVideoEncoderConfiguration, when
+	// encoder validation is enabled, for a value outside the fetched options
+	ErrInvalidEncoderConfig = errors.New("invalid encoder configuration")
+
+	// ErrClientClosed is returned by calls made after Close
+	ErrClientClosed = errors.New("client closed")
 )
 
 // ONVIFError represents an ONVIF-specific error
@@ -0,0 +1,37 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+)
+
+// Event service namespace
+const eventNamespace = "http://www.onvif.org/ver10/events/wsdl"
+
+// SetEventSynchronizationPoint asks the device to re-send the current state of all
+// properties for a subscription. The subscription reference is the address
+// returned when the subscription was created; consumers typically call this
+// right after subscribing.
+func (c *Client) SetEventSynchronizationPoint(ctx context.Context, subscriptionReference string) error {
+	if subscriptionReference == "" {
+		return fmt.Errorf("%w: subscription reference is required", ErrInvalidParameter)
+	}
+
+	type SetSynchronizationPoint struct {
+		XMLName xml.Name `xml:"tev:SetSynchronizationPoint"`
+		Xmlns   string   `xml:"xmlns:tev,attr"`
+	}
+
+	req := SetSynchronizationPoint{
+		Xmlns: eventNamespace,
+	}
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, subscriptionReference, "", req, nil); err != nil {
+		return fmt.Errorf("SetEventSynchronizationPoint failed: %w", err)
+	}
+
+	return nil
+}
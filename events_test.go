@@ -0,0 +1,49 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSetEventSynchronizationPoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/onvif/subscription_1" {
+			t.Errorf("Expected request to subscription reference, got %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "SetSynchronizationPoint") || !strings.Contains(string(body), eventNamespace) {
+			t.Errorf("Unexpected request body: %s", body)
+		}
+		if !strings.Contains(string(body), `<Action xmlns="http://www.w3.org/2005/08/addressing">`+eventNamespace+`/PullPointSubscription/SetSynchronizationPointRequest</Action>`) {
+			t.Errorf("Expected WS-Addressing Action header, got: %s", body)
+		}
+
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tev:SetSynchronizationPointResponse xmlns:tev="http://www.onvif.org/ver10/events/wsdl"/>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if err := client.SetEventSynchronizationPoint(context.Background(), server.URL+"/onvif/subscription_1"); err != nil {
+		t.Fatalf("SetEventSynchronizationPoint() error = %v", err)
+	}
+
+	if err := client.SetEventSynchronizationPoint(context.Background(), ""); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for empty reference, got %v", err)
+	}
+}
@@ -204,8 +204,7 @@ func controlPTZ(client *onvif.Client, profileToken string) {
 		velocity := &onvif.PTZSpeed{
 			PanTilt: &onvif.Vector2D{X: 0.3, Y: 0.0},
 		}
-		timeout := "PT1S"
-		if err := client.ContinuousMove(ctx, profileToken, velocity, &timeout); err != nil {
+		if err := client.ContinuousMoveFor(ctx, profileToken, velocity, time.Second); err != nil {
 			log.Printf("Move failed: %v", err)
 		}
 		time.Sleep(1 * time.Second)
@@ -51,13 +51,14 @@ func main() {
 
 	// Get media profiles
 	fmt.Println("\nRetrieving media profiles...")
-	profiles, err := client.GetProfiles(ctx)
+	results, err := client.GetProfilesWithURIs(ctx)
 	if err != nil {
 		log.Fatalf("Failed to get profiles: %v", err)
 	}
 
-	fmt.Printf("\nFound %d profile(s):\n", len(profiles))
-	for i, profile := range profiles {
+	fmt.Printf("\nFound %d profile(s):\n", len(results))
+	for i, result := range results {
+		profile := result.Profile
 		fmt.Printf("\nProfile #%d:\n", i+1)
 		fmt.Printf("  Token: %s\n", profile.Token)
 		fmt.Printf("  Name: %s\n", profile.Name)
@@ -72,20 +73,16 @@ func main() {
 			fmt.Printf("  Quality: %.1f\n", profile.VideoEncoderConfiguration.Quality)
 		}
 
-		// Get stream URI
-		streamURI, err := client.GetStreamURI(ctx, profile.Token)
-		if err != nil {
-			fmt.Printf("  Stream URI: Error - %v\n", err)
+		if result.StreamErr != nil {
+			fmt.Printf("  Stream URI: Error - %v\n", result.StreamErr)
 		} else {
-			fmt.Printf("  Stream URI: %s\n", streamURI.URI)
+			fmt.Printf("  Stream URI: %s\n", result.StreamURI.URI)
 		}
 
-		// Get snapshot URI
-		snapshotURI, err := client.GetSnapshotURI(ctx, profile.Token)
-		if err != nil {
-			fmt.Printf("  Snapshot URI: Error - %v\n", err)
+		if result.SnapshotErr != nil {
+			fmt.Printf("  Snapshot URI: Error - %v\n", result.SnapshotErr)
 		} else {
-			fmt.Printf("  Snapshot URI: %s\n", snapshotURI.URI)
+			fmt.Printf("  Snapshot URI: %s\n", result.SnapshotURI.URI)
 		}
 	}
 
@@ -79,7 +79,7 @@ func main() {
 
 	if settings.Exposure != nil {
 		fmt.Printf("  Exposure Mode: %s\n", settings.Exposure.Mode)
-		if settings.Exposure.Mode == "MANUAL" {
+		if settings.Exposure.Mode == onvif.ExposureModeManual {
 			fmt.Printf("    Exposure Time: %.2f\n", settings.Exposure.ExposureTime)
 			fmt.Printf("    Gain: %.2f\n", settings.Exposure.Gain)
 		}
@@ -111,7 +111,7 @@ func main() {
 	
 	// Set to auto exposure
 	if settings.Exposure != nil {
-		settings.Exposure.Mode = "AUTO"
+		settings.Exposure.Mode = onvif.ExposureModeAuto
 	}
 
 	// Apply new settings
@@ -28,6 +28,7 @@ func main() {
 		},
 		Username:       "admin",
 		Password:       "SecurePass123",
+		RequireAuth:    true,
 		SupportPTZ:     true,
 		SupportImaging: true,
 		SupportEvents:  false,
@@ -196,27 +197,29 @@ func main() {
 	fmt.Println("Press Ctrl+C to stop the server...")
 	fmt.Println()
 
-	// Create context with cancellation
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
 	// Setup signal handler
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
 	// Start server in goroutine
+	errChan := make(chan error, 1)
 	go func() {
-		if err := srv.Start(ctx); err != nil {
-			log.Printf("Server error: %v", err)
-			cancel()
-		}
+		errChan <- srv.Start(context.Background())
 	}()
 
 	// Wait for interrupt signal
-	<-sigChan
-	fmt.Println("\n🛑 Shutting down server...")
-	cancel()
+	select {
+	case <-sigChan:
+		fmt.Println("\n🛑 Shutting down server...")
+	case err := <-errChan:
+		log.Fatalf("Server error: %v", err)
+	}
 
-	time.Sleep(1 * time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Shutdown error: %v", err)
+	}
+	<-errChan
 	fmt.Println("✅ Server stopped successfully")
 }
@@ -93,8 +93,7 @@ func demonstratePTZ(ctx context.Context, client *onvif.Client, profileToken stri
 			Y: 0.0,
 		},
 	}
-	timeout := "PT2S" // 2 seconds
-	if err := client.ContinuousMove(ctx, profileToken, velocity, &timeout); err != nil {
+	if err := client.ContinuousMoveFor(ctx, profileToken, velocity, 2*time.Second); err != nil {
 		log.Printf("Failed to move: %v\n", err)
 	} else {
 		time.Sleep(2 * time.Second)
@@ -262,25 +262,18 @@ func testPTZService(ctx context.Context, client *onvif.Client, results *TestResu
 	log.Println("\n=== Testing PTZ Service (NEW Methods) ===")
 
 	// Get profiles to find one with PTZ
-	profiles, err := client.GetProfiles(ctx)
+	ptzProfiles, err := client.GetPTZProfiles(ctx)
 	if err != nil {
 		log.Printf("⚠️  Could not get profiles for PTZ tests: %v", err)
 		return
 	}
 
-	var ptzProfile *onvif.Profile
-	for _, p := range profiles {
-		if p.PTZConfiguration != nil {
-			ptzProfile = p
-			break
-		}
-	}
-
-	if ptzProfile == nil {
+	if len(ptzProfiles) == 0 {
 		log.Println("⚠️  No PTZ-enabled profile found, skipping PTZ tests")
 		results.PTZTests["skipped"] = "No PTZ profile found"
 		return
 	}
+	ptzProfile := ptzProfiles[0]
 
 	log.Printf("Using PTZ profile: %s (%s)", ptzProfile.Name, ptzProfile.Token)
 	results.PTZTests["test_profile_token"] = ptzProfile.Token
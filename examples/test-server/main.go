@@ -185,6 +185,9 @@ func main() {
 	fmt.Println()
 
 	// Stop the server
-	cancel()
-	time.Sleep(500 * time.Millisecond)
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Shutdown error: %v", err)
+	}
 }
@@ -0,0 +1,186 @@
+package onvif
+
+import (
+	"context"
+	"fmt"
+)
+
+// Features summarizes what a device supports in one place. Initialize and
+// Bootstrap fill in what GetCapabilities reports; DetectFeatures adds what
+// GetServices, the media service capabilities, the PTZ nodes and the imaging
+// options report. Anything the device did not report is false.
+type Features struct {
+	// Services
+	Media     bool `json:"media"`
+	Media2    bool `json:"media2"`
+	PTZ       bool `json:"ptz"`
+	Imaging   bool `json:"imaging"`
+	Events    bool `json:"events"`
+	Analytics bool `json:"analytics"`
+	DeviceIO  bool `json:"device_io"`
+	Recording bool `json:"recording"`
+	Search    bool `json:"search"`
+	Replay    bool `json:"replay"`
+
+	// Events
+	PullPointEvents bool `json:"pull_point_events"`
+
+	// Media
+	SnapshotURI      bool `json:"snapshot_uri"`
+	OSD              bool `json:"osd"`
+	Rotation         bool `json:"rotation"`
+	RTPMulticast     bool `json:"rtp_multicast"`
+	RTPOverTCP       bool `json:"rtp_over_tcp"`
+	RTPOverRTSP      bool `json:"rtp_over_rtsp"`
+	AudioBackchannel bool `json:"audio_backchannel"` // The device has an audio output to send audio to
+
+	// PTZ, true if any node supports it
+	AbsolutePTZMove      bool `json:"absolute_ptz_move"`
+	RelativePTZMove      bool `json:"relative_ptz_move"`
+	PTZHome              bool `json:"ptz_home"`
+	PTZPresets           bool `json:"ptz_presets"`
+	PTZAuxiliaryCommands bool `json:"ptz_auxiliary_commands"`
+
+	// Imaging, true if any video source supports it
+	WDR bool `json:"wdr"`
+}
+
+// Features returns the features found by the last Initialize, Bootstrap or
+// DetectFeatures call. Before any of them, every flag is false.
+func (c *Client) Features() Features {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.features
+}
+
+// DetectFeatures initializes the client, then asks the device for the
+// details GetCapabilities leaves out and returns the combined features,
+// which Features returns from then on. Lookups the device rejects, as many
+// do for optional operations, leave their flags false; only a failing
+// Initialize or a cancelled context is an error.
+func (c *Client) DetectFeatures(ctx context.Context) (Features, error) {
+	if err := c.Initialize(ctx); err != nil {
+		return Features{}, fmt.Errorf("DetectFeatures failed: %w", err)
+	}
+	features := c.Features()
+
+	if xaddrs, err := c.getServiceXAddrs(ctx); err == nil {
+		features.Media2 = xaddrs[media2Namespace] != ""
+		features.Media = features.Media || xaddrs[mediaNamespace] != ""
+		features.PTZ = features.PTZ || xaddrs[ptzNamespace] != ""
+		features.Imaging = features.Imaging || xaddrs[imagingNamespace] != ""
+		features.Events = features.Events || xaddrs[eventNamespace] != ""
+		features.DeviceIO = features.DeviceIO || xaddrs[deviceIONamespace] != ""
+		features.Recording = features.Recording || xaddrs[recordingNamespace] != ""
+		features.Search = features.Search || xaddrs[searchNamespace] != ""
+		features.Replay = features.Replay || xaddrs[replayNamespace] != ""
+	}
+
+	if features.Media {
+		if caps, err := c.GetMediaServiceCapabilities(ctx); err == nil {
+			features.SnapshotURI = caps.SnapshotURI
+			features.OSD = caps.OSD
+			features.Rotation = caps.Rotation
+			features.RTPMulticast = features.RTPMulticast || caps.RTPMulticast
+			features.RTPOverTCP = features.RTPOverTCP || caps.RTP_TCP
+			features.RTPOverRTSP = features.RTPOverRTSP || caps.RTP_RTSP_TCP
+		}
+		if !features.AudioBackchannel {
+			if outputs, err := c.GetAudioOutputs(ctx); err == nil {
+				features.AudioBackchannel = len(outputs) > 0
+			}
+		}
+	}
+
+	if c.ptzEndpoint != "" {
+		if nodes, err := c.GetNodes(ctx); err == nil {
+			for _, node := range nodes {
+				features.addPTZNode(node)
+			}
+		}
+	}
+
+	if c.imagingEndpoint != "" {
+		if sources, err := c.GetVideoSources(ctx); err == nil {
+			for _, source := range sources {
+				options, err := c.GetOptions(ctx, source.Token)
+				if err == nil && supportsWDR(options) {
+					features.WDR = true
+					break
+				}
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return Features{}, fmt.Errorf("DetectFeatures failed: %w", err)
+	}
+
+	c.mu.Lock()
+	c.features = features
+	c.mu.Unlock()
+
+	return features, nil
+}
+
+// capabilityFeatures returns the features reported by GetCapabilities
+func capabilityFeatures(capabilities *Capabilities) Features {
+	var features Features
+
+	if capabilities.Media != nil && capabilities.Media.XAddr != "" {
+		features.Media = true
+		if streaming := capabilities.Media.StreamingCapabilities; streaming != nil {
+			features.RTPMulticast = streaming.RTPMulticast
+			features.RTPOverTCP = streaming.RTP_TCP
+			features.RTPOverRTSP = streaming.RTP_RTSP_TCP
+		}
+	}
+	features.PTZ = capabilities.PTZ != nil && capabilities.PTZ.XAddr != ""
+	features.Imaging = capabilities.Imaging != nil && capabilities.Imaging.XAddr != ""
+	if capabilities.Events != nil && capabilities.Events.XAddr != "" {
+		features.Events = true
+		features.PullPointEvents = capabilities.Events.WSPullPointSupport
+	}
+	features.Analytics = capabilities.Analytics != nil && capabilities.Analytics.XAddr != ""
+
+	if ext := capabilities.Extension; ext != nil {
+		if ext.DeviceIO != nil && ext.DeviceIO.XAddr != "" {
+			features.DeviceIO = true
+			features.AudioBackchannel = ext.DeviceIO.AudioOutputs > 0
+		}
+		features.Recording = ext.Recording != nil && ext.Recording.XAddr != ""
+		features.Search = ext.Search != nil && ext.Search.XAddr != ""
+		features.Replay = ext.Replay != nil && ext.Replay.XAddr != ""
+	}
+
+	return features
+}
+
+// addPTZNode sets the PTZ features a node supports
+func (f *Features) addPTZNode(node *PTZNode) {
+	if spaces := node.SupportedPTZSpaces; spaces != nil {
+		if len(spaces.AbsolutePanTiltPositionSpace) > 0 || len(spaces.AbsoluteZoomPositionSpace) > 0 {
+			f.AbsolutePTZMove = true
+		}
+		if len(spaces.RelativePanTiltTranslationSpace) > 0 || len(spaces.RelativeZoomTranslationSpace) > 0 {
+			f.RelativePTZMove = true
+		}
+	}
+	f.PTZHome = f.PTZHome || node.HomeSupported
+	f.PTZPresets = f.PTZPresets || node.MaximumNumberOfPresets > 0
+	f.PTZAuxiliaryCommands = f.PTZAuxiliaryCommands || len(node.AuxiliaryCommands) > 0
+}
+
+// supportsWDR reports whether imaging options offer turning WDR on
+func supportsWDR(options *ImagingOptions) bool {
+	if options.WideDynamicRange == nil {
+		return false
+	}
+	for _, mode := range options.WideDynamicRange.Mode {
+		if WDRMode(mode) == WDRModeOn {
+			return true
+		}
+	}
+	return false
+}
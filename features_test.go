@@ -0,0 +1,76 @@
+package onvif
+
+import (
+	"context"
+	"testing"
+
+	"github.com/0x524a/onvif-go/onviftest"
+)
+
+func TestDetectFeatures(t *testing.T) {
+	mock := onviftest.NewMockTransport().
+		Handle("GetCapabilities", onviftest.Capabilities("http://camera.test", "Device", "Media", "PTZ", "Imaging")).
+		Handle("GetServices", `<tds:GetServicesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+			<tds:Service><tds:Namespace>http://www.onvif.org/ver20/media/wsdl</tds:Namespace><tds:XAddr>http://camera.test/onvif/media2_service</tds:XAddr></tds:Service>
+		</tds:GetServicesResponse>`).
+		Handle("GetServiceCapabilities", `<trt:GetServiceCapabilitiesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+			<trt:Capabilities SnapshotUri="true" OSD="true"><trt:StreamingCapabilities RTP_RTSP_TCP="true"/></trt:Capabilities>
+		</trt:GetServiceCapabilitiesResponse>`).
+		Handle("GetAudioOutputs", `<trt:GetAudioOutputsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+			<trt:AudioOutputs token="speaker"/>
+		</trt:GetAudioOutputsResponse>`).
+		Handle("GetNodes", `<tptz:GetNodesResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+			<tptz:PTZNode token="node"><tt:MaximumNumberOfPresets>16</tt:MaximumNumberOfPresets><tt:HomeSupported>true</tt:HomeSupported>
+				<tt:SupportedPTZSpaces><tt:AbsolutePanTiltPositionSpace><tt:URI>`+PanTiltPositionGenericSpace+`</tt:URI></tt:AbsolutePanTiltPositionSpace></tt:SupportedPTZSpaces>
+			</tptz:PTZNode>
+		</tptz:GetNodesResponse>`).
+		Handle("GetVideoSources", `<trt:GetVideoSourcesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+			<trt:VideoSources token="source"/>
+		</trt:GetVideoSourcesResponse>`).
+		Handle("GetOptions", `<timg:GetOptionsResponse xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+			<timg:ImagingOptions><tt:WideDynamicRange><tt:Mode>OFF</tt:Mode><tt:Mode>ON</tt:Mode></tt:WideDynamicRange></timg:ImagingOptions>
+		</timg:GetOptionsResponse>`)
+
+	client, err := NewClient("http://camera.test/onvif/device_service", WithHTTPClient(mock.Client()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if got := client.Features(); got != (Features{}) {
+		t.Errorf("Features() before Initialize = %+v, want none", got)
+	}
+
+	features, err := client.DetectFeatures(context.Background())
+	if err != nil {
+		t.Fatalf("DetectFeatures() error = %v", err)
+	}
+
+	want := Features{
+		Media:            true,
+		Media2:           true,
+		PTZ:              true,
+		Imaging:          true,
+		SnapshotURI:      true,
+		OSD:              true,
+		RTPOverRTSP:      true,
+		AudioBackchannel: true,
+		AbsolutePTZMove:  true,
+		PTZHome:          true,
+		PTZPresets:       true,
+		WDR:              true,
+	}
+	if features != want {
+		t.Errorf("DetectFeatures() = %+v, want %+v", features, want)
+	}
+	if got := client.Features(); got != want {
+		t.Errorf("Features() = %+v, want %+v", got, want)
+	}
+
+	// Initialize alone only knows what GetCapabilities reports
+	if err := client.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if got := client.Features(); got != (Features{Media: true, PTZ: true, Imaging: true}) {
+		t.Errorf("Features() after Initialize = %+v", got)
+	}
+}
@@ -0,0 +1,111 @@
+package onvif
+
+import (
+	"fmt"
+	"strings"
+)
+
+// String summarizes the profile as its token and name followed by the
+// encoder settings and the configurations it carries, e.g.
+// `Profile_1 "Main": H264 1920x1080 25fps 4096kbps, audio G711, PTZ`
+func (p *Profile) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+
+	var parts []string
+	if vec := p.VideoEncoderConfiguration; vec != nil {
+		video := vec.Encoding
+		if vec.Resolution != nil {
+			video += fmt.Sprintf(" %dx%d", vec.Resolution.Width, vec.Resolution.Height)
+		}
+		if rc := vec.RateControl; rc != nil {
+			video += fmt.Sprintf(" %dfps %dkbps", rc.FrameRateLimit, rc.BitrateLimit)
+		}
+		parts = append(parts, strings.TrimSpace(video))
+	}
+	if p.AudioEncoderConfiguration != nil {
+		parts = append(parts, "audio "+p.AudioEncoderConfiguration.Encoding)
+	}
+	if p.PTZConfiguration != nil {
+		parts = append(parts, "PTZ")
+	}
+	if p.MetadataConfiguration != nil {
+		parts = append(parts, "metadata")
+	}
+
+	s := fmt.Sprintf("%s %q", p.Token, p.Name)
+	if len(parts) > 0 {
+		s += ": " + strings.Join(parts, ", ")
+	}
+	return s
+}
+
+// String lists the services the device reported, e.g. "device, media, ptz"
+func (c *Capabilities) String() string {
+	if c == nil {
+		return "<nil>"
+	}
+
+	var services []string
+	add := func(present bool, name string) {
+		if present {
+			services = append(services, name)
+		}
+	}
+	add(c.Device != nil, "device")
+	add(c.Media != nil, "media")
+	add(c.PTZ != nil, "ptz")
+	add(c.Imaging != nil, "imaging")
+	add(c.Events != nil, "events")
+	add(c.Analytics != nil, "analytics")
+	if ext := c.Extension; ext != nil {
+		add(ext.DeviceIO != nil, "deviceio")
+		add(ext.Recording != nil, "recording")
+		add(ext.Search != nil, "search")
+		add(ext.Replay != nil, "replay")
+	}
+
+	if len(services) == 0 {
+		return "no services"
+	}
+	return strings.Join(services, ", ")
+}
+
+// String summarizes the position, move status and error of the PTZ unit, e.g.
+// "pan 0.500 tilt -0.200 zoom 0.100, pan/tilt MOVING, zoom IDLE"
+func (s *PTZStatus) String() string {
+	if s == nil {
+		return "<nil>"
+	}
+
+	var parts []string
+	if pos := s.Position; pos != nil {
+		var position []string
+		if pos.PanTilt != nil {
+			position = append(position, fmt.Sprintf("pan %.3f tilt %.3f", pos.PanTilt.X, pos.PanTilt.Y))
+		}
+		if pos.Zoom != nil {
+			position = append(position, fmt.Sprintf("zoom %.3f", pos.Zoom.X))
+		}
+		if len(position) > 0 {
+			parts = append(parts, strings.Join(position, " "))
+		}
+	}
+	if move := s.MoveStatus; move != nil {
+		if move.PanTilt != "" {
+			parts = append(parts, "pan/tilt "+move.PanTilt)
+		}
+		if move.Zoom != "" {
+			parts = append(parts, "zoom "+move.Zoom)
+		}
+	}
+	if s.Error != "" {
+		parts = append(parts, fmt.Sprintf("error %q", s.Error))
+	}
+
+	if len(parts) == 0 {
+		return "unknown"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,65 @@
+package onvif
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestProfileString(t *testing.T) {
+	profile := &Profile{
+		Token: "Profile_1",
+		Name:  "Main",
+		VideoEncoderConfiguration: &VideoEncoderConfiguration{
+			Encoding:    "H264",
+			Resolution:  &VideoResolution{Width: 1920, Height: 1080},
+			RateControl: &VideoRateControl{FrameRateLimit: 25, BitrateLimit: 4096},
+		},
+		AudioEncoderConfiguration: &AudioEncoderConfiguration{Encoding: "G711"},
+		PTZConfiguration:          &PTZConfiguration{},
+	}
+
+	want := `Profile_1 "Main": H264 1920x1080 25fps 4096kbps, audio G711, PTZ`
+	if got := fmt.Sprint(profile); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	if got := (&Profile{Token: "Profile_2", Name: "Sub"}).String(); got != `Profile_2 "Sub"` {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestCapabilitiesString(t *testing.T) {
+	caps := &Capabilities{
+		Device:    &DeviceCapabilities{},
+		Media:     &MediaCapabilities{},
+		PTZ:       &PTZCapabilities{},
+		Extension: &CapabilitiesExtension{Recording: &RecordingCapabilities{}},
+	}
+
+	if got := caps.String(); got != "device, media, ptz, recording" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := (&Capabilities{}).String(); got != "no services" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestPTZStatusString(t *testing.T) {
+	status := &PTZStatus{
+		Position: &PTZVector{
+			PanTilt: &Vector2D{X: 0.5, Y: -0.2},
+			Zoom:    &Vector1D{X: 0.1},
+		},
+		MoveStatus: &PTZMoveStatus{PanTilt: "MOVING", Zoom: "IDLE"},
+	}
+
+	want := "pan 0.500 tilt -0.200 zoom 0.100, pan/tilt MOVING, zoom IDLE"
+	if got := fmt.Sprintf("%v", status); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	var nilStatus *PTZStatus
+	if got := nilStatus.String(); got != "<nil>" {
+		t.Errorf("String() on nil = %q", got)
+	}
+}
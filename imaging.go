@@ -4,8 +4,6 @@ import (
 	"context"
 	"encoding/xml"
 	"fmt"
-
-	"github.com/0x524a/onvif-go/internal/soap"
 )
 
 // Imaging service namespace
@@ -35,17 +33,18 @@ func (c *Client) GetImagingSettings(ctx context.Context, videoSourceToken string
 			ColorSaturation *float64 `xml:"ColorSaturation"`
 			Contrast        *float64 `xml:"Contrast"`
 			Exposure        *struct {
-				Mode            string  `xml:"Mode"`
-				Priority        string  `xml:"Priority"`
-				MinExposureTime float64 `xml:"MinExposureTime"`
-				MaxExposureTime float64 `xml:"MaxExposureTime"`
-				MinGain         float64 `xml:"MinGain"`
-				MaxGain         float64 `xml:"MaxGain"`
-				MinIris         float64 `xml:"MinIris"`
-				MaxIris         float64 `xml:"MaxIris"`
-				ExposureTime    float64 `xml:"ExposureTime"`
-				Gain            float64 `xml:"Gain"`
-				Iris            float64 `xml:"Iris"`
+				Mode            ExposureMode  `xml:"Mode"`
+				Priority        string        `xml:"Priority"`
+				Window          *rectangleXML `xml:"Window"`
+				MinExposureTime float64       `xml:"MinExposureTime"`
+				MaxExposureTime float64       `xml:"MaxExposureTime"`
+				MinGain         float64       `xml:"MinGain"`
+				MaxGain         float64       `xml:"MaxGain"`
+				MinIris         float64       `xml:"MinIris"`
+				MaxIris         float64       `xml:"MaxIris"`
+				ExposureTime    float64       `xml:"ExposureTime"`
+				Gain            float64       `xml:"Gain"`
+				Iris            float64       `xml:"Iris"`
 			} `xml:"Exposure"`
 			Focus *struct {
 				AutoFocusMode string  `xml:"AutoFocusMode"`
@@ -53,17 +52,18 @@ func (c *Client) GetImagingSettings(ctx context.Context, videoSourceToken string
 				NearLimit     float64 `xml:"NearLimit"`
 				FarLimit      float64 `xml:"FarLimit"`
 			} `xml:"Focus"`
-			IrCutFilter      *string  `xml:"IrCutFilter"`
-			Sharpness        *float64 `xml:"Sharpness"`
+			IrCutFilter      *IrCutFilterMode `xml:"IrCutFilter"`
+			Sharpness        *float64         `xml:"Sharpness"`
 			WideDynamicRange *struct {
-				Mode  string  `xml:"Mode"`
+				Mode  WDRMode `xml:"Mode"`
 				Level float64 `xml:"Level"`
 			} `xml:"WideDynamicRange"`
 			WhiteBalance *struct {
-				Mode   string  `xml:"Mode"`
-				CrGain float64 `xml:"CrGain"`
-				CbGain float64 `xml:"CbGain"`
+				Mode   WhiteBalanceMode `xml:"Mode"`
+				CrGain float64          `xml:"CrGain"`
+				CbGain float64          `xml:"CbGain"`
 			} `xml:"WhiteBalance"`
+			IrCutFilterAutoAdjustment []irCutFilterAutoAdjustmentXML `xml:"Extension>Extension>IrCutFilterAutoAdjustment"`
 		} `xml:"ImagingSettings"`
 	}
 
@@ -74,8 +74,7 @@ func (c *Client) GetImagingSettings(ctx context.Context, videoSourceToken string
 
 	var resp GetImagingSettingsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetImagingSettings failed: %w", err)
@@ -110,6 +109,14 @@ func (c *Client) GetImagingSettings(ctx context.Context, videoSourceToken string
 			Gain:            resp.ImagingSettings.Exposure.Gain,
 			Iris:            resp.ImagingSettings.Exposure.Iris,
 		}
+		if window := resp.ImagingSettings.Exposure.Window; window != nil {
+			settings.Exposure.Window = &Rectangle{
+				Bottom: window.Bottom,
+				Top:    window.Top,
+				Right:  window.Right,
+				Left:   window.Left,
+			}
+		}
 	}
 
 	if resp.ImagingSettings.Focus != nil {
@@ -136,6 +143,13 @@ func (c *Client) GetImagingSettings(ctx context.Context, videoSourceToken string
 		}
 	}
 
+	if len(resp.ImagingSettings.IrCutFilterAutoAdjustment) > 0 {
+		settings.Extension = &ImagingSettingsExtension{}
+		for _, adjustment := range resp.ImagingSettings.IrCutFilterAutoAdjustment {
+			settings.Extension.IrCutFilterAutoAdjustment = append(settings.Extension.IrCutFilterAutoAdjustment, adjustment.toAdjustment())
+		}
+	}
+
 	return settings, nil
 }
 
@@ -159,17 +173,18 @@ func (c *Client) SetImagingSettings(ctx context.Context, videoSourceToken string
 			ColorSaturation *float64 `xml:"ColorSaturation,omitempty"`
 			Contrast        *float64 `xml:"Contrast,omitempty"`
 			Exposure        *struct {
-				Mode            string  `xml:"Mode"`
-				Priority        string  `xml:"Priority,omitempty"`
-				MinExposureTime float64 `xml:"MinExposureTime,omitempty"`
-				MaxExposureTime float64 `xml:"MaxExposureTime,omitempty"`
-				MinGain         float64 `xml:"MinGain,omitempty"`
-				MaxGain         float64 `xml:"MaxGain,omitempty"`
-				MinIris         float64 `xml:"MinIris,omitempty"`
-				MaxIris         float64 `xml:"MaxIris,omitempty"`
-				ExposureTime    float64 `xml:"ExposureTime,omitempty"`
-				Gain            float64 `xml:"Gain,omitempty"`
-				Iris            float64 `xml:"Iris,omitempty"`
+				Mode            ExposureMode  `xml:"Mode"`
+				Priority        string        `xml:"Priority,omitempty"`
+				Window          *rectangleXML `xml:"Window,omitempty"`
+				MinExposureTime float64       `xml:"MinExposureTime,omitempty"`
+				MaxExposureTime float64       `xml:"MaxExposureTime,omitempty"`
+				MinGain         float64       `xml:"MinGain,omitempty"`
+				MaxGain         float64       `xml:"MaxGain,omitempty"`
+				MinIris         float64       `xml:"MinIris,omitempty"`
+				MaxIris         float64       `xml:"MaxIris,omitempty"`
+				ExposureTime    float64       `xml:"ExposureTime,omitempty"`
+				Gain            float64       `xml:"Gain,omitempty"`
+				Iris            float64       `xml:"Iris,omitempty"`
 			} `xml:"Exposure,omitempty"`
 			Focus *struct {
 				AutoFocusMode string  `xml:"AutoFocusMode"`
@@ -177,17 +192,18 @@ func (c *Client) SetImagingSettings(ctx context.Context, videoSourceToken string
 				NearLimit     float64 `xml:"NearLimit,omitempty"`
 				FarLimit      float64 `xml:"FarLimit,omitempty"`
 			} `xml:"Focus,omitempty"`
-			IrCutFilter      *string  `xml:"IrCutFilter,omitempty"`
-			Sharpness        *float64 `xml:"Sharpness,omitempty"`
+			IrCutFilter      *IrCutFilterMode `xml:"IrCutFilter,omitempty"`
+			Sharpness        *float64         `xml:"Sharpness,omitempty"`
 			WideDynamicRange *struct {
-				Mode  string  `xml:"Mode"`
+				Mode  WDRMode `xml:"Mode"`
 				Level float64 `xml:"Level,omitempty"`
 			} `xml:"WideDynamicRange,omitempty"`
 			WhiteBalance *struct {
-				Mode   string  `xml:"Mode"`
-				CrGain float64 `xml:"CrGain,omitempty"`
-				CbGain float64 `xml:"CbGain,omitempty"`
+				Mode   WhiteBalanceMode `xml:"Mode"`
+				CrGain float64          `xml:"CrGain,omitempty"`
+				CbGain float64          `xml:"CbGain,omitempty"`
 			} `xml:"WhiteBalance,omitempty"`
+			IrCutFilterAutoAdjustment []irCutFilterAutoAdjustmentXML `xml:"Extension>Extension>IrCutFilterAutoAdjustment,omitempty"`
 		} `xml:"timg:ImagingSettings"`
 		ForcePersistence bool `xml:"timg:ForcePersistence"`
 	}
@@ -217,17 +233,18 @@ func (c *Client) SetImagingSettings(ctx context.Context, videoSourceToken string
 
 	if settings.Exposure != nil {
 		req.ImagingSettings.Exposure = &struct {
-			Mode            string  `xml:"Mode"`
-			Priority        string  `xml:"Priority,omitempty"`
-			MinExposureTime float64 `xml:"MinExposureTime,omitempty"`
-			MaxExposureTime float64 `xml:"MaxExposureTime,omitempty"`
-			MinGain         float64 `xml:"MinGain,omitempty"`
-			MaxGain         float64 `xml:"MaxGain,omitempty"`
-			MinIris         float64 `xml:"MinIris,omitempty"`
-			MaxIris         float64 `xml:"MaxIris,omitempty"`
-			ExposureTime    float64 `xml:"ExposureTime,omitempty"`
-			Gain            float64 `xml:"Gain,omitempty"`
-			Iris            float64 `xml:"Iris,omitempty"`
+			Mode            ExposureMode  `xml:"Mode"`
+			Priority        string        `xml:"Priority,omitempty"`
+			Window          *rectangleXML `xml:"Window,omitempty"`
+			MinExposureTime float64       `xml:"MinExposureTime,omitempty"`
+			MaxExposureTime float64       `xml:"MaxExposureTime,omitempty"`
+			MinGain         float64       `xml:"MinGain,omitempty"`
+			MaxGain         float64       `xml:"MaxGain,omitempty"`
+			MinIris         float64       `xml:"MinIris,omitempty"`
+			MaxIris         float64       `xml:"MaxIris,omitempty"`
+			ExposureTime    float64       `xml:"ExposureTime,omitempty"`
+			Gain            float64       `xml:"Gain,omitempty"`
+			Iris            float64       `xml:"Iris,omitempty"`
 		}{
 			Mode:            settings.Exposure.Mode,
 			Priority:        settings.Exposure.Priority,
@@ -241,6 +258,14 @@ func (c *Client) SetImagingSettings(ctx context.Context, videoSourceToken string
 			Gain:            settings.Exposure.Gain,
 			Iris:            settings.Exposure.Iris,
 		}
+		if window := settings.Exposure.Window; window != nil {
+			req.ImagingSettings.Exposure.Window = &rectangleXML{
+				Bottom: window.Bottom,
+				Top:    window.Top,
+				Right:  window.Right,
+				Left:   window.Left,
+			}
+		}
 	}
 
 	if settings.Focus != nil {
@@ -259,7 +284,7 @@ func (c *Client) SetImagingSettings(ctx context.Context, videoSourceToken string
 
 	if settings.WideDynamicRange != nil {
 		req.ImagingSettings.WideDynamicRange = &struct {
-			Mode  string  `xml:"Mode"`
+			Mode  WDRMode `xml:"Mode"`
 			Level float64 `xml:"Level,omitempty"`
 		}{
 			Mode:  settings.WideDynamicRange.Mode,
@@ -269,9 +294,9 @@ func (c *Client) SetImagingSettings(ctx context.Context, videoSourceToken string
 
 	if settings.WhiteBalance != nil {
 		req.ImagingSettings.WhiteBalance = &struct {
-			Mode   string  `xml:"Mode"`
-			CrGain float64 `xml:"CrGain,omitempty"`
-			CbGain float64 `xml:"CbGain,omitempty"`
+			Mode   WhiteBalanceMode `xml:"Mode"`
+			CrGain float64          `xml:"CrGain,omitempty"`
+			CbGain float64          `xml:"CbGain,omitempty"`
 		}{
 			Mode:   settings.WhiteBalance.Mode,
 			CrGain: settings.WhiteBalance.CrGain,
@@ -279,8 +304,13 @@ func (c *Client) SetImagingSettings(ctx context.Context, videoSourceToken string
 		}
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	if settings.Extension != nil {
+		for _, adjustment := range settings.Extension.IrCutFilterAutoAdjustment {
+			req.ImagingSettings.IrCutFilterAutoAdjustment = append(req.ImagingSettings.IrCutFilterAutoAdjustment, newIrCutFilterAutoAdjustmentXML(adjustment))
+		}
+	}
+
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetImagingSettings failed: %w", err)
@@ -289,6 +319,106 @@ func (c *Client) SetImagingSettings(ctx context.Context, videoSourceToken string
 	return nil
 }
 
+// SetExposureWindow sets the region auto exposure meters on, e.g. to expose
+// for a subject in front of a bright background. The other exposure settings
+// are read from the device and sent back unchanged, and the change is
+// persisted.
+func (c *Client) SetExposureWindow(ctx context.Context, videoSourceToken string, window Rectangle) error {
+	for _, v := range []float64{window.Bottom, window.Top, window.Right, window.Left} {
+		if v < 0 || v > 1 {
+			return fmt.Errorf("%w: exposure window edges must be between 0 and 1, got %+v", ErrInvalidParameter, window)
+		}
+	}
+	if window.Left >= window.Right || window.Top >= window.Bottom {
+		return fmt.Errorf("%w: exposure window is empty: %+v", ErrInvalidParameter, window)
+	}
+
+	current, err := c.GetImagingSettings(ctx, videoSourceToken)
+	if err != nil {
+		return err
+	}
+
+	exposure := Exposure{Mode: ExposureModeAuto}
+	if current.Exposure != nil {
+		exposure = *current.Exposure
+	}
+	exposure.Window = &window
+
+	return c.SetImagingSettings(ctx, videoSourceToken, &ImagingSettings{Exposure: &exposure}, true)
+}
+
+// SetIrCutFilterAutoAdjustment puts the IR cut filter in AUTO mode and sets
+// the light levels and delays at which it switches. Pass one Common
+// adjustment, or a ToOn and a ToOff adjustment to tune each direction. The
+// change is persisted.
+func (c *Client) SetIrCutFilterAutoAdjustment(ctx context.Context, videoSourceToken string, adjustments []IrCutFilterAutoAdjustment) error {
+	if len(adjustments) == 0 {
+		return fmt.Errorf("%w: at least one adjustment is required", ErrInvalidParameter)
+	}
+	for _, adjustment := range adjustments {
+		switch adjustment.BoundaryType {
+		case IrCutFilterBoundaryCommon, IrCutFilterBoundaryToOn, IrCutFilterBoundaryToOff:
+		default:
+			return fmt.Errorf("%w: unknown boundary type %q", ErrInvalidParameter, adjustment.BoundaryType)
+		}
+		if offset := adjustment.BoundaryOffset; offset != nil && (*offset < -1 || *offset > 1) {
+			return fmt.Errorf("%w: boundary offset must be between -1 and 1, got %v", ErrInvalidParameter, *offset)
+		}
+		if adjustment.ResponseTime < 0 {
+			return fmt.Errorf("%w: response time must not be negative", ErrInvalidParameter)
+		}
+	}
+
+	mode := IrCutFilterAuto
+	settings := &ImagingSettings{
+		IrCutFilter: &mode,
+		Extension:   &ImagingSettingsExtension{IrCutFilterAutoAdjustment: adjustments},
+	}
+
+	return c.SetImagingSettings(ctx, videoSourceToken, settings, true)
+}
+
+// irCutFilterAutoAdjustmentXML is the wire form of tt:IrCutFilterAutoAdjustment
+type irCutFilterAutoAdjustmentXML struct {
+	BoundaryType   string   `xml:"BoundaryType"`
+	BoundaryOffset *float64 `xml:"BoundaryOffset,omitempty"`
+	ResponseTime   string   `xml:"ResponseTime,omitempty"`
+}
+
+// toAdjustment converts the wire form into an IrCutFilterAutoAdjustment
+func (x irCutFilterAutoAdjustmentXML) toAdjustment() IrCutFilterAutoAdjustment {
+	adjustment := IrCutFilterAutoAdjustment{
+		BoundaryType:   x.BoundaryType,
+		BoundaryOffset: x.BoundaryOffset,
+	}
+	if x.ResponseTime != "" {
+		if d, err := ParseDuration(x.ResponseTime); err == nil {
+			adjustment.ResponseTime = d
+		}
+	}
+	return adjustment
+}
+
+// newIrCutFilterAutoAdjustmentXML converts an IrCutFilterAutoAdjustment into its wire form
+func newIrCutFilterAutoAdjustmentXML(adjustment IrCutFilterAutoAdjustment) irCutFilterAutoAdjustmentXML {
+	x := irCutFilterAutoAdjustmentXML{
+		BoundaryType:   adjustment.BoundaryType,
+		BoundaryOffset: adjustment.BoundaryOffset,
+	}
+	if adjustment.ResponseTime > 0 {
+		x.ResponseTime = FormatDuration(adjustment.ResponseTime)
+	}
+	return x
+}
+
+// rectangleXML is the wire form of tt:Rectangle
+type rectangleXML struct {
+	Bottom float64 `xml:"bottom,attr"`
+	Top    float64 `xml:"top,attr"`
+	Right  float64 `xml:"right,attr"`
+	Left   float64 `xml:"left,attr"`
+}
+
 // Move performs a focus move operation
 func (c *Client) Move(ctx context.Context, videoSourceToken string, focus *FocusMove) error {
 	endpoint := c.imagingEndpoint
@@ -337,8 +467,7 @@ func (c *Client) Move(ctx context.Context, videoSourceToken string, focus *Focus
 		// Implementation would add specific focus move types here
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("Move failed: %w", err)
@@ -406,6 +535,13 @@ func (c *Client) GetOptions(ctx context.Context, videoSourceToken string) (*Imag
 					Max float64 `xml:"Max"`
 				} `xml:"DefaultSpeed"`
 			} `xml:"Focus"`
+			WideDynamicRange *struct {
+				Mode  []string `xml:"Mode"`
+				Level *struct {
+					Min float64 `xml:"Min"`
+					Max float64 `xml:"Max"`
+				} `xml:"Level"`
+			} `xml:"WideDynamicRange"`
 		} `xml:"ImagingOptions"`
 	}
 
@@ -416,8 +552,7 @@ func (c *Client) GetOptions(ctx context.Context, videoSourceToken string) (*Imag
 
 	var resp GetOptionsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetOptions failed: %w", err)
@@ -446,6 +581,13 @@ func (c *Client) GetOptions(ctx context.Context, videoSourceToken string) (*Imag
 		}
 	}
 
+	if wdr := resp.ImagingOptions.WideDynamicRange; wdr != nil {
+		options.WideDynamicRange = &WideDynamicRangeOptions{Mode: wdr.Mode}
+		if wdr.Level != nil {
+			options.WideDynamicRange.Level = &FloatRange{Min: wdr.Level.Min, Max: wdr.Level.Max}
+		}
+	}
+
 	return options, nil
 }
 
@@ -501,8 +643,7 @@ func (c *Client) GetMoveOptions(ctx context.Context, videoSourceToken string) (*
 
 	var resp GetMoveOptionsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetMoveOptions failed: %w", err)
@@ -566,8 +707,7 @@ func (c *Client) StopFocus(ctx context.Context, videoSourceToken string) error {
 		VideoSourceToken: videoSourceToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("Stop failed: %w", err)
@@ -607,8 +747,7 @@ func (c *Client) GetImagingStatus(ctx context.Context, videoSourceToken string)
 
 	var resp GetStatusResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetStatus failed: %w", err)
@@ -622,3 +761,43 @@ func (c *Client) GetImagingStatus(ctx context.Context, videoSourceToken string)
 		},
 	}, nil
 }
+
+// GetImagingServiceCapabilities retrieves the capabilities of the imaging service
+func (c *Client) GetImagingServiceCapabilities(ctx context.Context) (*ImagingServiceCapabilities, error) {
+	endpoint := c.imagingEndpoint
+	if endpoint == "" {
+		return nil, ErrServiceNotSupported
+	}
+
+	type GetServiceCapabilities struct {
+		XMLName xml.Name `xml:"timg:GetServiceCapabilities"`
+		Xmlns   string   `xml:"xmlns:timg,attr"`
+	}
+
+	type GetServiceCapabilitiesResponse struct {
+		XMLName      xml.Name `xml:"GetServiceCapabilitiesResponse"`
+		Capabilities struct {
+			ImageStabilization bool `xml:"ImageStabilization,attr"`
+			Presets            bool `xml:"Presets,attr"`
+			AdaptablePreset    bool `xml:"AdaptablePreset,attr"`
+		} `xml:"Capabilities"`
+	}
+
+	req := GetServiceCapabilities{
+		Xmlns: imagingNamespace,
+	}
+
+	var resp GetServiceCapabilitiesResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetServiceCapabilities failed: %w", err)
+	}
+
+	return &ImagingServiceCapabilities{
+		ImageStabilization: resp.Capabilities.ImageStabilization,
+		Presets:            resp.Capabilities.Presets,
+		AdaptablePreset:    resp.Capabilities.AdaptablePreset,
+	}, nil
+}
@@ -0,0 +1,176 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetExposureWindow(t *testing.T) {
+	var setBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body := string(data)
+
+		var response string
+		if strings.Contains(body, "GetImagingSettings") {
+			response = `<timg:GetImagingSettingsResponse xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<timg:ImagingSettings>
+					<tt:Brightness>50</tt:Brightness>
+					<tt:Exposure>
+						<tt:Mode>AUTO</tt:Mode>
+						<tt:Priority>LowNoise</tt:Priority>
+						<tt:Window bottom="1" top="0" right="1" left="0"/>
+						<tt:MinExposureTime>10</tt:MinExposureTime>
+						<tt:MaxExposureTime>40000</tt:MaxExposureTime>
+					</tt:Exposure>
+				</timg:ImagingSettings>
+			</timg:GetImagingSettingsResponse>`
+		} else {
+			setBody = body
+			response = `<timg:SetImagingSettingsResponse xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl"/>`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>` + response + `</s:Body></s:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	settings, err := client.GetImagingSettings(ctx, "VideoSource_1")
+	if err != nil {
+		t.Fatalf("GetImagingSettings() error = %v", err)
+	}
+	if settings.Exposure == nil || settings.Exposure.Mode != ExposureModeAuto || settings.Exposure.Window == nil || *settings.Exposure.Window != (Rectangle{Bottom: 1, Right: 1}) {
+		t.Errorf("Unexpected exposure: %+v", settings.Exposure)
+	}
+
+	window := Rectangle{Bottom: 0.75, Top: 0.25, Right: 0.6, Left: 0.4}
+	if err := client.SetExposureWindow(ctx, "VideoSource_1", window); err != nil {
+		t.Fatalf("SetExposureWindow() error = %v", err)
+	}
+	for _, want := range []string{
+		`<Window bottom="0.75" top="0.25" right="0.6" left="0.4"></Window>`,
+		`<Priority>LowNoise</Priority>`,
+		`<MaxExposureTime>40000</MaxExposureTime>`,
+		`<timg:ForcePersistence>true</timg:ForcePersistence>`,
+	} {
+		if !strings.Contains(setBody, want) {
+			t.Errorf("Expected %s in request, got: %s", want, setBody)
+		}
+	}
+	if strings.Contains(setBody, "Brightness") {
+		t.Errorf("Expected only exposure settings to be sent, got: %s", setBody)
+	}
+
+	for _, invalid := range []Rectangle{
+		{Bottom: 1.5, Top: 0, Right: 1, Left: 0},
+		{Bottom: 0.5, Top: 0.5, Right: 1, Left: 0},
+	} {
+		if err := client.SetExposureWindow(ctx, "VideoSource_1", invalid); !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("Expected ErrInvalidParameter for %+v, got %v", invalid, err)
+		}
+	}
+}
+
+func TestIrCutFilterAutoAdjustment(t *testing.T) {
+	var setBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body := string(data)
+
+		var response string
+		if strings.Contains(body, "GetImagingSettings") {
+			response = `<timg:GetImagingSettingsResponse xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<timg:ImagingSettings>
+					<tt:IrCutFilter>AUTO</tt:IrCutFilter>
+					<tt:Extension>
+						<tt:ImageStabilization><tt:Mode>OFF</tt:Mode></tt:ImageStabilization>
+						<tt:Extension>
+							<tt:IrCutFilterAutoAdjustment>
+								<tt:BoundaryType>ToOn</tt:BoundaryType>
+								<tt:BoundaryOffset>-0.2</tt:BoundaryOffset>
+								<tt:ResponseTime>PT30S</tt:ResponseTime>
+							</tt:IrCutFilterAutoAdjustment>
+							<tt:IrCutFilterAutoAdjustment>
+								<tt:BoundaryType>ToOff</tt:BoundaryType>
+							</tt:IrCutFilterAutoAdjustment>
+						</tt:Extension>
+					</tt:Extension>
+				</timg:ImagingSettings>
+			</timg:GetImagingSettingsResponse>`
+		} else {
+			setBody = body
+			response = `<timg:SetImagingSettingsResponse xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl"/>`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>` + response + `</s:Body></s:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	settings, err := client.GetImagingSettings(ctx, "VideoSource_1")
+	if err != nil {
+		t.Fatalf("GetImagingSettings() error = %v", err)
+	}
+	if settings.IrCutFilter == nil || *settings.IrCutFilter != IrCutFilterAuto {
+		t.Errorf("Unexpected IR cut filter mode: %v", settings.IrCutFilter)
+	}
+	if settings.Extension == nil || len(settings.Extension.IrCutFilterAutoAdjustment) != 2 {
+		t.Fatalf("Expected 2 adjustments, got %+v", settings.Extension)
+	}
+	toOn := settings.Extension.IrCutFilterAutoAdjustment[0]
+	if toOn.BoundaryType != IrCutFilterBoundaryToOn || toOn.BoundaryOffset == nil || *toOn.BoundaryOffset != -0.2 || toOn.ResponseTime != 30*time.Second {
+		t.Errorf("Unexpected adjustment: %+v", toOn)
+	}
+	if toOff := settings.Extension.IrCutFilterAutoAdjustment[1]; toOff.BoundaryOffset != nil || toOff.ResponseTime != 0 {
+		t.Errorf("Unexpected adjustment: %+v", toOff)
+	}
+
+	offset := 0.3
+	err = client.SetIrCutFilterAutoAdjustment(ctx, "VideoSource_1", []IrCutFilterAutoAdjustment{
+		{BoundaryType: IrCutFilterBoundaryCommon, BoundaryOffset: &offset, ResponseTime: time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("SetIrCutFilterAutoAdjustment() error = %v", err)
+	}
+	for _, want := range []string{
+		`<IrCutFilter>AUTO</IrCutFilter>`,
+		`<Extension><Extension><IrCutFilterAutoAdjustment><BoundaryType>Common</BoundaryType><BoundaryOffset>0.3</BoundaryOffset><ResponseTime>PT1M</ResponseTime></IrCutFilterAutoAdjustment></Extension></Extension>`,
+	} {
+		if !strings.Contains(strings.Join(strings.Fields(setBody), ""), want) {
+			t.Errorf("Expected %s in request, got: %s", want, setBody)
+		}
+	}
+
+	invalid := 2.0
+	for _, adjustments := range [][]IrCutFilterAutoAdjustment{
+		nil,
+		{{BoundaryType: "Dusk"}},
+		{{BoundaryType: IrCutFilterBoundaryToOn, BoundaryOffset: &invalid}},
+	} {
+		if err := client.SetIrCutFilterAutoAdjustment(ctx, "VideoSource_1", adjustments); !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("Expected ErrInvalidParameter for %+v, got %v", adjustments, err)
+		}
+	}
+}
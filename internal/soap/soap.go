@@ -7,12 +7,41 @@ import (
 	"crypto/sha1"
 	"encoding/base64"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
 	"time"
 )
 
+// ErrEmptyResponse is returned when the device answers with an empty body
+var ErrEmptyResponse = errors.New("received empty response body")
+
+// WS-Security UsernameToken password types
+const (
+	PasswordDigestType = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordDigest"
+	PasswordTextType   = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordText"
+)
+
+// ErrNotAuthorized is returned when the device answers with a ter:NotAuthorized fault
+var ErrNotAuthorized = errors.New("sender not authorized")
+
+// HTTPError is returned when the device answers with a status other than 200
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
 // Envelope represents a SOAP envelope
 type Envelope struct {
 	XMLName xml.Name `xml:"http://www.w3.org/2003/05/soap-envelope Envelope"`
@@ -20,9 +49,25 @@ type Envelope struct {
 	Body    Body     `xml:"http://www.w3.org/2003/05/soap-envelope Body"`
 }
 
+// WS-Addressing namespace and the anonymous address that asks for the reply
+// on the same connection
+const (
+	AddressingNamespace = "http://www.w3.org/2005/08/addressing"
+	AnonymousAddress    = "http://www.w3.org/2005/08/addressing/anonymous"
+)
+
 // Header represents a SOAP header
 type Header struct {
-	Security *Security `xml:"Security,omitempty"`
+	MessageID string             `xml:"http://www.w3.org/2005/08/addressing MessageID,omitempty"`
+	ReplyTo   *EndpointReference `xml:"http://www.w3.org/2005/08/addressing ReplyTo,omitempty"`
+	To        string             `xml:"http://www.w3.org/2005/08/addressing To,omitempty"`
+	Action    string             `xml:"http://www.w3.org/2005/08/addressing Action,omitempty"`
+	Security  *Security          `xml:"Security,omitempty"`
+}
+
+// EndpointReference represents a WS-Addressing endpoint reference
+type EndpointReference struct {
+	Address string `xml:"http://www.w3.org/2005/08/addressing Address"`
 }
 
 // Body represents a SOAP body
@@ -67,13 +112,41 @@ type Nonce struct {
 	Nonce string `xml:",chardata"`
 }
 
-// Client represents a SOAP client
+// Client represents a SOAP client. Calls are safe for concurrent use once it
+// has been configured.
 type Client struct {
 	httpClient *http.Client
 	username   string
 	password   string
+	userAgent  string
+	plaintext  bool // Send the password as PasswordText instead of PasswordDigest
+	addressing bool // Send WS-Addressing headers with every call, not only event calls
 	debug      bool
 	logger     func(format string, args ...interface{})
+
+	mu          sync.Mutex
+	clockOffset time.Duration                                                    // Added to the local clock for the UsernameToken Created time, guarded by mu
+	resyncClock func(ctx context.Context) (time.Duration, error)                 // Measures the device clock offset after a NotAuthorized fault
+	observer    func(ctx context.Context, op string, d time.Duration, err error) // Called with the outcome of every call
+
+	operationTimeouts map[string]time.Duration // Per-operation timeouts replacing the HTTP client timeout
+	requestIDHeader   string                   // HTTP header carrying the context's request ID, if set
+}
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying id, which calls made with it
+// pass to the observer, write to the debug log and send in the request ID
+// header
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or an empty string
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
 }
 
 // NewClient creates a new SOAP client
@@ -93,6 +166,198 @@ func (c *Client) SetDebug(enabled bool, logger func(format string, args ...inter
 	c.logger = logger
 }
 
+// SetUserAgent sets the User-Agent header sent with each call. An empty value
+// leaves the HTTP client's default in place.
+func (c *Client) SetUserAgent(userAgent string) {
+	c.userAgent = userAgent
+}
+
+// SetPlaintextPassword selects PasswordText, which sends the password itself
+// in the UsernameToken, instead of the default PasswordDigest. Only devices
+// that do not implement digests correctly should need it.
+func (c *Client) SetPlaintextPassword(enabled bool) {
+	c.plaintext = enabled
+}
+
+// SetAddressing enables WS-Addressing MessageID, ReplyTo, To and Action
+// headers on every call. Calls to the event service and WS-Notification
+// operations always carry them, since those services require them.
+func (c *Client) SetAddressing(enabled bool) {
+	c.addressing = enabled
+}
+
+// requiresAddressing reports whether action belongs to a service that
+// mandates WS-Addressing
+func requiresAddressing(action string) bool {
+	return strings.HasPrefix(action, "http://www.onvif.org/ver10/events/wsdl/") ||
+		strings.HasPrefix(action, "http://docs.oasis-open.org/wsn/")
+}
+
+// SetClockOffset sets how far the device clock is ahead of the local clock.
+// The offset is applied to the Created time of the WS-Security UsernameToken.
+func (c *Client) SetClockOffset(offset time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clockOffset = offset
+}
+
+// ClockOffset returns the offset applied to the UsernameToken Created time,
+// including any correction learned by a clock resync
+func (c *Client) ClockOffset() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.clockOffset
+}
+
+// SetClockResync sets the function used to measure the device clock offset
+// when the device rejects the credentials. If the measured offset differs
+// from the current one, the call is retried once with the new offset.
+func (c *Client) SetClockResync(resync func(ctx context.Context) (time.Duration, error)) {
+	c.resyncClock = resync
+}
+
+// SetObserver sets a function called after every Call and CallStream with the
+// call's context, the operation name, the duration including any retry, and
+// the resulting error
+func (c *Client) SetObserver(observer func(ctx context.Context, op string, d time.Duration, err error)) {
+	c.observer = observer
+}
+
+// SetRequestIDHeader sets the HTTP header, e.g. "X-Request-ID", that carries
+// the request ID of the call's context. An empty name sends no header.
+func (c *Client) SetRequestIDHeader(name string) {
+	c.requestIDHeader = name
+}
+
+// SetOperationTimeouts sets timeouts for individual operations, keyed by
+// operation name as returned by OperationName. An operation with a timeout
+// runs under it instead of the HTTP client's timeout, so it can be longer or
+// shorter; other operations keep the HTTP client's timeout.
+func (c *Client) SetOperationTimeouts(timeouts map[string]time.Duration) {
+	c.operationTimeouts = timeouts
+}
+
+// operationTimeout returns the timeout set for the operation request invokes
+func (c *Client) operationTimeout(request interface{}) (time.Duration, bool) {
+	if len(c.operationTimeouts) == 0 {
+		return 0, false
+	}
+	d, ok := c.operationTimeouts[OperationName(request)]
+	return d, ok && d > 0
+}
+
+// OperationName returns the operation a request struct invokes, taken from the
+// local part of its XMLName tag, e.g. "GetProfiles" for `xml:"trt:GetProfiles"`
+func OperationName(request interface{}) string {
+	t := reflect.TypeOf(request)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return ""
+	}
+
+	name := t.Name()
+	if field, ok := t.FieldByName("XMLName"); ok {
+		if tag := strings.Split(field.Tag.Get("xml"), ",")[0]; tag != "" {
+			name = tag
+		}
+	}
+	if i := strings.LastIndexAny(name, ": "); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// Action returns the action URI of a request struct: the namespace bound to
+// the prefix of its XMLName tag followed by the operation name, e.g.
+// "http://www.onvif.org/ver20/ptz/wsdl/ContinuousMove" for a request tagged
+// `xml:"tptz:ContinuousMove"` with an `xml:"xmlns:tptz,attr"` field holding
+// the PTZ namespace. Event service actions also name the port type, see
+// actionURI. It returns an empty string if the namespace is not found.
+func Action(request interface{}) string {
+	v := reflect.ValueOf(request)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	t := v.Type()
+
+	field, ok := t.FieldByName("XMLName")
+	if !ok {
+		return ""
+	}
+	tag := strings.Split(field.Tag.Get("xml"), ",")[0]
+
+	// Either "namespace-URL Name" or "prefix:Name"
+	if i := strings.LastIndex(tag, " "); i >= 0 {
+		return actionURI(tag[:i], tag[i+1:])
+	}
+	i := strings.Index(tag, ":")
+	if i < 0 {
+		return ""
+	}
+	prefix, op := tag[:i], tag[i+1:]
+
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("xml") != "xmlns:"+prefix+",attr" || t.Field(i).Type.Kind() != reflect.String {
+			continue
+		}
+		if namespace := v.Field(i).String(); namespace != "" {
+			return actionURI(namespace, op)
+		}
+	}
+	return ""
+}
+
+// Port types of the event service and WS-BaseNotification operations, whose
+// actions are named after the port type instead of the namespace
+var (
+	eventPortTypes = map[string]string{
+		"GetServiceCapabilities":      "EventPortType",
+		"CreatePullPointSubscription": "EventPortType",
+		"GetEventProperties":          "EventPortType",
+		"PullMessages":                "PullPointSubscription",
+		"Seek":                        "PullPointSubscription",
+		"SetSynchronizationPoint":     "PullPointSubscription",
+	}
+	notificationPortTypes = map[string]string{
+		"Subscribe":         "NotificationProducer",
+		"GetCurrentMessage": "NotificationProducer",
+		"Renew":             "SubscriptionManager",
+		"Unsubscribe":       "SubscriptionManager",
+	}
+)
+
+// actionURI returns the action of operation op in namespace. Event service
+// and WS-BaseNotification actions follow their WSDLs, e.g.
+// "http://www.onvif.org/ver10/events/wsdl/PullPointSubscription/PullMessagesRequest";
+// all others are the namespace followed by the operation name.
+func actionURI(namespace, op string) string {
+	switch namespace {
+	case "http://www.onvif.org/ver10/events/wsdl":
+		if portType, ok := eventPortTypes[op]; ok {
+			return namespace + "/" + portType + "/" + op + "Request"
+		}
+	case "http://docs.oasis-open.org/wsn/b-2":
+		if portType, ok := notificationPortTypes[op]; ok {
+			return "http://docs.oasis-open.org/wsn/bw-2/" + portType + "/" + op + "Request"
+		}
+	}
+	return namespace + "/" + op
+}
+
+// requestIDLine returns the debug log line naming the request ID of ctx, or
+// an empty string if it has none
+func requestIDLine(ctx context.Context) string {
+	if id := RequestID(ctx); id != "" {
+		return "Request ID: " + id + "\n"
+	}
+	return ""
+}
+
 // logDebug logs debug information if debug mode is enabled
 func (c *Client) logDebug(format string, args ...interface{}) {
 	if c.debug && c.logger != nil {
@@ -100,8 +365,147 @@ func (c *Client) logDebug(format string, args ...interface{}) {
 	}
 }
 
-// Call makes a SOAP call to the specified endpoint
+// Attachments holds the binary parts of an MTOM/XOP response, keyed by
+// Content-ID without the enclosing angle brackets
+type Attachments map[string][]byte
+
+// Get returns the attachment an xop:Include href such as "cid:backup@device"
+// refers to
+func (a Attachments) Get(href string) ([]byte, bool) {
+	id := strings.TrimPrefix(href, "cid:")
+	if unescaped, err := url.PathUnescape(id); err == nil {
+		id = unescaped
+	}
+	data, ok := a[id]
+	return data, ok
+}
+
+// Call makes a SOAP call to the specified endpoint. A context that is already
+// done fails the call with ctx.Err() before anything is sent. An empty action
+// is replaced by the one derived from the request, see Action. MTOM responses
+// are decoded from their root part; use CallWithAttachments to also get the
+// binary parts.
 func (c *Client) Call(ctx context.Context, endpoint string, action string, request interface{}, response interface{}) error {
+	_, err := c.CallWithAttachments(ctx, endpoint, action, request, response)
+	return err
+}
+
+// CallWithAttachments makes a SOAP call like Call and also returns the
+// attachments of an MTOM (multipart/related) response, which xop:Include
+// elements in the response refer to by Content-ID. A plain response has no
+// attachments.
+func (c *Client) CallWithAttachments(ctx context.Context, endpoint string, action string, request interface{}, response interface{}) (attachments Attachments, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if c.observer != nil {
+		start := time.Now()
+		defer func() { c.observer(ctx, OperationName(request), time.Since(start), err) }()
+	}
+
+	if timeout, ok := c.operationTimeout(request); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var respBody []byte
+	err = c.roundTrip(ctx, endpoint, action, request, func(body io.Reader, parts Attachments) error {
+		var err error
+		if respBody, err = io.ReadAll(body); err != nil {
+			return fmt.Errorf("failed to read response body: %w", err)
+		}
+		attachments = parts
+
+		// Log response if debug is enabled
+		c.logDebug("=== SOAP Response ===\n%sStatus: %d\n%s\n", requestIDLine(ctx), http.StatusOK, string(respBody))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// If response is empty, return immediately
+	if len(respBody) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	// Unmarshal response content if response is provided
+	if response != nil {
+		// Create a flexible envelope structure for parsing responses
+		var envelope struct {
+			Body struct {
+				Content []byte `xml:",innerxml"`
+			} `xml:"Body"`
+		}
+
+		if err := xml.Unmarshal(respBody, &envelope); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal SOAP envelope: %w", err)
+		}
+
+		// Unmarshal the body content into the response
+		if err := xml.Unmarshal(envelope.Body.Content, response); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+	}
+
+	return attachments, nil
+}
+
+// CallStream makes a SOAP call like Call but hands the body of a successful
+// response to handle as it arrives instead of buffering it, so large
+// responses can be decoded incrementally. The reader yields the whole SOAP
+// envelope; for an MTOM response that is the buffered root part, and the
+// attachments are dropped. Errors from handle are returned unchanged.
+func (c *Client) CallStream(ctx context.Context, endpoint string, action string, request interface{}, handle func(body io.Reader) error) (err error) {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if c.observer != nil {
+		start := time.Now()
+		defer func() { c.observer(ctx, OperationName(request), time.Since(start), err) }()
+	}
+
+	if timeout, ok := c.operationTimeout(request); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return c.roundTrip(ctx, endpoint, action, request, func(body io.Reader, _ Attachments) error {
+		c.logDebug("=== SOAP Response ===\n%sStatus: %d\n(streamed)\n", requestIDLine(ctx), http.StatusOK)
+		return handle(body)
+	})
+}
+
+// roundTrip sends request and passes a successful response body to handle
+func (c *Client) roundTrip(ctx context.Context, endpoint string, action string, request interface{}, handle func(body io.Reader, attachments Attachments) error) error {
+	if action == "" {
+		action = Action(request)
+	}
+
+	err := c.send(ctx, endpoint, action, request, handle)
+
+	// A device whose clock is off rejects the UsernameToken as expired or
+	// not yet valid; retry with Created adjusted to the device clock
+	if errors.Is(err, ErrNotAuthorized) && c.resyncClock != nil && c.username != "" && c.password != "" {
+		offset, syncErr := c.resyncClock(ctx)
+		current := c.ClockOffset()
+		if syncErr == nil && (offset-current >= time.Second || current-offset >= time.Second) {
+			c.logDebug("=== Clock Resync ===\nDevice clock offset: %s\n", offset)
+			c.SetClockOffset(offset)
+			err = c.send(ctx, endpoint, action, request, handle)
+		}
+	}
+
+	return err
+}
+
+// send posts request in a SOAP envelope and passes the body of a successful
+// response to handle, along with the attachments of an MTOM response
+func (c *Client) send(ctx context.Context, endpoint string, action string, request interface{}, handle func(body io.Reader, attachments Attachments) error) error {
 	// Build SOAP envelope
 	envelope := &Envelope{
 		Body: Body{
@@ -116,6 +520,16 @@ func (c *Client) Call(ctx context.Context, endpoint string, action string, reque
 		}
 	}
 
+	if action != "" && (c.addressing || requiresAddressing(action)) {
+		if envelope.Header == nil {
+			envelope.Header = &Header{}
+		}
+		envelope.Header.MessageID = newMessageID()
+		envelope.Header.ReplyTo = &EndpointReference{Address: AnonymousAddress}
+		envelope.Header.To = endpoint
+		envelope.Header.Action = action
+	}
+
 	// Marshal envelope to XML
 	body, err := xml.MarshalIndent(envelope, "", "  ")
 	if err != nil {
@@ -126,7 +540,7 @@ func (c *Client) Call(ctx context.Context, endpoint string, action string, reque
 	xmlBody := append([]byte(xml.Header), body...)
 
 	// Log request if debug is enabled
-	c.logDebug("=== SOAP Request ===\nEndpoint: %s\nAction: %s\n%s\n", endpoint, action, string(xmlBody))
+	c.logDebug("=== SOAP Request ===\n%sEndpoint: %s\nAction: %s\n%s\n", requestIDLine(ctx), endpoint, action, string(xmlBody))
 
 	// Create HTTP request
 	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(xmlBody))
@@ -135,84 +549,180 @@ func (c *Client) Call(ctx context.Context, endpoint string, action string, reque
 	}
 
 	// Set headers
-	req.Header.Set("Content-Type", "application/soap+xml; charset=utf-8")
+	// SOAP 1.2 carries the action in the Content-Type; some devices insist on
+	// the SOAP 1.1 SOAPAction header instead, so both are sent
+	contentType := "application/soap+xml; charset=utf-8"
 	if action != "" {
-		req.Header.Set("SOAPAction", action)
+		contentType += fmt.Sprintf("; action=%q", action)
+		req.Header.Set("SOAPAction", fmt.Sprintf("%q", action))
+	}
+	req.Header.Set("Content-Type", contentType)
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if id := RequestID(ctx); id != "" && c.requestIDHeader != "" {
+		req.Header.Set(c.requestIDHeader, id)
+	}
+
+	// An operation timeout is carried by ctx and replaces the client's own
+	httpClient := c.httpClient
+	if _, ok := c.operationTimeout(request); ok && httpClient.Timeout != 0 {
+		untimed := *httpClient
+		untimed.Timeout = 0
+		httpClient = &untimed
 	}
 
 	// Send request
-	resp, err := c.httpClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send HTTP request: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	// Read response body
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	// Log response if debug is enabled
-	c.logDebug("=== SOAP Response ===\nStatus: %d\n%s\n", resp.StatusCode, string(respBody))
-
 	// Check HTTP status
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP request failed with status %d: %s", resp.StatusCode, string(respBody))
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read response body: %w", err)
+		}
+
+		// Log response if debug is enabled
+		c.logDebug("=== SOAP Response ===\n%sStatus: %d\n%s\n", requestIDLine(ctx), resp.StatusCode, string(respBody))
+
+		httpErr := &HTTPError{StatusCode: resp.StatusCode, Body: string(respBody)}
+		if isNotAuthorized(respBody) {
+			return fmt.Errorf("%w: %w", ErrNotAuthorized, httpErr)
+		}
+		return httpErr
 	}
 
-	// If response is empty, return immediately
-	if len(respBody) == 0 {
-		return fmt.Errorf("received empty response body")
+	// MTOM responses carry the envelope in the root part of a
+	// multipart/related body and binary data in the other parts
+	if mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil && mediaType == "multipart/related" {
+		root, attachments, err := readMultipart(resp.Body, params)
+		if err != nil {
+			return fmt.Errorf("failed to read MTOM response: %w", err)
+		}
+		return handle(bytes.NewReader(root), attachments)
 	}
 
-	// Unmarshal response content if response is provided
-	if response != nil {
-		// Create a flexible envelope structure for parsing responses
-		var envelope struct {
-			Body struct {
-				Content []byte `xml:",innerxml"`
-			} `xml:"Body"`
+	return handle(resp.Body, nil)
+}
+
+// readMultipart splits a multipart/related body into its root part, named by
+// the start parameter or else the first part, and the remaining parts
+func readMultipart(body io.Reader, params map[string]string) ([]byte, Attachments, error) {
+	boundary := params["boundary"]
+	if boundary == "" {
+		return nil, nil, errors.New("missing multipart boundary")
+	}
+	start := strings.Trim(params["start"], "<>")
+
+	var root []byte
+	attachments := make(Attachments)
+	reader := multipart.NewReader(body, boundary)
+	for first := true; ; first = false {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
 		}
 
-		if err := xml.Unmarshal(respBody, &envelope); err != nil {
-			return fmt.Errorf("failed to unmarshal SOAP envelope: %w", err)
+		var data []byte
+		if strings.EqualFold(part.Header.Get("Content-Transfer-Encoding"), "base64") {
+			data, err = io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+		} else {
+			data, err = io.ReadAll(part)
+		}
+		if err != nil {
+			return nil, nil, err
 		}
 
-		// Unmarshal the body content into the response
-		if err := xml.Unmarshal(envelope.Body.Content, response); err != nil {
-			return fmt.Errorf("failed to unmarshal response: %w", err)
+		id := strings.Trim(part.Header.Get("Content-ID"), "<>")
+		if (start == "" && first) || (start != "" && id == start) {
+			root = data
+			continue
+		}
+		attachments[id] = data
+	}
+
+	if root == nil {
+		return nil, nil, errors.New("missing root part")
+	}
+	return root, attachments, nil
+}
+
+// faultCode is the wire form of a SOAP 1.2 fault code and its nested subcodes
+type faultCode struct {
+	Value   string     `xml:"Value"`
+	Subcode *faultCode `xml:"Subcode"`
+}
+
+// isNotAuthorized reports whether body is a SOAP fault with the ter:NotAuthorized subcode
+func isNotAuthorized(body []byte) bool {
+	var envelope struct {
+		Body struct {
+			Fault struct {
+				Code faultCode `xml:"Code"`
+			} `xml:"Fault"`
+		} `xml:"Body"`
+	}
+	if err := xml.Unmarshal(body, &envelope); err != nil {
+		return false
+	}
+
+	for code := envelope.Body.Fault.Code.Subcode; code != nil; code = code.Subcode {
+		if strings.HasSuffix(code.Value, ":NotAuthorized") || code.Value == "NotAuthorized" {
+			return true
 		}
 	}
+	return false
+}
 
-	return nil
+// newMessageID returns a random UUID URN for the WS-Addressing MessageID
+func newMessageID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)     // rand.Read always returns len(b), nil
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
 }
 
-// createSecurityHeader creates a WS-Security header with username token digest
+// createSecurityHeader creates a WS-Security header with username token digest.
+// It is called for every request so each token carries a fresh nonce and
+// Created time; devices reject a repeated nonce as a replay.
 func (c *Client) createSecurityHeader() *Security {
 	// Generate nonce
 	nonceBytes := make([]byte, 16)
 	_, _ = rand.Read(nonceBytes) // rand.Read always returns len(nonceBytes), nil
 	nonce := base64.StdEncoding.EncodeToString(nonceBytes)
 
-	// Get current timestamp
-	created := time.Now().UTC().Format(time.RFC3339)
+	// Get current timestamp on the device clock
+	created := time.Now().Add(c.ClockOffset()).UTC().Format(time.RFC3339)
 
-	// Calculate password digest: Base64(SHA1(nonce + created + password))
-	hash := sha1.New()
-	hash.Write(nonceBytes)
-	hash.Write([]byte(created))
-	hash.Write([]byte(c.password))
-	digest := base64.StdEncoding.EncodeToString(hash.Sum(nil))
+	password := Password{
+		Type:     PasswordTextType,
+		Password: c.password,
+	}
+	if !c.plaintext {
+		// Calculate password digest: Base64(SHA1(nonce + created + password))
+		hash := sha1.New()
+		hash.Write(nonceBytes)
+		hash.Write([]byte(created))
+		hash.Write([]byte(c.password))
+		password = Password{
+			Type:     PasswordDigestType,
+			Password: base64.StdEncoding.EncodeToString(hash.Sum(nil)),
+		}
+	}
 
 	return &Security{
 		MustUnderstand: "1",
 		UsernameToken: &UsernameToken{
 			Username: c.username,
-			Password: Password{
-				Type:     "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordDigest",
-				Password: digest,
-			},
+			Password: password,
 			Nonce: Nonce{
 				Type:  "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-soap-message-security-1.0#Base64Binary",
 				Nonce: nonce,
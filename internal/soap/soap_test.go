@@ -2,8 +2,15 @@ package soap
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -214,6 +221,393 @@ func TestClientCallWithTimeout(t *testing.T) {
 	}
 }
 
+func TestClientCallCanceledContext(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(&http.Client{}, "admin", "password")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := client.Call(ctx, server.URL, "", struct{}{}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Call() error = %v, want context.Canceled", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Errorf("expected no request to reach the server, got %d", n)
+	}
+}
+
+func TestClientObserver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	type GetProfiles struct {
+		XMLName xml.Name `xml:"trt:GetProfiles"`
+	}
+
+	var ops, ids []string
+	var errs []error
+	client := NewClient(&http.Client{}, "", "")
+	client.SetObserver(func(ctx context.Context, op string, d time.Duration, err error) {
+		ops = append(ops, op)
+		ids = append(ids, RequestID(ctx))
+		errs = append(errs, err)
+	})
+
+	err := client.Call(WithRequestID(context.Background(), "req-1"), server.URL, "", GetProfiles{}, nil)
+	if err == nil {
+		t.Fatal("expected an error for HTTP 500")
+	}
+	if len(ops) != 1 || ops[0] != "GetProfiles" || ids[0] != "req-1" || errs[0] != err {
+		t.Errorf("unexpected observations: %v %v %v", ops, ids, errs)
+	}
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("expected HTTPError with status 500, got %v", err)
+	}
+}
+
+func TestClientRequestID(t *testing.T) {
+	var header string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("X-Request-ID")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<Envelope><Body></Body></Envelope>`))
+	}))
+	defer server.Close()
+
+	type GetProfiles struct {
+		XMLName xml.Name `xml:"trt:GetProfiles"`
+	}
+
+	var logged strings.Builder
+	client := NewClient(&http.Client{}, "", "")
+	client.SetDebug(true, func(format string, args ...interface{}) {
+		fmt.Fprintf(&logged, format, args...)
+	})
+	ctx := WithRequestID(context.Background(), "req-42")
+
+	if err := client.Call(ctx, server.URL, "", GetProfiles{}, nil); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if header != "" {
+		t.Errorf("Request ID header sent without SetRequestIDHeader: %q", header)
+	}
+	if strings.Count(logged.String(), "Request ID: req-42") != 2 {
+		t.Errorf("Expected the request ID in the request and response logs, got: %s", logged.String())
+	}
+
+	client.SetRequestIDHeader("X-Request-ID")
+	if err := client.Call(ctx, server.URL, "", GetProfiles{}, nil); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if header != "req-42" {
+		t.Errorf("X-Request-ID = %q, want req-42", header)
+	}
+	if err := client.Call(context.Background(), server.URL, "", GetProfiles{}, nil); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if header != "" {
+		t.Errorf("Request ID header sent without a request ID: %q", header)
+	}
+}
+
+func TestOperationName(t *testing.T) {
+	type SetPreset struct {
+		XMLName xml.Name `xml:"tptz:SetPreset"`
+	}
+	type GetUsers struct{}
+
+	tests := []struct {
+		request interface{}
+		want    string
+	}{
+		{SetPreset{}, "SetPreset"},
+		{&SetPreset{}, "SetPreset"},
+		{GetUsers{}, "GetUsers"},
+		{"raw", ""},
+	}
+	for _, tt := range tests {
+		if got := OperationName(tt.request); got != tt.want {
+			t.Errorf("OperationName(%T) = %q, want %q", tt.request, got, tt.want)
+		}
+	}
+}
+
+func TestAction(t *testing.T) {
+	type ContinuousMove struct {
+		XMLName xml.Name `xml:"tptz:ContinuousMove"`
+		Xmlns   string   `xml:"xmlns:tptz,attr"`
+		Xmlnst  string   `xml:"xmlns:tt,attr"`
+	}
+	type Renew struct {
+		XMLName xml.Name `xml:"http://docs.oasis-open.org/wsn/b-2 Renew"`
+	}
+	type PullMessages struct {
+		XMLName xml.Name `xml:"tev:PullMessages"`
+		Xmlns   string   `xml:"xmlns:tev,attr"`
+	}
+	type CreatePullPointSubscription struct {
+		XMLName xml.Name `xml:"tev:CreatePullPointSubscription"`
+		Xmlns   string   `xml:"xmlns:tev,attr"`
+	}
+	type SetSynchronizationPoint struct {
+		XMLName xml.Name `xml:"tev:SetSynchronizationPoint"`
+		Xmlns   string   `xml:"xmlns:tev,attr"`
+	}
+	type GetUsers struct{}
+
+	move := ContinuousMove{Xmlns: "http://www.onvif.org/ver20/ptz/wsdl", Xmlnst: "http://www.onvif.org/ver10/schema"}
+
+	tests := []struct {
+		request interface{}
+		want    string
+	}{
+		{move, "http://www.onvif.org/ver20/ptz/wsdl/ContinuousMove"},
+		{&move, "http://www.onvif.org/ver20/ptz/wsdl/ContinuousMove"},
+		{Renew{}, "http://docs.oasis-open.org/wsn/bw-2/SubscriptionManager/RenewRequest"},
+		{PullMessages{Xmlns: "http://www.onvif.org/ver10/events/wsdl"}, "http://www.onvif.org/ver10/events/wsdl/PullPointSubscription/PullMessagesRequest"},
+		{CreatePullPointSubscription{Xmlns: "http://www.onvif.org/ver10/events/wsdl"}, "http://www.onvif.org/ver10/events/wsdl/EventPortType/CreatePullPointSubscriptionRequest"},
+		{SetSynchronizationPoint{Xmlns: "http://www.onvif.org/ver10/events/wsdl"}, "http://www.onvif.org/ver10/events/wsdl/PullPointSubscription/SetSynchronizationPointRequest"},
+		{ContinuousMove{}, ""},
+		{GetUsers{}, ""},
+		{"raw", ""},
+	}
+	for _, tt := range tests {
+		if got := Action(tt.request); got != tt.want {
+			t.Errorf("Action(%T) = %q, want %q", tt.request, got, tt.want)
+		}
+	}
+}
+
+func TestClientCallActionHeaders(t *testing.T) {
+	type GetProfiles struct {
+		XMLName xml.Name `xml:"trt:GetProfiles"`
+		Xmlns   string   `xml:"xmlns:trt,attr"`
+	}
+
+	var soapAction, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		soapAction = r.Header.Get("SOAPAction")
+		contentType = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<Envelope><Body></Body></Envelope>`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&http.Client{}, "", "")
+	request := GetProfiles{Xmlns: "http://www.onvif.org/ver10/media/wsdl"}
+
+	if err := client.Call(context.Background(), server.URL, "", request, nil); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	want := `"http://www.onvif.org/ver10/media/wsdl/GetProfiles"`
+	if soapAction != want {
+		t.Errorf("SOAPAction = %s, want %s", soapAction, want)
+	}
+	if contentType != "application/soap+xml; charset=utf-8; action="+want {
+		t.Errorf("Unexpected Content-Type %s", contentType)
+	}
+
+	if err := client.Call(context.Background(), server.URL, "urn:vendor/Custom", request, nil); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if soapAction != `"urn:vendor/Custom"` {
+		t.Errorf("Explicit action not sent, got SOAPAction %s", soapAction)
+	}
+}
+
+func TestCallWithAttachments(t *testing.T) {
+	type GetSystemBackup struct {
+		XMLName xml.Name `xml:"tds:GetSystemBackup"`
+		Xmlns   string   `xml:"xmlns:tds,attr"`
+	}
+
+	type GetSystemBackupResponse struct {
+		Include struct {
+			Href string `xml:"href,attr"`
+		} `xml:"BackupFiles>Data>Include"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", `multipart/related; type="application/xop+xml"; boundary="MIMEBoundary"; start="<root@device>"; start-info="application/soap+xml"`)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("--MIMEBoundary\r\n" +
+			"Content-Type: application/octet-stream\r\n" +
+			"Content-Transfer-Encoding: binary\r\n" +
+			"Content-ID: <backup%401@device>\r\n\r\n" +
+			"\x00\x01binary\r\n" +
+			"--MIMEBoundary\r\n" +
+			"Content-Type: application/xop+xml; charset=UTF-8; type=\"application/soap+xml\"\r\n" +
+			"Content-ID: <root@device>\r\n\r\n" +
+			`<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>` +
+			`<tds:GetSystemBackupResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"><tds:BackupFiles><tds:Data>` +
+			`<xop:Include xmlns:xop="http://www.w3.org/2004/08/xop/include" href="cid:backup%25401@device"/>` +
+			`</tds:Data></tds:BackupFiles></tds:GetSystemBackupResponse></s:Body></s:Envelope>` + "\r\n" +
+			"--MIMEBoundary--\r\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient(&http.Client{}, "", "")
+	request := GetSystemBackup{Xmlns: "http://www.onvif.org/ver10/device/wsdl"}
+
+	var resp GetSystemBackupResponse
+	attachments, err := client.CallWithAttachments(context.Background(), server.URL, "", request, &resp)
+	if err != nil {
+		t.Fatalf("CallWithAttachments() error = %v", err)
+	}
+	if resp.Include.Href != "cid:backup%25401@device" {
+		t.Errorf("Root part not decoded, got href %q", resp.Include.Href)
+	}
+	data, ok := attachments.Get(resp.Include.Href)
+	if !ok || string(data) != "\x00\x01binary" {
+		t.Errorf("Attachment = %q, %v; attachments %v", data, ok, attachments)
+	}
+
+	// Call decodes the root part too
+	resp = GetSystemBackupResponse{}
+	if err := client.Call(context.Background(), server.URL, "", request, &resp); err != nil || resp.Include.Href == "" {
+		t.Errorf("Call() = %v, href %q", err, resp.Include.Href)
+	}
+}
+
+func TestClientAddressing(t *testing.T) {
+	type GetProfiles struct {
+		XMLName xml.Name `xml:"trt:GetProfiles"`
+		Xmlns   string   `xml:"xmlns:trt,attr"`
+	}
+	type PullMessages struct {
+		XMLName xml.Name `xml:"tev:PullMessages"`
+		Xmlns   string   `xml:"xmlns:tev,attr"`
+	}
+
+	var header *Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var envelope Envelope
+		body, _ := io.ReadAll(r.Body)
+		if err := xml.Unmarshal(body, &envelope); err != nil {
+			t.Errorf("Failed to parse request: %v", err)
+		}
+		header = envelope.Header
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<Envelope><Body></Body></Envelope>`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&http.Client{}, "", "")
+	profiles := GetProfiles{Xmlns: "http://www.onvif.org/ver10/media/wsdl"}
+	pull := PullMessages{Xmlns: "http://www.onvif.org/ver10/events/wsdl"}
+
+	if err := client.Call(context.Background(), server.URL, "", profiles, nil); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if header != nil {
+		t.Errorf("Expected no header for a media call, got %+v", header)
+	}
+
+	if err := client.Call(context.Background(), server.URL+"/subscription", "", pull, nil); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if header == nil {
+		t.Fatal("Expected addressing headers for an event call")
+	}
+	if header.Action != "http://www.onvif.org/ver10/events/wsdl/PullPointSubscription/PullMessagesRequest" || header.To != server.URL+"/subscription" {
+		t.Errorf("Unexpected Action %q or To %q", header.Action, header.To)
+	}
+	if !strings.HasPrefix(header.MessageID, "urn:uuid:") || len(header.MessageID) != len("urn:uuid:")+36 {
+		t.Errorf("Unexpected MessageID %q", header.MessageID)
+	}
+	if header.ReplyTo == nil || header.ReplyTo.Address != AnonymousAddress {
+		t.Errorf("Unexpected ReplyTo %+v", header.ReplyTo)
+	}
+	firstID := header.MessageID
+
+	client.SetAddressing(true)
+	if err := client.Call(context.Background(), server.URL, "", profiles, nil); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if header == nil || header.Action != "http://www.onvif.org/ver10/media/wsdl/GetProfiles" {
+		t.Errorf("Expected addressing headers once enabled, got %+v", header)
+	} else if header.MessageID == firstID {
+		t.Error("Expected a new MessageID for every call")
+	}
+}
+
+func TestClientOperationTimeouts(t *testing.T) {
+	type SystemReboot struct {
+		XMLName xml.Name `xml:"tds:SystemReboot"`
+	}
+	type GetStatus struct {
+		XMLName xml.Name `xml:"tptz:GetStatus"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<Envelope><Body></Body></Envelope>`))
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{Timeout: 30 * time.Millisecond}
+	client := NewClient(httpClient, "", "")
+	client.SetOperationTimeouts(map[string]time.Duration{"SystemReboot": time.Second})
+
+	if err := client.Call(context.Background(), server.URL, "", SystemReboot{}, nil); err != nil {
+		t.Errorf("Expected SystemReboot to run under its own timeout, got %v", err)
+	}
+	if err := client.Call(context.Background(), server.URL, "", GetStatus{}, nil); err == nil {
+		t.Error("Expected GetStatus to keep the HTTP client timeout")
+	}
+	if httpClient.Timeout != 30*time.Millisecond {
+		t.Errorf("HTTP client timeout was modified: %s", httpClient.Timeout)
+	}
+}
+
+func TestClientCallFreshNonce(t *testing.T) {
+	var nonces []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var envelope Envelope
+		body, _ := io.ReadAll(r.Body)
+		if err := xml.Unmarshal(body, &envelope); err != nil || envelope.Header == nil || envelope.Header.Security == nil {
+			t.Errorf("Expected a security header, got: %s", body)
+		} else {
+			nonces = append(nonces, envelope.Header.Security.UsernameToken.Nonce.Nonce)
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<Envelope><Body></Body></Envelope>`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&http.Client{}, "admin", "password")
+
+	for i := 0; i < 2; i++ {
+		if err := client.Call(context.Background(), server.URL, "", struct{}{}, nil); err != nil {
+			t.Fatalf("Call() error = %v", err)
+		}
+	}
+
+	if len(nonces) != 2 {
+		t.Fatalf("Expected 2 nonces, got %d", len(nonces))
+	}
+	for _, nonce := range nonces {
+		if raw, err := base64.StdEncoding.DecodeString(nonce); err != nil || len(raw) != 16 {
+			t.Errorf("Expected a 16-byte base64 nonce, got %q", nonce)
+		}
+	}
+	if nonces[0] == nonces[1] {
+		t.Errorf("Expected a fresh nonce per call, got %q twice", nonces[0])
+	}
+}
+
 func TestSecurityHeaderCreation(t *testing.T) {
 	httpClient := &http.Client{}
 	client := NewClient(httpClient, "testuser", "testpass")
@@ -1,78 +1,41 @@
 package onvif
 
 import (
+	"bytes"
 	"context"
 	"encoding/xml"
 	"fmt"
-
-	"github.com/0x524a/onvif-go/internal/soap"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 )
 
 // Media service namespace
 const mediaNamespace = "http://www.onvif.org/ver10/media/wsdl"
 
-// GetProfiles retrieves all media profiles
+// GetProfiles retrieves all media profiles. They come in the order the
+// device returns them, which is camera-dependent and may change between calls,
+// unless the client was created with WithStableProfileOrder.
 func (c *Client) GetProfiles(ctx context.Context) ([]*Profile, error) {
 	endpoint := c.mediaEndpoint
 	if endpoint == "" {
 		endpoint = c.endpoint
 	}
 
-	type GetProfiles struct {
-		XMLName xml.Name `xml:"trt:GetProfiles"`
-		Xmlns   string   `xml:"xmlns:trt,attr"`
+	type GetProfilesResponse struct {
+		XMLName  xml.Name        `xml:"GetProfilesResponse"`
+		Profiles []rawProfileXML `xml:"Profiles"`
 	}
 
-	type GetProfilesResponse struct {
-		XMLName  xml.Name `xml:"GetProfilesResponse"`
-		Profiles []struct {
-			Token                    string `xml:"token,attr"`
-			Name                     string `xml:"Name"`
-			VideoSourceConfiguration *struct {
-				Token       string `xml:"token,attr"`
-				Name        string `xml:"Name"`
-				UseCount    int    `xml:"UseCount"`
-				SourceToken string `xml:"SourceToken"`
-				Bounds      *struct {
-					X      int `xml:"x,attr"`
-					Y      int `xml:"y,attr"`
-					Width  int `xml:"width,attr"`
-					Height int `xml:"height,attr"`
-				} `xml:"Bounds"`
-			} `xml:"VideoSourceConfiguration"`
-			VideoEncoderConfiguration *struct {
-				Token      string `xml:"token,attr"`
-				Name       string `xml:"Name"`
-				UseCount   int    `xml:"UseCount"`
-				Encoding   string `xml:"Encoding"`
-				Resolution *struct {
-					Width  int `xml:"Width"`
-					Height int `xml:"Height"`
-				} `xml:"Resolution"`
-				Quality     float64 `xml:"Quality"`
-				RateControl *struct {
-					FrameRateLimit   int `xml:"FrameRateLimit"`
-					EncodingInterval int `xml:"EncodingInterval"`
-					BitrateLimit     int `xml:"BitrateLimit"`
-				} `xml:"RateControl"`
-			} `xml:"VideoEncoderConfiguration"`
-			PTZConfiguration *struct {
-				Token     string `xml:"token,attr"`
-				Name      string `xml:"Name"`
-				UseCount  int    `xml:"UseCount"`
-				NodeToken string `xml:"NodeToken"`
-			} `xml:"PTZConfiguration"`
-		} `xml:"Profiles"`
-	}
-
-	req := GetProfiles{
+	req := getProfilesRequest{
 		Xmlns: mediaNamespace,
 	}
 
 	var resp GetProfilesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetProfiles failed: %w", err)
@@ -80,68 +43,520 @@ func (c *Client) GetProfiles(ctx context.Context) ([]*Profile, error) {
 
 	profiles := make([]*Profile, len(resp.Profiles))
 	for i, p := range resp.Profiles {
-		profile := &Profile{
-			Token: p.Token,
-			Name:  p.Name,
-		}
+		profiles[i] = p.decode(func(err error) { c.reportMalformed("GetProfiles", err) }).toProfile()
+	}
+	if c.sortProfiles {
+		slices.SortStableFunc(profiles, func(a, b *Profile) int {
+			return strings.Compare(a.Token, b.Token)
+		})
+	}
+
+	return profiles, nil
+}
+
+// GetProfilesStream retrieves all media profiles like GetProfiles, but decodes
+// them one at a time as the response arrives and passes each to fn instead of
+// collecting them, which keeps memory flat on devices with many profiles.
+// Returning an error from fn stops decoding and GetProfilesStream returns
+// that error unchanged.
+func (c *Client) GetProfilesStream(ctx context.Context, fn func(*Profile) error) error {
+	endpoint := c.mediaEndpoint
+	if endpoint == "" {
+		endpoint = c.endpoint
+	}
+
+	req := getProfilesRequest{
+		Xmlns: mediaNamespace,
+	}
+
+	var callbackErr error
+
+	soapClient := c.soapClient()
+
+	err := soapClient.CallStream(ctx, endpoint, "", req, func(body io.Reader) error {
+		decoder := xml.NewDecoder(body)
+		for {
+			tok, err := decoder.Token()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to decode response: %w", err)
+			}
+
+			start, ok := tok.(xml.StartElement)
+			if !ok || start.Name.Local != "Profiles" {
+				continue
+			}
 
-		if p.VideoSourceConfiguration != nil {
-			profile.VideoSourceConfiguration = &VideoSourceConfiguration{
-				Token:       p.VideoSourceConfiguration.Token,
-				Name:        p.VideoSourceConfiguration.Name,
-				UseCount:    p.VideoSourceConfiguration.UseCount,
-				SourceToken: p.VideoSourceConfiguration.SourceToken,
+			var p rawProfileXML
+			if err := decoder.DecodeElement(&p, &start); err != nil {
+				return fmt.Errorf("failed to decode profile: %w", err)
 			}
-			if p.VideoSourceConfiguration.Bounds != nil {
-				profile.VideoSourceConfiguration.Bounds = &IntRectangle{
-					X:      p.VideoSourceConfiguration.Bounds.X,
-					Y:      p.VideoSourceConfiguration.Bounds.Y,
-					Width:  p.VideoSourceConfiguration.Bounds.Width,
-					Height: p.VideoSourceConfiguration.Bounds.Height,
-				}
+			profile := p.decode(func(err error) { c.reportMalformed("GetProfilesStream", err) }).toProfile()
+
+			if err := fn(profile); err != nil {
+				callbackErr = err
+				return err
 			}
 		}
+	})
+	if callbackErr != nil {
+		return callbackErr
+	}
+	if err != nil {
+		return fmt.Errorf("GetProfilesStream failed: %w", err)
+	}
+
+	return nil
+}
+
+// getProfilesRequest is the trt:GetProfiles request shared by GetProfiles and
+// GetProfilesStream
+type getProfilesRequest struct {
+	XMLName xml.Name `xml:"trt:GetProfiles"`
+	Xmlns   string   `xml:"xmlns:trt,attr"`
+}
+
+// rawProfileXML holds an undecoded tt:Profile, so that a malformed section
+// can be left out without losing the rest of the profile
+type rawProfileXML struct {
+	Attrs []xml.Attr `xml:",any,attr"`
+	Inner []byte     `xml:",innerxml"`
+}
+
+// decode decodes the profile. If that fails, its sections are decoded one at
+// a time and each malformed one is left out and passed to report.
+func (r rawProfileXML) decode(report func(error)) profileXML {
+	var token string
+	for _, attr := range r.Attrs {
+		if attr.Name.Local == "token" {
+			token = attr.Value
+		}
+	}
+
+	wrap := func(inner []byte) []byte {
+		return append(append([]byte("<Profile>"), inner...), "</Profile>"...)
+	}
+
+	p := profileXML{Token: token}
+	if err := xml.Unmarshal(wrap(r.Inner), &p); err == nil {
+		return p
+	}
+
+	p = profileXML{Token: token}
+	decoder := xml.NewDecoder(bytes.NewReader(r.Inner))
+	for {
+		offset := decoder.InputOffset()
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			return p
+		}
+		if err != nil {
+			report(fmt.Errorf("profile %q: %w", token, err))
+			return p
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if err := decoder.Skip(); err != nil {
+			report(fmt.Errorf("profile %q: %w", token, err))
+			return p
+		}
+
+		section := p
+		if err := xml.Unmarshal(wrap(r.Inner[offset:decoder.InputOffset()]), &section); err != nil {
+			report(fmt.Errorf("profile %q: malformed %s: %w", token, start.Name.Local, err))
+			continue
+		}
+		p = section
+	}
+}
+
+// profileXML is the wire form of a tt:Profile in a GetProfiles response
+type profileXML struct {
+	Token                    string `xml:"token,attr"`
+	Name                     string `xml:"Name"`
+	VideoSourceConfiguration *struct {
+		Token       string `xml:"token,attr"`
+		Name        string `xml:"Name"`
+		UseCount    int    `xml:"UseCount"`
+		SourceToken string `xml:"SourceToken"`
+		Bounds      *struct {
+			X      int `xml:"x,attr"`
+			Y      int `xml:"y,attr"`
+			Width  int `xml:"width,attr"`
+			Height int `xml:"height,attr"`
+		} `xml:"Bounds"`
+	} `xml:"VideoSourceConfiguration"`
+	VideoEncoderConfiguration *struct {
+		Token      string `xml:"token,attr"`
+		Name       string `xml:"Name"`
+		UseCount   int    `xml:"UseCount"`
+		Encoding   string `xml:"Encoding"`
+		Resolution *struct {
+			Width  int `xml:"Width"`
+			Height int `xml:"Height"`
+		} `xml:"Resolution"`
+		Quality     float64 `xml:"Quality"`
+		RateControl *struct {
+			FrameRateLimit   int `xml:"FrameRateLimit"`
+			EncodingInterval int `xml:"EncodingInterval"`
+			BitrateLimit     int `xml:"BitrateLimit"`
+		} `xml:"RateControl"`
+	} `xml:"VideoEncoderConfiguration"`
+	AudioSourceConfiguration *struct {
+		Token       string `xml:"token,attr"`
+		Name        string `xml:"Name"`
+		UseCount    int    `xml:"UseCount"`
+		SourceToken string `xml:"SourceToken"`
+	} `xml:"AudioSourceConfiguration"`
+	AudioEncoderConfiguration   *audioEncoderConfigurationXML   `xml:"AudioEncoderConfiguration"`
+	VideoAnalyticsConfiguration *videoAnalyticsConfigurationXML `xml:"VideoAnalyticsConfiguration"`
+	PTZConfiguration            *struct {
+		Token     string `xml:"token,attr"`
+		Name      string `xml:"Name"`
+		UseCount  int    `xml:"UseCount"`
+		NodeToken string `xml:"NodeToken"`
+	} `xml:"PTZConfiguration"`
+	MetadataConfiguration *struct {
+		Token     string `xml:"token,attr"`
+		Name      string `xml:"Name"`
+		UseCount  int    `xml:"UseCount"`
+		Analytics bool   `xml:"Analytics"`
+	} `xml:"MetadataConfiguration"`
+	Extension *struct {
+		AudioOutputConfiguration  *audioOutputConfigurationXML `xml:"AudioOutputConfiguration"`
+		AudioDecoderConfiguration *struct {
+			Token    string `xml:"token,attr"`
+			Name     string `xml:"Name"`
+			UseCount int    `xml:"UseCount"`
+		} `xml:"AudioDecoderConfiguration"`
+	} `xml:"Extension"`
+}
+
+// audioOutputConfigurationXML is the wire form of a tt:AudioOutputConfiguration
+type audioOutputConfigurationXML struct {
+	Token       string `xml:"token,attr"`
+	Name        string `xml:"Name"`
+	UseCount    int    `xml:"UseCount"`
+	OutputToken string `xml:"OutputToken"`
+	SendPrimacy string `xml:"SendPrimacy"`
+	OutputLevel int    `xml:"OutputLevel"`
+}
+
+// toAudioOutputConfiguration converts the wire form into an AudioOutputConfiguration
+func (x *audioOutputConfigurationXML) toAudioOutputConfiguration() *AudioOutputConfiguration {
+	return &AudioOutputConfiguration{
+		Token:       x.Token,
+		Name:        x.Name,
+		UseCount:    x.UseCount,
+		OutputToken: x.OutputToken,
+		SendPrimacy: x.SendPrimacy,
+		OutputLevel: x.OutputLevel,
+	}
+}
+
+// videoAnalyticsConfigurationXML is the wire form of a
+// tt:VideoAnalyticsConfiguration
+type videoAnalyticsConfigurationXML struct {
+	Token                        string `xml:"token,attr"`
+	Name                         string `xml:"Name"`
+	UseCount                     int    `xml:"UseCount"`
+	AnalyticsEngineConfiguration struct {
+		AnalyticsModule []analyticsEntryXML `xml:"AnalyticsModule"`
+	} `xml:"AnalyticsEngineConfiguration"`
+	RuleEngineConfiguration struct {
+		Rule []analyticsEntryXML `xml:"Rule"`
+	} `xml:"RuleEngineConfiguration"`
+}
+
+// analyticsEntryXML is the wire form of a tt:Config naming an analytics
+// module or rule
+type analyticsEntryXML struct {
+	Name string `xml:"Name,attr"`
+	Type string `xml:"Type,attr"`
+}
 
-		if p.VideoEncoderConfiguration != nil {
-			profile.VideoEncoderConfiguration = &VideoEncoderConfiguration{
-				Token:    p.VideoEncoderConfiguration.Token,
-				Name:     p.VideoEncoderConfiguration.Name,
-				UseCount: p.VideoEncoderConfiguration.UseCount,
-				Encoding: p.VideoEncoderConfiguration.Encoding,
-				Quality:  p.VideoEncoderConfiguration.Quality,
+// toVideoAnalyticsConfiguration converts the wire form into a VideoAnalyticsConfiguration
+func (x *videoAnalyticsConfigurationXML) toVideoAnalyticsConfiguration() *VideoAnalyticsConfiguration {
+	config := &VideoAnalyticsConfiguration{
+		Token:    x.Token,
+		Name:     x.Name,
+		UseCount: x.UseCount,
+	}
+	for _, module := range x.AnalyticsEngineConfiguration.AnalyticsModule {
+		config.AnalyticsModules = append(config.AnalyticsModules, AnalyticsEntry(module))
+	}
+	for _, rule := range x.RuleEngineConfiguration.Rule {
+		config.Rules = append(config.Rules, AnalyticsEntry(rule))
+	}
+	return config
+}
+
+// toProfile converts the wire form into a Profile
+func (x profileXML) toProfile() *Profile {
+	profile := &Profile{
+		Token: x.Token,
+		Name:  x.Name,
+	}
+
+	if x.VideoSourceConfiguration != nil {
+		profile.VideoSourceConfiguration = &VideoSourceConfiguration{
+			Token:       x.VideoSourceConfiguration.Token,
+			Name:        x.VideoSourceConfiguration.Name,
+			UseCount:    x.VideoSourceConfiguration.UseCount,
+			SourceToken: x.VideoSourceConfiguration.SourceToken,
+		}
+		if x.VideoSourceConfiguration.Bounds != nil {
+			profile.VideoSourceConfiguration.Bounds = &IntRectangle{
+				X:      x.VideoSourceConfiguration.Bounds.X,
+				Y:      x.VideoSourceConfiguration.Bounds.Y,
+				Width:  x.VideoSourceConfiguration.Bounds.Width,
+				Height: x.VideoSourceConfiguration.Bounds.Height,
 			}
-			if p.VideoEncoderConfiguration.Resolution != nil {
-				profile.VideoEncoderConfiguration.Resolution = &VideoResolution{
-					Width:  p.VideoEncoderConfiguration.Resolution.Width,
-					Height: p.VideoEncoderConfiguration.Resolution.Height,
-				}
+		}
+	}
+
+	if x.VideoEncoderConfiguration != nil {
+		profile.VideoEncoderConfiguration = &VideoEncoderConfiguration{
+			Token:    x.VideoEncoderConfiguration.Token,
+			Name:     x.VideoEncoderConfiguration.Name,
+			UseCount: x.VideoEncoderConfiguration.UseCount,
+			Encoding: x.VideoEncoderConfiguration.Encoding,
+			Quality:  x.VideoEncoderConfiguration.Quality,
+		}
+		if x.VideoEncoderConfiguration.Resolution != nil {
+			profile.VideoEncoderConfiguration.Resolution = &VideoResolution{
+				Width:  x.VideoEncoderConfiguration.Resolution.Width,
+				Height: x.VideoEncoderConfiguration.Resolution.Height,
 			}
-			if p.VideoEncoderConfiguration.RateControl != nil {
-				profile.VideoEncoderConfiguration.RateControl = &VideoRateControl{
-					FrameRateLimit:   p.VideoEncoderConfiguration.RateControl.FrameRateLimit,
-					EncodingInterval: p.VideoEncoderConfiguration.RateControl.EncodingInterval,
-					BitrateLimit:     p.VideoEncoderConfiguration.RateControl.BitrateLimit,
-				}
+		}
+		if x.VideoEncoderConfiguration.RateControl != nil {
+			profile.VideoEncoderConfiguration.RateControl = &VideoRateControl{
+				FrameRateLimit:   x.VideoEncoderConfiguration.RateControl.FrameRateLimit,
+				EncodingInterval: x.VideoEncoderConfiguration.RateControl.EncodingInterval,
+				BitrateLimit:     x.VideoEncoderConfiguration.RateControl.BitrateLimit,
 			}
 		}
+	}
+
+	if x.AudioSourceConfiguration != nil {
+		profile.AudioSourceConfiguration = &AudioSourceConfiguration{
+			Token:       x.AudioSourceConfiguration.Token,
+			Name:        x.AudioSourceConfiguration.Name,
+			UseCount:    x.AudioSourceConfiguration.UseCount,
+			SourceToken: x.AudioSourceConfiguration.SourceToken,
+		}
+	}
+
+	if x.AudioEncoderConfiguration != nil {
+		profile.AudioEncoderConfiguration = x.AudioEncoderConfiguration.toAudioEncoderConfiguration()
+	}
+
+	if x.VideoAnalyticsConfiguration != nil {
+		profile.VideoAnalyticsConfiguration = x.VideoAnalyticsConfiguration.toVideoAnalyticsConfiguration()
+	}
+
+	if x.PTZConfiguration != nil {
+		profile.PTZConfiguration = &PTZConfiguration{
+			Token:     x.PTZConfiguration.Token,
+			Name:      x.PTZConfiguration.Name,
+			UseCount:  x.PTZConfiguration.UseCount,
+			NodeToken: x.PTZConfiguration.NodeToken,
+		}
+	}
 
-		if p.PTZConfiguration != nil {
-			profile.PTZConfiguration = &PTZConfiguration{
-				Token:     p.PTZConfiguration.Token,
-				Name:      p.PTZConfiguration.Name,
-				UseCount:  p.PTZConfiguration.UseCount,
-				NodeToken: p.PTZConfiguration.NodeToken,
+	if x.MetadataConfiguration != nil {
+		profile.MetadataConfiguration = &MetadataConfiguration{
+			Token:     x.MetadataConfiguration.Token,
+			Name:      x.MetadataConfiguration.Name,
+			UseCount:  x.MetadataConfiguration.UseCount,
+			Analytics: x.MetadataConfiguration.Analytics,
+		}
+	}
+
+	if ext := x.Extension; ext != nil && (ext.AudioOutputConfiguration != nil || ext.AudioDecoderConfiguration != nil) {
+		profile.Extension = &ProfileExtension{}
+		if ext.AudioOutputConfiguration != nil {
+			profile.Extension.AudioOutputConfiguration = ext.AudioOutputConfiguration.toAudioOutputConfiguration()
+		}
+		if ext.AudioDecoderConfiguration != nil {
+			profile.Extension.AudioDecoderConfiguration = &AudioDecoderConfiguration{
+				Token:    ext.AudioDecoderConfiguration.Token,
+				Name:     ext.AudioDecoderConfiguration.Name,
+				UseCount: ext.AudioDecoderConfiguration.UseCount,
 			}
 		}
+	}
+
+	return profile
+}
 
-		profiles[i] = profile
+// HasPTZ reports whether the profile has a PTZ configuration
+func (p *Profile) HasPTZ() bool {
+	return p.PTZConfiguration != nil
+}
+
+// HasAudio reports whether the profile has an audio source or audio encoder
+// configuration
+func (p *Profile) HasAudio() bool {
+	return p.AudioSourceConfiguration != nil || p.AudioEncoderConfiguration != nil
+}
+
+// HasAnalytics reports whether the profile's metadata stream includes
+// analytics output
+func (p *Profile) HasAnalytics() bool {
+	return p.MetadataConfiguration != nil && p.MetadataConfiguration.Analytics
+}
+
+// HasAudioBackchannel reports whether the profile has an audio output and an
+// audio decoder configuration, so audio can be sent to the device on its stream
+func (p *Profile) HasAudioBackchannel() bool {
+	return p.Extension != nil && p.Extension.AudioOutputConfiguration != nil && p.Extension.AudioDecoderConfiguration != nil
+}
+
+// GetPTZProfiles retrieves the media profiles that have a PTZ configuration,
+// in the order GetProfiles returns them, so sorted by token with
+// WithStableProfileOrder
+func (c *Client) GetPTZProfiles(ctx context.Context) ([]*Profile, error) {
+	profiles, err := c.GetProfiles(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	return profiles, nil
+	var ptzProfiles []*Profile
+	for _, profile := range profiles {
+		if profile.HasPTZ() {
+			ptzProfiles = append(ptzProfiles, profile)
+		}
+	}
+
+	return ptzProfiles, nil
 }
 
-// GetStreamURI retrieves the stream URI for a profile
+// profileURIConcurrency bounds the URI requests GetProfilesWithURIs has in
+// flight, so that cameras with many profiles are not flooded
+const profileURIConcurrency = 4
+
+// GetProfilesWithURIs retrieves the media profiles, then the RTSP stream and
+// snapshot URIs of each, a few at a time, in the order the device reports
+// the profiles. The error is only that of GetProfiles; failures to get a URI,
+// such as from cameras without snapshots, are reported per profile.
+func (c *Client) GetProfilesWithURIs(ctx context.Context) ([]ProfileWithURIs, error) {
+	profiles, err := c.GetProfiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ProfileWithURIs, len(profiles))
+	sem := make(chan struct{}, profileURIConcurrency)
+	var wg sync.WaitGroup
+	fetch := func(fn func()) {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn()
+		}()
+	}
+
+	for i, profile := range profiles {
+		result := &results[i]
+		result.Profile = profile
+		fetch(func() {
+			result.StreamURI, result.StreamErr = c.GetStreamURI(ctx, result.Profile.Token)
+		})
+		fetch(func() {
+			result.SnapshotURI, result.SnapshotErr = c.GetSnapshotURI(ctx, result.Profile.Token)
+		})
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+// GetStreamURI retrieves the stream URI for a profile. The same URI carries
+// the audio backchannel of a profile whose HasAudioBackchannel reports true:
+// the RTSP client must send "Require: www.onvif.org/ver20/backchannel" in its
+// DESCRIBE request to get the sendonly (e.g. G.711) track in the SDP.
+//
+//	profiles, _ := client.GetProfiles(ctx)
+//	for _, profile := range profiles {
+//		if profile.HasAudioBackchannel() {
+//			uri, err := client.GetStreamURI(ctx, profile.Token)
+//			// DESCRIBE uri with the Require header
+//		}
+//	}
 func (c *Client) GetStreamURI(ctx context.Context, profileToken string) (*MediaURI, error) {
+	return c.getStreamURI(ctx, profileToken, "RTP-Unicast", "RTSP")
+}
+
+// Stream transports accepted by GetBestStreamURI
+const (
+	StreamTransportUDP       = "UDP"       // RTP unicast over UDP, which every device supports
+	StreamTransportRTSP      = "RTSP"      // RTP interleaved in the RTSP connection (RTP_RTSP_TCP)
+	StreamTransportTCP       = "TCP"       // RTP over TCP (RTP_TCP)
+	StreamTransportMulticast = "Multicast" // RTP multicast over UDP (RTPMulticast)
+)
+
+// GetBestStreamURI retrieves the stream URI for a profile using the first
+// transport in prefer that the device's StreamingCapabilities say it supports.
+// The capabilities found by Initialize are used, or fetched and kept if
+// Initialize has not run. If none of the preferred transports is supported,
+// or the capabilities cannot be fetched, it falls back to the RTSP setup
+// GetStreamURI uses; UDP is still used when preferred, as every device
+// supports it.
+func (c *Client) GetBestStreamURI(ctx context.Context, profileToken string, prefer []string) (*MediaURI, error) {
+	c.mu.RLock()
+	streaming := c.streamingCapabilities
+	c.mu.RUnlock()
+	if streaming == nil {
+		// Nothing is kept when GetCapabilities fails, so the next call asks again
+		streaming = &StreamingCapabilities{}
+		if capabilities, err := c.GetCapabilities(ctx); err == nil {
+			if capabilities.Media != nil && capabilities.Media.StreamingCapabilities != nil {
+				streaming = capabilities.Media.StreamingCapabilities
+			}
+
+			c.mu.Lock()
+			c.streamingCapabilities = streaming
+			c.mu.Unlock()
+		}
+	}
+
+	for _, transport := range prefer {
+		switch transport {
+		case StreamTransportUDP:
+			return c.getStreamURI(ctx, profileToken, "RTP-Unicast", "UDP")
+		case StreamTransportRTSP:
+			if streaming.RTP_RTSP_TCP {
+				return c.getStreamURI(ctx, profileToken, "RTP-Unicast", "RTSP")
+			}
+		case StreamTransportTCP:
+			if streaming.RTP_TCP {
+				return c.getStreamURI(ctx, profileToken, "RTP-Unicast", "TCP")
+			}
+		case StreamTransportMulticast:
+			if streaming.RTPMulticast {
+				return c.getStreamURI(ctx, profileToken, "RTP-Multicast", "UDP")
+			}
+		default:
+			return nil, fmt.Errorf("%w: unknown stream transport %q", ErrInvalidParameter, transport)
+		}
+	}
+
+	return c.GetStreamURI(ctx, profileToken)
+}
+
+// getStreamURI retrieves the stream URI for a profile with the given stream
+// type and transport protocol
+func (c *Client) getStreamURI(ctx context.Context, profileToken, stream, protocol string) (*MediaURI, error) {
 	endpoint := c.mediaEndpoint
 	if endpoint == "" {
 		endpoint = c.endpoint
@@ -175,23 +590,18 @@ func (c *Client) GetStreamURI(ctx context.Context, profileToken string) (*MediaU
 		Xmlnst:       "http://www.onvif.org/ver10/schema",
 		ProfileToken: profileToken,
 	}
-	req.StreamSetup.Stream = "RTP-Unicast"
-	req.StreamSetup.Transport.Protocol = "RTSP"
+	req.StreamSetup.Stream = stream
+	req.StreamSetup.Transport.Protocol = protocol
 
 	var resp GetStreamUriResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetStreamUri failed: %w", err)
 	}
 
-	return &MediaURI{
-		URI:                 resp.MediaUri.Uri,
-		InvalidAfterConnect: resp.MediaUri.InvalidAfterConnect,
-		InvalidAfterReboot:  resp.MediaUri.InvalidAfterReboot,
-	}, nil
+	return newMediaURI(resp.MediaUri.Uri, resp.MediaUri.InvalidAfterConnect, resp.MediaUri.InvalidAfterReboot, resp.MediaUri.Timeout), nil
 }
 
 // GetSnapshotURI retrieves the snapshot URI for a profile
@@ -224,18 +634,91 @@ func (c *Client) GetSnapshotURI(ctx context.Context, profileToken string) (*Medi
 
 	var resp GetSnapshotUriResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetSnapshotUri failed: %w", err)
 	}
 
-	return &MediaURI{
-		URI:                 resp.MediaUri.Uri,
-		InvalidAfterConnect: resp.MediaUri.InvalidAfterConnect,
-		InvalidAfterReboot:  resp.MediaUri.InvalidAfterReboot,
-	}, nil
+	return newMediaURI(resp.MediaUri.Uri, resp.MediaUri.InvalidAfterConnect, resp.MediaUri.InvalidAfterReboot, resp.MediaUri.Timeout), nil
+}
+
+// newMediaURI builds a MediaURI from a response. A missing or malformed Timeout
+// leaves Timeout zero rather than failing, since the URI itself is still usable.
+func newMediaURI(uri string, invalidAfterConnect, invalidAfterReboot bool, timeout string) *MediaURI {
+	mediaURI := &MediaURI{
+		URI:                 uri,
+		InvalidAfterConnect: invalidAfterConnect,
+		InvalidAfterReboot:  invalidAfterReboot,
+	}
+
+	if timeout != "" {
+		if d, err := ParseDuration(timeout); err == nil {
+			mediaURI.Timeout = d
+		}
+	}
+
+	return mediaURI
+}
+
+// StreamURIProvider hands out the stream URI of a profile and fetches a new
+// one whenever the device has said the previous one is no longer valid. It is
+// safe for concurrent use.
+type StreamURIProvider struct {
+	client       *Client
+	profileToken string
+
+	mu       sync.Mutex
+	uri      *MediaURI
+	expires  time.Time // Zero if the URI does not time out
+	returned bool      // The URI has been handed out, so a connect may have used it
+}
+
+// StreamURIProvider returns a provider for the stream URI of profileToken.
+// Call Get before every connect attempt; no request is made until then.
+func (c *Client) StreamURIProvider(profileToken string) *StreamURIProvider {
+	return &StreamURIProvider{
+		client:       c,
+		profileToken: profileToken,
+	}
+}
+
+// Get returns the current stream URI. A new one is fetched with GetStreamURI
+// on the first call, after the Timeout the device gave for the previous one,
+// and on every call after the previous one was returned if the device marked
+// it InvalidAfterConnect.
+func (p *StreamURIProvider) Get(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stale := p.uri == nil ||
+		p.uri.InvalidAfterConnect && p.returned ||
+		!p.expires.IsZero() && !time.Now().Before(p.expires)
+
+	if stale {
+		uri, err := p.client.GetStreamURI(ctx, p.profileToken)
+		if err != nil {
+			return "", err
+		}
+		p.uri = uri
+		p.returned = false
+		p.expires = time.Time{}
+		if uri.Timeout > 0 {
+			p.expires = time.Now().Add(uri.Timeout)
+		}
+	}
+
+	p.returned = true
+	return p.uri.URI, nil
+}
+
+// Invalidate discards the current URI so the next Get fetches a new one. Call
+// it after the device rebooted if the URI was marked InvalidAfterReboot, or
+// whenever a connect with the URI fails.
+func (p *StreamURIProvider) Invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.uri = nil
 }
 
 // GetVideoEncoderConfiguration retrieves video encoder configuration
@@ -252,23 +735,8 @@ func (c *Client) GetVideoEncoderConfiguration(ctx context.Context, configuration
 	}
 
 	type GetVideoEncoderConfigurationResponse struct {
-		XMLName       xml.Name `xml:"GetVideoEncoderConfigurationResponse"`
-		Configuration struct {
-			Token      string `xml:"token,attr"`
-			Name       string `xml:"Name"`
-			UseCount   int    `xml:"UseCount"`
-			Encoding   string `xml:"Encoding"`
-			Resolution *struct {
-				Width  int `xml:"Width"`
-				Height int `xml:"Height"`
-			} `xml:"Resolution"`
-			Quality     float64 `xml:"Quality"`
-			RateControl *struct {
-				FrameRateLimit   int `xml:"FrameRateLimit"`
-				EncodingInterval int `xml:"EncodingInterval"`
-				BitrateLimit     int `xml:"BitrateLimit"`
-			} `xml:"RateControl"`
-		} `xml:"Configuration"`
+		XMLName       xml.Name                     `xml:"GetVideoEncoderConfigurationResponse"`
+		Configuration videoEncoderConfigurationXML `xml:"Configuration"`
 	}
 
 	req := GetVideoEncoderConfiguration{
@@ -278,37 +746,320 @@ func (c *Client) GetVideoEncoderConfiguration(ctx context.Context, configuration
 
 	var resp GetVideoEncoderConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetVideoEncoderConfiguration failed: %w", err)
 	}
 
+	return resp.Configuration.toVideoEncoderConfiguration(), nil
+}
+
+// GetVideoEncoderConfigurations retrieves all video encoder configurations on the device
+func (c *Client) GetVideoEncoderConfigurations(ctx context.Context) ([]*VideoEncoderConfiguration, error) {
+	endpoint := c.mediaEndpoint
+	if endpoint == "" {
+		endpoint = c.endpoint
+	}
+
+	type GetVideoEncoderConfigurations struct {
+		XMLName xml.Name `xml:"trt:GetVideoEncoderConfigurations"`
+		Xmlns   string   `xml:"xmlns:trt,attr"`
+	}
+
+	type GetVideoEncoderConfigurationsResponse struct {
+		XMLName        xml.Name                       `xml:"GetVideoEncoderConfigurationsResponse"`
+		Configurations []videoEncoderConfigurationXML `xml:"Configurations"`
+	}
+
+	req := GetVideoEncoderConfigurations{
+		Xmlns: mediaNamespace,
+	}
+
+	var resp GetVideoEncoderConfigurationsResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetVideoEncoderConfigurations failed: %w", err)
+	}
+
+	configs := make([]*VideoEncoderConfiguration, len(resp.Configurations))
+	for i, cfg := range resp.Configurations {
+		configs[i] = cfg.toVideoEncoderConfiguration()
+	}
+
+	return configs, nil
+}
+
+// GetVideoEncoderConfigurationOptions retrieves the resolutions, quality,
+// frame rates and bitrates a video encoder configuration accepts. Either
+// token may be empty: configurationToken narrows the options to one
+// configuration and profileToken to what is compatible with a profile. With
+// WithEncoderValidation, options fetched for a configuration token are kept
+// to check SetVideoEncoderConfiguration against.
+func (c *Client) GetVideoEncoderConfigurationOptions(ctx context.Context, configurationToken, profileToken string) (*VideoEncoderConfigurationOptions, error) {
+	endpoint := c.mediaEndpoint
+	if endpoint == "" {
+		endpoint = c.endpoint
+	}
+
+	type GetVideoEncoderConfigurationOptions struct {
+		XMLName            xml.Name `xml:"trt:GetVideoEncoderConfigurationOptions"`
+		Xmlns              string   `xml:"xmlns:trt,attr"`
+		ConfigurationToken string   `xml:"trt:ConfigurationToken,omitempty"`
+		ProfileToken       string   `xml:"trt:ProfileToken,omitempty"`
+	}
+
+	type GetVideoEncoderConfigurationOptionsResponse struct {
+		XMLName xml.Name `xml:"GetVideoEncoderConfigurationOptionsResponse"`
+		Options struct {
+			QualityRange *floatRangeXML          `xml:"QualityRange"`
+			JPEG         *videoEncoderOptionsXML `xml:"JPEG"`
+			MPEG4        *videoEncoderOptionsXML `xml:"MPEG4"`
+			H264         *videoEncoderOptionsXML `xml:"H264"`
+			Extension    struct {
+				JPEG  *videoEncoderOptionsXML `xml:"JPEG"`
+				MPEG4 *videoEncoderOptionsXML `xml:"MPEG4"`
+				H264  *videoEncoderOptionsXML `xml:"H264"`
+			} `xml:"Extension"`
+		} `xml:"Options"`
+	}
+
+	req := GetVideoEncoderConfigurationOptions{
+		Xmlns:              mediaNamespace,
+		ConfigurationToken: configurationToken,
+		ProfileToken:       profileToken,
+	}
+
+	var resp GetVideoEncoderConfigurationOptionsResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetVideoEncoderConfigurationOptions failed: %w", err)
+	}
+
+	opts := resp.Options
+	options := &VideoEncoderConfigurationOptions{
+		JPEG:  opts.JPEG.toVideoEncoderOptions(opts.Extension.JPEG),
+		MPEG4: opts.MPEG4.toVideoEncoderOptions(opts.Extension.MPEG4),
+		H264:  opts.H264.toVideoEncoderOptions(opts.Extension.H264),
+	}
+	if opts.QualityRange != nil {
+		options.QualityRange = opts.QualityRange.toFloatRange()
+	}
+
+	if c.validateEnc && configurationToken != "" {
+		c.mu.Lock()
+		if c.encoderOptions == nil {
+			c.encoderOptions = make(map[string]*VideoEncoderConfigurationOptions)
+		}
+		c.encoderOptions[configurationToken] = options
+		c.mu.Unlock()
+	}
+
+	return options, nil
+}
+
+// videoEncoderOptionsXML is the wire form of the per-encoding options, both
+// in the options and in their extension, which adds the bitrate range
+type videoEncoderOptionsXML struct {
+	ResolutionsAvailable []struct {
+		Width  int `xml:"Width"`
+		Height int `xml:"Height"`
+	} `xml:"ResolutionsAvailable"`
+	FrameRateRange        *intRangeXML `xml:"FrameRateRange"`
+	EncodingIntervalRange *intRangeXML `xml:"EncodingIntervalRange"`
+	BitrateRange          *intRangeXML `xml:"BitrateRange"`
+}
+
+// toVideoEncoderOptions converts the wire form into VideoEncoderOptions,
+// taking the bitrate range from ext if present. It returns nil if the
+// encoding is not offered.
+func (x *videoEncoderOptionsXML) toVideoEncoderOptions(ext *videoEncoderOptionsXML) *VideoEncoderOptions {
+	if x == nil {
+		return nil
+	}
+
+	options := &VideoEncoderOptions{
+		FrameRateRange:        x.FrameRateRange.toIntRange(),
+		EncodingIntervalRange: x.EncodingIntervalRange.toIntRange(),
+		BitrateRange:          x.BitrateRange.toIntRange(),
+	}
+	for _, res := range x.ResolutionsAvailable {
+		options.ResolutionsAvailable = append(options.ResolutionsAvailable, &VideoResolution{Width: res.Width, Height: res.Height})
+	}
+	if ext != nil && ext.BitrateRange != nil {
+		options.BitrateRange = ext.BitrateRange.toIntRange()
+	}
+
+	return options
+}
+
+// intRangeXML is the wire form of tt:IntRange
+type intRangeXML struct {
+	Min int `xml:"Min"`
+	Max int `xml:"Max"`
+}
+
+// toIntRange converts the wire form into an IntRange, or nil if absent
+func (x *intRangeXML) toIntRange() *IntRange {
+	if x == nil {
+		return nil
+	}
+	return &IntRange{Min: x.Min, Max: x.Max}
+}
+
+// checkVideoEncoderConfiguration checks config against the options fetched
+// for it, naming the first field outside them
+func checkVideoEncoderConfiguration(config *VideoEncoderConfiguration, options *VideoEncoderConfigurationOptions) error {
+	if r := options.QualityRange; r != nil && config.Quality > 0 && (config.Quality < r.Min || config.Quality > r.Max) {
+		return fmt.Errorf("%w: Quality %v outside [%v, %v]", ErrInvalidEncoderConfig, config.Quality, r.Min, r.Max)
+	}
+
+	var encoding *VideoEncoderOptions
+	switch config.Encoding {
+	case "JPEG":
+		encoding = options.JPEG
+	case "MPEG4":
+		encoding = options.MPEG4
+	case "H264":
+		encoding = options.H264
+	}
+	if encoding == nil {
+		return nil
+	}
+
+	if res := config.Resolution; res != nil && len(encoding.ResolutionsAvailable) > 0 {
+		available := false
+		for _, r := range encoding.ResolutionsAvailable {
+			if r.Width == res.Width && r.Height == res.Height {
+				available = true
+				break
+			}
+		}
+		if !available {
+			return fmt.Errorf("%w: Resolution %dx%d not available for %s", ErrInvalidEncoderConfig, res.Width, res.Height, config.Encoding)
+		}
+	}
+
+	if rc := config.RateControl; rc != nil {
+		if r := encoding.FrameRateRange; r != nil && (rc.FrameRateLimit < r.Min || rc.FrameRateLimit > r.Max) {
+			return fmt.Errorf("%w: RateControl.FrameRateLimit %d outside [%d, %d]", ErrInvalidEncoderConfig, rc.FrameRateLimit, r.Min, r.Max)
+		}
+		if r := encoding.BitrateRange; r != nil && (rc.BitrateLimit < r.Min || rc.BitrateLimit > r.Max) {
+			return fmt.Errorf("%w: RateControl.BitrateLimit %d outside [%d, %d]", ErrInvalidEncoderConfig, rc.BitrateLimit, r.Min, r.Max)
+		}
+	}
+
+	return nil
+}
+
+// videoEncoderConfigurationXML is the wire form of tt:VideoEncoderConfiguration
+// shared by the single and list responses
+type videoEncoderConfigurationXML struct {
+	Token      string `xml:"token,attr"`
+	Name       string `xml:"Name"`
+	UseCount   int    `xml:"UseCount"`
+	Encoding   string `xml:"Encoding"`
+	Resolution *struct {
+		Width  int `xml:"Width"`
+		Height int `xml:"Height"`
+	} `xml:"Resolution"`
+	Quality     float64 `xml:"Quality"`
+	RateControl *struct {
+		FrameRateLimit   int `xml:"FrameRateLimit"`
+		EncodingInterval int `xml:"EncodingInterval"`
+		BitrateLimit     int `xml:"BitrateLimit"`
+	} `xml:"RateControl"`
+	H264 *struct {
+		GovLength   int    `xml:"GovLength"`
+		H264Profile string `xml:"H264Profile"`
+	} `xml:"H264"`
+	H265 *struct {
+		GovLength   int    `xml:"GovLength"`
+		H265Profile string `xml:"H265Profile"`
+	} `xml:"H265"`
+	Multicast *multicastConfigurationXML `xml:"Multicast"`
+}
+
+// toVideoEncoderConfiguration converts the wire form into a VideoEncoderConfiguration
+func (x videoEncoderConfigurationXML) toVideoEncoderConfiguration() *VideoEncoderConfiguration {
 	config := &VideoEncoderConfiguration{
-		Token:    resp.Configuration.Token,
-		Name:     resp.Configuration.Name,
-		UseCount: resp.Configuration.UseCount,
-		Encoding: resp.Configuration.Encoding,
-		Quality:  resp.Configuration.Quality,
+		Token:    x.Token,
+		Name:     x.Name,
+		UseCount: x.UseCount,
+		Encoding: x.Encoding,
+		Quality:  x.Quality,
 	}
 
-	if resp.Configuration.Resolution != nil {
+	if x.Resolution != nil {
 		config.Resolution = &VideoResolution{
-			Width:  resp.Configuration.Resolution.Width,
-			Height: resp.Configuration.Resolution.Height,
+			Width:  x.Resolution.Width,
+			Height: x.Resolution.Height,
 		}
 	}
 
-	if resp.Configuration.RateControl != nil {
+	if x.RateControl != nil {
 		config.RateControl = &VideoRateControl{
-			FrameRateLimit:   resp.Configuration.RateControl.FrameRateLimit,
-			EncodingInterval: resp.Configuration.RateControl.EncodingInterval,
-			BitrateLimit:     resp.Configuration.RateControl.BitrateLimit,
+			FrameRateLimit:   x.RateControl.FrameRateLimit,
+			EncodingInterval: x.RateControl.EncodingInterval,
+			BitrateLimit:     x.RateControl.BitrateLimit,
 		}
 	}
 
-	return config, nil
+	if x.H264 != nil {
+		config.H264 = &H264Configuration{
+			GovLength:   x.H264.GovLength,
+			H264Profile: x.H264.H264Profile,
+		}
+	}
+
+	if x.H265 != nil {
+		config.H265 = &H265Configuration{
+			GovLength:   x.H265.GovLength,
+			H265Profile: x.H265.H265Profile,
+		}
+	}
+
+	if x.Multicast != nil {
+		config.Multicast = x.Multicast.toMulticastConfiguration()
+	}
+
+	return config
+}
+
+// multicastConfigurationXML is the wire form of tt:MulticastConfiguration in responses
+type multicastConfigurationXML struct {
+	Address struct {
+		Type        string `xml:"Type"`
+		IPv4Address string `xml:"IPv4Address"`
+		IPv6Address string `xml:"IPv6Address"`
+	} `xml:"Address"`
+	Port      int  `xml:"Port"`
+	TTL       int  `xml:"TTL"`
+	AutoStart bool `xml:"AutoStart"`
+}
+
+// toMulticastConfiguration converts the wire form into a MulticastConfiguration
+func (x multicastConfigurationXML) toMulticastConfiguration() *MulticastConfiguration {
+	address := &IPAddress{
+		Type:        x.Address.Type,
+		IPv4Address: x.Address.IPv4Address,
+		IPv6Address: x.Address.IPv6Address,
+		Address:     x.Address.IPv4Address,
+	}
+	if address.Type == "IPv6" {
+		address.Address = address.IPv6Address
+	}
+
+	return &MulticastConfiguration{
+		Address:   address,
+		Port:      x.Port,
+		TTL:       x.TTL,
+		AutoStart: x.AutoStart,
+	}
 }
 
 // GetVideoSources retrieves all video sources
@@ -341,8 +1092,7 @@ func (c *Client) GetVideoSources(ctx context.Context) ([]*VideoSource, error) {
 
 	var resp GetVideoSourcesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetVideoSources failed: %w", err)
@@ -389,8 +1139,7 @@ func (c *Client) GetAudioSources(ctx context.Context) ([]*AudioSource, error) {
 
 	var resp GetAudioSourcesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetAudioSources failed: %w", err)
@@ -432,8 +1181,7 @@ func (c *Client) GetAudioOutputs(ctx context.Context) ([]*AudioOutput, error) {
 
 	var resp GetAudioOutputsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetAudioOutputs failed: %w", err)
@@ -449,6 +1197,73 @@ func (c *Client) GetAudioOutputs(ctx context.Context) ([]*AudioOutput, error) {
 	return outputs, nil
 }
 
+// GetAudioOutputConfigurations retrieves all audio output configurations
+func (c *Client) GetAudioOutputConfigurations(ctx context.Context) ([]*AudioOutputConfiguration, error) {
+	endpoint := c.mediaEndpoint
+	if endpoint == "" {
+		endpoint = c.endpoint
+	}
+
+	type GetAudioOutputConfigurations struct {
+		XMLName xml.Name `xml:"trt:GetAudioOutputConfigurations"`
+		Xmlns   string   `xml:"xmlns:trt,attr"`
+	}
+
+	type GetAudioOutputConfigurationsResponse struct {
+		XMLName        xml.Name                      `xml:"GetAudioOutputConfigurationsResponse"`
+		Configurations []audioOutputConfigurationXML `xml:"Configurations"`
+	}
+
+	req := GetAudioOutputConfigurations{
+		Xmlns: mediaNamespace,
+	}
+
+	var resp GetAudioOutputConfigurationsResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetAudioOutputConfigurations failed: %w", err)
+	}
+
+	configs := make([]*AudioOutputConfiguration, len(resp.Configurations))
+	for i := range resp.Configurations {
+		configs[i] = resp.Configurations[i].toAudioOutputConfiguration()
+	}
+
+	return configs, nil
+}
+
+// SetSynchronizationPoint asks the device to insert a key frame in the stream
+// of a profile and to re-send the current state of its metadata (PTZ status,
+// imaging and event properties). ONVIF defines this operation on the media
+// service; the imaging service has no equivalent.
+func (c *Client) SetSynchronizationPoint(ctx context.Context, profileToken string) error {
+	endpoint := c.mediaEndpoint
+	if endpoint == "" {
+		endpoint = c.endpoint
+	}
+
+	type SetSynchronizationPoint struct {
+		XMLName      xml.Name `xml:"trt:SetSynchronizationPoint"`
+		Xmlns        string   `xml:"xmlns:trt,attr"`
+		ProfileToken string   `xml:"trt:ProfileToken"`
+	}
+
+	req := SetSynchronizationPoint{
+		Xmlns:        mediaNamespace,
+		ProfileToken: profileToken,
+	}
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("SetSynchronizationPoint failed: %w", err)
+	}
+
+	return nil
+}
+
 // CreateProfile creates a new media profile
 func (c *Client) CreateProfile(ctx context.Context, name, token string) (*Profile, error) {
 	endpoint := c.mediaEndpoint
@@ -481,8 +1296,7 @@ func (c *Client) CreateProfile(ctx context.Context, name, token string) (*Profil
 
 	var resp CreateProfileResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("CreateProfile failed: %w", err)
@@ -512,8 +1326,7 @@ func (c *Client) DeleteProfile(ctx context.Context, profileToken string) error {
 		ProfileToken: profileToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("DeleteProfile failed: %w", err)
@@ -522,13 +1335,31 @@ func (c *Client) DeleteProfile(ctx context.Context, profileToken string) error {
 	return nil
 }
 
-// SetVideoEncoderConfiguration sets video encoder configuration
+// SetVideoEncoderConfiguration sets video encoder configuration. The media 1
+// schema has no H.265, so a configuration with H265 encoding or settings is
+// rejected with ErrInvalidParameter; H.265 is configured through the media 2
+// service.
 func (c *Client) SetVideoEncoderConfiguration(ctx context.Context, config *VideoEncoderConfiguration, forcePersistence bool) error {
+	if config.Encoding == "H265" || config.H265 != nil {
+		return fmt.Errorf("%w: H265 is not part of the media 1 service, use media 2", ErrInvalidParameter)
+	}
+
 	endpoint := c.mediaEndpoint
 	if endpoint == "" {
 		endpoint = c.endpoint
 	}
 
+	if c.validateEnc {
+		c.mu.RLock()
+		options := c.encoderOptions[config.Token]
+		c.mu.RUnlock()
+		if options != nil {
+			if err := checkVideoEncoderConfiguration(config, options); err != nil {
+				return err
+			}
+		}
+	}
+
 	type SetVideoEncoderConfiguration struct {
 		XMLName       xml.Name `xml:"trt:SetVideoEncoderConfiguration"`
 		Xmlns         string   `xml:"xmlns:trt,attr"`
@@ -548,6 +1379,11 @@ func (c *Client) SetVideoEncoderConfiguration(ctx context.Context, config *Video
 				EncodingInterval int `xml:"tt:EncodingInterval"`
 				BitrateLimit     int `xml:"tt:BitrateLimit"`
 			} `xml:"tt:RateControl,omitempty"`
+			H264 *struct {
+				GovLength   int    `xml:"tt:GovLength"`
+				H264Profile string `xml:"tt:H264Profile"`
+			} `xml:"tt:H264,omitempty"`
+			Multicast *multicastConfigurationRequest `xml:"tt:Multicast,omitempty"`
 		} `xml:"trt:Configuration"`
 		ForcePersistence bool `xml:"trt:ForcePersistence"`
 	}
@@ -589,8 +1425,21 @@ func (c *Client) SetVideoEncoderConfiguration(ctx context.Context, config *Video
 		}
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	if config.H264 != nil {
+		req.Configuration.H264 = &struct {
+			GovLength   int    `xml:"tt:GovLength"`
+			H264Profile string `xml:"tt:H264Profile"`
+		}{
+			GovLength:   config.H264.GovLength,
+			H264Profile: config.H264.H264Profile,
+		}
+	}
+
+	if config.Multicast != nil {
+		req.Configuration.Multicast = newMulticastConfigurationRequest(config.Multicast)
+	}
+
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetVideoEncoderConfiguration failed: %w", err)
@@ -598,3 +1447,414 @@ func (c *Client) SetVideoEncoderConfiguration(ctx context.Context, config *Video
 
 	return nil
 }
+
+// GetAudioSourceConfigurations retrieves all audio source configurations on the device
+func (c *Client) GetAudioSourceConfigurations(ctx context.Context) ([]*AudioSourceConfiguration, error) {
+	endpoint := c.mediaEndpoint
+	if endpoint == "" {
+		endpoint = c.endpoint
+	}
+
+	type GetAudioSourceConfigurations struct {
+		XMLName xml.Name `xml:"trt:GetAudioSourceConfigurations"`
+		Xmlns   string   `xml:"xmlns:trt,attr"`
+	}
+
+	type GetAudioSourceConfigurationsResponse struct {
+		XMLName        xml.Name `xml:"GetAudioSourceConfigurationsResponse"`
+		Configurations []struct {
+			Token       string `xml:"token,attr"`
+			Name        string `xml:"Name"`
+			UseCount    int    `xml:"UseCount"`
+			SourceToken string `xml:"SourceToken"`
+		} `xml:"Configurations"`
+	}
+
+	req := GetAudioSourceConfigurations{
+		Xmlns: mediaNamespace,
+	}
+
+	var resp GetAudioSourceConfigurationsResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetAudioSourceConfigurations failed: %w", err)
+	}
+
+	configs := make([]*AudioSourceConfiguration, len(resp.Configurations))
+	for i, cfg := range resp.Configurations {
+		configs[i] = &AudioSourceConfiguration{
+			Token:       cfg.Token,
+			Name:        cfg.Name,
+			UseCount:    cfg.UseCount,
+			SourceToken: cfg.SourceToken,
+		}
+	}
+
+	return configs, nil
+}
+
+// AddAudioSourceConfiguration adds an audio source configuration to a profile.
+// Together with an audio encoder configuration this enables audio in the
+// profile's stream.
+func (c *Client) AddAudioSourceConfiguration(ctx context.Context, profileToken, configurationToken string) error {
+	endpoint := c.mediaEndpoint
+	if endpoint == "" {
+		endpoint = c.endpoint
+	}
+
+	type AddAudioSourceConfiguration struct {
+		XMLName            xml.Name `xml:"trt:AddAudioSourceConfiguration"`
+		Xmlns              string   `xml:"xmlns:trt,attr"`
+		ProfileToken       string   `xml:"trt:ProfileToken"`
+		ConfigurationToken string   `xml:"trt:ConfigurationToken"`
+	}
+
+	req := AddAudioSourceConfiguration{
+		Xmlns:              mediaNamespace,
+		ProfileToken:       profileToken,
+		ConfigurationToken: configurationToken,
+	}
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("AddAudioSourceConfiguration failed: %w", err)
+	}
+
+	return nil
+}
+
+// AddAudioEncoderConfiguration adds an audio encoder configuration to a
+// profile. The profile should already contain an audio source configuration.
+func (c *Client) AddAudioEncoderConfiguration(ctx context.Context, profileToken, configurationToken string) error {
+	endpoint := c.mediaEndpoint
+	if endpoint == "" {
+		endpoint = c.endpoint
+	}
+
+	type AddAudioEncoderConfiguration struct {
+		XMLName            xml.Name `xml:"trt:AddAudioEncoderConfiguration"`
+		Xmlns              string   `xml:"xmlns:trt,attr"`
+		ProfileToken       string   `xml:"trt:ProfileToken"`
+		ConfigurationToken string   `xml:"trt:ConfigurationToken"`
+	}
+
+	req := AddAudioEncoderConfiguration{
+		Xmlns:              mediaNamespace,
+		ProfileToken:       profileToken,
+		ConfigurationToken: configurationToken,
+	}
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("AddAudioEncoderConfiguration failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetAudioEncoderConfigurations retrieves all audio encoder configurations on the device
+func (c *Client) GetAudioEncoderConfigurations(ctx context.Context) ([]*AudioEncoderConfiguration, error) {
+	endpoint := c.mediaEndpoint
+	if endpoint == "" {
+		endpoint = c.endpoint
+	}
+
+	type GetAudioEncoderConfigurations struct {
+		XMLName xml.Name `xml:"trt:GetAudioEncoderConfigurations"`
+		Xmlns   string   `xml:"xmlns:trt,attr"`
+	}
+
+	type GetAudioEncoderConfigurationsResponse struct {
+		XMLName        xml.Name                       `xml:"GetAudioEncoderConfigurationsResponse"`
+		Configurations []audioEncoderConfigurationXML `xml:"Configurations"`
+	}
+
+	req := GetAudioEncoderConfigurations{
+		Xmlns: mediaNamespace,
+	}
+
+	var resp GetAudioEncoderConfigurationsResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetAudioEncoderConfigurations failed: %w", err)
+	}
+
+	configs := make([]*AudioEncoderConfiguration, len(resp.Configurations))
+	for i, cfg := range resp.Configurations {
+		configs[i] = cfg.toAudioEncoderConfiguration()
+	}
+
+	return configs, nil
+}
+
+// GetAudioEncoderConfigurationOptions retrieves the encodings, bitrates and
+// sample rates an audio encoder configuration accepts. Either token may be
+// empty: configurationToken narrows the options to one configuration and
+// profileToken to what is compatible with a profile.
+func (c *Client) GetAudioEncoderConfigurationOptions(ctx context.Context, configurationToken, profileToken string) (*AudioEncoderConfigurationOptions, error) {
+	endpoint := c.mediaEndpoint
+	if endpoint == "" {
+		endpoint = c.endpoint
+	}
+
+	type GetAudioEncoderConfigurationOptions struct {
+		XMLName            xml.Name `xml:"trt:GetAudioEncoderConfigurationOptions"`
+		Xmlns              string   `xml:"xmlns:trt,attr"`
+		ConfigurationToken string   `xml:"trt:ConfigurationToken,omitempty"`
+		ProfileToken       string   `xml:"trt:ProfileToken,omitempty"`
+	}
+
+	type GetAudioEncoderConfigurationOptionsResponse struct {
+		XMLName xml.Name `xml:"GetAudioEncoderConfigurationOptionsResponse"`
+		Options struct {
+			Options []struct {
+				Encoding       string `xml:"Encoding"`
+				BitrateList    []int  `xml:"BitrateList>Items"`
+				SampleRateList []int  `xml:"SampleRateList>Items"`
+			} `xml:"Options"`
+		} `xml:"Options"`
+	}
+
+	req := GetAudioEncoderConfigurationOptions{
+		Xmlns:              mediaNamespace,
+		ConfigurationToken: configurationToken,
+		ProfileToken:       profileToken,
+	}
+
+	var resp GetAudioEncoderConfigurationOptionsResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetAudioEncoderConfigurationOptions failed: %w", err)
+	}
+
+	options := &AudioEncoderConfigurationOptions{
+		Options: make([]*AudioEncoderConfigurationOption, len(resp.Options.Options)),
+	}
+	for i, opt := range resp.Options.Options {
+		options.Options[i] = &AudioEncoderConfigurationOption{
+			Encoding:       opt.Encoding,
+			BitrateList:    opt.BitrateList,
+			SampleRateList: opt.SampleRateList,
+		}
+	}
+
+	return options, nil
+}
+
+// Supports reports whether the device accepts encoding at the given bitrate
+// and sample rate
+func (o *AudioEncoderConfigurationOptions) Supports(encoding string, bitrate, sampleRate int) bool {
+	for _, opt := range o.Options {
+		if opt.Encoding != encoding {
+			continue
+		}
+		if slices.Contains(opt.BitrateList, bitrate) && slices.Contains(opt.SampleRateList, sampleRate) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetAudioEncoderConfiguration sets audio encoder configuration
+func (c *Client) SetAudioEncoderConfiguration(ctx context.Context, config *AudioEncoderConfiguration, forcePersistence bool) error {
+	endpoint := c.mediaEndpoint
+	if endpoint == "" {
+		endpoint = c.endpoint
+	}
+
+	type SetAudioEncoderConfiguration struct {
+		XMLName       xml.Name `xml:"trt:SetAudioEncoderConfiguration"`
+		Xmlns         string   `xml:"xmlns:trt,attr"`
+		Xmlnst        string   `xml:"xmlns:tt,attr"`
+		Configuration struct {
+			Token          string                         `xml:"token,attr"`
+			Name           string                         `xml:"tt:Name"`
+			UseCount       int                            `xml:"tt:UseCount"`
+			Encoding       string                         `xml:"tt:Encoding"`
+			Bitrate        int                            `xml:"tt:Bitrate"`
+			SampleRate     int                            `xml:"tt:SampleRate"`
+			Multicast      *multicastConfigurationRequest `xml:"tt:Multicast"`
+			SessionTimeout string                         `xml:"tt:SessionTimeout"`
+		} `xml:"trt:Configuration"`
+		ForcePersistence bool `xml:"trt:ForcePersistence"`
+	}
+
+	req := SetAudioEncoderConfiguration{
+		Xmlns:            mediaNamespace,
+		Xmlnst:           "http://www.onvif.org/ver10/schema",
+		ForcePersistence: forcePersistence,
+	}
+
+	req.Configuration.Token = config.Token
+	req.Configuration.Name = config.Name
+	req.Configuration.UseCount = config.UseCount
+	req.Configuration.Encoding = config.Encoding
+	req.Configuration.Bitrate = config.Bitrate
+	req.Configuration.SampleRate = config.SampleRate
+	req.Configuration.SessionTimeout = FormatDuration(config.SessionTimeout)
+
+	// Multicast is mandatory in tt:AudioEncoderConfiguration
+	multicast := config.Multicast
+	if multicast == nil {
+		multicast = &MulticastConfiguration{}
+	}
+	req.Configuration.Multicast = newMulticastConfigurationRequest(multicast)
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("SetAudioEncoderConfiguration failed: %w", err)
+	}
+
+	return nil
+}
+
+// audioEncoderConfigurationXML is the wire form of tt:AudioEncoderConfiguration
+type audioEncoderConfigurationXML struct {
+	Token          string                     `xml:"token,attr"`
+	Name           string                     `xml:"Name"`
+	UseCount       int                        `xml:"UseCount"`
+	Encoding       string                     `xml:"Encoding"`
+	Bitrate        int                        `xml:"Bitrate"`
+	SampleRate     int                        `xml:"SampleRate"`
+	Multicast      *multicastConfigurationXML `xml:"Multicast"`
+	SessionTimeout string                     `xml:"SessionTimeout"`
+}
+
+// toAudioEncoderConfiguration converts the wire form into an AudioEncoderConfiguration
+func (x audioEncoderConfigurationXML) toAudioEncoderConfiguration() *AudioEncoderConfiguration {
+	config := &AudioEncoderConfiguration{
+		Token:      x.Token,
+		Name:       x.Name,
+		UseCount:   x.UseCount,
+		Encoding:   x.Encoding,
+		Bitrate:    x.Bitrate,
+		SampleRate: x.SampleRate,
+	}
+
+	if x.Multicast != nil {
+		config.Multicast = x.Multicast.toMulticastConfiguration()
+	}
+
+	if x.SessionTimeout != "" {
+		if d, err := ParseDuration(x.SessionTimeout); err == nil {
+			config.SessionTimeout = d
+		}
+	}
+
+	return config
+}
+
+// GetMediaServiceCapabilities retrieves the capabilities of the media service
+func (c *Client) GetMediaServiceCapabilities(ctx context.Context) (*MediaServiceCapabilities, error) {
+	endpoint := c.mediaEndpoint
+	if endpoint == "" {
+		endpoint = c.endpoint
+	}
+
+	type GetServiceCapabilities struct {
+		XMLName xml.Name `xml:"trt:GetServiceCapabilities"`
+		Xmlns   string   `xml:"xmlns:trt,attr"`
+	}
+
+	type GetServiceCapabilitiesResponse struct {
+		XMLName      xml.Name `xml:"GetServiceCapabilitiesResponse"`
+		Capabilities struct {
+			SnapshotUri         bool `xml:"SnapshotUri,attr"`
+			Rotation            bool `xml:"Rotation,attr"`
+			VideoSourceMode     bool `xml:"VideoSourceMode,attr"`
+			OSD                 bool `xml:"OSD,attr"`
+			TemporaryOSDText    bool `xml:"TemporaryOSDText,attr"`
+			EXICompression      bool `xml:"EXICompression,attr"`
+			ProfileCapabilities struct {
+				MaximumNumberOfProfiles int `xml:"MaximumNumberOfProfiles,attr"`
+			} `xml:"ProfileCapabilities"`
+			StreamingCapabilities struct {
+				RTPMulticast        bool `xml:"RTPMulticast,attr"`
+				RTP_TCP             bool `xml:"RTP_TCP,attr"`
+				RTP_RTSP_TCP        bool `xml:"RTP_RTSP_TCP,attr"`
+				NonAggregateControl bool `xml:"NonAggregateControl,attr"`
+				NoRTSPStreaming     bool `xml:"NoRTSPStreaming,attr"`
+			} `xml:"StreamingCapabilities"`
+		} `xml:"Capabilities"`
+	}
+
+	req := GetServiceCapabilities{
+		Xmlns: mediaNamespace,
+	}
+
+	var resp GetServiceCapabilitiesResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetServiceCapabilities failed: %w", err)
+	}
+
+	caps := resp.Capabilities
+	return &MediaServiceCapabilities{
+		SnapshotURI:             caps.SnapshotUri,
+		Rotation:                caps.Rotation,
+		VideoSourceMode:         caps.VideoSourceMode,
+		OSD:                     caps.OSD,
+		TemporaryOSDText:        caps.TemporaryOSDText,
+		EXICompression:          caps.EXICompression,
+		MaximumNumberOfProfiles: caps.ProfileCapabilities.MaximumNumberOfProfiles,
+		RTPMulticast:            caps.StreamingCapabilities.RTPMulticast,
+		RTP_TCP:                 caps.StreamingCapabilities.RTP_TCP,
+		RTP_RTSP_TCP:            caps.StreamingCapabilities.RTP_RTSP_TCP,
+		NonAggregateControl:     caps.StreamingCapabilities.NonAggregateControl,
+		NoRTSPStreaming:         caps.StreamingCapabilities.NoRTSPStreaming,
+	}, nil
+}
+
+// multicastConfigurationRequest is the wire form of tt:MulticastConfiguration in requests
+type multicastConfigurationRequest struct {
+	Address struct {
+		Type        string `xml:"tt:Type"`
+		IPv4Address string `xml:"tt:IPv4Address,omitempty"`
+		IPv6Address string `xml:"tt:IPv6Address,omitempty"`
+	} `xml:"tt:Address"`
+	Port      int  `xml:"tt:Port"`
+	TTL       int  `xml:"tt:TTL"`
+	AutoStart bool `xml:"tt:AutoStart"`
+}
+
+// newMulticastConfigurationRequest builds the request form of a multicast configuration.
+// When only Address is set, it is sent as IPv4 unless Type is "IPv6".
+func newMulticastConfigurationRequest(m *MulticastConfiguration) *multicastConfigurationRequest {
+	req := &multicastConfigurationRequest{
+		Port:      m.Port,
+		TTL:       m.TTL,
+		AutoStart: m.AutoStart,
+	}
+
+	if m.Address == nil {
+		req.Address.Type = "IPv4"
+		return req
+	}
+
+	req.Address.Type = m.Address.Type
+	if req.Address.Type == "" {
+		req.Address.Type = "IPv4"
+	}
+	req.Address.IPv4Address = m.Address.IPv4Address
+	req.Address.IPv6Address = m.Address.IPv6Address
+	if m.Address.Address != "" {
+		if req.Address.Type == "IPv6" && req.Address.IPv6Address == "" {
+			req.Address.IPv6Address = m.Address.Address
+		} else if req.Address.Type == "IPv4" && req.Address.IPv4Address == "" {
+			req.Address.IPv4Address = m.Address.Address
+		}
+	}
+
+	return req
+}
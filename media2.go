@@ -0,0 +1,303 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+)
+
+// Media 2 service namespace
+const media2Namespace = "http://www.onvif.org/ver20/media/wsdl"
+
+// Mask types
+const (
+	MaskTypeColor     = "Color"
+	MaskTypePixelated = "Pixelated"
+	MaskTypeBlurred   = "Blurred"
+)
+
+// maskXML is the wire form of tr2:Mask in responses
+type maskXML struct {
+	Token              string `xml:"token,attr"`
+	ConfigurationToken string `xml:"ConfigurationToken"`
+	Polygon            struct {
+		Point []struct {
+			X float64 `xml:"x,attr"`
+			Y float64 `xml:"y,attr"`
+		} `xml:"Point"`
+	} `xml:"Polygon"`
+	Type  string `xml:"Type"`
+	Color *struct {
+		X          float64 `xml:"X,attr"`
+		Y          float64 `xml:"Y,attr"`
+		Z          float64 `xml:"Z,attr"`
+		Colorspace string  `xml:"Colorspace,attr"`
+	} `xml:"Color"`
+	Enabled bool `xml:"Enabled"`
+}
+
+// toMask converts the wire form into a Mask
+func (m maskXML) toMask() *Mask {
+	mask := &Mask{
+		Token:              m.Token,
+		ConfigurationToken: m.ConfigurationToken,
+		Type:               m.Type,
+		Enabled:            m.Enabled,
+	}
+
+	for _, point := range m.Polygon.Point {
+		mask.Polygon = append(mask.Polygon, Point{X: point.X, Y: point.Y})
+	}
+
+	if m.Color != nil {
+		mask.Color = &Color{
+			X:          m.Color.X,
+			Y:          m.Color.Y,
+			Z:          m.Color.Z,
+			Colorspace: m.Color.Colorspace,
+		}
+	}
+
+	return mask
+}
+
+// maskRequest is the wire form of tr2:Mask in requests
+type maskRequest struct {
+	Token              string `xml:"token,attr,omitempty"`
+	ConfigurationToken string `xml:"tr2:ConfigurationToken"`
+	Polygon            struct {
+		Point []pointRequest `xml:"tt:Point"`
+	} `xml:"tr2:Polygon"`
+	Type    string        `xml:"tr2:Type"`
+	Color   *colorRequest `xml:"tr2:Color,omitempty"`
+	Enabled bool          `xml:"tr2:Enabled"`
+}
+
+type pointRequest struct {
+	X float64 `xml:"x,attr"`
+	Y float64 `xml:"y,attr"`
+}
+
+type colorRequest struct {
+	X          float64 `xml:"X,attr"`
+	Y          float64 `xml:"Y,attr"`
+	Z          float64 `xml:"Z,attr"`
+	Colorspace string  `xml:"Colorspace,attr,omitempty"`
+}
+
+// validateMask checks that a mask has a polygon of at least 3 points and a
+// known type
+func validateMask(mask *Mask) error {
+	if len(mask.Polygon) < 3 {
+		return fmt.Errorf("%w: mask polygon needs at least 3 points, got %d", ErrInvalidParameter, len(mask.Polygon))
+	}
+	switch mask.Type {
+	case MaskTypeColor, MaskTypePixelated, MaskTypeBlurred:
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown mask type %q", ErrInvalidParameter, mask.Type)
+	}
+}
+
+// newMaskRequest converts a Mask into its request wire form
+func newMaskRequest(mask *Mask) *maskRequest {
+	req := &maskRequest{
+		Token:              mask.Token,
+		ConfigurationToken: mask.ConfigurationToken,
+		Type:               mask.Type,
+		Enabled:            mask.Enabled,
+	}
+
+	for _, point := range mask.Polygon {
+		req.Polygon.Point = append(req.Polygon.Point, pointRequest{X: point.X, Y: point.Y})
+	}
+
+	if mask.Color != nil {
+		req.Color = &colorRequest{
+			X:          mask.Color.X,
+			Y:          mask.Color.Y,
+			Z:          mask.Color.Z,
+			Colorspace: mask.Color.Colorspace,
+		}
+	}
+
+	return req
+}
+
+// media2ServiceEndpoint returns the media 2 service address. GetCapabilities
+// does not report it, so it is looked up with GetServices on first use.
+func (c *Client) media2ServiceEndpoint(ctx context.Context) (string, error) {
+	c.mu.RLock()
+	endpoint := c.media2Endpoint
+	c.mu.RUnlock()
+	if endpoint != "" {
+		return endpoint, nil
+	}
+
+	xaddrs, err := c.getServiceXAddrs(ctx)
+	if err != nil {
+		return "", err
+	}
+	xaddr := xaddrs[media2Namespace]
+	if xaddr == "" {
+		return "", ErrServiceNotSupported
+	}
+	endpoint = c.serviceXAddr(xaddr)
+
+	c.mu.Lock()
+	c.media2Endpoint = endpoint
+	c.mu.Unlock()
+
+	return endpoint, nil
+}
+
+// GetMasks retrieves the privacy masks of a video source configuration. An
+// empty configuration token returns the masks of every configuration.
+// Masks are only available through the media 2 service.
+func (c *Client) GetMasks(ctx context.Context, configurationToken string) ([]*Mask, error) {
+	endpoint, err := c.media2ServiceEndpoint(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("GetMasks failed: %w", err)
+	}
+
+	type GetMasks struct {
+		XMLName            xml.Name `xml:"tr2:GetMasks"`
+		Xmlns              string   `xml:"xmlns:tr2,attr"`
+		ConfigurationToken string   `xml:"tr2:ConfigurationToken,omitempty"`
+	}
+
+	type GetMasksResponse struct {
+		XMLName xml.Name  `xml:"GetMasksResponse"`
+		Masks   []maskXML `xml:"Masks"`
+	}
+
+	req := GetMasks{
+		Xmlns:              media2Namespace,
+		ConfigurationToken: configurationToken,
+	}
+
+	var resp GetMasksResponse
+
+	soapClient := c.soapClient()
+
+	if err = soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetMasks failed: %w", err)
+	}
+
+	masks := make([]*Mask, len(resp.Masks))
+	for i, m := range resp.Masks {
+		masks[i] = m.toMask()
+	}
+
+	return masks, nil
+}
+
+// CreateMask creates a privacy mask and returns the token the device assigned
+// to it. The mask's own token is ignored.
+func (c *Client) CreateMask(ctx context.Context, mask Mask) (string, error) {
+	if err := validateMask(&mask); err != nil {
+		return "", err
+	}
+
+	endpoint, err := c.media2ServiceEndpoint(ctx)
+	if err != nil {
+		return "", fmt.Errorf("CreateMask failed: %w", err)
+	}
+
+	type CreateMask struct {
+		XMLName xml.Name     `xml:"tr2:CreateMask"`
+		Xmlns   string       `xml:"xmlns:tr2,attr"`
+		Xmlnst  string       `xml:"xmlns:tt,attr"`
+		Mask    *maskRequest `xml:"tr2:Mask"`
+	}
+
+	type CreateMaskResponse struct {
+		XMLName xml.Name `xml:"CreateMaskResponse"`
+		Token   string   `xml:"Token"`
+	}
+
+	mask.Token = ""
+	req := CreateMask{
+		Xmlns:  media2Namespace,
+		Xmlnst: "http://www.onvif.org/ver10/schema",
+		Mask:   newMaskRequest(&mask),
+	}
+
+	var resp CreateMaskResponse
+
+	soapClient := c.soapClient()
+
+	if err = soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return "", fmt.Errorf("CreateMask failed: %w", err)
+	}
+
+	return resp.Token, nil
+}
+
+// SetMask updates the privacy mask identified by the mask's token. The mask
+// is replaced as a whole, so it needs a polygon and a type as for CreateMask.
+func (c *Client) SetMask(ctx context.Context, mask Mask) error {
+	if mask.Token == "" {
+		return fmt.Errorf("%w: mask token is required", ErrInvalidParameter)
+	}
+	if err := validateMask(&mask); err != nil {
+		return err
+	}
+
+	endpoint, err := c.media2ServiceEndpoint(ctx)
+	if err != nil {
+		return fmt.Errorf("SetMask failed: %w", err)
+	}
+
+	type SetMask struct {
+		XMLName xml.Name     `xml:"tr2:SetMask"`
+		Xmlns   string       `xml:"xmlns:tr2,attr"`
+		Xmlnst  string       `xml:"xmlns:tt,attr"`
+		Mask    *maskRequest `xml:"tr2:Mask"`
+	}
+
+	req := SetMask{
+		Xmlns:  media2Namespace,
+		Xmlnst: "http://www.onvif.org/ver10/schema",
+		Mask:   newMaskRequest(&mask),
+	}
+
+	soapClient := c.soapClient()
+
+	if err = soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("SetMask failed: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteMask deletes a privacy mask
+func (c *Client) DeleteMask(ctx context.Context, maskToken string) error {
+	if maskToken == "" {
+		return fmt.Errorf("%w: mask token is required", ErrInvalidParameter)
+	}
+
+	endpoint, err := c.media2ServiceEndpoint(ctx)
+	if err != nil {
+		return fmt.Errorf("DeleteMask failed: %w", err)
+	}
+
+	type DeleteMask struct {
+		XMLName xml.Name `xml:"tr2:DeleteMask"`
+		Xmlns   string   `xml:"xmlns:tr2,attr"`
+		Token   string   `xml:"tr2:Token"`
+	}
+
+	req := DeleteMask{
+		Xmlns: media2Namespace,
+		Token: maskToken,
+	}
+
+	soapClient := c.soapClient()
+
+	if err = soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("DeleteMask failed: %w", err)
+	}
+
+	return nil
+}
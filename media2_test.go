@@ -0,0 +1,193 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMasks(t *testing.T) {
+	var getServicesCalls int32
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body := string(data)
+
+		var response string
+		switch {
+		case strings.Contains(body, "GetServices"):
+			atomic.AddInt32(&getServicesCalls, 1)
+			response = `<tds:GetServicesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+				<tds:Service>
+					<tds:Namespace>http://www.onvif.org/ver10/device/wsdl</tds:Namespace>
+					<tds:XAddr>` + server.URL + `/onvif/device_service</tds:XAddr>
+				</tds:Service>
+				<tds:Service>
+					<tds:Namespace>http://www.onvif.org/ver20/media/wsdl</tds:Namespace>
+					<tds:XAddr>` + server.URL + `/onvif/media2_service</tds:XAddr>
+				</tds:Service>
+			</tds:GetServicesResponse>`
+		case r.URL.Path != "/onvif/media2_service":
+			t.Errorf("Mask operation sent to %s", r.URL.Path)
+		case strings.Contains(body, "GetMasks"):
+			if !strings.Contains(body, "<tr2:ConfigurationToken>VideoSourceConfig_1</tr2:ConfigurationToken>") {
+				t.Errorf("Expected configuration token in request, got: %s", body)
+			}
+			response = `<tr2:GetMasksResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tr2:Masks token="Mask_1">
+					<tr2:ConfigurationToken>VideoSourceConfig_1</tr2:ConfigurationToken>
+					<tr2:Polygon>
+						<tt:Point x="-0.5" y="0.5"/>
+						<tt:Point x="0.5" y="0.5"/>
+						<tt:Point x="0.5" y="-0.5"/>
+					</tr2:Polygon>
+					<tr2:Type>Color</tr2:Type>
+					<tr2:Color X="16" Y="128" Z="128" Colorspace="http://www.onvif.org/ver10/colorspace/YCbCr"/>
+					<tr2:Enabled>true</tr2:Enabled>
+				</tr2:Masks>
+			</tr2:GetMasksResponse>`
+		case strings.Contains(body, "CreateMask"):
+			for _, want := range []string{
+				`<tr2:ConfigurationToken>VideoSourceConfig_1</tr2:ConfigurationToken>`,
+				`<tt:Point x="-1" y="1"></tt:Point>`,
+				`<tt:Point x="0" y="0"></tt:Point>`,
+				`<tr2:Type>Blurred</tr2:Type>`,
+				`<tr2:Enabled>true</tr2:Enabled>`,
+			} {
+				if !strings.Contains(body, want) {
+					t.Errorf("Expected %s in request, got: %s", want, body)
+				}
+			}
+			if strings.Contains(body, "tr2:Color") || strings.Contains(body, `token=`) {
+				t.Errorf("Unexpected color or token in request: %s", body)
+			}
+			response = `<tr2:CreateMaskResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl">
+				<tr2:Token>Mask_2</tr2:Token>
+			</tr2:CreateMaskResponse>`
+		case strings.Contains(body, "SetMask"):
+			if !strings.Contains(body, `<tr2:Mask token="Mask_2">`) || !strings.Contains(body, `<tr2:Type>Pixelated</tr2:Type>`) {
+				t.Errorf("Expected mask token and type in request, got: %s", body)
+			}
+			response = `<tr2:SetMaskResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl"/>`
+		case strings.Contains(body, "DeleteMask"):
+			if !strings.Contains(body, "<tr2:Token>Mask_2</tr2:Token>") {
+				t.Errorf("Expected mask token in request, got: %s", body)
+			}
+			response = `<tr2:DeleteMaskResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl"/>`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>` + response + `</s:Body></s:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	masks, err := client.GetMasks(ctx, "VideoSourceConfig_1")
+	if err != nil {
+		t.Fatalf("GetMasks() error = %v", err)
+	}
+	if len(masks) != 1 {
+		t.Fatalf("Expected 1 mask, got %d", len(masks))
+	}
+	mask := masks[0]
+	if mask.Token != "Mask_1" || mask.Type != MaskTypeColor || !mask.Enabled || len(mask.Polygon) != 3 {
+		t.Errorf("Unexpected mask: %+v", mask)
+	}
+	if mask.Polygon[0] != (Point{X: -0.5, Y: 0.5}) {
+		t.Errorf("Unexpected first point: %+v", mask.Polygon[0])
+	}
+	if mask.Color == nil || mask.Color.X != 16 || mask.Color.Colorspace == "" {
+		t.Errorf("Unexpected color: %+v", mask.Color)
+	}
+
+	token, err := client.CreateMask(ctx, Mask{
+		Token:              "ignored",
+		ConfigurationToken: "VideoSourceConfig_1",
+		Polygon:            []Point{{X: -1, Y: 1}, {X: 0, Y: 0}, {X: -1, Y: 0}},
+		Type:               MaskTypeBlurred,
+		Enabled:            true,
+	})
+	if err != nil {
+		t.Fatalf("CreateMask() error = %v", err)
+	}
+	if token != "Mask_2" {
+		t.Errorf("Expected token Mask_2, got %q", token)
+	}
+
+	triangle := []Point{{X: -1, Y: 1}, {X: 0, Y: 0}, {X: -1, Y: 0}}
+	if err := client.SetMask(ctx, Mask{Token: "Mask_2", Polygon: triangle, Type: MaskTypePixelated}); err != nil {
+		t.Fatalf("SetMask() error = %v", err)
+	}
+	if err := client.SetMask(ctx, Mask{Polygon: triangle, Type: MaskTypePixelated}); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter without a token, got %v", err)
+	}
+	if err := client.SetMask(ctx, Mask{Token: "Mask_2", Polygon: triangle[:2], Type: MaskTypePixelated}); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter from SetMask with 2 points, got %v", err)
+	}
+	if err := client.SetMask(ctx, Mask{Token: "Mask_2", Polygon: triangle, Type: "Striped"}); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter from SetMask with an unknown type, got %v", err)
+	}
+
+	if err := client.DeleteMask(ctx, "Mask_2"); err != nil {
+		t.Fatalf("DeleteMask() error = %v", err)
+	}
+
+	if err := client.DeleteMask(ctx, ""); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter from DeleteMask without a token, got %v", err)
+	}
+
+	for name, mask := range map[string]Mask{
+		"two points":   {Polygon: []Point{{X: -1, Y: 1}, {X: 0, Y: 0}}, Type: MaskTypeBlurred},
+		"no polygon":   {Type: MaskTypeColor},
+		"unknown type": {Polygon: []Point{{X: -1, Y: 1}, {X: 0, Y: 0}, {X: -1, Y: 0}}, Type: "Striped"},
+		"no type":      {Polygon: []Point{{X: -1, Y: 1}, {X: 0, Y: 0}, {X: -1, Y: 0}}},
+	} {
+		if _, err := client.CreateMask(ctx, mask); !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("CreateMask() with %s: expected ErrInvalidParameter, got %v", name, err)
+		}
+	}
+
+	if calls := atomic.LoadInt32(&getServicesCalls); calls != 1 {
+		t.Errorf("Expected the media 2 address to be looked up once, got %d lookups", calls)
+	}
+}
+
+func TestMasksWithoutMedia2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:GetServicesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+					<tds:Service>
+						<tds:Namespace>http://www.onvif.org/ver10/media/wsdl</tds:Namespace>
+						<tds:XAddr>http://192.168.1.10/onvif/media_service</tds:XAddr>
+					</tds:Service>
+				</tds:GetServicesResponse>
+			</s:Body>
+		</s:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if _, err := client.GetMasks(context.Background(), ""); !errors.Is(err, ErrServiceNotSupported) {
+		t.Errorf("Expected ErrServiceNotSupported, got %v", err)
+	}
+}
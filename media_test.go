@@ -0,0 +1,1327 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetAudioOutputConfigurations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<trt:GetAudioOutputConfigurationsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<trt:Configurations token="AudioOutputConfig_1">
+						<tt:Name>Speaker</tt:Name>
+						<tt:UseCount>1</tt:UseCount>
+						<tt:OutputToken>AudioOutput_1</tt:OutputToken>
+						<tt:SendPrimacy>www.onvif.org/ver20/HalfDuplex/Client</tt:SendPrimacy>
+						<tt:OutputLevel>80</tt:OutputLevel>
+					</trt:Configurations>
+				</trt:GetAudioOutputConfigurationsResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	configs, err := client.GetAudioOutputConfigurations(context.Background())
+	if err != nil {
+		t.Fatalf("GetAudioOutputConfigurations() error = %v", err)
+	}
+
+	if len(configs) != 1 {
+		t.Fatalf("Expected 1 configuration, got %d", len(configs))
+	}
+	cfg := configs[0]
+	if cfg.Token != "AudioOutputConfig_1" || cfg.OutputToken != "AudioOutput_1" || cfg.OutputLevel != 80 {
+		t.Errorf("Unexpected configuration: %+v", cfg)
+	}
+}
+
+func TestSetSynchronizationPoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "<trt:ProfileToken>Profile_1</trt:ProfileToken>") {
+			t.Errorf("Expected profile token in request, got: %s", body)
+		}
+
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<trt:SetSynchronizationPointResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if err := client.SetSynchronizationPoint(context.Background(), "Profile_1"); err != nil {
+		t.Fatalf("SetSynchronizationPoint() error = %v", err)
+	}
+}
+
+func TestGetMediaServiceCapabilities(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<trt:GetServiceCapabilitiesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+					<trt:Capabilities SnapshotUri="true" OSD="true" Rotation="false">
+						<trt:ProfileCapabilities MaximumNumberOfProfiles="8"/>
+						<trt:StreamingCapabilities RTPMulticast="true" RTP_RTSP_TCP="true"/>
+					</trt:Capabilities>
+				</trt:GetServiceCapabilitiesResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	caps, err := client.GetMediaServiceCapabilities(context.Background())
+	if err != nil {
+		t.Fatalf("GetMediaServiceCapabilities() error = %v", err)
+	}
+
+	if !caps.SnapshotURI || !caps.OSD || caps.Rotation {
+		t.Errorf("Unexpected service flags: %+v", caps)
+	}
+	if caps.MaximumNumberOfProfiles != 8 {
+		t.Errorf("Expected MaximumNumberOfProfiles 8, got %d", caps.MaximumNumberOfProfiles)
+	}
+	if !caps.RTPMulticast || !caps.RTP_RTSP_TCP || caps.RTP_TCP {
+		t.Errorf("Unexpected streaming flags: %+v", caps)
+	}
+}
+
+func TestGetVideoEncoderConfigurations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<trt:GetVideoEncoderConfigurationsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<trt:Configurations token="VideoEncoder_1">
+						<tt:Name>MainStream</tt:Name>
+						<tt:UseCount>2</tt:UseCount>
+						<tt:Encoding>H264</tt:Encoding>
+						<tt:Resolution><tt:Width>1920</tt:Width><tt:Height>1080</tt:Height></tt:Resolution>
+						<tt:Quality>5</tt:Quality>
+					</trt:Configurations>
+					<trt:Configurations token="VideoEncoder_2">
+						<tt:Name>SubStream</tt:Name>
+						<tt:Encoding>H264</tt:Encoding>
+						<tt:Resolution><tt:Width>640</tt:Width><tt:Height>360</tt:Height></tt:Resolution>
+					</trt:Configurations>
+				</trt:GetVideoEncoderConfigurationsResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	configs, err := client.GetVideoEncoderConfigurations(context.Background())
+	if err != nil {
+		t.Fatalf("GetVideoEncoderConfigurations() error = %v", err)
+	}
+
+	if len(configs) != 2 {
+		t.Fatalf("Expected 2 configurations, got %d", len(configs))
+	}
+	if configs[0].Token != "VideoEncoder_1" || configs[0].UseCount != 2 || configs[0].Resolution.Width != 1920 {
+		t.Errorf("Unexpected first configuration: %+v", configs[0])
+	}
+	if configs[1].Token != "VideoEncoder_2" || configs[1].Resolution.Height != 360 {
+		t.Errorf("Unexpected second configuration: %+v", configs[1])
+	}
+}
+
+func TestVideoEncoderConfigurationH264RoundTrip(t *testing.T) {
+	var setBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "SetVideoEncoderConfiguration") {
+			setBody = string(body)
+			response := `<?xml version="1.0" encoding="UTF-8"?>
+			<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+				<s:Body>
+					<trt:SetVideoEncoderConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>
+				</s:Body>
+			</s:Envelope>`
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(response))
+			return
+		}
+
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<trt:GetVideoEncoderConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<trt:Configuration token="VideoEncoder_1">
+						<tt:Name>MainStream</tt:Name>
+						<tt:Encoding>H264</tt:Encoding>
+						<tt:H264>
+							<tt:GovLength>50</tt:GovLength>
+							<tt:H264Profile>High</tt:H264Profile>
+						</tt:H264>
+					</trt:Configuration>
+				</trt:GetVideoEncoderConfigurationResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	config, err := client.GetVideoEncoderConfiguration(context.Background(), "VideoEncoder_1")
+	if err != nil {
+		t.Fatalf("GetVideoEncoderConfiguration() error = %v", err)
+	}
+
+	if config.H264 == nil || config.H264.GovLength != 50 || config.H264.H264Profile != "High" {
+		t.Fatalf("Unexpected H264 configuration: %+v", config.H264)
+	}
+	if config.H265 != nil {
+		t.Errorf("Expected no H265 configuration, got %+v", config.H265)
+	}
+
+	config.H264.GovLength = 25
+	if err := client.SetVideoEncoderConfiguration(context.Background(), config, true); err != nil {
+		t.Fatalf("SetVideoEncoderConfiguration() error = %v", err)
+	}
+
+	if !strings.Contains(setBody, "<tt:GovLength>25</tt:GovLength>") || !strings.Contains(setBody, "<tt:H264Profile>High</tt:H264Profile>") {
+		t.Errorf("Expected H264 configuration in request, got: %s", setBody)
+	}
+	if strings.Contains(setBody, "H265") {
+		t.Errorf("Expected no H265 element in request, got: %s", setBody)
+	}
+
+	setBody = ""
+	for name, h265 := range map[string]*VideoEncoderConfiguration{
+		"encoding": {Token: "VideoEncoder_1", Encoding: "H265"},
+		"settings": {Token: "VideoEncoder_1", Encoding: "H264", H265: &H265Configuration{GovLength: 30, H265Profile: "Main"}},
+	} {
+		if err := client.SetVideoEncoderConfiguration(context.Background(), h265, true); !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("H265 %s: expected ErrInvalidParameter, got %v", name, err)
+		}
+	}
+	if setBody != "" {
+		t.Errorf("Expected no request for an H265 configuration, got: %s", setBody)
+	}
+}
+
+func TestVideoEncoderConfigurationMulticast(t *testing.T) {
+	var setBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "SetVideoEncoderConfiguration") {
+			setBody = string(body)
+			response := `<?xml version="1.0" encoding="UTF-8"?>
+			<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+				<s:Body>
+					<trt:SetVideoEncoderConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>
+				</s:Body>
+			</s:Envelope>`
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(response))
+			return
+		}
+
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<trt:GetVideoEncoderConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<trt:Configuration token="VideoEncoder_1">
+						<tt:Name>MainStream</tt:Name>
+						<tt:Encoding>H264</tt:Encoding>
+						<tt:Multicast>
+							<tt:Address>
+								<tt:Type>IPv4</tt:Type>
+								<tt:IPv4Address>239.0.0.1</tt:IPv4Address>
+							</tt:Address>
+							<tt:Port>5000</tt:Port>
+							<tt:TTL>1</tt:TTL>
+							<tt:AutoStart>false</tt:AutoStart>
+						</tt:Multicast>
+					</trt:Configuration>
+				</trt:GetVideoEncoderConfigurationResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	config, err := client.GetVideoEncoderConfiguration(context.Background(), "VideoEncoder_1")
+	if err != nil {
+		t.Fatalf("GetVideoEncoderConfiguration() error = %v", err)
+	}
+
+	m := config.Multicast
+	if m == nil || m.Address == nil || m.Address.IPv4Address != "239.0.0.1" || m.Port != 5000 || m.TTL != 1 {
+		t.Fatalf("Unexpected multicast configuration: %+v", m)
+	}
+
+	config.Multicast = &MulticastConfiguration{
+		Address:   &IPAddress{Address: "239.0.0.2"},
+		Port:      6000,
+		TTL:       4,
+		AutoStart: true,
+	}
+	if err := client.SetVideoEncoderConfiguration(context.Background(), config, false); err != nil {
+		t.Fatalf("SetVideoEncoderConfiguration() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"<tt:Type>IPv4</tt:Type>",
+		"<tt:IPv4Address>239.0.0.2</tt:IPv4Address>",
+		"<tt:Port>6000</tt:Port>",
+		"<tt:TTL>4</tt:TTL>",
+		"<tt:AutoStart>true</tt:AutoStart>",
+	} {
+		if !strings.Contains(setBody, want) {
+			t.Errorf("Expected %s in request, got: %s", want, setBody)
+		}
+	}
+}
+
+func TestGetStreamURITimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<trt:GetStreamUriResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<trt:MediaUri>
+						<tt:Uri>rtsp://192.168.1.100/stream1</tt:Uri>
+						<tt:InvalidAfterConnect>true</tt:InvalidAfterConnect>
+						<tt:InvalidAfterReboot>true</tt:InvalidAfterReboot>
+						<tt:Timeout>PT1M30S</tt:Timeout>
+					</trt:MediaUri>
+				</trt:GetStreamUriResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	uri, err := client.GetStreamURI(context.Background(), "Profile_1")
+	if err != nil {
+		t.Fatalf("GetStreamURI() error = %v", err)
+	}
+
+	if uri.URI != "rtsp://192.168.1.100/stream1" || !uri.InvalidAfterConnect {
+		t.Errorf("Unexpected media URI: %+v", uri)
+	}
+	if uri.Timeout != 90*time.Second {
+		t.Errorf("Expected Timeout 1m30s, got %v", uri.Timeout)
+	}
+}
+
+func TestGetBestStreamURI(t *testing.T) {
+	var capabilityCalls int32
+	var setup string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body := string(data)
+
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tds:GetCapabilitiesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<tds:Capabilities>
+						<tt:Media>
+							<tt:XAddr>` + "http://" + r.Host + `/onvif/media_service</tt:XAddr>
+							<tt:StreamingCapabilities>
+								<tt:RTPMulticast>false</tt:RTPMulticast>
+								<tt:RTP_TCP>true</tt:RTP_TCP>
+								<tt:RTP_RTSP_TCP>false</tt:RTP_RTSP_TCP>
+							</tt:StreamingCapabilities>
+						</tt:Media>
+					</tds:Capabilities>
+				</tds:GetCapabilitiesResponse>
+			</s:Body>
+		</s:Envelope>`
+		if strings.Contains(body, "GetCapabilities") {
+			atomic.AddInt32(&capabilityCalls, 1)
+		} else {
+			start := strings.Index(body, "<trt:StreamSetup>")
+			end := strings.Index(body, "</trt:StreamSetup>")
+			setup = strings.Join(strings.Fields(body[start:end]), "")
+			response = `<?xml version="1.0" encoding="UTF-8"?>
+			<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+				<s:Body>
+					<trt:GetStreamUriResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+						<trt:MediaUri><tt:Uri>rtsp://192.168.1.100/stream1</tt:Uri></trt:MediaUri>
+					</trt:GetStreamUriResponse>
+				</s:Body>
+			</s:Envelope>`
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		prefer    []string
+		wantSetup string
+	}{
+		{"first supported", []string{StreamTransportRTSP, StreamTransportTCP}, "<tt:Stream>RTP-Unicast</tt:Stream><tt:Transport><tt:Protocol>TCP</tt:Protocol></tt:Transport>"},
+		{"fallback", []string{StreamTransportMulticast}, "<tt:Stream>RTP-Unicast</tt:Stream><tt:Transport><tt:Protocol>RTSP</tt:Protocol></tt:Transport>"},
+		{"udp", []string{StreamTransportUDP}, "<tt:Stream>RTP-Unicast</tt:Stream><tt:Transport><tt:Protocol>UDP</tt:Protocol></tt:Transport>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uri, err := client.GetBestStreamURI(ctx, "Profile_1", tt.prefer)
+			if err != nil {
+				t.Fatalf("GetBestStreamURI() error = %v", err)
+			}
+			if uri.URI != "rtsp://192.168.1.100/stream1" {
+				t.Errorf("Unexpected URI %q", uri.URI)
+			}
+			if setup != "<trt:StreamSetup>"+tt.wantSetup {
+				t.Errorf("Unexpected stream setup %s", setup)
+			}
+		})
+	}
+
+	// Capabilities fetched by the first call are kept
+	if calls := atomic.LoadInt32(&capabilityCalls); calls != 1 {
+		t.Errorf("Expected 1 GetCapabilities call before Initialize, got %d", calls)
+	}
+
+	// Capabilities found by Initialize are reused
+	if err := client.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	before := atomic.LoadInt32(&capabilityCalls)
+	if _, err := client.GetBestStreamURI(ctx, "Profile_1", []string{StreamTransportTCP}); err != nil {
+		t.Fatalf("GetBestStreamURI() error = %v", err)
+	}
+	if after := atomic.LoadInt32(&capabilityCalls); after != before {
+		t.Errorf("Expected no GetCapabilities call after Initialize, got %d", after-before)
+	}
+
+	if _, err := client.GetBestStreamURI(ctx, "Profile_1", []string{"QUIC"}); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for an unknown transport, got %v", err)
+	}
+}
+
+func TestGetBestStreamURIWithoutCapabilities(t *testing.T) {
+	var capabilityCalls int32
+	var setup string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body := string(data)
+
+		if strings.Contains(body, "GetCapabilities") {
+			atomic.AddInt32(&capabilityCalls, 1)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		start := strings.Index(body, "<trt:StreamSetup>")
+		end := strings.Index(body, "</trt:StreamSetup>")
+		setup = strings.Join(strings.Fields(body[start:end]), "")
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<trt:GetStreamUriResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<trt:MediaUri><tt:Uri>rtsp://192.168.1.100/stream1</tt:Uri></trt:MediaUri>
+				</trt:GetStreamUriResponse>
+			</s:Body>
+		</s:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	ctx := context.Background()
+
+	uri, err := client.GetBestStreamURI(ctx, "Profile_1", []string{StreamTransportTCP})
+	if err != nil {
+		t.Fatalf("GetBestStreamURI() error = %v", err)
+	}
+	if uri.URI != "rtsp://192.168.1.100/stream1" {
+		t.Errorf("Unexpected URI %q", uri.URI)
+	}
+	if want := "<trt:StreamSetup><tt:Stream>RTP-Unicast</tt:Stream><tt:Transport><tt:Protocol>RTSP</tt:Protocol></tt:Transport>"; setup != want {
+		t.Errorf("Expected the GetStreamURI setup, got %s", setup)
+	}
+
+	if _, err := client.GetBestStreamURI(ctx, "Profile_1", []string{StreamTransportTCP}); err != nil {
+		t.Fatalf("GetBestStreamURI() error = %v", err)
+	}
+	if calls := atomic.LoadInt32(&capabilityCalls); calls != 2 {
+		t.Errorf("Expected a failed GetCapabilities to be retried, got %d calls", calls)
+	}
+}
+
+func TestStreamURIProvider(t *testing.T) {
+	tests := []struct {
+		name                string
+		invalidAfterConnect string
+		timeout             string
+		wait                time.Duration
+		wantFetches         int32
+	}{
+		{"valid until reboot", "false", "PT0S", 0, 1},
+		{"invalid after connect", "true", "PT0S", 0, 3},
+		{"timed out", "false", "PT0.02S", 30 * time.Millisecond, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetches int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&fetches, 1)
+				response := `<?xml version="1.0" encoding="UTF-8"?>
+				<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+					<s:Body>
+						<trt:GetStreamUriResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+							<trt:MediaUri>
+								<tt:Uri>rtsp://192.168.1.100/stream1</tt:Uri>
+								<tt:InvalidAfterConnect>` + tt.invalidAfterConnect + `</tt:InvalidAfterConnect>
+								<tt:InvalidAfterReboot>true</tt:InvalidAfterReboot>
+								<tt:Timeout>` + tt.timeout + `</tt:Timeout>
+							</trt:MediaUri>
+						</trt:GetStreamUriResponse>
+					</s:Body>
+				</s:Envelope>`
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(response))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(server.URL)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			provider := client.StreamURIProvider("Profile_1")
+			for i := 0; i < 3; i++ {
+				uri, err := provider.Get(context.Background())
+				if err != nil {
+					t.Fatalf("Get() error = %v", err)
+				}
+				if uri != "rtsp://192.168.1.100/stream1" {
+					t.Errorf("Unexpected URI %q", uri)
+				}
+				time.Sleep(tt.wait)
+			}
+
+			if got := atomic.LoadInt32(&fetches); got != tt.wantFetches {
+				t.Errorf("Expected %d fetches, got %d", tt.wantFetches, got)
+			}
+
+			provider.Invalidate()
+			if _, err := provider.Get(context.Background()); err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if got := atomic.LoadInt32(&fetches); got != tt.wantFetches+1 {
+				t.Errorf("Expected Invalidate to force a fetch, got %d fetches", got)
+			}
+		})
+	}
+}
+
+func TestAudioEncoderConfigurationRoundTrip(t *testing.T) {
+	var setBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "SetAudioEncoderConfiguration") {
+			setBody = string(body)
+			response := `<?xml version="1.0" encoding="UTF-8"?>
+			<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+				<s:Body>
+					<trt:SetAudioEncoderConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>
+				</s:Body>
+			</s:Envelope>`
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(response))
+			return
+		}
+
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<trt:GetAudioEncoderConfigurationsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<trt:Configurations token="AudioEncoder_1">
+						<tt:Name>Microphone</tt:Name>
+						<tt:UseCount>2</tt:UseCount>
+						<tt:Encoding>G711</tt:Encoding>
+						<tt:Bitrate>64</tt:Bitrate>
+						<tt:SampleRate>8</tt:SampleRate>
+						<tt:Multicast>
+							<tt:Address>
+								<tt:Type>IPv4</tt:Type>
+								<tt:IPv4Address>0.0.0.0</tt:IPv4Address>
+							</tt:Address>
+							<tt:Port>0</tt:Port>
+							<tt:TTL>1</tt:TTL>
+							<tt:AutoStart>false</tt:AutoStart>
+						</tt:Multicast>
+						<tt:SessionTimeout>PT60S</tt:SessionTimeout>
+					</trt:Configurations>
+				</trt:GetAudioEncoderConfigurationsResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	configs, err := client.GetAudioEncoderConfigurations(context.Background())
+	if err != nil {
+		t.Fatalf("GetAudioEncoderConfigurations() error = %v", err)
+	}
+	if len(configs) != 1 {
+		t.Fatalf("Expected 1 configuration, got %d", len(configs))
+	}
+
+	config := configs[0]
+	if config.Token != "AudioEncoder_1" || config.Encoding != "G711" || config.Bitrate != 64 || config.SampleRate != 8 {
+		t.Errorf("Unexpected configuration: %+v", config)
+	}
+	if config.SessionTimeout != time.Minute {
+		t.Errorf("Expected 1m session timeout, got %v", config.SessionTimeout)
+	}
+	if config.Multicast == nil || config.Multicast.TTL != 1 {
+		t.Errorf("Unexpected multicast configuration: %+v", config.Multicast)
+	}
+
+	config.Encoding = "AAC"
+	config.Bitrate = 128
+	config.SampleRate = 16
+	if err := client.SetAudioEncoderConfiguration(context.Background(), config, true); err != nil {
+		t.Fatalf("SetAudioEncoderConfiguration() error = %v", err)
+	}
+
+	for _, want := range []string{
+		`token="AudioEncoder_1"`,
+		"<tt:Encoding>AAC</tt:Encoding>",
+		"<tt:Bitrate>128</tt:Bitrate>",
+		"<tt:SampleRate>16</tt:SampleRate>",
+		"<tt:SessionTimeout>PT1M</tt:SessionTimeout>",
+		"<tt:Multicast>",
+		"<trt:ForcePersistence>true</trt:ForcePersistence>",
+	} {
+		if !strings.Contains(setBody, want) {
+			t.Errorf("Expected %s in request, got: %s", want, setBody)
+		}
+	}
+}
+
+func TestGetAudioEncoderConfigurationOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "<trt:ProfileToken>Profile_1</trt:ProfileToken>") {
+			t.Errorf("Expected profile token in request, got: %s", body)
+		}
+		if strings.Contains(string(body), "ConfigurationToken") {
+			t.Errorf("Expected no configuration token in request, got: %s", body)
+		}
+
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<trt:GetAudioEncoderConfigurationOptionsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<trt:Options>
+						<tt:Options>
+							<tt:Encoding>G711</tt:Encoding>
+							<tt:BitrateList><tt:Items>64</tt:Items></tt:BitrateList>
+							<tt:SampleRateList><tt:Items>8</tt:Items></tt:SampleRateList>
+						</tt:Options>
+						<tt:Options>
+							<tt:Encoding>AAC</tt:Encoding>
+							<tt:BitrateList><tt:Items>64</tt:Items><tt:Items>128</tt:Items></tt:BitrateList>
+							<tt:SampleRateList><tt:Items>16</tt:Items><tt:Items>48</tt:Items></tt:SampleRateList>
+						</tt:Options>
+					</trt:Options>
+				</trt:GetAudioEncoderConfigurationOptionsResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	options, err := client.GetAudioEncoderConfigurationOptions(context.Background(), "", "Profile_1")
+	if err != nil {
+		t.Fatalf("GetAudioEncoderConfigurationOptions() error = %v", err)
+	}
+
+	if len(options.Options) != 2 {
+		t.Fatalf("Expected 2 options, got %d", len(options.Options))
+	}
+	aac := options.Options[1]
+	if aac.Encoding != "AAC" || len(aac.BitrateList) != 2 || aac.SampleRateList[1] != 48 {
+		t.Errorf("Unexpected AAC option: %+v", aac)
+	}
+
+	if !options.Supports("AAC", 128, 48) {
+		t.Error("Expected AAC at 128 kbps / 48 kHz to be supported")
+	}
+	if options.Supports("G711", 128, 8) {
+		t.Error("Expected G711 at 128 kbps to be unsupported")
+	}
+	if options.Supports("G726", 32, 8) {
+		t.Error("Expected G726 to be unsupported")
+	}
+}
+
+func TestGetAudioSourceConfigurations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<trt:GetAudioSourceConfigurationsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<trt:Configurations token="AudioSourceConfig_1">
+						<tt:Name>Microphone</tt:Name>
+						<tt:UseCount>1</tt:UseCount>
+						<tt:SourceToken>AudioSource_1</tt:SourceToken>
+					</trt:Configurations>
+				</trt:GetAudioSourceConfigurationsResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	configs, err := client.GetAudioSourceConfigurations(context.Background())
+	if err != nil {
+		t.Fatalf("GetAudioSourceConfigurations() error = %v", err)
+	}
+
+	if len(configs) != 1 {
+		t.Fatalf("Expected 1 configuration, got %d", len(configs))
+	}
+	if cfg := configs[0]; cfg.Token != "AudioSourceConfig_1" || cfg.SourceToken != "AudioSource_1" || cfg.UseCount != 1 {
+		t.Errorf("Unexpected configuration: %+v", cfg)
+	}
+}
+
+func TestAddAudioConfigurations(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<trt:AddAudioSourceConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if err := client.AddAudioSourceConfiguration(context.Background(), "Profile_1", "AudioSourceConfig_1"); err != nil {
+		t.Fatalf("AddAudioSourceConfiguration() error = %v", err)
+	}
+	if err := client.AddAudioEncoderConfiguration(context.Background(), "Profile_1", "AudioEncoder_1"); err != nil {
+		t.Fatalf("AddAudioEncoderConfiguration() error = %v", err)
+	}
+
+	if len(bodies) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(bodies))
+	}
+	for i, want := range []string{
+		"<trt:ConfigurationToken>AudioSourceConfig_1</trt:ConfigurationToken>",
+		"<trt:ConfigurationToken>AudioEncoder_1</trt:ConfigurationToken>",
+	} {
+		if !strings.Contains(bodies[i], "<trt:ProfileToken>Profile_1</trt:ProfileToken>") || !strings.Contains(bodies[i], want) {
+			t.Errorf("Unexpected request %d: %s", i, bodies[i])
+		}
+	}
+	if !strings.Contains(bodies[0], "AddAudioSourceConfiguration") || !strings.Contains(bodies[1], "AddAudioEncoderConfiguration") {
+		t.Errorf("Unexpected actions: %v", bodies)
+	}
+}
+
+func TestGetProfilesMalformedSection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<trt:Profiles token="Profile_1">
+						<tt:Name>Main</tt:Name>
+						<tt:VideoEncoderConfiguration token="VideoEncoder_1">
+							<tt:Encoding>H264</tt:Encoding>
+							<tt:Resolution><tt:Width>1920</tt:Width><tt:Height>1080</tt:Height></tt:Resolution>
+						</tt:VideoEncoderConfiguration>
+					</trt:Profiles>
+					<trt:Profiles token="Profile_2">
+						<tt:Name>Broken</tt:Name>
+						<tt:VideoSourceConfiguration token="VideoSource_2">
+							<tt:SourceToken>Source_2</tt:SourceToken>
+						</tt:VideoSourceConfiguration>
+						<tt:VideoEncoderConfiguration token="VideoEncoder_2">
+							<tt:Encoding>H264</tt:Encoding>
+							<tt:Resolution><tt:Width>wide</tt:Width><tt:Height>1080</tt:Height></tt:Resolution>
+						</tt:VideoEncoderConfiguration>
+						<tt:PTZConfiguration token="PTZ_2">
+							<tt:NodeToken>Node_2</tt:NodeToken>
+						</tt:PTZConfiguration>
+					</trt:Profiles>
+				</trt:GetProfilesResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	var reported []error
+	client, err := NewClient(server.URL, WithResponseHook(func(op string, err error) {
+		if op != "GetProfiles" {
+			t.Errorf("Unexpected operation %q", op)
+		}
+		reported = append(reported, err)
+	}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	profiles, err := client.GetProfiles(context.Background())
+	if err != nil {
+		t.Fatalf("GetProfiles() error = %v", err)
+	}
+	if len(profiles) != 2 {
+		t.Fatalf("Expected 2 profiles, got %d", len(profiles))
+	}
+
+	if profiles[0].VideoEncoderConfiguration == nil || profiles[0].VideoEncoderConfiguration.Resolution.Width != 1920 {
+		t.Errorf("Expected the good profile to decode fully, got %+v", profiles[0].VideoEncoderConfiguration)
+	}
+
+	broken := profiles[1]
+	if broken.Token != "Profile_2" || broken.Name != "Broken" {
+		t.Errorf("Unexpected broken profile %+v", broken)
+	}
+	if broken.VideoEncoderConfiguration != nil {
+		t.Errorf("Expected the malformed encoder to be nil, got %+v", broken.VideoEncoderConfiguration)
+	}
+	if broken.VideoSourceConfiguration == nil || broken.VideoSourceConfiguration.SourceToken != "Source_2" {
+		t.Errorf("Expected the video source to survive, got %+v", broken.VideoSourceConfiguration)
+	}
+	if broken.PTZConfiguration == nil || broken.PTZConfiguration.NodeToken != "Node_2" {
+		t.Errorf("Expected the PTZ configuration to survive, got %+v", broken.PTZConfiguration)
+	}
+
+	if len(reported) != 1 || !strings.Contains(reported[0].Error(), "Profile_2") || !strings.Contains(reported[0].Error(), "VideoEncoderConfiguration") {
+		t.Errorf("Expected one report of the malformed encoder, got %v", reported)
+	}
+}
+
+func TestGetProfilesStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<trt:Profiles token="Profile_1">
+						<tt:Name>Main</tt:Name>
+						<tt:VideoEncoderConfiguration token="VideoEncoder_1">
+							<tt:Name>H264</tt:Name>
+							<tt:Encoding>H264</tt:Encoding>
+							<tt:Resolution><tt:Width>1920</tt:Width><tt:Height>1080</tt:Height></tt:Resolution>
+						</tt:VideoEncoderConfiguration>
+					</trt:Profiles>
+					<trt:Profiles token="Profile_2">
+						<tt:Name>Sub</tt:Name>
+					</trt:Profiles>
+					<trt:Profiles token="Profile_3">
+						<tt:Name>Extra</tt:Name>
+					</trt:Profiles>
+				</trt:GetProfilesResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	var tokens []string
+	err = client.GetProfilesStream(context.Background(), func(p *Profile) error {
+		tokens = append(tokens, p.Token)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("GetProfilesStream() error = %v", err)
+	}
+	if strings.Join(tokens, ",") != "Profile_1,Profile_2,Profile_3" {
+		t.Errorf("Unexpected profiles: %v", tokens)
+	}
+
+	profiles, err := client.GetProfiles(context.Background())
+	if err != nil {
+		t.Fatalf("GetProfiles() error = %v", err)
+	}
+	if len(profiles) != 3 || profiles[0].VideoEncoderConfiguration == nil ||
+		profiles[0].VideoEncoderConfiguration.Resolution.Width != 1920 {
+		t.Errorf("GetProfiles disagrees with the stream: %+v", profiles)
+	}
+
+	// An error from the callback stops decoding and is returned unchanged
+	errStop := errors.New("stop")
+	calls := 0
+	err = client.GetProfilesStream(context.Background(), func(p *Profile) error {
+		calls++
+		return errStop
+	})
+	if err != errStop {
+		t.Errorf("Expected callback error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected decoding to stop after 1 profile, got %d calls", calls)
+	}
+}
+
+func TestStableProfileOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<trt:Profiles token="Profile_2"><tt:Name>Sub</tt:Name></trt:Profiles>
+					<trt:Profiles token="Profile_3"><tt:Name>Third</tt:Name></trt:Profiles>
+					<trt:Profiles token="Profile_1"><tt:Name>Main</tt:Name></trt:Profiles>
+				</trt:GetProfilesResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	tokens := func(profiles []*Profile) string {
+		var names []string
+		for _, p := range profiles {
+			names = append(names, p.Token)
+		}
+		return strings.Join(names, ",")
+	}
+
+	tests := []struct {
+		name string
+		opts []ClientOption
+		want string
+	}{
+		{"device order", nil, "Profile_2,Profile_3,Profile_1"},
+		{"stable order", []ClientOption{WithStableProfileOrder()}, "Profile_1,Profile_2,Profile_3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(server.URL, tt.opts...)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			profiles, err := client.GetProfiles(context.Background())
+			if err != nil {
+				t.Fatalf("GetProfiles() error = %v", err)
+			}
+			if got := tokens(profiles); got != tt.want {
+				t.Errorf("GetProfiles() order = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetPTZProfiles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<trt:Profiles token="Profile_1">
+						<tt:Name>Main</tt:Name>
+						<tt:AudioEncoderConfiguration token="AudioEncoder_1">
+							<tt:Name>G711</tt:Name>
+							<tt:Encoding>G711</tt:Encoding>
+							<tt:Bitrate>64</tt:Bitrate>
+							<tt:SampleRate>8</tt:SampleRate>
+						</tt:AudioEncoderConfiguration>
+						<tt:VideoAnalyticsConfiguration token="Analytics_1">
+							<tt:Name>Analytics</tt:Name>
+							<tt:UseCount>1</tt:UseCount>
+							<tt:AnalyticsEngineConfiguration>
+								<tt:AnalyticsModule Name="MyCellMotion" Type="tt:CellMotionEngine">
+									<tt:Parameters><tt:SimpleItem Name="Sensitivity" Value="50"/></tt:Parameters>
+								</tt:AnalyticsModule>
+							</tt:AnalyticsEngineConfiguration>
+							<tt:RuleEngineConfiguration>
+								<tt:Rule Name="MyMotionDetector" Type="tt:CellMotionDetector"/>
+								<tt:Rule Name="MyLineDetector" Type="tt:LineDetector"/>
+							</tt:RuleEngineConfiguration>
+						</tt:VideoAnalyticsConfiguration>
+						<tt:MetadataConfiguration token="Metadata_1">
+							<tt:Name>Metadata</tt:Name>
+							<tt:Analytics>true</tt:Analytics>
+						</tt:MetadataConfiguration>
+					</trt:Profiles>
+					<trt:Profiles token="Profile_2">
+						<tt:Name>PTZ</tt:Name>
+						<tt:AudioSourceConfiguration token="AudioSource_1">
+							<tt:Name>Microphone</tt:Name>
+							<tt:SourceToken>AudioInput_1</tt:SourceToken>
+						</tt:AudioSourceConfiguration>
+						<tt:PTZConfiguration token="PTZ_1">
+							<tt:Name>PTZ</tt:Name>
+							<tt:NodeToken>Node_1</tt:NodeToken>
+						</tt:PTZConfiguration>
+						<tt:MetadataConfiguration token="Metadata_2">
+							<tt:Name>Events only</tt:Name>
+							<tt:Analytics>false</tt:Analytics>
+						</tt:MetadataConfiguration>
+						<tt:Extension>
+							<tt:AudioOutputConfiguration token="AudioOutput_1">
+								<tt:Name>Speaker</tt:Name>
+								<tt:UseCount>1</tt:UseCount>
+								<tt:OutputToken>AudioOutput_1</tt:OutputToken>
+								<tt:SendPrimacy>www.onvif.org/ver20/HalfDuplex/Auto</tt:SendPrimacy>
+								<tt:OutputLevel>8</tt:OutputLevel>
+							</tt:AudioOutputConfiguration>
+							<tt:AudioDecoderConfiguration token="AudioDecoder_1">
+								<tt:Name>Decoder</tt:Name>
+								<tt:UseCount>1</tt:UseCount>
+							</tt:AudioDecoderConfiguration>
+						</tt:Extension>
+					</trt:Profiles>
+					<trt:Profiles token="Profile_3">
+						<tt:Name>Video only</tt:Name>
+					</trt:Profiles>
+				</trt:GetProfilesResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	profiles, err := client.GetProfiles(context.Background())
+	if err != nil {
+		t.Fatalf("GetProfiles() error = %v", err)
+	}
+	if len(profiles) != 3 {
+		t.Fatalf("Expected 3 profiles, got %d", len(profiles))
+	}
+
+	tests := []struct {
+		token                              string
+		ptz, audio, analytics, backchannel bool
+	}{
+		{"Profile_1", false, true, true, false},
+		{"Profile_2", true, true, false, true},
+		{"Profile_3", false, false, false, false},
+	}
+	for i, tt := range tests {
+		p := profiles[i]
+		if p.Token != tt.token || p.HasPTZ() != tt.ptz || p.HasAudio() != tt.audio || p.HasAnalytics() != tt.analytics {
+			t.Errorf("%s: HasPTZ() = %v, HasAudio() = %v, HasAnalytics() = %v, want %v, %v, %v",
+				p.Token, p.HasPTZ(), p.HasAudio(), p.HasAnalytics(), tt.ptz, tt.audio, tt.analytics)
+		}
+		if p.HasAudioBackchannel() != tt.backchannel {
+			t.Errorf("%s: HasAudioBackchannel() = %v, want %v", p.Token, p.HasAudioBackchannel(), tt.backchannel)
+		}
+	}
+	if ext := profiles[1].Extension; ext == nil || ext.AudioOutputConfiguration.OutputToken != "AudioOutput_1" ||
+		ext.AudioOutputConfiguration.OutputLevel != 8 || ext.AudioDecoderConfiguration.Token != "AudioDecoder_1" {
+		t.Errorf("Unexpected profile extension: %+v", ext)
+	}
+	if enc := profiles[0].AudioEncoderConfiguration; enc == nil || enc.Encoding != "G711" || enc.SampleRate != 8 {
+		t.Errorf("Unexpected audio encoder configuration: %+v", enc)
+	}
+	if src := profiles[1].AudioSourceConfiguration; src == nil || src.SourceToken != "AudioInput_1" {
+		t.Errorf("Unexpected audio source configuration: %+v", src)
+	}
+	analytics := profiles[0].VideoAnalyticsConfiguration
+	if analytics == nil || analytics.Token != "Analytics_1" || analytics.UseCount != 1 {
+		t.Fatalf("Unexpected video analytics configuration: %+v", analytics)
+	}
+	if len(analytics.AnalyticsModules) != 1 || analytics.AnalyticsModules[0] != (AnalyticsEntry{Name: "MyCellMotion", Type: "tt:CellMotionEngine"}) {
+		t.Errorf("Unexpected analytics modules: %+v", analytics.AnalyticsModules)
+	}
+	if len(analytics.Rules) != 2 || analytics.Rules[1] != (AnalyticsEntry{Name: "MyLineDetector", Type: "tt:LineDetector"}) {
+		t.Errorf("Unexpected rules: %+v", analytics.Rules)
+	}
+	if profiles[0].MetadataConfiguration == nil || profiles[0].MetadataConfiguration.Token != "Metadata_1" {
+		t.Errorf("Unexpected metadata configuration: %+v", profiles[0].MetadataConfiguration)
+	}
+	if profiles[1].VideoAnalyticsConfiguration != nil {
+		t.Errorf("Expected no video analytics configuration, got %+v", profiles[1].VideoAnalyticsConfiguration)
+	}
+
+	ptzProfiles, err := client.GetPTZProfiles(context.Background())
+	if err != nil {
+		t.Fatalf("GetPTZProfiles() error = %v", err)
+	}
+	if len(ptzProfiles) != 1 || ptzProfiles[0].Token != "Profile_2" {
+		t.Errorf("Expected only Profile_2, got %v", ptzProfiles)
+	}
+}
+
+func TestEncoderValidation(t *testing.T) {
+	var sets atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var response string
+		if strings.Contains(string(body), "SetVideoEncoderConfiguration") {
+			sets.Add(1)
+			response = `<trt:SetVideoEncoderConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>`
+		} else {
+			response = `<trt:GetVideoEncoderConfigurationOptionsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Options>
+					<tt:QualityRange><tt:Min>1</tt:Min><tt:Max>10</tt:Max></tt:QualityRange>
+					<tt:H264>
+						<tt:ResolutionsAvailable><tt:Width>1920</tt:Width><tt:Height>1080</tt:Height></tt:ResolutionsAvailable>
+						<tt:ResolutionsAvailable><tt:Width>1280</tt:Width><tt:Height>720</tt:Height></tt:ResolutionsAvailable>
+						<tt:FrameRateRange><tt:Min>1</tt:Min><tt:Max>30</tt:Max></tt:FrameRateRange>
+					</tt:H264>
+					<tt:Extension>
+						<tt:H264><tt:BitrateRange><tt:Min>64</tt:Min><tt:Max>8192</tt:Max></tt:BitrateRange></tt:H264>
+					</tt:Extension>
+				</trt:Options>
+			</trt:GetVideoEncoderConfigurationOptionsResponse>`
+		}
+		_, _ = w.Write([]byte(`<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>` + response + `</s:Body></s:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithEncoderValidation())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	ctx := context.Background()
+
+	valid := func() *VideoEncoderConfiguration {
+		return &VideoEncoderConfiguration{
+			Token:       "VideoEncoder_1",
+			Encoding:    "H264",
+			Resolution:  &VideoResolution{Width: 1280, Height: 720},
+			Quality:     5,
+			RateControl: &VideoRateControl{FrameRateLimit: 25, BitrateLimit: 4096},
+		}
+	}
+
+	// Without options there is nothing to check against
+	tooFast := valid()
+	tooFast.RateControl.BitrateLimit = 20000
+	if err := client.SetVideoEncoderConfiguration(ctx, tooFast, false); err != nil {
+		t.Fatalf("SetVideoEncoderConfiguration() before fetching options error = %v", err)
+	}
+
+	options, err := client.GetVideoEncoderConfigurationOptions(ctx, "VideoEncoder_1", "")
+	if err != nil {
+		t.Fatalf("GetVideoEncoderConfigurationOptions() error = %v", err)
+	}
+	if options.H264 == nil || len(options.H264.ResolutionsAvailable) != 2 || options.H264.BitrateRange == nil || options.H264.BitrateRange.Max != 8192 {
+		t.Fatalf("Unexpected options: %+v", options.H264)
+	}
+	if options.JPEG != nil {
+		t.Errorf("Expected no JPEG options, got %+v", options.JPEG)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*VideoEncoderConfiguration)
+		field  string
+	}{
+		{"valid", func(*VideoEncoderConfiguration) {}, ""},
+		{"resolution", func(c *VideoEncoderConfiguration) { c.Resolution.Width = 640 }, "Resolution"},
+		{"quality", func(c *VideoEncoderConfiguration) { c.Quality = 11 }, "Quality"},
+		{"frame rate", func(c *VideoEncoderConfiguration) { c.RateControl.FrameRateLimit = 60 }, "RateControl.FrameRateLimit"},
+		{"bitrate", func(c *VideoEncoderConfiguration) { c.RateControl.BitrateLimit = 20000 }, "RateControl.BitrateLimit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := valid()
+			tt.modify(config)
+			before := sets.Load()
+			err := client.SetVideoEncoderConfiguration(ctx, config, false)
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("SetVideoEncoderConfiguration() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidEncoderConfig) || !strings.Contains(err.Error(), tt.field) {
+				t.Errorf("Expected ErrInvalidEncoderConfig naming %s, got %v", tt.field, err)
+			}
+			if sets.Load() != before {
+				t.Error("Invalid configuration was sent")
+			}
+		})
+	}
+}
+
+func TestGetProfilesWithURIs(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		request := string(body)
+
+		var response string
+		switch {
+		case strings.Contains(request, "GetProfiles"):
+			response = `<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Profiles token="main"><tt:Name>Main</tt:Name></trt:Profiles>
+				<trt:Profiles token="sub"><tt:Name>Sub</tt:Name></trt:Profiles>
+				<trt:Profiles token="third"><tt:Name>Third</tt:Name></trt:Profiles>
+			</trt:GetProfilesResponse>`
+		default:
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				peak := maxInFlight.Load()
+				if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+
+			token := request[strings.Index(request, "ProfileToken>")+len("ProfileToken>"):]
+			token = token[:strings.Index(token, "<")]
+			if strings.Contains(request, "GetSnapshotUri") {
+				if token == "sub" {
+					w.WriteHeader(http.StatusBadRequest)
+					response = `<s:Fault><s:Code><s:Value>s:Sender</s:Value></s:Code><s:Reason><s:Text xml:lang="en">no snapshot</s:Text></s:Reason></s:Fault>`
+					break
+				}
+				response = `<trt:GetSnapshotUriResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"><trt:MediaUri><tt:Uri xmlns:tt="http://www.onvif.org/ver10/schema">http://camera.test/` + token + `.jpg</tt:Uri></trt:MediaUri></trt:GetSnapshotUriResponse>`
+			} else {
+				response = `<trt:GetStreamUriResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"><trt:MediaUri><tt:Uri xmlns:tt="http://www.onvif.org/ver10/schema">rtsp://camera.test/` + token + `</tt:Uri></trt:MediaUri></trt:GetStreamUriResponse>`
+			}
+		}
+		_, _ = w.Write([]byte(`<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>` + response + `</s:Body></s:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	results, err := client.GetProfilesWithURIs(context.Background())
+	if err != nil {
+		t.Fatalf("GetProfilesWithURIs() error = %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 profiles, got %d", len(results))
+	}
+
+	for _, result := range results {
+		token := result.Profile.Token
+		if result.StreamErr != nil || result.StreamURI == nil || result.StreamURI.URI != "rtsp://camera.test/"+token {
+			t.Errorf("Profile %s: stream URI %+v, error %v", token, result.StreamURI, result.StreamErr)
+		}
+		if token == "sub" {
+			if result.SnapshotErr == nil || result.SnapshotURI != nil {
+				t.Errorf("Profile sub: expected a snapshot error, got %+v", result.SnapshotURI)
+			}
+			continue
+		}
+		if result.SnapshotErr != nil || result.SnapshotURI == nil || result.SnapshotURI.URI != "http://camera.test/"+token+".jpg" {
+			t.Errorf("Profile %s: snapshot URI %+v, error %v", token, result.SnapshotURI, result.SnapshotErr)
+		}
+	}
+	if results[0].Profile.Token != "main" || results[2].Profile.Token != "third" {
+		t.Errorf("Profiles out of order: %s, %s", results[0].Profile.Token, results[2].Profile.Token)
+	}
+
+	if peak := maxInFlight.Load(); peak < 2 || peak > profileURIConcurrency {
+		t.Errorf("Expected between 2 and %d concurrent URI requests, got %d", profileURIConcurrency, peak)
+	}
+}
@@ -0,0 +1,197 @@
+package onvif
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// NewMediaRequest creates an HTTP request for a URI returned by the device,
+// such as a snapshot URI, carrying the client's User-Agent and its
+// credentials as pre-emptive basic auth. Send it with DoMediaRequest to also
+// answer a digest challenge, which most cameras use for these URIs.
+func (c *Client) NewMediaRequest(ctx context.Context, method, uri string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("NewMediaRequest failed: %w", err)
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if id := RequestIDFromContext(ctx); id != "" && c.requestIDHdr != "" {
+		req.Header.Set(c.requestIDHdr, id)
+	}
+
+	username, password := c.GetCredentials()
+	if username != "" && !c.noAuth {
+		req.SetBasicAuth(username, password)
+	}
+
+	return req, nil
+}
+
+// DoMediaRequest sends a request made by NewMediaRequest with the client's
+// HTTP client. If the device answers 401 with a digest challenge, the request
+// is sent once more with a digest Authorization for the client's
+// credentials. Requests with a body are only retried if req.GetBody is set.
+// The caller must close the response body.
+func (c *Client) DoMediaRequest(req *http.Request) (*http.Response, error) {
+	resp, err := c.doHTTP(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	username, password := c.GetCredentials()
+	if username == "" || c.noAuth || (req.Body != nil && req.GetBody == nil) {
+		return resp, nil
+	}
+	challenge, ok := parseDigestChallenge(resp.Header.Values("WWW-Authenticate"))
+	if !ok {
+		return resp, nil
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	retry.Header.Set("Authorization", challenge.authorization(req.Method, req.URL.RequestURI(), username, password, newCnonce()))
+
+	return c.doHTTP(retry)
+}
+
+// digestChallenge holds the parameters of an HTTP digest challenge
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string // MD5 or SHA-256
+	qop       string // auth, or empty for the RFC 2069 form
+}
+
+// parseDigestChallenge finds a digest challenge among WWW-Authenticate values
+// that uses an algorithm and qop this client implements
+func parseDigestChallenge(values []string) (*digestChallenge, bool) {
+	for _, value := range values {
+		scheme, rest, _ := strings.Cut(strings.TrimSpace(value), " ")
+		if !strings.EqualFold(scheme, "Digest") {
+			continue
+		}
+
+		params := parseAuthParams(rest)
+		challenge := &digestChallenge{
+			realm:     params["realm"],
+			nonce:     params["nonce"],
+			opaque:    params["opaque"],
+			algorithm: strings.ToUpper(params["algorithm"]),
+		}
+		if challenge.algorithm == "" {
+			challenge.algorithm = "MD5"
+		}
+		if challenge.nonce == "" || (challenge.algorithm != "MD5" && challenge.algorithm != "SHA-256") {
+			continue
+		}
+		if qop, ok := params["qop"]; ok {
+			for _, option := range strings.Split(qop, ",") {
+				if strings.TrimSpace(option) == "auth" {
+					challenge.qop = "auth"
+				}
+			}
+			if challenge.qop == "" {
+				continue
+			}
+		}
+		return challenge, true
+	}
+	return nil, false
+}
+
+// parseAuthParams splits the comma separated key=value parameters of an
+// authentication challenge, unquoting quoted values
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for s != "" {
+		s = strings.TrimLeft(s, " ,")
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		rest = strings.TrimLeft(rest, " ")
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				b.WriteByte(rest[i])
+			}
+			value, s = b.String(), rest[min(i+1, len(rest)):]
+		} else {
+			value, s, _ = strings.Cut(rest, ",")
+			value = strings.TrimSpace(value)
+		}
+		params[key] = value
+	}
+	return params
+}
+
+// authorization returns the Authorization header answering the challenge
+func (d *digestChallenge) authorization(method, uri, username, password, cnonce string) string {
+	newHash := md5.New
+	if d.algorithm == "SHA-256" {
+		newHash = sha256.New
+	}
+	h := func(s string) string {
+		return digestHash(newHash, s)
+	}
+
+	ha1 := h(username + ":" + d.realm + ":" + password)
+	ha2 := h(method + ":" + uri)
+
+	const nc = "00000001" // Each challenge is answered once
+	var response string
+	if d.qop != "" {
+		response = h(ha1 + ":" + d.nonce + ":" + nc + ":" + cnonce + ":" + d.qop + ":" + ha2)
+	} else {
+		response = h(ha1 + ":" + d.nonce + ":" + ha2)
+	}
+
+	header := fmt.Sprintf(`Digest username=%q, realm=%q, nonce=%q, uri=%q, algorithm=%s, response=%q`,
+		username, d.realm, d.nonce, uri, d.algorithm, response)
+	if d.qop != "" {
+		header += fmt.Sprintf(`, qop=%s, nc=%s, cnonce=%q`, d.qop, nc, cnonce)
+	}
+	if d.opaque != "" {
+		header += fmt.Sprintf(`, opaque=%q`, d.opaque)
+	}
+	return header
+}
+
+// digestHash returns the hex digest of s
+func digestHash(newHash func() hash.Hash, s string) string {
+	hasher := newHash()
+	hasher.Write([]byte(s))
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// newCnonce returns a random client nonce
+func newCnonce() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b) // rand.Read always returns len(b), nil
+	return hex.EncodeToString(b)
+}
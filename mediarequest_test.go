@@ -0,0 +1,94 @@
+package onvif
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDigestAuthorization(t *testing.T) {
+	// Example from RFC 2617 section 3.5
+	challenge, ok := parseDigestChallenge([]string{
+		`Basic realm="testrealm@host.com"`,
+		`Digest realm="testrealm@host.com", qop="auth,auth-int", nonce="dcd98b7102dd2f0e8b11d0f600bfb0c093", opaque="5ccc069c403ebaf9f0171e9517f40e41"`,
+	})
+	if !ok {
+		t.Fatal("Expected the digest challenge to be found")
+	}
+	if challenge.qop != "auth" || challenge.algorithm != "MD5" || challenge.opaque != "5ccc069c403ebaf9f0171e9517f40e41" {
+		t.Errorf("Unexpected challenge: %+v", challenge)
+	}
+
+	header := challenge.authorization("GET", "/dir/index.html", "Mufasa", "Circle Of Life", "0a4f113b")
+	params := parseAuthParams(strings.TrimPrefix(header, "Digest "))
+	if params["response"] != "6629fae49393a05397450978507c4ef1" {
+		t.Errorf("Unexpected response in %s", header)
+	}
+	if params["nc"] != "00000001" || params["cnonce"] != "0a4f113b" || params["opaque"] != challenge.opaque {
+		t.Errorf("Unexpected parameters in %s", header)
+	}
+
+	if _, ok := parseDigestChallenge([]string{`Digest realm="r", nonce="n", algorithm=SHA-512-256`}); ok {
+		t.Error("Expected an unsupported algorithm to be skipped")
+	}
+	if _, ok := parseDigestChallenge([]string{`Digest realm="r", nonce="n", qop="auth-int"`}); ok {
+		t.Error("Expected an unsupported qop to be skipped")
+	}
+}
+
+func TestDoMediaRequest(t *testing.T) {
+	var authorizations []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		authorizations = append(authorizations, auth)
+		if r.Header.Get("User-Agent") != "MyVMS/2.0" {
+			t.Errorf("Unexpected User-Agent %q", r.Header.Get("User-Agent"))
+		}
+
+		if !strings.HasPrefix(auth, "Digest ") {
+			w.Header().Set("WWW-Authenticate", `Digest realm="camera", qop="auth", nonce="abc123", algorithm=SHA-256`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		params := parseAuthParams(strings.TrimPrefix(auth, "Digest "))
+		challenge := &digestChallenge{realm: "camera", nonce: "abc123", algorithm: "SHA-256", qop: "auth"}
+		want := parseAuthParams(strings.TrimPrefix(challenge.authorization(r.Method, r.URL.RequestURI(), "admin", "password", params["cnonce"]), "Digest "))
+		if params["response"] != want["response"] || params["uri"] != "/snapshot.jpg?channel=1" {
+			t.Errorf("Unexpected digest authorization %s", auth)
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write([]byte("jpeg"))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithCredentials("admin", "password"), WithUserAgent("MyVMS/2.0"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	req, err := client.NewMediaRequest(context.Background(), http.MethodGet, server.URL+"/snapshot.jpg?channel=1")
+	if err != nil {
+		t.Fatalf("NewMediaRequest() error = %v", err)
+	}
+	if username, password, ok := req.BasicAuth(); !ok || username != "admin" || password != "password" {
+		t.Errorf("Expected pre-emptive basic auth, got %q %q %v", username, password, ok)
+	}
+
+	resp, err := client.DoMediaRequest(req)
+	if err != nil {
+		t.Fatalf("DoMediaRequest() error = %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "jpeg" {
+		t.Errorf("Unexpected response %d %q", resp.StatusCode, body)
+	}
+	if len(authorizations) != 2 || !strings.HasPrefix(authorizations[0], "Basic ") {
+		t.Errorf("Expected a basic attempt followed by a digest retry, got %v", authorizations)
+	}
+}
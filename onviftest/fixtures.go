@@ -0,0 +1,119 @@
+package onviftest
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+// Envelope wraps SOAP body content in a SOAP 1.2 envelope
+func Envelope(body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>` + body + `</s:Body></s:Envelope>`
+}
+
+// Fault returns a SOAP fault with a code such as "Sender" or "Receiver" and
+// optional nested subcodes such as "ter:InvalidArgVal"
+func Fault(code, reason string, subcodes ...string) string {
+	var codeXML strings.Builder
+	codeXML.WriteString("<s:Code><s:Value>s:" + code + "</s:Value>")
+	for _, subcode := range subcodes {
+		codeXML.WriteString("<s:Subcode><s:Value>" + escape(subcode) + "</s:Value>")
+	}
+	codeXML.WriteString(strings.Repeat("</s:Subcode>", len(subcodes)) + "</s:Code>")
+
+	return `<s:Fault xmlns:ter="http://www.onvif.org/ver10/error">` + codeXML.String() +
+		`<s:Reason><s:Text xml:lang="en">` + escape(reason) + `</s:Text></s:Reason></s:Fault>`
+}
+
+// DeviceInformation returns a GetDeviceInformation response
+func DeviceInformation(manufacturer, model, firmwareVersion, serialNumber, hardwareID string) string {
+	return `<tds:GetDeviceInformationResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">` +
+		`<tds:Manufacturer>` + escape(manufacturer) + `</tds:Manufacturer>` +
+		`<tds:Model>` + escape(model) + `</tds:Model>` +
+		`<tds:FirmwareVersion>` + escape(firmwareVersion) + `</tds:FirmwareVersion>` +
+		`<tds:SerialNumber>` + escape(serialNumber) + `</tds:SerialNumber>` +
+		`<tds:HardwareId>` + escape(hardwareID) + `</tds:HardwareId>` +
+		`</tds:GetDeviceInformationResponse>`
+}
+
+// Capabilities returns a GetCapabilities response advertising the named
+// services, e.g. "Device", "Media", "PTZ", "Imaging" and "Events", at
+// baseURL + "/onvif/<service>_service". Without services, all five are
+// advertised.
+func Capabilities(baseURL string, services ...string) string {
+	if len(services) == 0 {
+		services = []string{"Device", "Media", "PTZ", "Imaging", "Events"}
+	}
+
+	var b strings.Builder
+	b.WriteString(`<tds:GetCapabilitiesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema"><tds:Capabilities>`)
+	for _, service := range services {
+		xaddr := strings.TrimSuffix(baseURL, "/") + "/onvif/" + strings.ToLower(service) + "_service"
+		fmt.Fprintf(&b, `<tt:%s><tt:XAddr>%s</tt:XAddr></tt:%s>`, service, escape(xaddr), service)
+	}
+	b.WriteString(`</tds:Capabilities></tds:GetCapabilitiesResponse>`)
+	return b.String()
+}
+
+// Profile describes a media profile for Profiles
+type Profile struct {
+	Token    string
+	Name     string
+	Encoding string // Video encoding such as "H264"; no video encoder configuration if empty
+	Width    int
+	Height   int
+}
+
+// Profiles returns a GetProfiles response
+func Profiles(profiles ...Profile) string {
+	var b strings.Builder
+	b.WriteString(`<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">`)
+	for _, p := range profiles {
+		fmt.Fprintf(&b, `<trt:Profiles token="%s" fixed="true"><tt:Name>%s</tt:Name>`, escape(p.Token), escape(p.Name))
+		if p.Encoding != "" {
+			fmt.Fprintf(&b, `<tt:VideoEncoderConfiguration token="%s_encoder"><tt:Name>%s</tt:Name><tt:Encoding>%s</tt:Encoding>`+
+				`<tt:Resolution><tt:Width>%d</tt:Width><tt:Height>%d</tt:Height></tt:Resolution></tt:VideoEncoderConfiguration>`,
+				escape(p.Token), escape(p.Name), escape(p.Encoding), p.Width, p.Height)
+		}
+		b.WriteString(`</trt:Profiles>`)
+	}
+	b.WriteString(`</trt:GetProfilesResponse>`)
+	return b.String()
+}
+
+// StreamURI returns a GetStreamUri response
+func StreamURI(uri string) string {
+	return mediaURI("GetStreamUriResponse", uri)
+}
+
+// SnapshotURI returns a GetSnapshotUri response
+func SnapshotURI(uri string) string {
+	return mediaURI("GetSnapshotUriResponse", uri)
+}
+
+func mediaURI(response, uri string) string {
+	return `<trt:` + response + ` xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">` +
+		`<trt:MediaUri><tt:Uri>` + escape(uri) + `</tt:Uri><tt:InvalidAfterConnect>false</tt:InvalidAfterConnect>` +
+		`<tt:InvalidAfterReboot>false</tt:InvalidAfterReboot><tt:Timeout>PT0S</tt:Timeout></trt:MediaUri>` +
+		`</trt:` + response + `>`
+}
+
+// SystemDateAndTime returns a GetSystemDateAndTime response reporting t as
+// the device's UTC time
+func SystemDateAndTime(t time.Time) string {
+	t = t.UTC()
+	return fmt.Sprintf(`<tds:GetSystemDateAndTimeResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">`+
+		`<tds:SystemDateAndTime><tt:DateTimeType>Manual</tt:DateTimeType><tt:DaylightSavings>false</tt:DaylightSavings>`+
+		`<tt:TimeZone><tt:TZ>UTC</tt:TZ></tt:TimeZone>`+
+		`<tt:UTCDateTime><tt:Time><tt:Hour>%d</tt:Hour><tt:Minute>%d</tt:Minute><tt:Second>%d</tt:Second></tt:Time>`+
+		`<tt:Date><tt:Year>%d</tt:Year><tt:Month>%d</tt:Month><tt:Day>%d</tt:Day></tt:Date></tt:UTCDateTime>`+
+		`</tds:SystemDateAndTime></tds:GetSystemDateAndTimeResponse>`,
+		t.Hour(), t.Minute(), t.Second(), t.Year(), int(t.Month()), t.Day())
+}
+
+// escape escapes text for use in XML character data and attribute values
+func escape(s string) string {
+	return html.EscapeString(s)
+}
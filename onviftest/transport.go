@@ -0,0 +1,179 @@
+// Package onviftest provides an HTTP transport that answers ONVIF SOAP
+// requests with canned responses, and builders for common responses, for
+// testing code that uses an *onvif.Client without a camera or a test server.
+//
+//	mock := onviftest.NewMockTransport().
+//	    Handle("GetDeviceInformation", onviftest.DeviceInformation("Acme", "Cam 1", "1.0", "SN1", "HW1")).
+//	    Handle("GetProfiles", onviftest.Profiles(onviftest.Profile{Token: "main", Name: "Main"}))
+//
+//	client, err := onvif.NewClient("http://camera.test/onvif/device_service",
+//	    onvif.WithHTTPClient(mock.Client()))
+package onviftest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Request is a SOAP request received by a MockTransport
+type Request struct {
+	Operation string // Local name of the first element in the SOAP body
+	URL       string
+	Body      string
+}
+
+// response is a canned HTTP response
+type response struct {
+	status int
+	body   string
+}
+
+// MockTransport is an http.RoundTripper that answers SOAP requests with
+// canned responses keyed by operation name. Operations without a response
+// are answered with an ActionNotSupported fault. It is safe for concurrent
+// use.
+type MockTransport struct {
+	mu        sync.Mutex
+	responses map[string]response
+	requests  []Request
+}
+
+// NewMockTransport creates a transport without any responses
+func NewMockTransport() *MockTransport {
+	return &MockTransport{
+		responses: make(map[string]response),
+	}
+}
+
+// Handle sets the response to an operation, replacing any earlier one. The
+// response is either a full SOAP envelope or the body content, such as one
+// returned by the builders in this package, which is wrapped in an envelope.
+func (m *MockTransport) Handle(operation, body string) *MockTransport {
+	return m.handle(operation, http.StatusOK, body)
+}
+
+// HandleFault answers an operation with a SOAP fault, e.g.
+// HandleFault("GetNode", "Sender", "node not found", "ter:InvalidArgVal", "ter:NoEntity")
+func (m *MockTransport) HandleFault(operation, code, reason string, subcodes ...string) *MockTransport {
+	status := http.StatusInternalServerError
+	if code == "Sender" {
+		status = http.StatusBadRequest
+	}
+	return m.handle(operation, status, Fault(code, reason, subcodes...))
+}
+
+func (m *MockTransport) handle(operation string, status int, body string) *MockTransport {
+	if rootElement(body) != "Envelope" {
+		body = Envelope(body)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.responses[operation] = response{status: status, body: body}
+	return m
+}
+
+// Client returns an HTTP client using the transport, to pass to
+// onvif.WithHTTPClient
+func (m *MockTransport) Client() *http.Client {
+	return &http.Client{Transport: m}
+}
+
+// Requests returns the requests received so far, oldest first
+func (m *MockTransport) Requests() []Request {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Request(nil), m.requests...)
+}
+
+// Calls returns how many requests for an operation were received
+func (m *MockTransport) Calls(operation string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	calls := 0
+	for _, req := range m.requests {
+		if req.Operation == operation {
+			calls++
+		}
+	}
+	return calls
+}
+
+// RoundTrip implements http.RoundTripper
+func (m *MockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request: %w", err)
+		}
+	}
+	operation := operationName(body)
+
+	m.mu.Lock()
+	m.requests = append(m.requests, Request{Operation: operation, URL: req.URL.String(), Body: string(body)})
+	resp, ok := m.responses[operation]
+	m.mu.Unlock()
+
+	if !ok {
+		resp = response{
+			status: http.StatusInternalServerError,
+			body:   Envelope(Fault("Receiver", "no mock response for "+operation, "ter:ActionNotSupported")),
+		}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.status, http.StatusText(resp.status)),
+		StatusCode:    resp.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/soap+xml; charset=utf-8"}},
+		Body:          io.NopCloser(strings.NewReader(resp.body)),
+		ContentLength: int64(len(resp.body)),
+		Request:       req,
+	}, nil
+}
+
+// rootElement returns the local name of the first element in data, or ""
+func rootElement(data string) string {
+	decoder := xml.NewDecoder(strings.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
+
+// operationName returns the local name of the first element in a SOAP body,
+// or "" if there is none
+func operationName(envelope []byte) string {
+	decoder := xml.NewDecoder(bytes.NewReader(envelope))
+	inBody := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			if inBody {
+				return start.Name.Local
+			}
+			inBody = start.Name.Local == "Body"
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package onviftest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/0x524a/onvif-go"
+	"github.com/0x524a/onvif-go/internal/soap"
+	"github.com/0x524a/onvif-go/onviftest"
+)
+
+func TestMockTransport(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 30, 15, 0, time.UTC)
+	mock := onviftest.NewMockTransport().
+		Handle("GetDeviceInformation", onviftest.DeviceInformation("Acme & Co", "Cam 1", "1.0", "SN1", "HW1")).
+		Handle("GetCapabilities", onviftest.Capabilities("http://camera.test", "Device", "Media", "PTZ")).
+		Handle("GetProfiles", onviftest.Profiles(
+			onviftest.Profile{Token: "main", Name: "Main", Encoding: "H264", Width: 1920, Height: 1080},
+			onviftest.Profile{Token: "sub", Name: "Sub"},
+		)).
+		Handle("GetStreamUri", onviftest.StreamURI("rtsp://camera.test/main")).
+		Handle("GetSnapshotUri", onviftest.SnapshotURI("http://camera.test/snapshot.jpg?a=1&b=2")).
+		Handle("GetSystemDateAndTime", onviftest.SystemDateAndTime(now)).
+		HandleFault("GetNode", "Sender", "node not found", "ter:InvalidArgVal", "ter:NoEntity")
+
+	client, err := onvif.NewClient("http://camera.test/onvif/device_service", onvif.WithHTTPClient(mock.Client()))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	ctx := context.Background()
+
+	info, err := client.GetDeviceInformation(ctx)
+	if err != nil {
+		t.Fatalf("GetDeviceInformation() error = %v", err)
+	}
+	if info.Manufacturer != "Acme & Co" || info.HardwareID != "HW1" {
+		t.Errorf("Unexpected device information: %+v", info)
+	}
+
+	if err := client.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if client.MediaEndpoint() != "http://camera.test/onvif/media_service" {
+		t.Errorf("Unexpected media endpoint %q", client.MediaEndpoint())
+	}
+
+	profiles, err := client.GetProfiles(ctx)
+	if err != nil {
+		t.Fatalf("GetProfiles() error = %v", err)
+	}
+	if len(profiles) != 2 || profiles[0].VideoEncoderConfiguration == nil || profiles[0].VideoEncoderConfiguration.Resolution.Width != 1920 {
+		t.Fatalf("Unexpected profiles: %+v", profiles)
+	}
+	if profiles[1].VideoEncoderConfiguration != nil {
+		t.Errorf("Expected no encoder for the second profile, got %+v", profiles[1].VideoEncoderConfiguration)
+	}
+
+	stream, err := client.GetStreamURI(ctx, "main")
+	if err != nil || stream.URI != "rtsp://camera.test/main" {
+		t.Errorf("GetStreamURI() = %+v, %v", stream, err)
+	}
+	snapshot, err := client.GetSnapshotURI(ctx, "main")
+	if err != nil || snapshot.URI != "http://camera.test/snapshot.jpg?a=1&b=2" {
+		t.Errorf("GetSnapshotURI() = %+v, %v", snapshot, err)
+	}
+
+	dateTime, err := client.GetSystemDateAndTime(ctx)
+	if err != nil || !dateTime.UTCDateTime.Equal(now) {
+		t.Errorf("GetSystemDateAndTime() = %+v, %v", dateTime, err)
+	}
+
+	var httpErr *soap.HTTPError
+	if _, err := client.GetNode(ctx, "PTZNode_1"); !errors.As(err, &httpErr) || httpErr.StatusCode != 400 {
+		t.Errorf("Expected a fault from GetNode, got %v", err)
+	}
+	if _, err := client.GetPresets(ctx, "main"); !errors.As(err, &httpErr) || httpErr.StatusCode != 500 {
+		t.Errorf("Expected an unhandled operation to fail, got %v", err)
+	}
+
+	if calls := mock.Calls("GetProfiles"); calls != 1 {
+		t.Errorf("Expected 1 GetProfiles call, got %d", calls)
+	}
+	requests := mock.Requests()
+	if len(requests) == 0 || requests[0].Operation != "GetDeviceInformation" || requests[0].URL != "http://camera.test/onvif/device_service" {
+		t.Errorf("Unexpected first request: %+v", requests)
+	}
+}
@@ -4,14 +4,18 @@ import (
 	"context"
 	"encoding/xml"
 	"fmt"
-
-	"github.com/0x524a/onvif-go/internal/soap"
+	"math"
+	"strings"
+	"time"
 )
 
 // PTZ service namespace
 const ptzNamespace = "http://www.onvif.org/ver20/ptz/wsdl"
 
-// ContinuousMove starts continuous PTZ movement
+// ContinuousMove starts continuous PTZ movement. The optional timeout is an
+// ISO8601 duration string such as "PT2S".
+//
+// Deprecated: Use ContinuousMoveFor, which takes a time.Duration.
 func (c *Client) ContinuousMove(ctx context.Context, profileToken string, velocity *PTZSpeed, timeout *string) error {
 	endpoint := c.ptzEndpoint
 	if endpoint == "" {
@@ -39,7 +43,16 @@ func (c *Client) ContinuousMove(ctx context.Context, profileToken string, veloci
 	req := ContinuousMove{
 		Xmlns:        ptzNamespace,
 		ProfileToken: profileToken,
-		Timeout:      timeout,
+	}
+
+	// Validate and normalize the timeout so malformed values fail locally
+	if timeout != nil {
+		d, err := ParseDuration(*timeout)
+		if err != nil {
+			return fmt.Errorf("%w: invalid timeout: %v", ErrInvalidParameter, err)
+		}
+		normalized := FormatDuration(d)
+		req.Timeout = &normalized
 	}
 
 	if velocity != nil {
@@ -78,8 +91,7 @@ func (c *Client) ContinuousMove(ctx context.Context, profileToken string, veloci
 		}
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("ContinuousMove failed: %w", err)
@@ -88,6 +100,17 @@ func (c *Client) ContinuousMove(ctx context.Context, profileToken string, veloci
 	return nil
 }
 
+// ContinuousMoveFor starts continuous PTZ movement that the device stops after d.
+// A zero duration omits the timeout so the device's default applies.
+func (c *Client) ContinuousMoveFor(ctx context.Context, profileToken string, velocity *PTZSpeed, d time.Duration) error {
+	if d == 0 {
+		return c.ContinuousMove(ctx, profileToken, velocity, nil)
+	}
+
+	timeout := FormatDuration(d)
+	return c.ContinuousMove(ctx, profileToken, velocity, &timeout)
+}
+
 // AbsoluteMove moves PTZ to an absolute position
 func (c *Client) AbsoluteMove(ctx context.Context, profileToken string, position *PTZVector, speed *PTZSpeed) error {
 	endpoint := c.ptzEndpoint
@@ -95,6 +118,17 @@ func (c *Client) AbsoluteMove(ctx context.Context, profileToken string, position
 		return ErrServiceNotSupported
 	}
 
+	if c.validateMove {
+		if node := c.moveNode(ctx, profileToken); node != nil && node.SupportedPTZSpaces != nil {
+			spaces := node.SupportedPTZSpaces
+			err := checkVector(position, spaces.AbsolutePanTiltPositionSpace, spaces.AbsoluteZoomPositionSpace,
+				PanTiltPositionGenericSpace, ZoomPositionGenericSpace)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	type AbsoluteMove struct {
 		XMLName      xml.Name `xml:"tptz:AbsoluteMove"`
 		Xmlns        string   `xml:"xmlns:tptz,attr"`
@@ -200,8 +234,7 @@ func (c *Client) AbsoluteMove(ctx context.Context, profileToken string, position
 		}
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("AbsoluteMove failed: %w", err)
@@ -210,6 +243,52 @@ func (c *Client) AbsoluteMove(ctx context.Context, profileToken string, position
 	return nil
 }
 
+// AbsoluteMoveAndWait moves PTZ to an absolute position and blocks until the
+// device reports both pan/tilt and zoom as IDLE, polling GetStatus every
+// pollInterval. It returns early with ErrPTZFault if the status reports an
+// error, and with ctx.Err() once ctx is done.
+func (c *Client) AbsoluteMoveAndWait(ctx context.Context, profileToken string, position *PTZVector, speed *PTZSpeed, pollInterval time.Duration) error {
+	if pollInterval <= 0 {
+		return fmt.Errorf("%w: poll interval must be positive", ErrInvalidParameter)
+	}
+
+	if err := c.AbsoluteMove(ctx, profileToken, position, speed); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	// Wait before the first poll so the device has a chance to start moving
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		status, err := c.GetStatus(ctx, profileToken)
+		if err != nil {
+			return err
+		}
+
+		if msg := strings.TrimSpace(status.Error); msg != "" && !strings.EqualFold(msg, "NO error") {
+			return fmt.Errorf("%w: %s", ErrPTZFault, msg)
+		}
+
+		move := status.MoveStatus
+		if move == nil || move.PanTilt == "" && move.Zoom == "" {
+			return fmt.Errorf("%w: device does not report PTZ move status", ErrInvalidResponse)
+		}
+
+		// An axis the device does not report, such as zoom on a fixed lens,
+		// counts as idle
+		if (move.PanTilt == "" || move.PanTilt == "IDLE") && (move.Zoom == "" || move.Zoom == "IDLE") {
+			return nil
+		}
+	}
+}
+
 // RelativeMove moves PTZ relative to current position
 func (c *Client) RelativeMove(ctx context.Context, profileToken string, translation *PTZVector, speed *PTZSpeed) error {
 	endpoint := c.ptzEndpoint
@@ -217,6 +296,17 @@ func (c *Client) RelativeMove(ctx context.Context, profileToken string, translat
 		return ErrServiceNotSupported
 	}
 
+	if c.validateMove {
+		if node := c.moveNode(ctx, profileToken); node != nil && node.SupportedPTZSpaces != nil {
+			spaces := node.SupportedPTZSpaces
+			err := checkVector(translation, spaces.RelativePanTiltTranslationSpace, spaces.RelativeZoomTranslationSpace,
+				PanTiltTranslationGenericSpace, ZoomTranslationGenericSpace)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	type RelativeMove struct {
 		XMLName      xml.Name `xml:"tptz:RelativeMove"`
 		Xmlns        string   `xml:"xmlns:tptz,attr"`
@@ -322,8 +412,7 @@ func (c *Client) RelativeMove(ctx context.Context, profileToken string, translat
 		}
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("RelativeMove failed: %w", err)
@@ -359,8 +448,7 @@ func (c *Client) Stop(ctx context.Context, profileToken string, panTilt, zoom bo
 		req.Zoom = &zoom
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("Stop failed: %w", err)
@@ -412,8 +500,7 @@ func (c *Client) GetStatus(ctx context.Context, profileToken string) (*PTZStatus
 
 	var resp GetStatusResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetStatus failed: %w", err)
@@ -489,8 +576,7 @@ func (c *Client) GetPresets(ctx context.Context, profileToken string) ([]*PTZPre
 
 	var resp GetPresetsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetPresets failed: %w", err)
@@ -593,8 +679,7 @@ func (c *Client) GotoPreset(ctx context.Context, profileToken, presetToken strin
 		}
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("GotoPreset failed: %w", err)
@@ -637,8 +722,7 @@ func (c *Client) SetPreset(ctx context.Context, profileToken, presetName, preset
 
 	var resp SetPresetResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return "", fmt.Errorf("SetPreset failed: %w", err)
@@ -667,8 +751,7 @@ func (c *Client) RemovePreset(ctx context.Context, profileToken, presetToken str
 		PresetToken:  presetToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("RemovePreset failed: %w", err)
@@ -742,8 +825,7 @@ func (c *Client) GotoHomePosition(ctx context.Context, profileToken string, spee
 		}
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("GotoHomePosition failed: %w", err)
@@ -770,8 +852,7 @@ func (c *Client) SetHomePosition(ctx context.Context, profileToken string) error
 		ProfileToken: profileToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetHomePosition failed: %w", err)
@@ -810,8 +891,7 @@ func (c *Client) GetConfiguration(ctx context.Context, configurationToken string
 
 	var resp GetConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetConfiguration failed: %w", err)
@@ -853,8 +933,7 @@ func (c *Client) GetConfigurations(ctx context.Context) ([]*PTZConfiguration, er
 
 	var resp GetConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.soapClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetConfigurations failed: %w", err)
@@ -872,3 +951,892 @@ func (c *Client) GetConfigurations(ctx context.Context) ([]*PTZConfiguration, er
 
 	return configs, nil
 }
+
+// GetPTZServiceCapabilities retrieves the capabilities of the PTZ service
+func (c *Client) GetPTZServiceCapabilities(ctx context.Context) (*PTZServiceCapabilities, error) {
+	endpoint := c.ptzEndpoint
+	if endpoint == "" {
+		return nil, ErrServiceNotSupported
+	}
+
+	type GetServiceCapabilities struct {
+		XMLName xml.Name `xml:"tptz:GetServiceCapabilities"`
+		Xmlns   string   `xml:"xmlns:tptz,attr"`
+	}
+
+	type GetServiceCapabilitiesResponse struct {
+		XMLName      xml.Name `xml:"GetServiceCapabilitiesResponse"`
+		Capabilities struct {
+			EFlip                       bool `xml:"EFlip,attr"`
+			Reverse                     bool `xml:"Reverse,attr"`
+			GetCompatibleConfigurations bool `xml:"GetCompatibleConfigurations,attr"`
+			MoveStatus                  bool `xml:"MoveStatus,attr"`
+			StatusPosition              bool `xml:"StatusPosition,attr"`
+		} `xml:"Capabilities"`
+	}
+
+	req := GetServiceCapabilities{
+		Xmlns: ptzNamespace,
+	}
+
+	var resp GetServiceCapabilitiesResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetServiceCapabilities failed: %w", err)
+	}
+
+	return &PTZServiceCapabilities{
+		EFlip:                       resp.Capabilities.EFlip,
+		Reverse:                     resp.Capabilities.Reverse,
+		GetCompatibleConfigurations: resp.Capabilities.GetCompatibleConfigurations,
+		MoveStatus:                  resp.Capabilities.MoveStatus,
+		StatusPosition:              resp.Capabilities.StatusPosition,
+	}, nil
+}
+
+// Preset tour operations accepted by OperatePresetTour
+const (
+	PresetTourOperationStart = "Start"
+	PresetTourOperationStop  = "Stop"
+	PresetTourOperationPause = "Pause"
+)
+
+// tourSpotXML is the wire form of tt:PTZPresetTourSpot
+type tourSpotXML struct {
+	PresetDetail struct {
+		PresetToken string `xml:"PresetToken"`
+	} `xml:"PresetDetail"`
+	Speed *struct {
+		PanTilt *struct {
+			X     float64 `xml:"x,attr"`
+			Y     float64 `xml:"y,attr"`
+			Space string  `xml:"space,attr,omitempty"`
+		} `xml:"PanTilt"`
+		Zoom *struct {
+			X     float64 `xml:"x,attr"`
+			Space string  `xml:"space,attr,omitempty"`
+		} `xml:"Zoom"`
+	} `xml:"Speed"`
+	StayTime string `xml:"StayTime"`
+}
+
+// toTourSpot converts the wire form into a TourSpot. A malformed StayTime is left zero.
+func (x *tourSpotXML) toTourSpot() *TourSpot {
+	spot := &TourSpot{
+		PresetToken: x.PresetDetail.PresetToken,
+	}
+
+	if x.StayTime != "" {
+		if d, err := ParseDuration(x.StayTime); err == nil {
+			spot.StayTime = d
+		}
+	}
+
+	if x.Speed != nil {
+		spot.Speed = &PTZSpeed{}
+		if x.Speed.PanTilt != nil {
+			spot.Speed.PanTilt = &Vector2D{
+				X:     x.Speed.PanTilt.X,
+				Y:     x.Speed.PanTilt.Y,
+				Space: x.Speed.PanTilt.Space,
+			}
+		}
+		if x.Speed.Zoom != nil {
+			spot.Speed.Zoom = &Vector1D{
+				X:     x.Speed.Zoom.X,
+				Space: x.Speed.Zoom.Space,
+			}
+		}
+	}
+
+	return spot
+}
+
+// presetTourXML is the wire form of tt:PresetTour
+type presetTourXML struct {
+	Token  string `xml:"token,attr"`
+	Name   string `xml:"Name"`
+	Status *struct {
+		State           string       `xml:"State"`
+		CurrentTourSpot *tourSpotXML `xml:"CurrentTourSpot"`
+	} `xml:"Status"`
+	AutoStart         bool `xml:"AutoStart"`
+	StartingCondition *struct {
+		RandomPresetOrder bool   `xml:"RandomPresetOrder,attr"`
+		RecurringTime     int    `xml:"RecurringTime"`
+		RecurringDuration string `xml:"RecurringDuration"`
+		Direction         string `xml:"Direction"`
+	} `xml:"StartingCondition"`
+	TourSpot []tourSpotXML `xml:"TourSpot"`
+}
+
+// toPresetTour converts the wire form into a PresetTour
+func (x *presetTourXML) toPresetTour() *PresetTour {
+	tour := &PresetTour{
+		Token:     x.Token,
+		Name:      x.Name,
+		AutoStart: x.AutoStart,
+	}
+
+	if x.Status != nil {
+		tour.Status = &PresetTourStatus{
+			State: x.Status.State,
+		}
+		if x.Status.CurrentTourSpot != nil {
+			tour.Status.CurrentTourSpot = x.Status.CurrentTourSpot.toTourSpot()
+		}
+	}
+
+	if x.StartingCondition != nil {
+		tour.StartingCondition = &PresetTourStartingCondition{
+			RecurringTime:     x.StartingCondition.RecurringTime,
+			Direction:         x.StartingCondition.Direction,
+			RandomPresetOrder: x.StartingCondition.RandomPresetOrder,
+		}
+		if x.StartingCondition.RecurringDuration != "" {
+			if d, err := ParseDuration(x.StartingCondition.RecurringDuration); err == nil {
+				tour.StartingCondition.RecurringDuration = d
+			}
+		}
+	}
+
+	for i := range x.TourSpot {
+		tour.TourSpots = append(tour.TourSpots, x.TourSpot[i].toTourSpot())
+	}
+
+	return tour
+}
+
+// GetPresetTours retrieves the preset tours of a profile
+func (c *Client) GetPresetTours(ctx context.Context, profileToken string) ([]*PresetTour, error) {
+	endpoint := c.ptzEndpoint
+	if endpoint == "" {
+		return nil, ErrServiceNotSupported
+	}
+
+	type GetPresetTours struct {
+		XMLName      xml.Name `xml:"tptz:GetPresetTours"`
+		Xmlns        string   `xml:"xmlns:tptz,attr"`
+		ProfileToken string   `xml:"tptz:ProfileToken"`
+	}
+
+	type GetPresetToursResponse struct {
+		XMLName    xml.Name        `xml:"GetPresetToursResponse"`
+		PresetTour []presetTourXML `xml:"PresetTour"`
+	}
+
+	req := GetPresetTours{
+		Xmlns:        ptzNamespace,
+		ProfileToken: profileToken,
+	}
+
+	var resp GetPresetToursResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetPresetTours failed: %w", err)
+	}
+
+	tours := make([]*PresetTour, len(resp.PresetTour))
+	for i := range resp.PresetTour {
+		tours[i] = resp.PresetTour[i].toPresetTour()
+	}
+
+	return tours, nil
+}
+
+// GetPresetTour retrieves a single preset tour
+func (c *Client) GetPresetTour(ctx context.Context, profileToken, presetTourToken string) (*PresetTour, error) {
+	endpoint := c.ptzEndpoint
+	if endpoint == "" {
+		return nil, ErrServiceNotSupported
+	}
+
+	type GetPresetTour struct {
+		XMLName         xml.Name `xml:"tptz:GetPresetTour"`
+		Xmlns           string   `xml:"xmlns:tptz,attr"`
+		ProfileToken    string   `xml:"tptz:ProfileToken"`
+		PresetTourToken string   `xml:"tptz:PresetTourToken"`
+	}
+
+	type GetPresetTourResponse struct {
+		XMLName    xml.Name      `xml:"GetPresetTourResponse"`
+		PresetTour presetTourXML `xml:"PresetTour"`
+	}
+
+	req := GetPresetTour{
+		Xmlns:           ptzNamespace,
+		ProfileToken:    profileToken,
+		PresetTourToken: presetTourToken,
+	}
+
+	var resp GetPresetTourResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetPresetTour failed: %w", err)
+	}
+
+	return resp.PresetTour.toPresetTour(), nil
+}
+
+// OperatePresetTour starts, stops or pauses a preset tour.
+// Operation is one of PresetTourOperationStart, PresetTourOperationStop or PresetTourOperationPause.
+func (c *Client) OperatePresetTour(ctx context.Context, profileToken, presetTourToken, operation string) error {
+	endpoint := c.ptzEndpoint
+	if endpoint == "" {
+		return ErrServiceNotSupported
+	}
+
+	switch operation {
+	case PresetTourOperationStart, PresetTourOperationStop, PresetTourOperationPause:
+	default:
+		return fmt.Errorf("%w: unknown preset tour operation %q", ErrInvalidParameter, operation)
+	}
+
+	type OperatePresetTour struct {
+		XMLName         xml.Name `xml:"tptz:OperatePresetTour"`
+		Xmlns           string   `xml:"xmlns:tptz,attr"`
+		ProfileToken    string   `xml:"tptz:ProfileToken"`
+		PresetTourToken string   `xml:"tptz:PresetTourToken"`
+		Operation       string   `xml:"tptz:Operation"`
+	}
+
+	req := OperatePresetTour{
+		Xmlns:           ptzNamespace,
+		ProfileToken:    profileToken,
+		PresetTourToken: presetTourToken,
+		Operation:       operation,
+	}
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("OperatePresetTour failed: %w", err)
+	}
+
+	return nil
+}
+
+// CreatePresetTour creates an empty preset tour and returns its token
+func (c *Client) CreatePresetTour(ctx context.Context, profileToken string) (string, error) {
+	endpoint := c.ptzEndpoint
+	if endpoint == "" {
+		return "", ErrServiceNotSupported
+	}
+
+	type CreatePresetTour struct {
+		XMLName      xml.Name `xml:"tptz:CreatePresetTour"`
+		Xmlns        string   `xml:"xmlns:tptz,attr"`
+		ProfileToken string   `xml:"tptz:ProfileToken"`
+	}
+
+	type CreatePresetTourResponse struct {
+		XMLName         xml.Name `xml:"CreatePresetTourResponse"`
+		PresetTourToken string   `xml:"PresetTourToken"`
+	}
+
+	req := CreatePresetTour{
+		Xmlns:        ptzNamespace,
+		ProfileToken: profileToken,
+	}
+
+	var resp CreatePresetTourResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return "", fmt.Errorf("CreatePresetTour failed: %w", err)
+	}
+
+	return resp.PresetTourToken, nil
+}
+
+// tourSpotRequest is the request form of tt:PTZPresetTourSpot
+type tourSpotRequest struct {
+	PresetDetail struct {
+		PresetToken string `xml:"tt:PresetToken"`
+	} `xml:"tt:PresetDetail"`
+	Speed *struct {
+		PanTilt *struct {
+			X     float64 `xml:"x,attr"`
+			Y     float64 `xml:"y,attr"`
+			Space string  `xml:"space,attr,omitempty"`
+		} `xml:"tt:PanTilt,omitempty"`
+		Zoom *struct {
+			X     float64 `xml:"x,attr"`
+			Space string  `xml:"space,attr,omitempty"`
+		} `xml:"tt:Zoom,omitempty"`
+	} `xml:"tt:Speed,omitempty"`
+	StayTime string `xml:"tt:StayTime,omitempty"`
+}
+
+// ModifyPresetTour replaces the settings and ordered tour spots of an existing preset tour.
+// The tour's Token selects the tour to modify.
+func (c *Client) ModifyPresetTour(ctx context.Context, profileToken string, tour PresetTour) error {
+	endpoint := c.ptzEndpoint
+	if endpoint == "" {
+		return ErrServiceNotSupported
+	}
+
+	if tour.Token == "" {
+		return fmt.Errorf("%w: preset tour token is required", ErrInvalidParameter)
+	}
+
+	type ModifyPresetTour struct {
+		XMLName      xml.Name `xml:"tptz:ModifyPresetTour"`
+		Xmlns        string   `xml:"xmlns:tptz,attr"`
+		Xmlnst       string   `xml:"xmlns:tt,attr"`
+		ProfileToken string   `xml:"tptz:ProfileToken"`
+		PresetTour   struct {
+			Token  string `xml:"token,attr"`
+			Name   string `xml:"tt:Name,omitempty"`
+			Status struct {
+				State string `xml:"tt:State"`
+			} `xml:"tt:Status"`
+			AutoStart         bool `xml:"tt:AutoStart"`
+			StartingCondition struct {
+				RandomPresetOrder bool   `xml:"RandomPresetOrder,attr,omitempty"`
+				RecurringTime     int    `xml:"tt:RecurringTime,omitempty"`
+				RecurringDuration string `xml:"tt:RecurringDuration,omitempty"`
+				Direction         string `xml:"tt:Direction,omitempty"`
+			} `xml:"tt:StartingCondition"`
+			TourSpot []tourSpotRequest `xml:"tt:TourSpot"`
+		} `xml:"tptz:PresetTour"`
+	}
+
+	req := ModifyPresetTour{
+		Xmlns:        ptzNamespace,
+		Xmlnst:       "http://www.onvif.org/ver10/schema",
+		ProfileToken: profileToken,
+	}
+
+	req.PresetTour.Token = tour.Token
+	req.PresetTour.Name = tour.Name
+	req.PresetTour.AutoStart = tour.AutoStart
+
+	// Status is mandatory in the schema; devices ignore it on modify
+	req.PresetTour.Status.State = "Idle"
+	if tour.Status != nil && tour.Status.State != "" {
+		req.PresetTour.Status.State = tour.Status.State
+	}
+
+	if sc := tour.StartingCondition; sc != nil {
+		req.PresetTour.StartingCondition.RandomPresetOrder = sc.RandomPresetOrder
+		req.PresetTour.StartingCondition.RecurringTime = sc.RecurringTime
+		req.PresetTour.StartingCondition.Direction = sc.Direction
+		if sc.RecurringDuration > 0 {
+			req.PresetTour.StartingCondition.RecurringDuration = FormatDuration(sc.RecurringDuration)
+		}
+	}
+
+	for _, spot := range tour.TourSpots {
+		if spot == nil {
+			continue
+		}
+
+		var s tourSpotRequest
+		s.PresetDetail.PresetToken = spot.PresetToken
+		if spot.StayTime > 0 {
+			s.StayTime = FormatDuration(spot.StayTime)
+		}
+
+		if spot.Speed != nil {
+			s.Speed = &struct {
+				PanTilt *struct {
+					X     float64 `xml:"x,attr"`
+					Y     float64 `xml:"y,attr"`
+					Space string  `xml:"space,attr,omitempty"`
+				} `xml:"tt:PanTilt,omitempty"`
+				Zoom *struct {
+					X     float64 `xml:"x,attr"`
+					Space string  `xml:"space,attr,omitempty"`
+				} `xml:"tt:Zoom,omitempty"`
+			}{}
+
+			if spot.Speed.PanTilt != nil {
+				s.Speed.PanTilt = &struct {
+					X     float64 `xml:"x,attr"`
+					Y     float64 `xml:"y,attr"`
+					Space string  `xml:"space,attr,omitempty"`
+				}{
+					X:     spot.Speed.PanTilt.X,
+					Y:     spot.Speed.PanTilt.Y,
+					Space: spot.Speed.PanTilt.Space,
+				}
+			}
+
+			if spot.Speed.Zoom != nil {
+				s.Speed.Zoom = &struct {
+					X     float64 `xml:"x,attr"`
+					Space string  `xml:"space,attr,omitempty"`
+				}{
+					X:     spot.Speed.Zoom.X,
+					Space: spot.Speed.Zoom.Space,
+				}
+			}
+		}
+
+		req.PresetTour.TourSpot = append(req.PresetTour.TourSpot, s)
+	}
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("ModifyPresetTour failed: %w", err)
+	}
+
+	return nil
+}
+
+// RemovePresetTour removes a preset tour
+func (c *Client) RemovePresetTour(ctx context.Context, profileToken, presetTourToken string) error {
+	endpoint := c.ptzEndpoint
+	if endpoint == "" {
+		return ErrServiceNotSupported
+	}
+
+	type RemovePresetTour struct {
+		XMLName         xml.Name `xml:"tptz:RemovePresetTour"`
+		Xmlns           string   `xml:"xmlns:tptz,attr"`
+		ProfileToken    string   `xml:"tptz:ProfileToken"`
+		PresetTourToken string   `xml:"tptz:PresetTourToken"`
+	}
+
+	req := RemovePresetTour{
+		Xmlns:           ptzNamespace,
+		ProfileToken:    profileToken,
+		PresetTourToken: presetTourToken,
+	}
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("RemovePresetTour failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetNodes retrieves every PTZ node of the device
+func (c *Client) GetNodes(ctx context.Context) ([]*PTZNode, error) {
+	endpoint := c.ptzEndpoint
+	if endpoint == "" {
+		return nil, ErrServiceNotSupported
+	}
+
+	type GetNodes struct {
+		XMLName xml.Name `xml:"tptz:GetNodes"`
+		Xmlns   string   `xml:"xmlns:tptz,attr"`
+	}
+
+	type GetNodesResponse struct {
+		XMLName xml.Name     `xml:"GetNodesResponse"`
+		PTZNode []ptzNodeXML `xml:"PTZNode"`
+	}
+
+	req := GetNodes{
+		Xmlns: ptzNamespace,
+	}
+
+	var resp GetNodesResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetNodes failed: %w", err)
+	}
+
+	nodes := make([]*PTZNode, len(resp.PTZNode))
+	for i, node := range resp.PTZNode {
+		nodes[i] = node.toPTZNode()
+	}
+
+	return nodes, nil
+}
+
+// GetNode retrieves a PTZ node, including the auxiliary commands it supports
+func (c *Client) GetNode(ctx context.Context, nodeToken string) (*PTZNode, error) {
+	endpoint := c.ptzEndpoint
+	if endpoint == "" {
+		return nil, ErrServiceNotSupported
+	}
+
+	type GetNode struct {
+		XMLName   xml.Name `xml:"tptz:GetNode"`
+		Xmlns     string   `xml:"xmlns:tptz,attr"`
+		NodeToken string   `xml:"tptz:NodeToken"`
+	}
+
+	type GetNodeResponse struct {
+		XMLName xml.Name   `xml:"GetNodeResponse"`
+		PTZNode ptzNodeXML `xml:"PTZNode"`
+	}
+
+	req := GetNode{
+		Xmlns:     ptzNamespace,
+		NodeToken: nodeToken,
+	}
+
+	var resp GetNodeResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetNode failed: %w", err)
+	}
+
+	return resp.PTZNode.toPTZNode(), nil
+}
+
+// ptzNodeXML is the wire form of tt:PTZNode
+type ptzNodeXML struct {
+	Token                  string   `xml:"token,attr"`
+	FixedHomePosition      bool     `xml:"FixedHomePosition,attr"`
+	GeoMove                bool     `xml:"GeoMove,attr"`
+	Name                   string   `xml:"Name"`
+	MaximumNumberOfPresets int      `xml:"MaximumNumberOfPresets"`
+	HomeSupported          bool     `xml:"HomeSupported"`
+	AuxiliaryCommands      []string `xml:"AuxiliaryCommands"`
+	SupportedPTZSpaces     *struct {
+		AbsolutePanTiltPositionSpace []struct {
+			URI    string        `xml:"URI"`
+			XRange floatRangeXML `xml:"XRange"`
+			YRange floatRangeXML `xml:"YRange"`
+		} `xml:"AbsolutePanTiltPositionSpace"`
+		AbsoluteZoomPositionSpace []struct {
+			URI    string        `xml:"URI"`
+			XRange floatRangeXML `xml:"XRange"`
+		} `xml:"AbsoluteZoomPositionSpace"`
+		RelativePanTiltTranslationSpace []struct {
+			URI    string        `xml:"URI"`
+			XRange floatRangeXML `xml:"XRange"`
+			YRange floatRangeXML `xml:"YRange"`
+		} `xml:"RelativePanTiltTranslationSpace"`
+		RelativeZoomTranslationSpace []struct {
+			URI    string        `xml:"URI"`
+			XRange floatRangeXML `xml:"XRange"`
+		} `xml:"RelativeZoomTranslationSpace"`
+	} `xml:"SupportedPTZSpaces"`
+}
+
+// toPTZNode converts the wire form into a PTZNode
+func (x ptzNodeXML) toPTZNode() *PTZNode {
+	node := &PTZNode{
+		Token:                  x.Token,
+		Name:                   x.Name,
+		FixedHomePosition:      x.FixedHomePosition,
+		GeoMove:                x.GeoMove,
+		MaximumNumberOfPresets: x.MaximumNumberOfPresets,
+		HomeSupported:          x.HomeSupported,
+		AuxiliaryCommands:      x.AuxiliaryCommands,
+	}
+
+	if spaces := x.SupportedPTZSpaces; spaces != nil {
+		node.SupportedPTZSpaces = &PTZSpaces{}
+		for _, space := range spaces.AbsolutePanTiltPositionSpace {
+			node.SupportedPTZSpaces.AbsolutePanTiltPositionSpace = append(node.SupportedPTZSpaces.AbsolutePanTiltPositionSpace, &Space2DDescription{
+				URI:    space.URI,
+				XRange: space.XRange.toFloatRange(),
+				YRange: space.YRange.toFloatRange(),
+			})
+		}
+		for _, space := range spaces.AbsoluteZoomPositionSpace {
+			node.SupportedPTZSpaces.AbsoluteZoomPositionSpace = append(node.SupportedPTZSpaces.AbsoluteZoomPositionSpace, &Space1DDescription{
+				URI:    space.URI,
+				XRange: space.XRange.toFloatRange(),
+			})
+		}
+		for _, space := range spaces.RelativePanTiltTranslationSpace {
+			node.SupportedPTZSpaces.RelativePanTiltTranslationSpace = append(node.SupportedPTZSpaces.RelativePanTiltTranslationSpace, &Space2DDescription{
+				URI:    space.URI,
+				XRange: space.XRange.toFloatRange(),
+				YRange: space.YRange.toFloatRange(),
+			})
+		}
+		for _, space := range spaces.RelativeZoomTranslationSpace {
+			node.SupportedPTZSpaces.RelativeZoomTranslationSpace = append(node.SupportedPTZSpaces.RelativeZoomTranslationSpace, &Space1DDescription{
+				URI:    space.URI,
+				XRange: space.XRange.toFloatRange(),
+			})
+		}
+	}
+
+	return node
+}
+
+// floatRangeXML is the wire form of tt:FloatRange
+type floatRangeXML struct {
+	Min float64 `xml:"Min"`
+	Max float64 `xml:"Max"`
+}
+
+// toFloatRange converts the wire form into a FloatRange
+func (x floatRangeXML) toFloatRange() *FloatRange {
+	return &FloatRange{Min: x.Min, Max: x.Max}
+}
+
+// PTZ absolute position spaces
+const (
+	PanTiltPositionGenericSpace = "http://www.onvif.org/ver10/tptz/PanTiltSpaces/PositionGenericSpace"
+	PanTiltPositionSpaceDegrees = "http://www.onvif.org/ver10/tptz/PanTiltSpaces/SphericalPositionSpaceDegrees"
+	ZoomPositionGenericSpace    = "http://www.onvif.org/ver10/tptz/ZoomSpaces/PositionGenericSpace"
+)
+
+// PTZ relative translation spaces
+const (
+	PanTiltTranslationGenericSpace = "http://www.onvif.org/ver10/tptz/PanTiltSpaces/TranslationGenericSpace"
+	ZoomTranslationGenericSpace    = "http://www.onvif.org/ver10/tptz/ZoomSpaces/TranslationGenericSpace"
+)
+
+// moveNode returns the PTZ node of a profile for move validation, looking it
+// up on the first move of the profile, or nil if it cannot be determined
+func (c *Client) moveNode(ctx context.Context, profileToken string) *PTZNode {
+	c.mu.RLock()
+	node, ok := c.moveNodes[profileToken]
+	c.mu.RUnlock()
+	if ok {
+		return node
+	}
+
+	profiles, err := c.GetProfiles(ctx)
+	if err != nil {
+		return nil
+	}
+	var nodeToken string
+	for _, profile := range profiles {
+		if profile.Token == profileToken && profile.PTZConfiguration != nil {
+			nodeToken = profile.PTZConfiguration.NodeToken
+		}
+	}
+	if nodeToken == "" {
+		return nil
+	}
+	if node, err = c.GetNode(ctx, nodeToken); err != nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	if c.moveNodes == nil {
+		c.moveNodes = make(map[string]*PTZNode)
+	}
+	c.moveNodes[profileToken] = node
+	c.mu.Unlock()
+
+	return node
+}
+
+// checkVector returns ErrOutOfRange if vec lies outside the ranges listed for
+// its spaces. A component without a space is taken to be in the generic space;
+// components in spaces that are not listed are not checked.
+func checkVector(vec *PTZVector, panTiltSpaces []*Space2DDescription, zoomSpaces []*Space1DDescription, panTiltGeneric, zoomGeneric string) error {
+	if vec == nil {
+		return nil
+	}
+
+	if panTilt := vec.PanTilt; panTilt != nil {
+		uri := panTilt.Space
+		if uri == "" {
+			uri = panTiltGeneric
+		}
+		for _, space := range panTiltSpaces {
+			if space.URI != uri {
+				continue
+			}
+			if err := checkRange("pan", panTilt.X, space.XRange, uri); err != nil {
+				return err
+			}
+			if err := checkRange("tilt", panTilt.Y, space.YRange, uri); err != nil {
+				return err
+			}
+		}
+	}
+
+	if zoom := vec.Zoom; zoom != nil {
+		uri := zoom.Space
+		if uri == "" {
+			uri = zoomGeneric
+		}
+		for _, space := range zoomSpaces {
+			if space.URI != uri {
+				continue
+			}
+			if err := checkRange("zoom", zoom.X, space.XRange, uri); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkRange returns ErrOutOfRange if v lies outside r
+func checkRange(axis string, v float64, r *FloatRange, space string) error {
+	if r == nil || (v >= r.Min && v <= r.Max) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s %v outside [%v, %v] of %s", ErrOutOfRange, axis, v, r.Min, r.Max, space)
+}
+
+// DegreesToVector converts a pan/tilt position in degrees into a position in
+// the generic (normalized) spaces of node, as returned by GetNode, so it can be
+// passed to AbsoluteMove. The mapping is linear between the ranges the node
+// advertises for its degree and generic spaces. Zoom is taken in the node's
+// native zoom space if it advertises one besides the generic space, and as a
+// generic value otherwise. Results are clamped to the generic ranges.
+func (c *Client) DegreesToVector(node *PTZNode, pan, tilt, zoom float64) (*PTZVector, error) {
+	degrees, generic, err := panTiltSpaces(node)
+	if err != nil {
+		return nil, err
+	}
+	native, genericZoom := zoomSpaces(node)
+	zoomRange := genericZoom.XRange
+	if native != nil {
+		zoomRange = native.XRange
+	}
+
+	return &PTZVector{
+		PanTilt: &Vector2D{
+			X:     mapRange(pan, degrees.XRange, generic.XRange),
+			Y:     mapRange(tilt, degrees.YRange, generic.YRange),
+			Space: PanTiltPositionGenericSpace,
+		},
+		Zoom: &Vector1D{
+			X:     mapRange(zoom, zoomRange, genericZoom.XRange),
+			Space: ZoomPositionGenericSpace,
+		},
+	}, nil
+}
+
+// VectorToDegrees converts a position in the generic spaces of node, such as
+// the one reported by GetStatus, into pan/tilt in degrees and zoom in the
+// node's native zoom space. It is the inverse of DegreesToVector.
+func (c *Client) VectorToDegrees(node *PTZNode, vec *PTZVector) (pan, tilt, zoom float64, err error) {
+	degrees, generic, err := panTiltSpaces(node)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if vec == nil {
+		return 0, 0, 0, fmt.Errorf("%w: position is required", ErrInvalidParameter)
+	}
+	native, genericZoom := zoomSpaces(node)
+	zoomRange := genericZoom.XRange
+	if native != nil {
+		zoomRange = native.XRange
+	}
+
+	if vec.PanTilt != nil {
+		pan = mapRange(vec.PanTilt.X, generic.XRange, degrees.XRange)
+		tilt = mapRange(vec.PanTilt.Y, generic.YRange, degrees.YRange)
+	}
+	if vec.Zoom != nil {
+		zoom = mapRange(vec.Zoom.X, genericZoom.XRange, zoomRange)
+	}
+
+	return pan, tilt, zoom, nil
+}
+
+// panTiltSpaces returns the degree and generic absolute pan/tilt spaces of
+// node. The generic space defaults to the range ONVIF defines for it.
+func panTiltSpaces(node *PTZNode) (degrees, generic *Space2DDescription, err error) {
+	generic = &Space2DDescription{
+		URI:    PanTiltPositionGenericSpace,
+		XRange: &FloatRange{Min: -1, Max: 1},
+		YRange: &FloatRange{Min: -1, Max: 1},
+	}
+	if node == nil || node.SupportedPTZSpaces == nil {
+		return nil, nil, fmt.Errorf("%w: node does not list its PTZ spaces", ErrInvalidParameter)
+	}
+
+	for _, space := range node.SupportedPTZSpaces.AbsolutePanTiltPositionSpace {
+		if space.XRange == nil || space.YRange == nil {
+			continue
+		}
+		switch space.URI {
+		case PanTiltPositionSpaceDegrees:
+			degrees = space
+		case PanTiltPositionGenericSpace:
+			generic = space
+		}
+	}
+	if degrees == nil {
+		return nil, nil, fmt.Errorf("%w: node %s has no pan/tilt space in degrees", ErrServiceNotSupported, node.Token)
+	}
+
+	return degrees, generic, nil
+}
+
+// zoomSpaces returns the first non-generic absolute zoom space of node, if
+// any, and its generic zoom space, which defaults to the range ONVIF defines
+func zoomSpaces(node *PTZNode) (native, generic *Space1DDescription) {
+	generic = &Space1DDescription{
+		URI:    ZoomPositionGenericSpace,
+		XRange: &FloatRange{Min: 0, Max: 1},
+	}
+	for _, space := range node.SupportedPTZSpaces.AbsoluteZoomPositionSpace {
+		if space.XRange == nil {
+			continue
+		}
+		if space.URI == ZoomPositionGenericSpace {
+			generic = space
+		} else if native == nil {
+			native = space
+		}
+	}
+	return native, generic
+}
+
+// mapRange maps v linearly from one range onto another, clamping the result
+func mapRange(v float64, from, to *FloatRange) float64 {
+	if from.Max == from.Min {
+		return to.Min
+	}
+	mapped := to.Min + (v-from.Min)*(to.Max-to.Min)/(from.Max-from.Min)
+	return math.Max(to.Min, math.Min(to.Max, mapped))
+}
+
+// SendAuxiliaryCommand sends an auxiliary command such as "tt:Wiper|On" or
+// "tt:IRLamp|Auto" to the PTZ node of a profile and returns the device's reply.
+// The supported commands are listed in PTZNode.AuxiliaryCommands.
+func (c *Client) SendAuxiliaryCommand(ctx context.Context, profileToken, command string) (string, error) {
+	endpoint := c.ptzEndpoint
+	if endpoint == "" {
+		return "", ErrServiceNotSupported
+	}
+
+	if command == "" {
+		return "", fmt.Errorf("%w: auxiliary command is required", ErrInvalidParameter)
+	}
+
+	type SendAuxiliaryCommand struct {
+		XMLName       xml.Name `xml:"tptz:SendAuxiliaryCommand"`
+		Xmlns         string   `xml:"xmlns:tptz,attr"`
+		ProfileToken  string   `xml:"tptz:ProfileToken"`
+		AuxiliaryData string   `xml:"tptz:AuxiliaryData"`
+	}
+
+	type SendAuxiliaryCommandResponse struct {
+		XMLName           xml.Name `xml:"SendAuxiliaryCommandResponse"`
+		AuxiliaryResponse string   `xml:"AuxiliaryResponse"`
+	}
+
+	req := SendAuxiliaryCommand{
+		Xmlns:         ptzNamespace,
+		ProfileToken:  profileToken,
+		AuxiliaryData: command,
+	}
+
+	var resp SendAuxiliaryCommandResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return "", fmt.Errorf("SendAuxiliaryCommand failed: %w", err)
+	}
+
+	return resp.AuxiliaryResponse, nil
+}
@@ -0,0 +1,627 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetPTZServiceCapabilities(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tptz:GetServiceCapabilitiesResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl">
+					<tptz:Capabilities GetCompatibleConfigurations="true" MoveStatus="true" StatusPosition="true"/>
+				</tptz:GetServiceCapabilitiesResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if _, err := client.GetPTZServiceCapabilities(context.Background()); !errors.Is(err, ErrServiceNotSupported) {
+		t.Errorf("Expected ErrServiceNotSupported before Initialize, got %v", err)
+	}
+
+	client.ptzEndpoint = server.URL
+	caps, err := client.GetPTZServiceCapabilities(context.Background())
+	if err != nil {
+		t.Fatalf("GetPTZServiceCapabilities() error = %v", err)
+	}
+
+	if !caps.GetCompatibleConfigurations || !caps.MoveStatus || !caps.StatusPosition || caps.EFlip {
+		t.Errorf("Unexpected capabilities: %+v", caps)
+	}
+}
+
+func TestContinuousMoveTimeout(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if action := r.Header.Get("SOAPAction"); action != `"http://www.onvif.org/ver20/ptz/wsdl/ContinuousMove"` {
+			t.Errorf("Unexpected SOAPAction %s", action)
+		}
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tptz:ContinuousMoveResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"/>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.ptzEndpoint = server.URL
+
+	velocity := &PTZSpeed{PanTilt: &Vector2D{X: 0.5}}
+
+	timeout := "PT90S"
+	if err := client.ContinuousMove(context.Background(), "Profile_1", velocity, &timeout); err != nil {
+		t.Fatalf("ContinuousMove() error = %v", err)
+	}
+	if !strings.Contains(body, "<tptz:Timeout>PT1M30S</tptz:Timeout>") {
+		t.Errorf("Expected normalized timeout in request, got: %s", body)
+	}
+
+	invalid := "2 seconds"
+	if err := client.ContinuousMove(context.Background(), "Profile_1", velocity, &invalid); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for malformed timeout, got %v", err)
+	}
+}
+
+func TestContinuousMoveFor(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tptz:ContinuousMoveResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"/>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.ptzEndpoint = server.URL
+
+	velocity := &PTZSpeed{PanTilt: &Vector2D{X: 0.5}}
+
+	if err := client.ContinuousMoveFor(context.Background(), "Profile_1", velocity, 1500*time.Millisecond); err != nil {
+		t.Fatalf("ContinuousMoveFor() error = %v", err)
+	}
+	if !strings.Contains(body, "<tptz:Timeout>PT1.5S</tptz:Timeout>") {
+		t.Errorf("Expected timeout in request, got: %s", body)
+	}
+
+	if err := client.ContinuousMoveFor(context.Background(), "Profile_1", velocity, 0); err != nil {
+		t.Fatalf("ContinuousMoveFor() error = %v", err)
+	}
+	if strings.Contains(body, "Timeout") {
+		t.Errorf("Expected no Timeout element for zero duration, got: %s", body)
+	}
+}
+
+func TestAbsoluteMoveAndWait(t *testing.T) {
+	statusResponse := func(panTilt, zoom, ptzError string) string {
+		return `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tptz:GetStatusResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<tptz:PTZStatus>
+						<tt:MoveStatus>
+							<tt:PanTilt>` + panTilt + `</tt:PanTilt>
+							<tt:Zoom>` + zoom + `</tt:Zoom>
+						</tt:MoveStatus>
+						<tt:Error>` + ptzError + `</tt:Error>
+					</tptz:PTZStatus>
+				</tptz:GetStatusResponse>
+			</s:Body>
+		</s:Envelope>`
+	}
+
+	tests := []struct {
+		name      string
+		status    func(poll int32) string
+		wantErr   error
+		wantPolls int32
+	}{
+		{
+			name: "waits until idle",
+			status: func(poll int32) string {
+				if poll < 3 {
+					return statusResponse("MOVING", "IDLE", "")
+				}
+				return statusResponse("IDLE", "IDLE", "NO error")
+			},
+			wantPolls: 3,
+		},
+		{
+			name: "fault",
+			status: func(poll int32) string {
+				return statusResponse("MOVING", "IDLE", "Obstacle detected")
+			},
+			wantErr:   ErrPTZFault,
+			wantPolls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var polls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				response := `<?xml version="1.0" encoding="UTF-8"?>
+				<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+					<s:Body>
+						<tptz:AbsoluteMoveResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"/>
+					</s:Body>
+				</s:Envelope>`
+				if strings.Contains(string(data), "GetStatus") {
+					response = tt.status(atomic.AddInt32(&polls, 1))
+				}
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(response))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(server.URL)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			client.ptzEndpoint = server.URL
+
+			position := &PTZVector{PanTilt: &Vector2D{X: 0.5, Y: 0.5}}
+			err = client.AbsoluteMoveAndWait(context.Background(), "Profile_1", position, nil, time.Millisecond)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AbsoluteMoveAndWait() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(&polls); got != tt.wantPolls {
+				t.Errorf("Expected %d GetStatus polls, got %d", tt.wantPolls, got)
+			}
+		})
+	}
+}
+
+func TestGetPresetTours(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tptz:GetPresetToursResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<tptz:PresetTour token="Tour_1">
+						<tt:Name>Perimeter</tt:Name>
+						<tt:Status>
+							<tt:State>Touring</tt:State>
+							<tt:CurrentTourSpot>
+								<tt:PresetDetail><tt:PresetToken>Preset_2</tt:PresetToken></tt:PresetDetail>
+								<tt:StayTime>PT10S</tt:StayTime>
+							</tt:CurrentTourSpot>
+						</tt:Status>
+						<tt:AutoStart>true</tt:AutoStart>
+						<tt:StartingCondition RandomPresetOrder="false">
+							<tt:RecurringTime>0</tt:RecurringTime>
+							<tt:Direction>Forward</tt:Direction>
+						</tt:StartingCondition>
+						<tt:TourSpot>
+							<tt:PresetDetail><tt:PresetToken>Preset_1</tt:PresetToken></tt:PresetDetail>
+							<tt:Speed><tt:PanTilt x="0.5" y="0.5"/></tt:Speed>
+							<tt:StayTime>PT5S</tt:StayTime>
+						</tt:TourSpot>
+						<tt:TourSpot>
+							<tt:PresetDetail><tt:PresetToken>Preset_2</tt:PresetToken></tt:PresetDetail>
+							<tt:StayTime>PT10S</tt:StayTime>
+						</tt:TourSpot>
+					</tptz:PresetTour>
+				</tptz:GetPresetToursResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.ptzEndpoint = server.URL
+
+	tours, err := client.GetPresetTours(context.Background(), "Profile_1")
+	if err != nil {
+		t.Fatalf("GetPresetTours() error = %v", err)
+	}
+
+	if len(tours) != 1 {
+		t.Fatalf("Expected 1 tour, got %d", len(tours))
+	}
+	tour := tours[0]
+	if tour.Token != "Tour_1" || tour.Name != "Perimeter" || !tour.AutoStart {
+		t.Errorf("Unexpected tour: %+v", tour)
+	}
+	if tour.Status == nil || tour.Status.State != "Touring" || tour.Status.CurrentTourSpot.PresetToken != "Preset_2" {
+		t.Errorf("Unexpected status: %+v", tour.Status)
+	}
+	if tour.StartingCondition == nil || tour.StartingCondition.Direction != "Forward" {
+		t.Errorf("Unexpected starting condition: %+v", tour.StartingCondition)
+	}
+	if len(tour.TourSpots) != 2 {
+		t.Fatalf("Expected 2 tour spots, got %d", len(tour.TourSpots))
+	}
+	if tour.TourSpots[0].StayTime != 5*time.Second || tour.TourSpots[0].Speed.PanTilt.X != 0.5 {
+		t.Errorf("Unexpected first tour spot: %+v", tour.TourSpots[0])
+	}
+}
+
+func TestOperatePresetTour(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tptz:OperatePresetTourResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"/>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.ptzEndpoint = server.URL
+
+	if err := client.OperatePresetTour(context.Background(), "Profile_1", "Tour_1", PresetTourOperationStart); err != nil {
+		t.Fatalf("OperatePresetTour() error = %v", err)
+	}
+	if !strings.Contains(body, "<tptz:Operation>Start</tptz:Operation>") {
+		t.Errorf("Expected operation in request, got: %s", body)
+	}
+
+	if err := client.OperatePresetTour(context.Background(), "Profile_1", "Tour_1", "Rewind"); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for unknown operation, got %v", err)
+	}
+}
+
+func TestModifyPresetTour(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tptz:ModifyPresetTourResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"/>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.ptzEndpoint = server.URL
+
+	tour := PresetTour{
+		Token: "Tour_1",
+		Name:  "Perimeter",
+		TourSpots: []*TourSpot{
+			{PresetToken: "Preset_1", StayTime: 90 * time.Second, Speed: &PTZSpeed{PanTilt: &Vector2D{X: 0.5, Y: 0.25}}},
+			{PresetToken: "Preset_2", StayTime: 500 * time.Millisecond},
+		},
+	}
+
+	if err := client.ModifyPresetTour(context.Background(), "Profile_1", tour); err != nil {
+		t.Fatalf("ModifyPresetTour() error = %v", err)
+	}
+
+	for _, want := range []string{
+		`<tptz:PresetTour token="Tour_1">`,
+		"<tt:PresetToken>Preset_1</tt:PresetToken>",
+		"<tt:StayTime>PT1M30S</tt:StayTime>",
+		`<tt:PanTilt x="0.5" y="0.25">`,
+		"<tt:StayTime>PT0.5S</tt:StayTime>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %s in request, got: %s", want, body)
+		}
+	}
+	if strings.Index(body, "Preset_1") > strings.Index(body, "Preset_2") {
+		t.Errorf("Expected tour spots in order, got: %s", body)
+	}
+
+	if err := client.ModifyPresetTour(context.Background(), "Profile_1", PresetTour{}); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter without token, got %v", err)
+	}
+}
+
+func TestGetNodeAuxiliaryCommands(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tptz:GetNodeResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<tptz:PTZNode token="PTZNode_1" FixedHomePosition="false">
+						<tt:Name>Dome</tt:Name>
+						<tt:MaximumNumberOfPresets>256</tt:MaximumNumberOfPresets>
+						<tt:HomeSupported>true</tt:HomeSupported>
+						<tt:AuxiliaryCommands>tt:Wiper|On</tt:AuxiliaryCommands>
+						<tt:AuxiliaryCommands>tt:IRLamp|Auto</tt:AuxiliaryCommands>
+					</tptz:PTZNode>
+				</tptz:GetNodeResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.ptzEndpoint = server.URL
+
+	node, err := client.GetNode(context.Background(), "PTZNode_1")
+	if err != nil {
+		t.Fatalf("GetNode() error = %v", err)
+	}
+
+	if node.Token != "PTZNode_1" || node.MaximumNumberOfPresets != 256 || !node.HomeSupported {
+		t.Errorf("Unexpected node: %+v", node)
+	}
+	if len(node.AuxiliaryCommands) != 2 || node.AuxiliaryCommands[0] != "tt:Wiper|On" {
+		t.Errorf("Unexpected auxiliary commands: %v", node.AuxiliaryCommands)
+	}
+}
+
+func TestMoveValidation(t *testing.T) {
+	var moves, lookups int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body := string(data)
+
+		var response string
+		switch {
+		case strings.Contains(body, "GetProfiles"):
+			lookups++
+			response = `<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Profiles token="Profile_1">
+					<tt:Name>Main</tt:Name>
+					<tt:PTZConfiguration token="PTZ_1">
+						<tt:Name>PTZ</tt:Name>
+						<tt:NodeToken>PTZNode_1</tt:NodeToken>
+					</tt:PTZConfiguration>
+				</trt:Profiles>
+			</trt:GetProfilesResponse>`
+		case strings.Contains(body, "GetNode"):
+			response = `<tptz:GetNodeResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tptz:PTZNode token="PTZNode_1">
+					<tt:Name>Dome</tt:Name>
+					<tt:SupportedPTZSpaces>
+						<tt:AbsolutePanTiltPositionSpace>
+							<tt:URI>http://www.onvif.org/ver10/tptz/PanTiltSpaces/PositionGenericSpace</tt:URI>
+							<tt:XRange><tt:Min>-1</tt:Min><tt:Max>1</tt:Max></tt:XRange>
+							<tt:YRange><tt:Min>-0.5</tt:Min><tt:Max>1</tt:Max></tt:YRange>
+						</tt:AbsolutePanTiltPositionSpace>
+						<tt:AbsoluteZoomPositionSpace>
+							<tt:URI>http://www.onvif.org/ver10/tptz/ZoomSpaces/PositionGenericSpace</tt:URI>
+							<tt:XRange><tt:Min>0</tt:Min><tt:Max>1</tt:Max></tt:XRange>
+						</tt:AbsoluteZoomPositionSpace>
+						<tt:RelativePanTiltTranslationSpace>
+							<tt:URI>http://www.onvif.org/ver10/tptz/PanTiltSpaces/TranslationGenericSpace</tt:URI>
+							<tt:XRange><tt:Min>-1</tt:Min><tt:Max>1</tt:Max></tt:XRange>
+							<tt:YRange><tt:Min>-1</tt:Min><tt:Max>1</tt:Max></tt:YRange>
+						</tt:RelativePanTiltTranslationSpace>
+						<tt:RelativeZoomTranslationSpace>
+							<tt:URI>http://www.onvif.org/ver10/tptz/ZoomSpaces/TranslationGenericSpace</tt:URI>
+							<tt:XRange><tt:Min>-0.25</tt:Min><tt:Max>0.25</tt:Max></tt:XRange>
+						</tt:RelativeZoomTranslationSpace>
+					</tt:SupportedPTZSpaces>
+				</tptz:PTZNode>
+			</tptz:GetNodeResponse>`
+		case strings.Contains(body, "AbsoluteMove"), strings.Contains(body, "RelativeMove"):
+			moves++
+			response = `<tptz:MoveResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"/>`
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>` + response + `</s:Body></s:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithMoveValidation())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.ptzEndpoint = server.URL
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		move    func(context.Context, string, *PTZVector, *PTZSpeed) error
+		vector  *PTZVector
+		wantErr bool
+	}{
+		{"absolute in range", client.AbsoluteMove, &PTZVector{PanTilt: &Vector2D{X: 1, Y: -0.5}, Zoom: &Vector1D{X: 0.5}}, false},
+		{"absolute tilt out of range", client.AbsoluteMove, &PTZVector{PanTilt: &Vector2D{X: 0, Y: -0.6}}, true},
+		{"absolute zoom out of range", client.AbsoluteMove, &PTZVector{Zoom: &Vector1D{X: 1.1, Space: ZoomPositionGenericSpace}}, true},
+		{"absolute unlisted space", client.AbsoluteMove, &PTZVector{PanTilt: &Vector2D{X: 170, Space: PanTiltPositionSpaceDegrees}}, false},
+		{"relative in range", client.RelativeMove, &PTZVector{PanTilt: &Vector2D{X: -1, Y: 1}, Zoom: &Vector1D{X: 0.25}}, false},
+		{"relative zoom out of range", client.RelativeMove, &PTZVector{Zoom: &Vector1D{X: 0.5}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := moves
+			err := tt.move(ctx, "Profile_1", tt.vector, nil)
+			if tt.wantErr {
+				if !errors.Is(err, ErrOutOfRange) {
+					t.Errorf("Expected ErrOutOfRange, got %v", err)
+				}
+				if moves != before {
+					t.Error("Out of range move was sent")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if moves != before+1 {
+				t.Error("Move was not sent")
+			}
+		})
+	}
+
+	if lookups != 1 {
+		t.Errorf("Expected the node to be looked up once, got %d lookups", lookups)
+	}
+}
+
+func TestPTZDegreeConversion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tptz:GetNodeResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<tptz:PTZNode token="PTZNode_1">
+						<tt:Name>Dome</tt:Name>
+						<tt:SupportedPTZSpaces>
+							<tt:AbsolutePanTiltPositionSpace>
+								<tt:URI>http://www.onvif.org/ver10/tptz/PanTiltSpaces/PositionGenericSpace</tt:URI>
+								<tt:XRange><tt:Min>-1</tt:Min><tt:Max>1</tt:Max></tt:XRange>
+								<tt:YRange><tt:Min>-1</tt:Min><tt:Max>1</tt:Max></tt:YRange>
+							</tt:AbsolutePanTiltPositionSpace>
+							<tt:AbsolutePanTiltPositionSpace>
+								<tt:URI>http://www.onvif.org/ver10/tptz/PanTiltSpaces/SphericalPositionSpaceDegrees</tt:URI>
+								<tt:XRange><tt:Min>-180</tt:Min><tt:Max>180</tt:Max></tt:XRange>
+								<tt:YRange><tt:Min>-90</tt:Min><tt:Max>0</tt:Max></tt:YRange>
+							</tt:AbsolutePanTiltPositionSpace>
+							<tt:AbsoluteZoomPositionSpace>
+								<tt:URI>http://www.onvif.org/ver10/tptz/ZoomSpaces/PositionGenericSpace</tt:URI>
+								<tt:XRange><tt:Min>0</tt:Min><tt:Max>1</tt:Max></tt:XRange>
+							</tt:AbsoluteZoomPositionSpace>
+							<tt:AbsoluteZoomPositionSpace>
+								<tt:URI>http://www.onvif.org/ver10/tptz/ZoomSpaces/PositionSpaceMillimeter</tt:URI>
+								<tt:XRange><tt:Min>4</tt:Min><tt:Max>124</tt:Max></tt:XRange>
+							</tt:AbsoluteZoomPositionSpace>
+						</tt:SupportedPTZSpaces>
+					</tptz:PTZNode>
+				</tptz:GetNodeResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.ptzEndpoint = server.URL
+
+	node, err := client.GetNode(context.Background(), "PTZNode_1")
+	if err != nil {
+		t.Fatalf("GetNode() error = %v", err)
+	}
+	if node.SupportedPTZSpaces == nil || len(node.SupportedPTZSpaces.AbsolutePanTiltPositionSpace) != 2 {
+		t.Fatalf("Unexpected PTZ spaces: %+v", node.SupportedPTZSpaces)
+	}
+
+	vec, err := client.DegreesToVector(node, 90, -45, 64)
+	if err != nil {
+		t.Fatalf("DegreesToVector() error = %v", err)
+	}
+	if vec.PanTilt.X != 0.5 || vec.PanTilt.Y != 0 || vec.Zoom.X != 0.5 {
+		t.Errorf("Unexpected vector: pan/tilt %+v, zoom %+v", vec.PanTilt, vec.Zoom)
+	}
+	if vec.PanTilt.Space != PanTiltPositionGenericSpace || vec.Zoom.Space != ZoomPositionGenericSpace {
+		t.Errorf("Expected generic spaces, got %q and %q", vec.PanTilt.Space, vec.Zoom.Space)
+	}
+
+	pan, tilt, zoom, err := client.VectorToDegrees(node, vec)
+	if err != nil {
+		t.Fatalf("VectorToDegrees() error = %v", err)
+	}
+	if pan != 90 || tilt != -45 || zoom != 64 {
+		t.Errorf("Round trip gave pan %v, tilt %v, zoom %v", pan, tilt, zoom)
+	}
+
+	// Out of range input is clamped to the generic space
+	if vec, _ := client.DegreesToVector(node, 270, 0, 4); vec.PanTilt.X != 1 {
+		t.Errorf("Expected pan to be clamped to 1, got %v", vec.PanTilt.X)
+	}
+
+	if _, err := client.DegreesToVector(&PTZNode{SupportedPTZSpaces: &PTZSpaces{}}, 0, 0, 0); !errors.Is(err, ErrServiceNotSupported) {
+		t.Errorf("Expected ErrServiceNotSupported without a degree space, got %v", err)
+	}
+}
+
+func TestSendAuxiliaryCommand(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "<tptz:AuxiliaryData>tt:Wiper|On</tptz:AuxiliaryData>") {
+			t.Errorf("Expected auxiliary data in request, got: %s", body)
+		}
+
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tptz:SendAuxiliaryCommandResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl">
+					<tptz:AuxiliaryResponse>tt:Wiper|On</tptz:AuxiliaryResponse>
+				</tptz:SendAuxiliaryCommandResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.ptzEndpoint = server.URL
+
+	reply, err := client.SendAuxiliaryCommand(context.Background(), "Profile_1", "tt:Wiper|On")
+	if err != nil {
+		t.Fatalf("SendAuxiliaryCommand() error = %v", err)
+	}
+	if reply != "tt:Wiper|On" {
+		t.Errorf("Unexpected reply: %s", reply)
+	}
+}
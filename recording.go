@@ -0,0 +1,266 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// Recording, search and replay service namespaces
+const (
+	recordingNamespace = "http://www.onvif.org/ver10/recording/wsdl"
+	searchNamespace    = "http://www.onvif.org/ver10/search/wsdl"
+	replayNamespace    = "http://www.onvif.org/ver10/replay/wsdl"
+)
+
+// defaultSearchKeepAlive is how long the device keeps a search session alive
+// between calls to GetRecordingSearchResults
+const defaultSearchKeepAlive = 60 * time.Second
+
+// recordingSourceXML is the wire form of tt:RecordingSourceInformation
+type recordingSourceXML struct {
+	SourceId    string `xml:"SourceId"`
+	Name        string `xml:"Name"`
+	Location    string `xml:"Location"`
+	Description string `xml:"Description"`
+	Address     string `xml:"Address"`
+}
+
+// toSource converts the wire form into a RecordingSourceInformation
+func (s recordingSourceXML) toSource() *RecordingSourceInformation {
+	return &RecordingSourceInformation{
+		SourceID:    s.SourceId,
+		Name:        s.Name,
+		Location:    s.Location,
+		Description: s.Description,
+		Address:     s.Address,
+	}
+}
+
+// GetRecordings retrieves all recordings stored on the device
+func (c *Client) GetRecordings(ctx context.Context) ([]*Recording, error) {
+	endpoint := c.recordingEndpoint
+	if endpoint == "" {
+		return nil, ErrServiceNotSupported
+	}
+
+	type GetRecordings struct {
+		XMLName xml.Name `xml:"trc:GetRecordings"`
+		Xmlns   string   `xml:"xmlns:trc,attr"`
+	}
+
+	type GetRecordingsResponse struct {
+		XMLName        xml.Name `xml:"GetRecordingsResponse"`
+		RecordingItems []struct {
+			RecordingToken string `xml:"RecordingToken"`
+			Configuration  struct {
+				Source               recordingSourceXML `xml:"Source"`
+				Content              string             `xml:"Content"`
+				MaximumRetentionTime string             `xml:"MaximumRetentionTime"`
+			} `xml:"Configuration"`
+			Tracks struct {
+				Track []struct {
+					TrackToken    string `xml:"TrackToken"`
+					Configuration struct {
+						TrackType   string `xml:"TrackType"`
+						Description string `xml:"Description"`
+					} `xml:"Configuration"`
+				} `xml:"Track"`
+			} `xml:"Tracks"`
+		} `xml:"RecordingItem"`
+	}
+
+	req := GetRecordings{
+		Xmlns: recordingNamespace,
+	}
+
+	var resp GetRecordingsResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetRecordings failed: %w", err)
+	}
+
+	recordings := make([]*Recording, len(resp.RecordingItems))
+	for i, item := range resp.RecordingItems {
+		recording := &Recording{
+			Token:                item.RecordingToken,
+			Source:               item.Configuration.Source.toSource(),
+			Content:              item.Configuration.Content,
+			MaximumRetentionTime: item.Configuration.MaximumRetentionTime,
+		}
+		for _, track := range item.Tracks.Track {
+			recording.Tracks = append(recording.Tracks, &RecordingTrack{
+				Token:       track.TrackToken,
+				TrackType:   track.Configuration.TrackType,
+				Description: track.Configuration.Description,
+			})
+		}
+		recordings[i] = recording
+	}
+
+	return recordings, nil
+}
+
+// FindRecordings starts a recording search and returns the search token used
+// to fetch results with GetRecordingSearchResults
+func (c *Client) FindRecordings(ctx context.Context, scope RecordingSearchScope) (string, error) {
+	endpoint := c.searchEndpoint
+	if endpoint == "" {
+		return "", ErrServiceNotSupported
+	}
+
+	type FindRecordings struct {
+		XMLName xml.Name `xml:"tse:FindRecordings"`
+		Xmlns   string   `xml:"xmlns:tse,attr"`
+		Xmlnst  string   `xml:"xmlns:tt,attr"`
+		Scope   struct {
+			IncludedSources []struct {
+				Token string `xml:"tt:Token"`
+			} `xml:"tt:IncludedSources,omitempty"`
+			IncludedRecordings         []string `xml:"tt:IncludedRecordings,omitempty"`
+			RecordingInformationFilter string   `xml:"tt:RecordingInformationFilter,omitempty"`
+		} `xml:"tse:Scope"`
+		KeepAliveTime string `xml:"tse:KeepAliveTime"`
+	}
+
+	type FindRecordingsResponse struct {
+		XMLName     xml.Name `xml:"FindRecordingsResponse"`
+		SearchToken string   `xml:"SearchToken"`
+	}
+
+	req := FindRecordings{
+		Xmlns:         searchNamespace,
+		Xmlnst:        "http://www.onvif.org/ver10/schema",
+		KeepAliveTime: FormatDuration(defaultSearchKeepAlive),
+	}
+	for _, source := range scope.IncludedSources {
+		req.Scope.IncludedSources = append(req.Scope.IncludedSources, struct {
+			Token string `xml:"tt:Token"`
+		}{Token: source})
+	}
+	req.Scope.IncludedRecordings = scope.IncludedRecordings
+	req.Scope.RecordingInformationFilter = scope.RecordingInformationFilter
+
+	var resp FindRecordingsResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return "", fmt.Errorf("FindRecordings failed: %w", err)
+	}
+
+	return resp.SearchToken, nil
+}
+
+// GetRecordingSearchResults retrieves results of a search started with FindRecordings.
+// The device waits up to waitTime for results; SearchState is "Completed" once
+// all matches have been returned.
+func (c *Client) GetRecordingSearchResults(ctx context.Context, searchToken string, maxResults int, waitTime time.Duration) (*FindRecordingResult, error) {
+	endpoint := c.searchEndpoint
+	if endpoint == "" {
+		return nil, ErrServiceNotSupported
+	}
+
+	type GetRecordingSearchResults struct {
+		XMLName     xml.Name `xml:"tse:GetRecordingSearchResults"`
+		Xmlns       string   `xml:"xmlns:tse,attr"`
+		SearchToken string   `xml:"tse:SearchToken"`
+		MaxResults  int      `xml:"tse:MaxResults,omitempty"`
+		WaitTime    string   `xml:"tse:WaitTime,omitempty"`
+	}
+
+	type GetRecordingSearchResultsResponse struct {
+		XMLName    xml.Name `xml:"GetRecordingSearchResultsResponse"`
+		ResultList struct {
+			SearchState          string `xml:"SearchState"`
+			RecordingInformation []struct {
+				RecordingToken    string             `xml:"RecordingToken"`
+				Source            recordingSourceXML `xml:"Source"`
+				EarliestRecording time.Time          `xml:"EarliestRecording"`
+				LatestRecording   time.Time          `xml:"LatestRecording"`
+				Content           string             `xml:"Content"`
+				RecordingStatus   string             `xml:"RecordingStatus"`
+			} `xml:"RecordingInformation"`
+		} `xml:"ResultList"`
+	}
+
+	req := GetRecordingSearchResults{
+		Xmlns:       searchNamespace,
+		SearchToken: searchToken,
+		MaxResults:  maxResults,
+	}
+	if waitTime > 0 {
+		req.WaitTime = FormatDuration(waitTime)
+	}
+
+	var resp GetRecordingSearchResultsResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetRecordingSearchResults failed: %w", err)
+	}
+
+	result := &FindRecordingResult{
+		SearchState: resp.ResultList.SearchState,
+	}
+	for _, info := range resp.ResultList.RecordingInformation {
+		result.RecordingInformation = append(result.RecordingInformation, &RecordingInformation{
+			RecordingToken:    info.RecordingToken,
+			Source:            info.Source.toSource(),
+			EarliestRecording: info.EarliestRecording,
+			LatestRecording:   info.LatestRecording,
+			Content:           info.Content,
+			RecordingStatus:   info.RecordingStatus,
+		})
+	}
+
+	return result, nil
+}
+
+// GetReplayURI retrieves the RTSP URI for playing back a recording
+func (c *Client) GetReplayURI(ctx context.Context, recordingToken string) (string, error) {
+	endpoint := c.replayEndpoint
+	if endpoint == "" {
+		return "", ErrServiceNotSupported
+	}
+
+	type GetReplayUri struct {
+		XMLName     xml.Name `xml:"trp:GetReplayUri"`
+		Xmlns       string   `xml:"xmlns:trp,attr"`
+		Xmlnst      string   `xml:"xmlns:tt,attr"`
+		StreamSetup struct {
+			Stream    string `xml:"tt:Stream"`
+			Transport struct {
+				Protocol string `xml:"tt:Protocol"`
+			} `xml:"tt:Transport"`
+		} `xml:"trp:StreamSetup"`
+		RecordingToken string `xml:"trp:RecordingToken"`
+	}
+
+	type GetReplayUriResponse struct {
+		XMLName xml.Name `xml:"GetReplayUriResponse"`
+		Uri     string   `xml:"Uri"`
+	}
+
+	req := GetReplayUri{
+		Xmlns:          replayNamespace,
+		Xmlnst:         "http://www.onvif.org/ver10/schema",
+		RecordingToken: recordingToken,
+	}
+	req.StreamSetup.Stream = "RTP-Unicast"
+	req.StreamSetup.Transport.Protocol = "RTSP"
+
+	var resp GetReplayUriResponse
+
+	soapClient := c.soapClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return "", fmt.Errorf("GetReplayUri failed: %w", err)
+	}
+
+	return resp.Uri, nil
+}
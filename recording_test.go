@@ -0,0 +1,214 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetRecordings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<trc:GetRecordingsResponse xmlns:trc="http://www.onvif.org/ver10/recording/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<trc:RecordingItem>
+						<tt:RecordingToken>SDRecording_1</tt:RecordingToken>
+						<tt:Configuration>
+							<tt:Source>
+								<tt:SourceId>VideoSource_1</tt:SourceId>
+								<tt:Name>Front door</tt:Name>
+							</tt:Source>
+							<tt:Content>SD card</tt:Content>
+							<tt:MaximumRetentionTime>PT0S</tt:MaximumRetentionTime>
+						</tt:Configuration>
+						<tt:Tracks>
+							<tt:Track>
+								<tt:TrackToken>VIDEO001</tt:TrackToken>
+								<tt:Configuration>
+									<tt:TrackType>Video</tt:TrackType>
+								</tt:Configuration>
+							</tt:Track>
+						</tt:Tracks>
+					</trc:RecordingItem>
+				</trc:GetRecordingsResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.recordingEndpoint = server.URL
+
+	recordings, err := client.GetRecordings(context.Background())
+	if err != nil {
+		t.Fatalf("GetRecordings() error = %v", err)
+	}
+
+	if len(recordings) != 1 {
+		t.Fatalf("Expected 1 recording, got %d", len(recordings))
+	}
+	rec := recordings[0]
+	if rec.Token != "SDRecording_1" || rec.Source.SourceID != "VideoSource_1" || rec.Content != "SD card" {
+		t.Errorf("Unexpected recording: %+v", rec)
+	}
+	if len(rec.Tracks) != 1 || rec.Tracks[0].TrackType != "Video" {
+		t.Errorf("Unexpected tracks: %+v", rec.Tracks)
+	}
+}
+
+func TestFindRecordings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "<tt:IncludedRecordings>SDRecording_1</tt:IncludedRecordings>") {
+			t.Errorf("Expected scope in request, got: %s", body)
+		}
+		if !strings.Contains(string(body), "<tse:KeepAliveTime>PT1M</tse:KeepAliveTime>") {
+			t.Errorf("Expected keep alive time in request, got: %s", body)
+		}
+
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tse:FindRecordingsResponse xmlns:tse="http://www.onvif.org/ver10/search/wsdl">
+					<tse:SearchToken>search-1</tse:SearchToken>
+				</tse:FindRecordingsResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.searchEndpoint = server.URL
+
+	token, err := client.FindRecordings(context.Background(), RecordingSearchScope{
+		IncludedRecordings: []string{"SDRecording_1"},
+	})
+	if err != nil {
+		t.Fatalf("FindRecordings() error = %v", err)
+	}
+
+	if token != "search-1" {
+		t.Errorf("Expected search token search-1, got %s", token)
+	}
+}
+
+func TestGetRecordingSearchResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "<tse:WaitTime>PT5S</tse:WaitTime>") {
+			t.Errorf("Expected wait time in request, got: %s", body)
+		}
+
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<tse:GetRecordingSearchResultsResponse xmlns:tse="http://www.onvif.org/ver10/search/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+					<tse:ResultList>
+						<tt:SearchState>Completed</tt:SearchState>
+						<tt:RecordingInformation>
+							<tt:RecordingToken>SDRecording_1</tt:RecordingToken>
+							<tt:EarliestRecording>2024-01-01T00:00:00Z</tt:EarliestRecording>
+							<tt:LatestRecording>2024-01-02T00:00:00Z</tt:LatestRecording>
+							<tt:RecordingStatus>Recording</tt:RecordingStatus>
+						</tt:RecordingInformation>
+					</tse:ResultList>
+				</tse:GetRecordingSearchResultsResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.searchEndpoint = server.URL
+
+	result, err := client.GetRecordingSearchResults(context.Background(), "search-1", 10, 5*time.Second)
+	if err != nil {
+		t.Fatalf("GetRecordingSearchResults() error = %v", err)
+	}
+
+	if result.SearchState != "Completed" || len(result.RecordingInformation) != 1 {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+	info := result.RecordingInformation[0]
+	if info.RecordingToken != "SDRecording_1" || info.RecordingStatus != "Recording" {
+		t.Errorf("Unexpected recording information: %+v", info)
+	}
+	if !info.LatestRecording.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected LatestRecording: %v", info.LatestRecording)
+	}
+}
+
+func TestGetReplayURI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "<trp:RecordingToken>SDRecording_1</trp:RecordingToken>") {
+			t.Errorf("Expected recording token in request, got: %s", body)
+		}
+
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+		<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+			<s:Body>
+				<trp:GetReplayUriResponse xmlns:trp="http://www.onvif.org/ver10/replay/wsdl">
+					<trp:Uri>rtsp://192.168.1.100/replay/SDRecording_1</trp:Uri>
+				</trp:GetReplayUriResponse>
+			</s:Body>
+		</s:Envelope>`
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.replayEndpoint = server.URL
+
+	uri, err := client.GetReplayURI(context.Background(), "SDRecording_1")
+	if err != nil {
+		t.Fatalf("GetReplayURI() error = %v", err)
+	}
+
+	if uri != "rtsp://192.168.1.100/replay/SDRecording_1" {
+		t.Errorf("Unexpected replay URI: %s", uri)
+	}
+}
+
+func TestRecordingServicesNotSupported(t *testing.T) {
+	client, err := NewClient("http://192.168.1.100/onvif/device_service")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := client.GetRecordings(ctx); !errors.Is(err, ErrServiceNotSupported) {
+		t.Errorf("GetRecordings: expected ErrServiceNotSupported, got %v", err)
+	}
+	if _, err := client.FindRecordings(ctx, RecordingSearchScope{}); !errors.Is(err, ErrServiceNotSupported) {
+		t.Errorf("FindRecordings: expected ErrServiceNotSupported, got %v", err)
+	}
+	if _, err := client.GetReplayURI(ctx, "rec"); !errors.Is(err, ErrServiceNotSupported) {
+		t.Errorf("GetReplayURI: expected ErrServiceNotSupported, got %v", err)
+	}
+}
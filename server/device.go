@@ -3,8 +3,10 @@ package server
 import (
 	"encoding/xml"
 	"fmt"
+	"slices"
 	"time"
 
+	"github.com/0x524a/onvif-go"
 	"github.com/0x524a/onvif-go/server/soap"
 )
 
@@ -39,61 +41,62 @@ type Capabilities struct {
 // AnalyticsCapabilities represents analytics service capabilities
 type AnalyticsCapabilities struct {
 	XAddr                  string `xml:"XAddr"`
-	RuleSupport            bool   `xml:"RuleSupport,attr"`
-	AnalyticsModuleSupport bool   `xml:"AnalyticsModuleSupport,attr"`
+	RuleSupport            bool   `xml:"RuleSupport"`
+	AnalyticsModuleSupport bool   `xml:"AnalyticsModuleSupport"`
 }
 
 // DeviceCapabilities represents device service capabilities
 type DeviceCapabilities struct {
-	XAddr   string                `xml:"XAddr"`
-	Network *NetworkCapabilities  `xml:"Network,omitempty"`
-	System  *SystemCapabilities   `xml:"System,omitempty"`
-	IO      *IOCapabilities       `xml:"IO,omitempty"`
+	XAddr    string                `xml:"XAddr"`
+	Network  *NetworkCapabilities  `xml:"Network,omitempty"`
+	System   *SystemCapabilities   `xml:"System,omitempty"`
+	IO       *IOCapabilities       `xml:"IO,omitempty"`
 	Security *SecurityCapabilities `xml:"Security,omitempty"`
 }
 
 // NetworkCapabilities represents network capabilities
 type NetworkCapabilities struct {
-	IPFilter          bool `xml:"IPFilter,attr"`
-	ZeroConfiguration bool `xml:"ZeroConfiguration,attr"`
-	IPVersion6        bool `xml:"IPVersion6,attr"`
-	DynDNS            bool `xml:"DynDNS,attr"`
+	IPFilter          bool `xml:"IPFilter"`
+	ZeroConfiguration bool `xml:"ZeroConfiguration"`
+	IPVersion6        bool `xml:"IPVersion6"`
+	DynDNS            bool `xml:"DynDNS"`
 }
 
 // SystemCapabilities represents system capabilities
 type SystemCapabilities struct {
-	DiscoveryResolve  bool `xml:"DiscoveryResolve,attr"`
-	DiscoveryBye      bool `xml:"DiscoveryBye,attr"`
-	RemoteDiscovery   bool `xml:"RemoteDiscovery,attr"`
-	SystemBackup      bool `xml:"SystemBackup,attr"`
-	SystemLogging     bool `xml:"SystemLogging,attr"`
-	FirmwareUpgrade   bool `xml:"FirmwareUpgrade,attr"`
+	DiscoveryResolve  bool      `xml:"DiscoveryResolve"`
+	DiscoveryBye      bool      `xml:"DiscoveryBye"`
+	RemoteDiscovery   bool      `xml:"RemoteDiscovery"`
+	SystemBackup      bool      `xml:"SystemBackup"`
+	SystemLogging     bool      `xml:"SystemLogging"`
+	FirmwareUpgrade   bool      `xml:"FirmwareUpgrade"`
+	SupportedVersions []Version `xml:"SupportedVersions"`
 }
 
 // IOCapabilities represents I/O capabilities
 type IOCapabilities struct {
-	InputConnectors int `xml:"InputConnectors,attr"`
-	RelayOutputs    int `xml:"RelayOutputs,attr"`
+	InputConnectors int `xml:"InputConnectors"`
+	RelayOutputs    int `xml:"RelayOutputs"`
 }
 
 // SecurityCapabilities represents security capabilities
 type SecurityCapabilities struct {
-	TLS11                bool `xml:"TLS1.1,attr"`
-	TLS12                bool `xml:"TLS1.2,attr"`
-	OnboardKeyGeneration bool `xml:"OnboardKeyGeneration,attr"`
-	AccessPolicyConfig   bool `xml:"AccessPolicyConfig,attr"`
-	X509Token            bool `xml:"X.509Token,attr"`
-	SAMLToken            bool `xml:"SAMLToken,attr"`
-	KerberosToken        bool `xml:"KerberosToken,attr"`
-	RELToken             bool `xml:"RELToken,attr"`
+	TLS11                bool `xml:"TLS1.1"`
+	TLS12                bool `xml:"TLS1.2"`
+	OnboardKeyGeneration bool `xml:"OnboardKeyGeneration"`
+	AccessPolicyConfig   bool `xml:"AccessPolicyConfig"`
+	X509Token            bool `xml:"X.509Token"`
+	SAMLToken            bool `xml:"SAMLToken"`
+	KerberosToken        bool `xml:"KerberosToken"`
+	RELToken             bool `xml:"RELToken"`
 }
 
 // EventCapabilities represents event service capabilities
 type EventCapabilities struct {
 	XAddr                         string `xml:"XAddr"`
-	WSSubscriptionPolicySupport   bool   `xml:"WSSubscriptionPolicySupport,attr"`
-	WSPullPointSupport            bool   `xml:"WSPullPointSupport,attr"`
-	WSPausableSubscriptionSupport bool   `xml:"WSPausableSubscriptionManagerInterfaceSupport,attr"`
+	WSSubscriptionPolicySupport   bool   `xml:"WSSubscriptionPolicySupport"`
+	WSPullPointSupport            bool   `xml:"WSPullPointSupport"`
+	WSPausableSubscriptionSupport bool   `xml:"WSPausableSubscriptionManagerInterfaceSupport"`
 }
 
 // ImagingCapabilities represents imaging service capabilities
@@ -109,9 +112,9 @@ type MediaCapabilities struct {
 
 // StreamingCapabilities represents streaming capabilities
 type StreamingCapabilities struct {
-	RTPMulticast bool `xml:"RTPMulticast,attr"`
-	RTP_TCP      bool `xml:"RTP_TCP,attr"`
-	RTP_RTSP_TCP bool `xml:"RTP_RTSP_TCP,attr"`
+	RTPMulticast bool `xml:"RTPMulticast"`
+	RTP_TCP      bool `xml:"RTP_TCP"`
+	RTP_RTSP_TCP bool `xml:"RTP_RTSP_TCP"`
 }
 
 // PTZCapabilities represents PTZ service capabilities
@@ -127,8 +130,8 @@ type GetServicesResponse struct {
 
 // Service represents a service
 type Service struct {
-	Namespace string `xml:"Namespace"`
-	XAddr     string `xml:"XAddr"`
+	Namespace string  `xml:"Namespace"`
+	XAddr     string  `xml:"XAddr"`
 	Version   Version `xml:"Version"`
 }
 
@@ -144,6 +147,64 @@ type SystemRebootResponse struct {
 	Message string   `xml:"Message"`
 }
 
+// Date and time types reported by GetSystemDateAndTime
+const (
+	DateTimeTypeNTP    = "NTP"
+	DateTimeTypeManual = "Manual"
+)
+
+// SetSystemDateAndTimeRequest represents SetSystemDateAndTime request
+type SetSystemDateAndTimeRequest struct {
+	XMLName         xml.Name `xml:"http://www.onvif.org/ver10/device/wsdl SetSystemDateAndTime"`
+	DateTimeType    string   `xml:"DateTimeType"`
+	DaylightSavings bool     `xml:"DaylightSavings"`
+	TimeZone        *struct {
+		TZ string `xml:"TZ"`
+	} `xml:"TimeZone"`
+	UTCDateTime *soap.DateTime `xml:"UTCDateTime"`
+}
+
+// SetSystemDateAndTimeResponse represents SetSystemDateAndTime response
+type SetSystemDateAndTimeResponse struct {
+	XMLName xml.Name `xml:"http://www.onvif.org/ver10/device/wsdl SetSystemDateAndTimeResponse"`
+}
+
+// User levels accepted by CreateUsers and SetUser
+const (
+	UserLevelAdministrator = "Administrator"
+	UserLevelOperator      = "Operator"
+	UserLevelUser          = "User"
+)
+
+// User represents a user account. Passwords are accepted by CreateUsers and
+// SetUser but never returned by GetUsers.
+type User struct {
+	Username  string `xml:"Username"`
+	Password  string `xml:"Password,omitempty"`
+	UserLevel string `xml:"UserLevel"`
+}
+
+// GetUsersResponse represents GetUsers response
+type GetUsersResponse struct {
+	XMLName xml.Name `xml:"http://www.onvif.org/ver10/device/wsdl GetUsersResponse"`
+	User    []User   `xml:"User"`
+}
+
+// CreateUsersResponse represents CreateUsers response
+type CreateUsersResponse struct {
+	XMLName xml.Name `xml:"http://www.onvif.org/ver10/device/wsdl CreateUsersResponse"`
+}
+
+// DeleteUsersResponse represents DeleteUsers response
+type DeleteUsersResponse struct {
+	XMLName xml.Name `xml:"http://www.onvif.org/ver10/device/wsdl DeleteUsersResponse"`
+}
+
+// SetUserResponse represents SetUser response
+type SetUserResponse struct {
+	XMLName xml.Name `xml:"http://www.onvif.org/ver10/device/wsdl SetUserResponse"`
+}
+
 // Device service handlers
 
 // HandleGetDeviceInformation handles GetDeviceInformation request
@@ -157,16 +218,11 @@ func (s *Server) HandleGetDeviceInformation(body interface{}) (interface{}, erro
 	}, nil
 }
 
-// HandleGetCapabilities handles GetCapabilities request
+// HandleGetCapabilities handles GetCapabilities request. Only the services
+// enabled in the configuration are reported, at the address the server is
+// listening on.
 func (s *Server) HandleGetCapabilities(body interface{}) (interface{}, error) {
-	// Get the host from the request (in a real implementation)
-	// For now, use a placeholder
-	host := s.config.Host
-	if host == "0.0.0.0" || host == "" {
-		host = "localhost"
-	}
-
-	baseURL := fmt.Sprintf("http://%s:%d%s", host, s.config.Port, s.config.BasePath)
+	baseURL := s.serviceBaseURL()
 
 	capabilities := &Capabilities{
 		Device: &DeviceCapabilities{
@@ -184,6 +240,9 @@ func (s *Server) HandleGetCapabilities(body interface{}) (interface{}, error) {
 				SystemBackup:     false,
 				SystemLogging:    false,
 				FirmwareUpgrade:  false,
+				SupportedVersions: []Version{
+					{Major: 2, Minor: 5},
+				},
 			},
 			IO: &IOCapabilities{
 				InputConnectors: 0,
@@ -210,6 +269,12 @@ func (s *Server) HandleGetCapabilities(body interface{}) (interface{}, error) {
 		},
 	}
 
+	if s.config.SupportAnalytics {
+		capabilities.Analytics = &AnalyticsCapabilities{
+			XAddr: baseURL + "/analytics_service",
+		}
+	}
+
 	if s.config.SupportPTZ {
 		capabilities.PTZ = &PTZCapabilities{
 			XAddr: baseURL + "/ptz_service",
@@ -226,7 +291,7 @@ func (s *Server) HandleGetCapabilities(body interface{}) (interface{}, error) {
 		capabilities.Events = &EventCapabilities{
 			XAddr:                         baseURL + "/events_service",
 			WSSubscriptionPolicySupport:   false,
-			WSPullPointSupport:            false,
+			WSPullPointSupport:            true,
 			WSPausableSubscriptionSupport: false,
 		}
 	}
@@ -236,50 +301,112 @@ func (s *Server) HandleGetCapabilities(body interface{}) (interface{}, error) {
 	}, nil
 }
 
-// HandleGetSystemDateAndTime handles GetSystemDateAndTime request
+// HandleGetSystemDateAndTime handles GetSystemDateAndTime request. The
+// local time is the system clock in the configured POSIX time zone.
 func (s *Server) HandleGetSystemDateAndTime(body interface{}) (interface{}, error) {
-	now := time.Now().UTC()
+	s.timeMu.Lock()
+	defer s.timeMu.Unlock()
+
+	now := s.systemTime.Add(time.Since(s.systemTimeAt))
 
 	return &soap.GetSystemDateAndTimeResponse{
 		SystemDateAndTime: soap.SystemDateAndTime{
-			DateTimeType:    "NTP",
-			DaylightSavings: false,
+			DateTimeType:    s.dateTimeType,
+			DaylightSavings: s.daylightSavings,
 			TimeZone: soap.TimeZone{
-				TZ: "UTC",
+				TZ: s.timeZone,
 			},
-			UTCDateTime:   soap.ToDateTime(now),
-			LocalDateTime: soap.ToDateTime(now.Local()),
+			UTCDateTime:   soap.ToDateTime(now.UTC()),
+			LocalDateTime: soap.ToDateTime(now.In(s.timeLocation)),
 		},
 	}, nil
 }
 
-// HandleGetServices handles GetServices request
-func (s *Server) HandleGetServices(body interface{}) (interface{}, error) {
-	host := s.config.Host
-	if host == "0.0.0.0" || host == "" {
-		host = "localhost"
+// HandleSetSystemDateAndTime handles SetSystemDateAndTime request. A Manual
+// request must carry the UTC date and time, which sets the system clock; an
+// NTP request keeps the clock running.
+func (s *Server) HandleSetSystemDateAndTime(body interface{}) (interface{}, error) {
+	var req SetSystemDateAndTimeRequest
+	if err := unmarshalBody(body, &req); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
+	}
+
+	if req.DateTimeType != DateTimeTypeNTP && req.DateTimeType != DateTimeTypeManual {
+		return nil, fmt.Errorf("%w: unknown date time type %q", ErrInvalidArgs, req.DateTimeType)
 	}
 
-	baseURL := fmt.Sprintf("http://%s:%d%s", host, s.config.Port, s.config.BasePath)
+	var location *time.Location
+	if req.TimeZone != nil {
+		var err error
+		if location, err = onvif.ParsePOSIXTZ(req.TimeZone.TZ); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidTimeZone, err)
+		}
+	}
+
+	var systemTime time.Time
+	if req.DateTimeType == DateTimeTypeManual {
+		if req.UTCDateTime == nil {
+			return nil, fmt.Errorf("%w: UTCDateTime is required for Manual", ErrInvalidDateTime)
+		}
+		d := req.UTCDateTime
+		systemTime = time.Date(d.Date.Year, time.Month(d.Date.Month), d.Date.Day, d.Time.Hour, d.Time.Minute, d.Time.Second, 0, time.UTC)
+		// time.Date normalizes out of range values; reject them instead
+		if soap.ToDateTime(systemTime) != *d {
+			return nil, fmt.Errorf("%w: %04d-%02d-%02dT%02d:%02d:%02dZ", ErrInvalidDateTime,
+				d.Date.Year, d.Date.Month, d.Date.Day, d.Time.Hour, d.Time.Minute, d.Time.Second)
+		}
+	}
+
+	s.timeMu.Lock()
+	defer s.timeMu.Unlock()
+
+	s.dateTimeType = req.DateTimeType
+	s.daylightSavings = req.DaylightSavings
+	if location != nil {
+		s.timeZone = req.TimeZone.TZ
+		s.timeLocation = location
+	}
+	if !systemTime.IsZero() {
+		s.systemTime = systemTime
+		s.systemTimeAt = time.Now()
+	}
+
+	return &SetSystemDateAndTimeResponse{}, nil
+}
+
+// HandleGetServices handles GetServices request. Each enabled service is
+// listed with its namespace, the address the server is listening on and the
+// ONVIF version it implements.
+func (s *Server) HandleGetServices(body interface{}) (interface{}, error) {
+	baseURL := s.serviceBaseURL()
+	version := Version{Major: 2, Minor: 5}
 
 	services := []Service{
 		{
 			Namespace: "http://www.onvif.org/ver10/device/wsdl",
 			XAddr:     baseURL + "/device_service",
-			Version:   Version{Major: 2, Minor: 5},
+			Version:   version,
 		},
 		{
 			Namespace: "http://www.onvif.org/ver10/media/wsdl",
 			XAddr:     baseURL + "/media_service",
-			Version:   Version{Major: 2, Minor: 5},
+			Version:   version,
 		},
 	}
 
+	if s.config.SupportMedia2 {
+		services = append(services, Service{
+			Namespace: "http://www.onvif.org/ver20/media/wsdl",
+			XAddr:     baseURL + "/media2_service",
+			Version:   version,
+		})
+	}
+
 	if s.config.SupportPTZ {
 		services = append(services, Service{
 			Namespace: "http://www.onvif.org/ver20/ptz/wsdl",
 			XAddr:     baseURL + "/ptz_service",
-			Version:   Version{Major: 2, Minor: 5},
+			Version:   version,
 		})
 	}
 
@@ -287,7 +414,23 @@ func (s *Server) HandleGetServices(body interface{}) (interface{}, error) {
 		services = append(services, Service{
 			Namespace: "http://www.onvif.org/ver20/imaging/wsdl",
 			XAddr:     baseURL + "/imaging_service",
-			Version:   Version{Major: 2, Minor: 5},
+			Version:   version,
+		})
+	}
+
+	if s.config.SupportEvents {
+		services = append(services, Service{
+			Namespace: "http://www.onvif.org/ver10/events/wsdl",
+			XAddr:     baseURL + "/events_service",
+			Version:   version,
+		})
+	}
+
+	if s.config.SupportAnalytics {
+		services = append(services, Service{
+			Namespace: "http://www.onvif.org/ver20/analytics/wsdl",
+			XAddr:     baseURL + "/analytics_service",
+			Version:   version,
 		})
 	}
 
@@ -302,3 +445,139 @@ func (s *Server) HandleSystemReboot(body interface{}) (interface{}, error) {
 		Message: "Device rebooting",
 	}, nil
 }
+
+// HandleGetUsers handles GetUsers request
+func (s *Server) HandleGetUsers(body interface{}) (interface{}, error) {
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+
+	users := make([]User, len(s.users))
+	for i, user := range s.users {
+		users[i] = User{Username: user.Username, UserLevel: user.UserLevel}
+	}
+
+	return &GetUsersResponse{
+		User: users,
+	}, nil
+}
+
+// HandleCreateUsers handles CreateUsers request. Either all users are
+// created or, if any is invalid or its username is taken, none is.
+func (s *Server) HandleCreateUsers(body interface{}) (interface{}, error) {
+	var req struct {
+		User []User `xml:"User"`
+	}
+
+	if err := unmarshalBody(body, &req); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
+	}
+
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+
+	for i, user := range req.User {
+		if user.Username == "" {
+			return nil, fmt.Errorf("%w: username is required", ErrInvalidArgs)
+		}
+		if err := validateUserLevel(user.UserLevel); err != nil {
+			return nil, err
+		}
+		if s.findUser(user.Username) >= 0 || slices.ContainsFunc(req.User[:i], func(u User) bool { return u.Username == user.Username }) {
+			return nil, fmt.Errorf("%w: %s", ErrUsernameClash, user.Username)
+		}
+	}
+
+	s.users = append(s.users, req.User...)
+
+	return &CreateUsersResponse{}, nil
+}
+
+// HandleDeleteUsers handles DeleteUsers request. Either all users are
+// deleted or, if any does not exist, none is.
+func (s *Server) HandleDeleteUsers(body interface{}) (interface{}, error) {
+	var req struct {
+		Username []string `xml:"Username"`
+	}
+
+	if err := unmarshalBody(body, &req); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
+	}
+
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+
+	for _, username := range req.Username {
+		if s.findUser(username) < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrUsernameMissing, username)
+		}
+	}
+
+	s.users = slices.DeleteFunc(s.users, func(user User) bool {
+		return slices.Contains(req.Username, user.Username)
+	})
+
+	return &DeleteUsersResponse{}, nil
+}
+
+// HandleSetUser handles SetUser request. Each user's level is
+// changed, and its password when one is given; a changed password is
+// required from the next request on.
+func (s *Server) HandleSetUser(body interface{}) (interface{}, error) {
+	var req struct {
+		User []User `xml:"User"`
+	}
+
+	if err := unmarshalBody(body, &req); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
+	}
+
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+
+	for _, user := range req.User {
+		if s.findUser(user.Username) < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrUsernameMissing, user.Username)
+		}
+		if err := validateUserLevel(user.UserLevel); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, user := range req.User {
+		stored := &s.users[s.findUser(user.Username)]
+		stored.UserLevel = user.UserLevel
+		if user.Password != "" {
+			stored.Password = user.Password
+		}
+	}
+
+	return &SetUserResponse{}, nil
+}
+
+// userPassword returns the password of a user account, for authentication
+func (s *Server) userPassword(username string) (string, bool) {
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+
+	if i := s.findUser(username); i >= 0 {
+		return s.users[i].Password, true
+	}
+	return "", false
+}
+
+// findUser returns the index of a user account, or -1. The caller must hold
+// usersMu.
+func (s *Server) findUser(username string) int {
+	return slices.IndexFunc(s.users, func(user User) bool {
+		return user.Username == username
+	})
+}
+
+// validateUserLevel checks that a user level is one that can be assigned
+func validateUserLevel(level string) error {
+	switch level {
+	case UserLevelAdministrator, UserLevelOperator, UserLevelUser:
+		return nil
+	}
+	return fmt.Errorf("%w: unknown user level %q", ErrInvalidArgs, level)
+}
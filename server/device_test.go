@@ -0,0 +1,333 @@
+package server
+
+import (
+	"context"
+	"encoding/xml"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0x524a/onvif-go"
+	"github.com/0x524a/onvif-go/server/soap"
+)
+
+// startConfiguredServer runs Start with config on an ephemeral port and
+// returns the address it bound
+func startConfiguredServer(t *testing.T, config *Config) (*Server, string) {
+	t.Helper()
+
+	config.Host = "127.0.0.1"
+	config.Port = 0
+
+	srv, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Start(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	for i := 0; i < 100; i++ {
+		srv.mu.Lock()
+		addr := srv.listenAddr
+		srv.mu.Unlock()
+		if addr != nil {
+			return srv, addr.String()
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("server did not start")
+	return nil, ""
+}
+
+func TestGetCapabilitiesReflectsConfig(t *testing.T) {
+	config := DefaultConfig()
+	config.SupportPTZ = true
+	config.SupportImaging = false
+	config.SupportEvents = true
+	config.SimulatedMotionInterval = 0
+
+	_, addr := startConfiguredServer(t, config)
+	_, port, _ := net.SplitHostPort(addr)
+
+	client, err := onvif.NewClient("http://"+addr+"/onvif/device_service",
+		onvif.WithCredentials(config.Username, config.Password))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := client.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	caps, err := client.GetCapabilities(ctx)
+	if err != nil {
+		t.Fatalf("GetCapabilities failed: %v", err)
+	}
+
+	base := "http://127.0.0.1:" + port + "/onvif"
+	if caps.Device == nil || caps.Device.XAddr != base+"/device_service" {
+		t.Errorf("unexpected device capabilities: %+v", caps.Device)
+	}
+	if caps.Media == nil || caps.Media.XAddr != base+"/media_service" {
+		t.Errorf("unexpected media capabilities: %+v", caps.Media)
+	}
+	if caps.Media != nil && (caps.Media.StreamingCapabilities == nil || !caps.Media.StreamingCapabilities.RTP_RTSP_TCP) {
+		t.Errorf("expected RTP_RTSP_TCP streaming, got %+v", caps.Media.StreamingCapabilities)
+	}
+	if caps.PTZ == nil || caps.PTZ.XAddr != base+"/ptz_service" {
+		t.Errorf("unexpected PTZ capabilities: %+v", caps.PTZ)
+	}
+	if caps.Events == nil || caps.Events.XAddr != base+"/events_service" || !caps.Events.WSPullPointSupport {
+		t.Errorf("unexpected events capabilities: %+v", caps.Events)
+	}
+	if caps.Imaging != nil {
+		t.Errorf("imaging is disabled but was reported: %+v", caps.Imaging)
+	}
+	if caps.Analytics != nil {
+		t.Errorf("analytics is not served but was reported: %+v", caps.Analytics)
+	}
+}
+
+func TestServiceEndpointsOmitDisabledServices(t *testing.T) {
+	config := DefaultConfig()
+	config.SupportPTZ = false
+	config.SupportImaging = false
+	config.SupportEvents = false
+
+	endpoints := config.ServiceEndpoints("camera.local")
+	if len(endpoints) != 2 {
+		t.Errorf("expected only device and media endpoints, got %v", endpoints)
+	}
+	if got := endpoints["media"]; got != "http://camera.local:8080/onvif/media_service" {
+		t.Errorf("unexpected media endpoint %q", got)
+	}
+
+	config.Port = 80
+	if got := config.ServiceEndpoints("camera.local")["device"]; strings.Contains(got, ":80") {
+		t.Errorf("default port should be omitted, got %q", got)
+	}
+}
+
+func TestGetServicesReflectsConfig(t *testing.T) {
+	config := DefaultConfig()
+	config.SupportImaging = false
+	config.SupportEvents = true
+	config.SupportMedia2 = true
+	config.SimulatedMotionInterval = 0
+	config.RequireAuth = false
+
+	_, addr := startConfiguredServer(t, config)
+
+	data := postSOAP(t, "http://"+addr+"/onvif/device_service",
+		`<tds:GetServices xmlns:tds="http://www.onvif.org/ver10/device/wsdl"><tds:IncludeCapability>false</tds:IncludeCapability></tds:GetServices>`)
+
+	var envelope struct {
+		Body struct {
+			Response struct {
+				Service []struct {
+					Namespace string `xml:"Namespace"`
+					XAddr     string `xml:"XAddr"`
+					Major     int    `xml:"Version>Major"`
+				} `xml:"Service"`
+			} `xml:"GetServicesResponse"`
+		} `xml:"Body"`
+	}
+	if err := xml.Unmarshal([]byte(data), &envelope); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, service := range envelope.Body.Response.Service {
+		if service.Major != 2 {
+			t.Errorf("%s: unexpected version %d", service.Namespace, service.Major)
+		}
+		got[service.Namespace] = service.XAddr
+	}
+
+	base := "http://" + addr + "/onvif"
+	want := map[string]string{
+		"http://www.onvif.org/ver10/device/wsdl": base + "/device_service",
+		"http://www.onvif.org/ver10/media/wsdl":  base + "/media_service",
+		"http://www.onvif.org/ver20/media/wsdl":  base + "/media2_service",
+		"http://www.onvif.org/ver20/ptz/wsdl":    base + "/ptz_service",
+		"http://www.onvif.org/ver10/events/wsdl": base + "/events_service",
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected %d services, got %v", len(want), got)
+	}
+	for namespace, xaddr := range want {
+		if got[namespace] != xaddr {
+			t.Errorf("%s: expected %q, got %q", namespace, xaddr, got[namespace])
+		}
+	}
+}
+
+func TestUserManagement(t *testing.T) {
+	config := DefaultConfig()
+	config.RequireAuth = true
+	config.SimulatedMotionInterval = 0
+
+	_, addr := startConfiguredServer(t, config)
+	endpoint := "http://" + addr + "/onvif/device_service"
+
+	client, err := onvif.NewClient(endpoint, onvif.WithCredentials(config.Username, config.Password))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	ctx := context.Background()
+
+	users, err := client.GetUsers(ctx)
+	if err != nil {
+		t.Fatalf("GetUsers failed: %v", err)
+	}
+	if len(users) != 1 || users[0].Username != config.Username || users[0].UserLevel != UserLevelAdministrator || users[0].Password != "" {
+		t.Fatalf("expected the configured administrator, got %+v", users)
+	}
+
+	err = client.CreateUsers(ctx, []*onvif.User{
+		{Username: "operator", Password: "op-pass", UserLevel: UserLevelOperator},
+		{Username: "viewer", Password: "view-pass", UserLevel: UserLevelUser},
+	})
+	if err != nil {
+		t.Fatalf("CreateUsers failed: %v", err)
+	}
+	if err := client.CreateUsers(ctx, []*onvif.User{{Username: "viewer", Password: "x", UserLevel: UserLevelUser}}); err == nil || !strings.Contains(err.Error(), "UsernameClash") {
+		t.Errorf("expected a username clash, got %v", err)
+	}
+
+	users, err = client.GetUsers(ctx)
+	if err != nil {
+		t.Fatalf("GetUsers failed: %v", err)
+	}
+	if len(users) != 3 || users[1].Username != "operator" || users[2].UserLevel != UserLevelUser {
+		t.Errorf("unexpected users after create: %+v", users)
+	}
+
+	// A created user can authenticate
+	viewer, err := onvif.NewClient(endpoint, onvif.WithCredentials("viewer", "view-pass"))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if _, err := viewer.GetDeviceInformation(ctx); err != nil {
+		t.Errorf("created user could not authenticate: %v", err)
+	}
+
+	// Changing the client's own password keeps it working
+	if err := client.SetUser(ctx, &onvif.User{Username: config.Username, Password: "new-pass", UserLevel: UserLevelAdministrator}); err != nil {
+		t.Fatalf("SetUser failed: %v", err)
+	}
+	if _, err := client.GetDeviceInformation(ctx); err != nil {
+		t.Errorf("client failed after changing its own password: %v", err)
+	}
+	stale, err := onvif.NewClient(endpoint, onvif.WithCredentials(config.Username, config.Password))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if _, err := stale.GetDeviceInformation(ctx); err == nil {
+		t.Error("expected the old password to be rejected")
+	}
+
+	if err := client.SetUser(ctx, &onvif.User{Username: "nobody", UserLevel: UserLevelUser}); err == nil || !strings.Contains(err.Error(), "UsernameMissing") {
+		t.Errorf("expected a missing username, got %v", err)
+	}
+
+	if err := client.DeleteUsers(ctx, []string{"viewer", "nobody"}); err == nil || !strings.Contains(err.Error(), "UsernameMissing") {
+		t.Errorf("expected a missing username, got %v", err)
+	}
+	if err := client.DeleteUsers(ctx, []string{"viewer"}); err != nil {
+		t.Fatalf("DeleteUsers failed: %v", err)
+	}
+	users, err = client.GetUsers(ctx)
+	if err != nil {
+		t.Fatalf("GetUsers failed: %v", err)
+	}
+	if len(users) != 2 || users[1].Username != "operator" {
+		t.Errorf("unexpected users after delete: %+v", users)
+	}
+	if _, err := viewer.GetDeviceInformation(ctx); err == nil {
+		t.Error("expected a deleted user to be rejected")
+	}
+}
+
+func TestSystemDateAndTime(t *testing.T) {
+	srv, server := newTestServer(t, func(srv *Server, h *soap.Handler) {
+		h.RegisterHandler("GetSystemDateAndTime", srv.HandleGetSystemDateAndTime)
+		h.RegisterHandler("SetSystemDateAndTime", srv.HandleSetSystemDateAndTime)
+	})
+	defer server.Close()
+
+	client, err := onvif.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	ctx := context.Background()
+
+	dateTime, err := client.GetSystemDateAndTime(ctx)
+	if err != nil {
+		t.Fatalf("GetSystemDateAndTime failed: %v", err)
+	}
+	if dateTime.DateTimeType != DateTimeTypeNTP || dateTime.TimeZone == nil || dateTime.TimeZone.TZ != "UTC0" {
+		t.Errorf("unexpected defaults: %+v", dateTime)
+	}
+	if d := time.Since(dateTime.UTCDateTime); d < -2*time.Second || d > 2*time.Second {
+		t.Errorf("expected the current time, got %v", dateTime.UTCDateTime)
+	}
+
+	postSOAP(t, server.URL, `<tds:SetSystemDateAndTime xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+<tds:DateTimeType>Manual</tds:DateTimeType>
+<tds:DaylightSavings>true</tds:DaylightSavings>
+<tds:TimeZone><tt:TZ>CET-1CEST,M3.5.0,M10.5.0/3</tt:TZ></tds:TimeZone>
+<tds:UTCDateTime>
+<tt:Date><tt:Year>2024</tt:Year><tt:Month>7</tt:Month><tt:Day>1</tt:Day></tt:Date>
+<tt:Time><tt:Hour>10</tt:Hour><tt:Minute>0</tt:Minute><tt:Second>0</tt:Second></tt:Time>
+</tds:UTCDateTime>
+</tds:SetSystemDateAndTime>`)
+
+	dateTime, err = client.GetSystemDateAndTime(ctx)
+	if err != nil {
+		t.Fatalf("GetSystemDateAndTime failed: %v", err)
+	}
+	set := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	if d := dateTime.UTCDateTime.Sub(set); dateTime.DateTimeType != DateTimeTypeManual || !dateTime.DaylightSavings || d < 0 || d > 2*time.Second {
+		t.Errorf("unexpected date and time after set: %+v", dateTime)
+	}
+	// CEST is UTC+2 in July
+	if got := dateTime.LocalDateTime.Sub(dateTime.UTCDateTime); got != 2*time.Hour {
+		t.Errorf("expected local time 2h ahead of UTC, got %v", got)
+	}
+	if loc, err := dateTime.Location(); err != nil || dateTime.UTCDateTime.In(loc).Hour() != 12 {
+		t.Errorf("unexpected location %v, %v", loc, err)
+	}
+
+	invalid := []struct {
+		name    string
+		request string
+		subcode string
+	}{
+		{"time zone", `<tds:DateTimeType>NTP</tds:DateTimeType><tds:TimeZone><tt:TZ>not a zone</tt:TZ></tds:TimeZone>`, "ter:InvalidTimeZone"},
+		{"missing time", `<tds:DateTimeType>Manual</tds:DateTimeType>`, "ter:InvalidDateTime"},
+		{"out of range", `<tds:DateTimeType>Manual</tds:DateTimeType><tds:UTCDateTime><tt:Date><tt:Year>2024</tt:Year><tt:Month>2</tt:Month><tt:Day>30</tt:Day></tt:Date><tt:Time><tt:Hour>0</tt:Hour><tt:Minute>0</tt:Minute><tt:Second>0</tt:Second></tt:Time></tds:UTCDateTime>`, "ter:InvalidDateTime"},
+	}
+	for _, tt := range invalid {
+		data, _ := postSOAPStatus(t, server.URL, `<tds:SetSystemDateAndTime xmlns:tds="http://www.onvif.org/ver10/device/wsdl">`+tt.request+`</tds:SetSystemDateAndTime>`)
+		if codes := faultCodes(t, data); len(codes) != 3 || codes[2] != tt.subcode {
+			t.Errorf("%s: expected %s fault, got %v", tt.name, tt.subcode, codes)
+		}
+	}
+
+	srv.timeMu.Lock()
+	tz := srv.timeZone
+	srv.timeMu.Unlock()
+	if tz != "CET-1CEST,M3.5.0,M10.5.0/3" {
+		t.Errorf("rejected requests changed the time zone to %q", tz)
+	}
+}
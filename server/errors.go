@@ -0,0 +1,69 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/0x524a/onvif-go/server/soap"
+)
+
+// Handler errors reported to clients as ONVIF faults. Handlers wrap them with
+// the offending token, e.g. fmt.Errorf("%w: %s", ErrProfileNotFound, token),
+// and the SOAP handler sends the matching fault subcodes.
+var (
+	// ErrInvalidArgs is returned when a request cannot be decoded
+	ErrInvalidArgs = &soap.Error{Code: "Sender", Subcodes: []string{"ter:InvalidArgs"}, Reason: "invalid request"}
+
+	// ErrProfileNotFound is returned when a profile token does not exist
+	ErrProfileNotFound = &soap.Error{Code: "Sender", Subcodes: []string{"ter:InvalidArgVal", "ter:NoProfile"}, Reason: "profile not found"}
+
+	// ErrInvalidStreamSetup is returned when a profile does not offer the
+	// requested stream type or transport protocol
+	ErrInvalidStreamSetup = &soap.Error{Code: "Sender", Subcodes: []string{"ter:InvalidArgVal", "ter:InvalidStreamSetup"}, Reason: "stream setup not supported"}
+
+	// ErrNoPTZProfile is returned when a profile has no PTZ configuration
+	ErrNoPTZProfile = &soap.Error{Code: "Sender", Subcodes: []string{"ter:InvalidArgVal", "ter:NoPTZProfile"}, Reason: "PTZ not supported for profile"}
+
+	// ErrNodeNotFound is returned when a PTZ node token does not exist
+	ErrNodeNotFound = &soap.Error{Code: "Sender", Subcodes: []string{"ter:InvalidArgVal", "ter:NoEntity"}, Reason: "PTZ node not found"}
+
+	// ErrPresetNotFound is returned when a preset token does not exist
+	ErrPresetNotFound = &soap.Error{Code: "Sender", Subcodes: []string{"ter:InvalidArgVal", "ter:NoToken"}, Reason: "preset not found"}
+
+	// ErrConfigNotFound is returned when a configuration token does not exist
+	ErrConfigNotFound = &soap.Error{Code: "Sender", Subcodes: []string{"ter:InvalidArgVal", "ter:NoConfig"}, Reason: "configuration not found"}
+
+	// ErrConfigModify is returned when requested configuration parameters cannot be set
+	ErrConfigModify = &soap.Error{Code: "Sender", Subcodes: []string{"ter:InvalidArgVal", "ter:ConfigModify"}, Reason: "configuration parameters not possible to set"}
+
+	// ErrVideoSourceNotFound is returned when a video source token does not exist
+	ErrVideoSourceNotFound = &soap.Error{Code: "Sender", Subcodes: []string{"ter:InvalidArgVal", "ter:NoSource"}, Reason: "video source not found"}
+
+	// ErrUsernameClash is returned when creating a user whose username is taken
+	ErrUsernameClash = &soap.Error{Code: "Sender", Subcodes: []string{"ter:OperationProhibited", "ter:UsernameClash"}, Reason: "username already exists"}
+
+	// ErrUsernameMissing is returned when a username does not exist
+	ErrUsernameMissing = &soap.Error{Code: "Sender", Subcodes: []string{"ter:InvalidArgVal", "ter:UsernameMissing"}, Reason: "username not recognized"}
+
+	// ErrInvalidTimeZone is returned when a time zone is not a valid POSIX TZ string
+	ErrInvalidTimeZone = &soap.Error{Code: "Sender", Subcodes: []string{"ter:InvalidArgVal", "ter:InvalidTimeZone"}, Reason: "invalid time zone"}
+
+	// ErrInvalidDateTime is returned when a date and time is missing or out of range
+	ErrInvalidDateTime = &soap.Error{Code: "Sender", Subcodes: []string{"ter:InvalidArgVal", "ter:InvalidDateTime"}, Reason: "invalid date and time"}
+
+	// ErrSettingsInvalid is returned when requested settings are missing or rejected
+	ErrSettingsInvalid = &soap.Error{Code: "Sender", Subcodes: []string{"ter:InvalidArgVal", "ter:SettingsInvalid"}, Reason: "invalid settings"}
+
+	// ErrActionNotSupported is returned when a profile does not support the requested action
+	ErrActionNotSupported = &soap.Error{Code: "Receiver", Subcodes: []string{"ter:ActionNotSupported"}, Reason: "action not supported"}
+)
+
+// ptzProfileError reports why a profile token has no PTZ state: either the
+// profile does not exist or it has no PTZ configuration
+func (s *Server) ptzProfileError(profileToken string) error {
+	for i := range s.config.Profiles {
+		if s.config.Profiles[i].Token == profileToken {
+			return fmt.Errorf("%w: %s", ErrNoPTZProfile, profileToken)
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrProfileNotFound, profileToken)
+}
@@ -0,0 +1,467 @@
+package server
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0x524a/onvif-go"
+	"github.com/0x524a/onvif-go/server/soap"
+)
+
+// TopicMotionAlarm is the topic fired by the simulated motion detector
+const TopicMotionAlarm = "tns1:VideoSource/MotionAlarm"
+
+// Event service namespaces and limits
+const (
+	topicsNamespace         = "http://www.onvif.org/ver10/topics"
+	topicDialectConcreteSet = "http://www.onvif.org/ver10/tev/topicExpression/ConcreteSet"
+	subscriptionPathSegment = "/events_service/subscription/"
+	defaultSubscriptionTTL  = 60 * time.Second
+	maxQueuedEvents         = 256
+	maxPullMessagesWait     = time.Minute
+)
+
+// Event service SOAP message types
+
+// CreatePullPointSubscriptionRequest represents CreatePullPointSubscription request
+type CreatePullPointSubscriptionRequest struct {
+	XMLName                xml.Name     `xml:"http://www.onvif.org/ver10/events/wsdl CreatePullPointSubscription"`
+	Filter                 *EventFilter `xml:"Filter"`
+	InitialTerminationTime string       `xml:"InitialTerminationTime,omitempty"`
+}
+
+// EventFilter restricts a subscription to matching topics
+type EventFilter struct {
+	TopicExpression []string `xml:"TopicExpression"`
+}
+
+// CreatePullPointSubscriptionResponse represents CreatePullPointSubscription response
+type CreatePullPointSubscriptionResponse struct {
+	XMLName               xml.Name          `xml:"http://www.onvif.org/ver10/events/wsdl CreatePullPointSubscriptionResponse"`
+	SubscriptionReference EndpointReference `xml:"SubscriptionReference"`
+	CurrentTime           string            `xml:"http://docs.oasis-open.org/wsn/b-2 CurrentTime"`
+	TerminationTime       string            `xml:"http://docs.oasis-open.org/wsn/b-2 TerminationTime"`
+}
+
+// EndpointReference represents a WS-Addressing endpoint reference
+type EndpointReference struct {
+	Address string `xml:"http://www.w3.org/2005/08/addressing Address"`
+}
+
+// PullMessagesRequest represents PullMessages request
+type PullMessagesRequest struct {
+	XMLName      xml.Name `xml:"http://www.onvif.org/ver10/events/wsdl PullMessages"`
+	Timeout      string   `xml:"Timeout"`
+	MessageLimit int      `xml:"MessageLimit"`
+}
+
+// PullMessagesResponse represents PullMessages response
+type PullMessagesResponse struct {
+	XMLName             xml.Name              `xml:"http://www.onvif.org/ver10/events/wsdl PullMessagesResponse"`
+	CurrentTime         string                `xml:"CurrentTime"`
+	TerminationTime     string                `xml:"TerminationTime"`
+	NotificationMessage []NotificationMessage `xml:"http://docs.oasis-open.org/wsn/b-2 NotificationMessage"`
+}
+
+// NotificationMessage represents a WS-BaseNotification message
+type NotificationMessage struct {
+	Topic   NotificationTopic   `xml:"http://docs.oasis-open.org/wsn/b-2 Topic"`
+	Message NotificationPayload `xml:"http://docs.oasis-open.org/wsn/b-2 Message"`
+}
+
+// NotificationTopic represents a topic in the ConcreteSet dialect
+type NotificationTopic struct {
+	Dialect string `xml:"Dialect,attr"`
+	TNS1    string `xml:"xmlns:tns1,attr"`
+	Value   string `xml:",chardata"`
+}
+
+// NotificationPayload wraps the ONVIF event message
+type NotificationPayload struct {
+	Message EventMessage `xml:"http://www.onvif.org/ver10/schema Message"`
+}
+
+// EventMessage represents an ONVIF event message
+type EventMessage struct {
+	UtcTime           string         `xml:"UtcTime,attr"`
+	PropertyOperation string         `xml:"PropertyOperation,attr,omitempty"`
+	Source            *EventItemList `xml:"http://www.onvif.org/ver10/schema Source,omitempty"`
+	Data              *EventItemList `xml:"http://www.onvif.org/ver10/schema Data,omitempty"`
+}
+
+// EventItemList represents a list of simple items
+type EventItemList struct {
+	SimpleItem []EventSimpleItem `xml:"http://www.onvif.org/ver10/schema SimpleItem"`
+}
+
+// EventSimpleItem represents a name/value pair in an event message
+type EventSimpleItem struct {
+	Name  string `xml:"Name,attr"`
+	Value string `xml:"Value,attr"`
+}
+
+// UnsubscribeResponse represents Unsubscribe response
+type UnsubscribeResponse struct {
+	XMLName xml.Name `xml:"http://docs.oasis-open.org/wsn/b-2 UnsubscribeResponse"`
+}
+
+// serverEvent is an event waiting in a subscription queue
+type serverEvent struct {
+	topic     string
+	time      time.Time
+	operation string
+	source    []EventSimpleItem
+	data      []EventSimpleItem
+}
+
+// eventSubscription is a pull point created by CreatePullPointSubscription
+type eventSubscription struct {
+	id      string
+	filter  []string
+	ttl     time.Duration
+	expires time.Time
+	queue   []*serverEvent
+	notify  chan struct{} // Signalled when an event is queued
+	done    chan struct{} // Closed when the subscription ends
+	handler *soap.Handler
+}
+
+// Event service handlers
+
+// HandleCreatePullPointSubscription handles CreatePullPointSubscription request
+func (s *Server) HandleCreatePullPointSubscription(body interface{}) (interface{}, error) {
+	var req CreatePullPointSubscriptionRequest
+	if err := unmarshalBody(body, &req); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
+	}
+
+	now := time.Now()
+	ttl, err := parseTerminationTime(req.InitialTerminationTime, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var filter []string
+	if req.Filter != nil {
+		for _, expr := range req.Filter.TopicExpression {
+			for _, topic := range strings.Split(expr, "|") {
+				if topic = strings.TrimSpace(topic); topic != "" {
+					filter = append(filter, topic)
+				}
+			}
+		}
+	}
+
+	s.eventsMu.Lock()
+	s.expireSubscriptions(now)
+	s.nextSubscriptionID++
+	sub := &eventSubscription{
+		id:      strconv.Itoa(s.nextSubscriptionID),
+		filter:  filter,
+		ttl:     ttl,
+		expires: now.Add(ttl),
+		notify:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	sub.handler = s.newSOAPHandler()
+	sub.handler.RegisterHandler("PullMessages", func(body interface{}) (interface{}, error) {
+		return s.pullMessages(sub, body)
+	})
+	sub.handler.RegisterHandler("Unsubscribe", func(body interface{}) (interface{}, error) {
+		return s.unsubscribe(sub)
+	})
+	s.subscriptions[sub.id] = sub
+	s.eventsMu.Unlock()
+
+	return &CreatePullPointSubscriptionResponse{
+		SubscriptionReference: EndpointReference{
+			Address: s.serviceBaseURL() + subscriptionPathSegment + sub.id,
+		},
+		CurrentTime:     formatEventTime(now),
+		TerminationTime: formatEventTime(sub.expires),
+	}, nil
+}
+
+// pullMessages handles PullMessages on a subscription, waiting up to the
+// requested timeout for an event when the queue is empty. Each pull keeps the
+// subscription alive for another termination period.
+func (s *Server) pullMessages(sub *eventSubscription, body interface{}) (interface{}, error) {
+	var req PullMessagesRequest
+	if err := unmarshalBody(body, &req); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
+	}
+
+	var timeout time.Duration
+	if req.Timeout != "" {
+		d, err := onvif.ParseDuration(req.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid timeout: %w", ErrInvalidArgs, err)
+		}
+		timeout = d
+	}
+
+	// Answer before the HTTP write timeout cuts the response off
+	maxWait := maxPullMessagesWait
+	if s.config.Timeout > 0 && s.config.Timeout-time.Second < maxWait {
+		maxWait = s.config.Timeout - time.Second
+	}
+	if timeout > maxWait {
+		timeout = maxWait
+	}
+
+	limit := req.MessageLimit
+	if limit <= 0 || limit > maxQueuedEvents {
+		limit = maxQueuedEvents
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	timedOut := timeout <= 0
+	for {
+		s.eventsMu.Lock()
+		select {
+		case <-sub.done:
+			s.eventsMu.Unlock()
+			return nil, fmt.Errorf("subscription not found: %s", sub.id)
+		default:
+		}
+
+		if len(sub.queue) > 0 || timedOut {
+			n := len(sub.queue)
+			if n > limit {
+				n = limit
+			}
+			events := sub.queue[:n]
+			sub.queue = append([]*serverEvent(nil), sub.queue[n:]...)
+
+			now := time.Now()
+			sub.expires = now.Add(sub.ttl)
+			resp := &PullMessagesResponse{
+				CurrentTime:     formatEventTime(now),
+				TerminationTime: formatEventTime(sub.expires),
+			}
+			s.eventsMu.Unlock()
+
+			for _, event := range events {
+				resp.NotificationMessage = append(resp.NotificationMessage, event.notificationMessage())
+			}
+			return resp, nil
+		}
+		s.eventsMu.Unlock()
+
+		select {
+		case <-sub.notify:
+		case <-sub.done:
+		case <-timer.C:
+			timedOut = true
+		}
+	}
+}
+
+// unsubscribe handles Unsubscribe on a subscription
+func (s *Server) unsubscribe(sub *eventSubscription) (interface{}, error) {
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+
+	if _, ok := s.subscriptions[sub.id]; !ok {
+		return nil, fmt.Errorf("subscription not found: %s", sub.id)
+	}
+	s.removeSubscription(sub)
+
+	return &UnsubscribeResponse{}, nil
+}
+
+// FireEvent queues an event on every pull point subscribed to topic. Topics
+// use the ONVIF topic set prefix, e.g. "tns1:Device/Trigger/DigitalInput", and
+// items are delivered as the message Data.
+func (s *Server) FireEvent(topic string, items map[string]string) {
+	data := make([]EventSimpleItem, 0, len(items))
+	for name, value := range items {
+		data = append(data, EventSimpleItem{Name: name, Value: value})
+	}
+	sort.Slice(data, func(i, j int) bool { return data[i].Name < data[j].Name })
+
+	s.publishEvent(&serverEvent{
+		topic:     topic,
+		time:      time.Now(),
+		operation: "Changed",
+		data:      data,
+	})
+}
+
+// publishEvent queues event on all matching subscriptions, dropping the
+// oldest queued event when a subscription falls too far behind
+func (s *Server) publishEvent(event *serverEvent) {
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+
+	s.expireSubscriptions(event.time)
+	for _, sub := range s.subscriptions {
+		if !topicMatches(sub.filter, event.topic) {
+			continue
+		}
+
+		if len(sub.queue) >= maxQueuedEvents {
+			sub.queue = sub.queue[1:]
+		}
+		sub.queue = append(sub.queue, event)
+
+		select {
+		case sub.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// simulateMotion toggles the motion alarm of every video source each
+// interval until ctx is done
+func (s *Server) simulateMotion(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	state := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		state = !state
+		seen := make(map[string]bool)
+		for _, profile := range s.config.Profiles {
+			token := profile.VideoSource.Token
+			if seen[token] {
+				continue
+			}
+			seen[token] = true
+
+			s.publishEvent(&serverEvent{
+				topic:     TopicMotionAlarm,
+				time:      time.Now(),
+				operation: "Changed",
+				source:    []EventSimpleItem{{Name: "Source", Value: token}},
+				data:      []EventSimpleItem{{Name: "State", Value: strconv.FormatBool(state)}},
+			})
+		}
+	}
+}
+
+// expireSubscriptions removes subscriptions whose termination time has
+// passed. The caller must hold eventsMu.
+func (s *Server) expireSubscriptions(now time.Time) {
+	for _, sub := range s.subscriptions {
+		if now.After(sub.expires) {
+			s.removeSubscription(sub)
+		}
+	}
+}
+
+// removeSubscription deletes sub and wakes any pending pull. The caller must
+// hold eventsMu.
+func (s *Server) removeSubscription(sub *eventSubscription) {
+	delete(s.subscriptions, sub.id)
+	close(sub.done)
+}
+
+// handleSubscription routes PullMessages and Unsubscribe to the subscription
+// named by the last path segment
+func (s *Server) handleSubscription(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	s.eventsMu.Lock()
+	s.expireSubscriptions(time.Now())
+	sub, ok := s.subscriptions[id]
+	s.eventsMu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	sub.handler.ServeHTTP(w, r)
+}
+
+// notificationMessage converts a queued event into its wire form
+func (e *serverEvent) notificationMessage() NotificationMessage {
+	msg := EventMessage{
+		UtcTime:           formatEventTime(e.time),
+		PropertyOperation: e.operation,
+	}
+	if len(e.source) > 0 {
+		msg.Source = &EventItemList{SimpleItem: e.source}
+	}
+	if len(e.data) > 0 {
+		msg.Data = &EventItemList{SimpleItem: e.data}
+	}
+
+	return NotificationMessage{
+		Topic: NotificationTopic{
+			Dialect: topicDialectConcreteSet,
+			TNS1:    topicsNamespace,
+			Value:   e.topic,
+		},
+		Message: NotificationPayload{Message: msg},
+	}
+}
+
+// topicMatches reports whether topic is selected by a ConcreteSet filter. An
+// expression ending in "//." also selects every topic below it.
+func topicMatches(filter []string, topic string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+
+	for _, expr := range filter {
+		if prefix, ok := strings.CutSuffix(expr, "//."); ok {
+			if topic == prefix || strings.HasPrefix(topic, prefix+"/") {
+				return true
+			}
+		} else if topic == expr {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseTerminationTime converts an InitialTerminationTime, either a duration
+// or an absolute xs:dateTime, into a subscription lifetime
+func parseTerminationTime(value string, now time.Time) (time.Duration, error) {
+	if value == "" {
+		return defaultSubscriptionTTL, nil
+	}
+
+	var ttl time.Duration
+	if strings.HasPrefix(value, "P") {
+		d, err := onvif.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid termination time: %w", ErrInvalidArgs, err)
+		}
+		ttl = d
+	} else {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid termination time: %w", ErrInvalidArgs, err)
+		}
+		ttl = t.Sub(now)
+	}
+
+	if ttl <= 0 {
+		return 0, fmt.Errorf("%w: termination time is in the past: %s", ErrInvalidArgs, value)
+	}
+
+	return ttl, nil
+}
+
+// formatEventTime formats t as an xs:dateTime in UTC
+func formatEventTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
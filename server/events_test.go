@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newEventsTestServer serves the events service of a server with events
+// enabled and authentication disabled
+func newEventsTestServer(t *testing.T) (*Server, *httptest.Server) {
+	t.Helper()
+
+	config := DefaultConfig()
+	config.RequireAuth = false
+	config.SupportEvents = true
+
+	srv, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	srv.registerEventsService(mux)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	return srv, ts
+}
+
+// createPullPoint subscribes with the given filter and returns the URL of the
+// pull point on the test server
+func createPullPoint(t *testing.T, ts *httptest.Server, filter string) string {
+	t.Helper()
+
+	body := `<tev:CreatePullPointSubscription>`
+	if filter != "" {
+		body += `<tev:Filter><wsnt:TopicExpression Dialect="http://www.onvif.org/ver10/tev/topicExpression/ConcreteSet">` +
+			filter + `</wsnt:TopicExpression></tev:Filter>`
+	}
+	body += `<tev:InitialTerminationTime>PT10S</tev:InitialTerminationTime></tev:CreatePullPointSubscription>`
+
+	var resp struct {
+		Body struct {
+			Response struct {
+				Address string `xml:"SubscriptionReference>Address"`
+			} `xml:"CreatePullPointSubscriptionResponse"`
+		} `xml:"Body"`
+	}
+	if err := xml.Unmarshal([]byte(postSOAP(t, ts.URL+"/onvif/events_service", body)), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	address, err := url.Parse(resp.Body.Response.Address)
+	if err != nil || !strings.HasPrefix(address.Path, "/onvif/events_service/subscription/") {
+		t.Fatalf("unexpected subscription address %q", resp.Body.Response.Address)
+	}
+
+	return ts.URL + address.Path
+}
+
+type pulledMessage struct {
+	Topic   string `xml:"Topic"`
+	Message struct {
+		Source []EventSimpleItem `xml:"Source>SimpleItem"`
+		Data   []EventSimpleItem `xml:"Data>SimpleItem"`
+	} `xml:"Message>Message"`
+}
+
+// pullMessages pulls from a pull point and returns the notification messages
+func pullMessages(t *testing.T, pullPoint, timeout string) []pulledMessage {
+	t.Helper()
+
+	data := postSOAP(t, pullPoint, `<tev:PullMessages><tev:Timeout>`+timeout+
+		`</tev:Timeout><tev:MessageLimit>10</tev:MessageLimit></tev:PullMessages>`)
+
+	var resp struct {
+		Body struct {
+			Response struct {
+				TerminationTime string          `xml:"TerminationTime"`
+				Messages        []pulledMessage `xml:"NotificationMessage"`
+			} `xml:"PullMessagesResponse"`
+		} `xml:"Body"`
+	}
+	if err := xml.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp.Body.Response.TerminationTime == "" {
+		t.Errorf("response missing TerminationTime:\n%s", data)
+	}
+
+	return resp.Body.Response.Messages
+}
+
+func TestEventPullPoint(t *testing.T) {
+	srv, ts := newEventsTestServer(t)
+	all := createPullPoint(t, ts, "")
+	digital := createPullPoint(t, ts, "tns1:Device/Trigger//.")
+
+	srv.FireEvent("tns1:Device/Trigger/DigitalInput", map[string]string{"LogicalState": "true", "InputToken": "input_0"})
+	srv.FireEvent(TopicMotionAlarm, map[string]string{"State": "true"})
+
+	messages := pullMessages(t, all, "PT1S")
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+	if messages[0].Topic != "tns1:Device/Trigger/DigitalInput" || messages[1].Topic != TopicMotionAlarm {
+		t.Errorf("unexpected topics %q, %q", messages[0].Topic, messages[1].Topic)
+	}
+	want := []EventSimpleItem{{Name: "InputToken", Value: "input_0"}, {Name: "LogicalState", Value: "true"}}
+	if got := messages[0].Message.Data; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("unexpected data items %v", got)
+	}
+
+	messages = pullMessages(t, digital, "PT1S")
+	if len(messages) != 1 || messages[0].Topic != "tns1:Device/Trigger/DigitalInput" {
+		t.Fatalf("filtered pull point: unexpected messages %+v", messages)
+	}
+
+	// An empty queue answers after the timeout with no messages
+	start := time.Now()
+	if messages := pullMessages(t, all, "PT0.2S"); len(messages) != 0 {
+		t.Errorf("expected no messages, got %d", len(messages))
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("pull returned after %v, expected it to wait for the timeout", elapsed)
+	}
+
+	// A pending pull returns as soon as an event is fired
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		srv.FireEvent(TopicMotionAlarm, map[string]string{"State": "false"})
+	}()
+	start = time.Now()
+	if messages := pullMessages(t, all, "PT10S"); len(messages) != 1 {
+		t.Errorf("expected 1 message, got %d", len(messages))
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("pull was not woken by the event, took %v", elapsed)
+	}
+
+	postSOAP(t, all, `<wsnt:Unsubscribe/>`)
+	if _, status := postSOAPStatus(t, all, `<tev:PullMessages><tev:Timeout>PT1S</tev:Timeout><tev:MessageLimit>1</tev:MessageLimit></tev:PullMessages>`); status != http.StatusNotFound {
+		t.Errorf("expected 404 after Unsubscribe, got %d", status)
+	}
+}
+
+func TestEventSubscriptionExpires(t *testing.T) {
+	srv, ts := newEventsTestServer(t)
+	pullPoint := createPullPoint(t, ts, "")
+
+	srv.eventsMu.Lock()
+	for _, sub := range srv.subscriptions {
+		sub.expires = time.Now().Add(-time.Second)
+	}
+	srv.eventsMu.Unlock()
+
+	if _, status := postSOAPStatus(t, pullPoint, `<tev:PullMessages><tev:Timeout>PT1S</tev:Timeout><tev:MessageLimit>1</tev:MessageLimit></tev:PullMessages>`); status != http.StatusNotFound {
+		t.Errorf("expected 404 for an expired subscription, got %d", status)
+	}
+}
+
+func TestSimulatedMotion(t *testing.T) {
+	srv, ts := newEventsTestServer(t)
+	pullPoint := createPullPoint(t, ts, TopicMotionAlarm)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go srv.simulateMotion(ctx, 10*time.Millisecond)
+
+	messages := pullMessages(t, pullPoint, "PT5S")
+	if len(messages) == 0 {
+		t.Fatal("expected a motion alarm")
+	}
+
+	msg := messages[0]
+	if len(msg.Message.Source) != 1 || msg.Message.Source[0].Name != "Source" || msg.Message.Source[0].Value != srv.config.Profiles[0].VideoSource.Token {
+		t.Errorf("unexpected source %v", msg.Message.Source)
+	}
+	if len(msg.Message.Data) != 1 || msg.Message.Data[0] != (EventSimpleItem{Name: "State", Value: "true"}) {
+		t.Errorf("unexpected data %v", msg.Message.Data)
+	}
+}
+
+func TestTopicMatches(t *testing.T) {
+	tests := []struct {
+		filter []string
+		topic  string
+		want   bool
+	}{
+		{nil, TopicMotionAlarm, true},
+		{[]string{TopicMotionAlarm}, TopicMotionAlarm, true},
+		{[]string{"tns1:VideoSource//."}, TopicMotionAlarm, true},
+		{[]string{"tns1:VideoSource//."}, "tns1:VideoSourceX/Alarm", false},
+		{[]string{"tns1:Device/Trigger/DigitalInput"}, TopicMotionAlarm, false},
+	}
+
+	for _, tt := range tests {
+		if got := topicMatches(tt.filter, tt.topic); got != tt.want {
+			t.Errorf("topicMatches(%v, %q) = %v, want %v", tt.filter, tt.topic, got, tt.want)
+		}
+	}
+}
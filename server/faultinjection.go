@@ -0,0 +1,99 @@
+package server
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/0x524a/onvif-go/server/soap"
+)
+
+// validateFaultInjection checks the configured fault injection options
+func validateFaultInjection(injections map[string]FaultInjection) error {
+	for operation, injection := range injections {
+		if injection.Latency < 0 || injection.RandomLatency < 0 {
+			return fmt.Errorf("invalid fault injection for %s: negative latency", operation)
+		}
+		if injection.ErrorPercent < 0 || injection.ErrorPercent > 100 {
+			return fmt.Errorf("invalid fault injection for %s: error percent %g out of range 0-100", operation, injection.ErrorPercent)
+		}
+	}
+	return nil
+}
+
+// injectFaults wraps a SOAP service handler with the configured fault
+// injection. Without any configured, next is returned unchanged.
+func (s *Server) injectFaults(next http.Handler) http.Handler {
+	if len(s.config.FaultInjection) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		operation := soapOperation(body)
+		injection, ok := s.config.FaultInjection[operation]
+		if !ok {
+			if injection, ok = s.config.FaultInjection["*"]; !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		delay := injection.Latency
+		if injection.RandomLatency > 0 {
+			delay += time.Duration(rand.Int63n(int64(injection.RandomLatency)))
+		}
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				// The client gave up; there is nobody to answer
+				timer.Stop()
+				return
+			}
+		}
+
+		if injection.ErrorPercent > 0 && rand.Float64()*100 < injection.ErrorPercent {
+			http.Error(w, "injected failure", http.StatusInternalServerError)
+			return
+		}
+
+		if injection.Fault != nil {
+			soap.WriteError(w, injection.Fault, "injected fault for "+operation)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// soapOperation returns the local name of the first element in a SOAP body,
+// or "" if there is none
+func soapOperation(envelope []byte) string {
+	decoder := xml.NewDecoder(bytes.NewReader(envelope))
+	inBody := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			if inBody {
+				return start.Name.Local
+			}
+			inBody = start.Name.Local == "Body"
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0x524a/onvif-go"
+	internalsoap "github.com/0x524a/onvif-go/internal/soap"
+)
+
+func TestFaultInjection(t *testing.T) {
+	config := DefaultConfig()
+	config.SimulatedMotionInterval = 0
+	config.FaultInjection = map[string]FaultInjection{
+		"GetProfiles":          {ErrorPercent: 100},
+		"GetStreamUri":         {Fault: ErrProfileNotFound},
+		"GetSystemDateAndTime": {Latency: 5 * time.Second},
+		"*":                    {Latency: 50 * time.Millisecond},
+	}
+
+	_, addr := startConfiguredServer(t, config)
+	client, err := onvif.NewClient("http://"+addr+"/onvif/device_service",
+		onvif.WithCredentials(config.Username, config.Password))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	ctx := context.Background()
+
+	start := time.Now()
+	if _, err := client.GetDeviceInformation(ctx); err != nil {
+		t.Fatalf("GetDeviceInformation failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected the default latency, answered in %v", elapsed)
+	}
+
+	var httpErr *internalsoap.HTTPError
+	if _, err := client.GetProfiles(ctx); !errors.As(err, &httpErr) || httpErr.StatusCode != 500 || strings.Contains(httpErr.Body, "Envelope") {
+		t.Errorf("expected a bare HTTP 500, got %v", err)
+	}
+
+	if _, err := client.GetStreamURI(ctx, "profile_0"); !errors.As(err, &httpErr) || httpErr.StatusCode != 400 || !strings.Contains(httpErr.Body, "ter:NoProfile") {
+		t.Errorf("expected a ter:NoProfile fault, got %v", err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if _, err := client.GetSystemDateAndTime(timeoutCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline to expire, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("cancelled call took %v", elapsed)
+	}
+}
+
+func TestFaultInjectionValidation(t *testing.T) {
+	for _, injection := range []FaultInjection{
+		{ErrorPercent: 150},
+		{ErrorPercent: -1},
+		{Latency: -time.Second},
+		{RandomLatency: -time.Second},
+	} {
+		config := DefaultConfig()
+		config.FaultInjection = map[string]FaultInjection{"GetProfiles": injection}
+		if _, err := New(config); err == nil {
+			t.Errorf("expected %+v to be rejected", injection)
+		}
+	}
+}
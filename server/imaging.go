@@ -206,22 +206,33 @@ type MoveResponse struct {
 
 var imagingMutex sync.RWMutex
 
+// Ranges advertised by GetOptions and enforced by SetImagingSettings
+var (
+	imagingLevelRange        = FloatRange{Min: 0, Max: 100}
+	imagingExposureTimeRange = FloatRange{Min: 1, Max: 10000}
+	imagingGainRange         = FloatRange{Min: 0, Max: 100}
+	imagingWhiteBalanceRange = FloatRange{Min: 0, Max: 255}
+)
+
 // HandleGetImagingSettings handles GetImagingSettings request
 func (s *Server) HandleGetImagingSettings(body interface{}) (interface{}, error) {
 	var req GetImagingSettingsRequest
 	if err := unmarshalBody(body, &req); err != nil {
-		return nil, fmt.Errorf("invalid request: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
 	}
 
 	// Get imaging state
 	imagingMutex.RLock()
 	defer imagingMutex.RUnlock()
 
-	state, ok := s.imagingState[req.VideoSourceToken]
+	current, ok := s.imagingState[req.VideoSourceToken]
 	if !ok {
-		return nil, fmt.Errorf("video source not found: %s", req.VideoSourceToken)
+		return nil, fmt.Errorf("%w: %s", ErrVideoSourceNotFound, req.VideoSourceToken)
 	}
 
+	// Work on a copy so the response does not alias the live state
+	state := *current
+
 	// Build imaging settings response
 	settings := &ImagingSettings{
 		Brightness:      &state.Brightness,
@@ -269,7 +280,7 @@ func (s *Server) HandleGetImagingSettings(body interface{}) (interface{}, error)
 func (s *Server) HandleSetImagingSettings(body interface{}) (interface{}, error) {
 	var req SetImagingSettingsRequest
 	if err := unmarshalBody(body, &req); err != nil {
-		return nil, fmt.Errorf("invalid request: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
 	}
 
 	// Get imaging state
@@ -278,22 +289,26 @@ func (s *Server) HandleSetImagingSettings(body interface{}) (interface{}, error)
 
 	state, ok := s.imagingState[req.VideoSourceToken]
 	if !ok {
-		return nil, fmt.Errorf("video source not found: %s", req.VideoSourceToken)
+		return nil, fmt.Errorf("%w: %s", ErrVideoSourceNotFound, req.VideoSourceToken)
 	}
 
-	// Update settings
 	settings := req.ImagingSettings
+	if settings == nil {
+		return nil, fmt.Errorf("%w: imaging settings are required", ErrSettingsInvalid)
+	}
+
+	// Update settings, clamping values to the advertised ranges
 	if settings.Brightness != nil {
-		state.Brightness = *settings.Brightness
+		state.Brightness = clampRange(*settings.Brightness, imagingLevelRange)
 	}
 	if settings.ColorSaturation != nil {
-		state.Saturation = *settings.ColorSaturation
+		state.Saturation = clampRange(*settings.ColorSaturation, imagingLevelRange)
 	}
 	if settings.Contrast != nil {
-		state.Contrast = *settings.Contrast
+		state.Contrast = clampRange(*settings.Contrast, imagingLevelRange)
 	}
 	if settings.Sharpness != nil {
-		state.Sharpness = *settings.Sharpness
+		state.Sharpness = clampRange(*settings.Sharpness, imagingLevelRange)
 	}
 	if settings.IrCutFilter != nil {
 		state.IrCutFilter = *settings.IrCutFilter
@@ -301,7 +316,7 @@ func (s *Server) HandleSetImagingSettings(body interface{}) (interface{}, error)
 	if settings.BacklightCompensation != nil {
 		state.BacklightComp.Mode = settings.BacklightCompensation.Mode
 		if settings.BacklightCompensation.Level != nil {
-			state.BacklightComp.Level = *settings.BacklightCompensation.Level
+			state.BacklightComp.Level = clampRange(*settings.BacklightCompensation.Level, imagingLevelRange)
 		}
 	}
 	if settings.Exposure != nil {
@@ -310,10 +325,10 @@ func (s *Server) HandleSetImagingSettings(body interface{}) (interface{}, error)
 			state.Exposure.Priority = *settings.Exposure.Priority
 		}
 		if settings.Exposure.ExposureTime != nil {
-			state.Exposure.ExposureTime = *settings.Exposure.ExposureTime
+			state.Exposure.ExposureTime = clampRange(*settings.Exposure.ExposureTime, imagingExposureTimeRange)
 		}
 		if settings.Exposure.Gain != nil {
-			state.Exposure.Gain = *settings.Exposure.Gain
+			state.Exposure.Gain = clampRange(*settings.Exposure.Gain, imagingGainRange)
 		}
 	}
 	if settings.Focus != nil {
@@ -322,16 +337,16 @@ func (s *Server) HandleSetImagingSettings(body interface{}) (interface{}, error)
 	if settings.WideDynamicRange != nil {
 		state.WideDynamicRange.Mode = settings.WideDynamicRange.Mode
 		if settings.WideDynamicRange.Level != nil {
-			state.WideDynamicRange.Level = *settings.WideDynamicRange.Level
+			state.WideDynamicRange.Level = clampRange(*settings.WideDynamicRange.Level, imagingLevelRange)
 		}
 	}
 	if settings.WhiteBalance != nil {
 		state.WhiteBalance.Mode = settings.WhiteBalance.Mode
 		if settings.WhiteBalance.CrGain != nil {
-			state.WhiteBalance.CrGain = *settings.WhiteBalance.CrGain
+			state.WhiteBalance.CrGain = clampRange(*settings.WhiteBalance.CrGain, imagingWhiteBalanceRange)
 		}
 		if settings.WhiteBalance.CbGain != nil {
-			state.WhiteBalance.CbGain = *settings.WhiteBalance.CbGain
+			state.WhiteBalance.CbGain = clampRange(*settings.WhiteBalance.CbGain, imagingWhiteBalanceRange)
 		}
 	}
 
@@ -340,26 +355,43 @@ func (s *Server) HandleSetImagingSettings(body interface{}) (interface{}, error)
 
 // HandleGetOptions handles GetOptions request
 func (s *Server) HandleGetOptions(body interface{}) (interface{}, error) {
+	var req GetOptionsRequest
+	if err := unmarshalBody(body, &req); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
+	}
+
+	imagingMutex.RLock()
+	_, ok := s.imagingState[req.VideoSourceToken]
+	imagingMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrVideoSourceNotFound, req.VideoSourceToken)
+	}
+
+	level := imagingLevelRange
+	exposureTime := imagingExposureTimeRange
+	gain := imagingGainRange
+	whiteBalance := imagingWhiteBalanceRange
+
 	// Return available imaging options/capabilities
 	options := &ImagingOptions{
-		Brightness: &FloatRange{Min: 0, Max: 100},
-		ColorSaturation: &FloatRange{Min: 0, Max: 100},
-		Contrast: &FloatRange{Min: 0, Max: 100},
-		Sharpness: &FloatRange{Min: 0, Max: 100},
+		Brightness:       &level,
+		ColorSaturation:  &level,
+		Contrast:         &level,
+		Sharpness:        &level,
 		IrCutFilterModes: []string{"ON", "OFF", "AUTO"},
 		BacklightCompensation: &BacklightCompensationOptions{
 			Mode:  []string{"OFF", "ON"},
-			Level: &FloatRange{Min: 0, Max: 100},
+			Level: &level,
 		},
 		Exposure: &ExposureOptions{
 			Mode:            []string{"AUTO", "MANUAL"},
 			Priority:        []string{"LowNoise", "FrameRate"},
-			MinExposureTime: &FloatRange{Min: 1, Max: 10000},
-			MaxExposureTime: &FloatRange{Min: 1, Max: 10000},
-			MinGain:         &FloatRange{Min: 0, Max: 100},
-			MaxGain:         &FloatRange{Min: 0, Max: 100},
-			ExposureTime:    &FloatRange{Min: 1, Max: 10000},
-			Gain:            &FloatRange{Min: 0, Max: 100},
+			MinExposureTime: &exposureTime,
+			MaxExposureTime: &exposureTime,
+			MinGain:         &gain,
+			MaxGain:         &gain,
+			ExposureTime:    &exposureTime,
+			Gain:            &gain,
 		},
 		Focus: &FocusOptions{
 			AutoFocusModes: []string{"AUTO", "MANUAL"},
@@ -369,12 +401,12 @@ func (s *Server) HandleGetOptions(body interface{}) (interface{}, error) {
 		},
 		WideDynamicRange: &WideDynamicRangeOptions{
 			Mode:  []string{"OFF", "ON"},
-			Level: &FloatRange{Min: 0, Max: 100},
+			Level: &level,
 		},
 		WhiteBalance: &WhiteBalanceOptions{
 			Mode:   []string{"AUTO", "MANUAL"},
-			YrGain: &FloatRange{Min: 0, Max: 255},
-			YbGain: &FloatRange{Min: 0, Max: 255},
+			YrGain: &whiteBalance,
+			YbGain: &whiteBalance,
 		},
 	}
 
@@ -387,7 +419,7 @@ func (s *Server) HandleGetOptions(body interface{}) (interface{}, error) {
 func (s *Server) HandleMove(body interface{}) (interface{}, error) {
 	var req MoveRequest
 	if err := unmarshalBody(body, &req); err != nil {
-		return nil, fmt.Errorf("invalid request: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
 	}
 
 	// Get imaging state
@@ -396,7 +428,7 @@ func (s *Server) HandleMove(body interface{}) (interface{}, error) {
 
 	state, ok := s.imagingState[req.VideoSourceToken]
 	if !ok {
-		return nil, fmt.Errorf("video source not found: %s", req.VideoSourceToken)
+		return nil, fmt.Errorf("%w: %s", ErrVideoSourceNotFound, req.VideoSourceToken)
 	}
 
 	// Process focus move
@@ -417,3 +449,8 @@ func (s *Server) HandleMove(body interface{}) (interface{}, error) {
 
 	return &MoveResponse{}, nil
 }
+
+// clampRange limits value to r
+func clampRange(value float64, r FloatRange) float64 {
+	return clamp(value, r.Min, r.Max)
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x524a/onvif-go/server/soap"
+)
+
+func newImagingTestServer(t *testing.T) (*Server, *httptest.Server) {
+	t.Helper()
+
+	return newTestServer(t, func(srv *Server, h *soap.Handler) {
+		h.RegisterHandler("GetImagingSettings", srv.HandleGetImagingSettings)
+		h.RegisterHandler("SetImagingSettings", srv.HandleSetImagingSettings)
+		h.RegisterHandler("GetOptions", srv.HandleGetOptions)
+	})
+}
+
+func getImagingSettings(t *testing.T, url, token string) *ImagingSettings {
+	t.Helper()
+
+	data := postSOAP(t, url, `<timg:GetImagingSettings><timg:VideoSourceToken>`+token+`</timg:VideoSourceToken></timg:GetImagingSettings>`)
+
+	var envelope struct {
+		Body struct {
+			Response GetImagingSettingsResponse `xml:"GetImagingSettingsResponse"`
+		} `xml:"Body"`
+	}
+	if err := xml.Unmarshal([]byte(data), &envelope); err != nil {
+		t.Fatalf("failed to decode settings: %v", err)
+	}
+
+	return envelope.Body.Response.ImagingSettings
+}
+
+func TestSetImagingSettings(t *testing.T) {
+	_, server := newImagingTestServer(t)
+	defer server.Close()
+
+	postSOAP(t, server.URL, `<timg:SetImagingSettings>
+<timg:VideoSourceToken>video_source_0</timg:VideoSourceToken>
+<timg:ImagingSettings>
+<tt:Brightness>75</tt:Brightness>
+<tt:Contrast>150</tt:Contrast>
+<tt:ColorSaturation>-20</tt:ColorSaturation>
+<tt:IrCutFilter>OFF</tt:IrCutFilter>
+<tt:WideDynamicRange><tt:Mode>ON</tt:Mode><tt:Level>101</tt:Level></tt:WideDynamicRange>
+</timg:ImagingSettings>
+</timg:SetImagingSettings>`)
+
+	settings := getImagingSettings(t, server.URL, "video_source_0")
+
+	checks := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"Brightness", *settings.Brightness, 75},
+		{"Contrast", *settings.Contrast, 100},
+		{"ColorSaturation", *settings.ColorSaturation, 0},
+		{"Sharpness", *settings.Sharpness, 50},
+		{"WideDynamicRange.Level", *settings.WideDynamicRange.Level, 100},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s: expected %v, got %v", c.name, c.want, c.got)
+		}
+	}
+	if *settings.IrCutFilter != "OFF" {
+		t.Errorf("expected IrCutFilter OFF, got %q", *settings.IrCutFilter)
+	}
+	if settings.WideDynamicRange.Mode != "ON" {
+		t.Errorf("expected WideDynamicRange mode ON, got %q", settings.WideDynamicRange.Mode)
+	}
+
+	// Other video sources are unaffected
+	other := getImagingSettings(t, server.URL, "video_source_1")
+	if *other.Brightness != 50 {
+		t.Errorf("expected video_source_1 brightness 50, got %v", *other.Brightness)
+	}
+}
+
+func TestImagingUnknownVideoSource(t *testing.T) {
+	_, server := newImagingTestServer(t)
+	defer server.Close()
+
+	requests := map[string]string{
+		"GetImagingSettings": `<timg:GetImagingSettings><timg:VideoSourceToken>missing</timg:VideoSourceToken></timg:GetImagingSettings>`,
+		"SetImagingSettings": `<timg:SetImagingSettings><timg:VideoSourceToken>missing</timg:VideoSourceToken><timg:ImagingSettings/></timg:SetImagingSettings>`,
+		"GetOptions":         `<timg:GetOptions><timg:VideoSourceToken>missing</timg:VideoSourceToken></timg:GetOptions>`,
+	}
+
+	for name, body := range requests {
+		t.Run(name, func(t *testing.T) {
+			data, status := postSOAPStatus(t, server.URL, body)
+			if status == http.StatusOK {
+				t.Fatalf("expected a fault, got %s", data)
+			}
+		})
+	}
+}
+
+func TestGetImagingOptions(t *testing.T) {
+	_, server := newImagingTestServer(t)
+	defer server.Close()
+
+	data := postSOAP(t, server.URL, `<timg:GetOptions><timg:VideoSourceToken>video_source_0</timg:VideoSourceToken></timg:GetOptions>`)
+
+	var envelope struct {
+		Body struct {
+			Response GetOptionsResponse `xml:"GetOptionsResponse"`
+		} `xml:"Body"`
+	}
+	if err := xml.Unmarshal([]byte(data), &envelope); err != nil {
+		t.Fatalf("failed to decode options: %v", err)
+	}
+
+	options := envelope.Body.Response.ImagingOptions
+	if options == nil || options.Brightness == nil {
+		t.Fatal("expected brightness options")
+	}
+	if options.Brightness.Min != 0 || options.Brightness.Max != 100 {
+		t.Errorf("expected brightness range 0..100, got %v..%v", options.Brightness.Min, options.Brightness.Max)
+	}
+	if len(options.IrCutFilterModes) != 3 {
+		t.Errorf("expected 3 IR cut filter modes, got %v", options.IrCutFilterModes)
+	}
+}
@@ -3,13 +3,18 @@ package server
 import (
 	"encoding/xml"
 	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 // Media service SOAP message types
 
 // GetProfilesResponse represents GetProfiles response
 type GetProfilesResponse struct {
-	XMLName  xml.Name          `xml:"http://www.onvif.org/ver10/media/wsdl GetProfilesResponse"`
+	XMLName  xml.Name       `xml:"http://www.onvif.org/ver10/media/wsdl GetProfilesResponse"`
 	Profiles []MediaProfile `xml:"Profiles"`
 }
 
@@ -46,16 +51,16 @@ type AudioSourceConfiguration struct {
 
 // VideoEncoderConfiguration represents video encoder configuration
 type VideoEncoderConfiguration struct {
-	Token          string            `xml:"token,attr"`
-	Name           string            `xml:"Name"`
-	UseCount       int               `xml:"UseCount"`
-	Encoding       string            `xml:"Encoding"`
-	Resolution     VideoResolution   `xml:"Resolution"`
-	Quality        float64           `xml:"Quality"`
-	RateControl    *VideoRateControl `xml:"RateControl,omitempty"`
-	H264           *H264Configuration `xml:"H264,omitempty"`
+	Token          string                  `xml:"token,attr"`
+	Name           string                  `xml:"Name"`
+	UseCount       int                     `xml:"UseCount"`
+	Encoding       string                  `xml:"Encoding"`
+	Resolution     VideoResolution         `xml:"Resolution"`
+	Quality        float64                 `xml:"Quality"`
+	RateControl    *VideoRateControl       `xml:"RateControl,omitempty"`
+	H264           *H264Configuration      `xml:"H264,omitempty"`
 	Multicast      *MulticastConfiguration `xml:"Multicast,omitempty"`
-	SessionTimeout string            `xml:"SessionTimeout"`
+	SessionTimeout string                  `xml:"SessionTimeout"`
 }
 
 // AudioEncoderConfiguration represents audio encoder configuration
@@ -130,14 +135,14 @@ type MulticastConfiguration struct {
 
 // IPAddress represents an IP address
 type IPAddress struct {
-	Type    string `xml:"Type"`
+	Type        string `xml:"Type"`
 	IPv4Address string `xml:"IPv4Address,omitempty"`
 	IPv6Address string `xml:"IPv6Address,omitempty"`
 }
 
-// GetStreamURIResponse represents GetStreamURI response
+// GetStreamURIResponse represents GetStreamUri response
 type GetStreamURIResponse struct {
-	XMLName  xml.Name `xml:"http://www.onvif.org/ver10/media/wsdl GetStreamURIResponse"`
+	XMLName  xml.Name `xml:"http://www.onvif.org/ver10/media/wsdl GetStreamUriResponse"`
 	MediaUri MediaUri `xml:"MediaUri"`
 }
 
@@ -155,6 +160,32 @@ type GetSnapshotURIResponse struct {
 	MediaUri MediaUri `xml:"MediaUri"`
 }
 
+// GetVideoEncoderConfigurationResponse represents GetVideoEncoderConfiguration response
+type GetVideoEncoderConfigurationResponse struct {
+	XMLName       xml.Name                   `xml:"http://www.onvif.org/ver10/media/wsdl GetVideoEncoderConfigurationResponse"`
+	Configuration *VideoEncoderConfiguration `xml:"Configuration"`
+}
+
+// SetVideoEncoderConfigurationRequest represents SetVideoEncoderConfiguration
+// request. Omitted elements keep their current value.
+type SetVideoEncoderConfigurationRequest struct {
+	XMLName       xml.Name `xml:"http://www.onvif.org/ver10/media/wsdl SetVideoEncoderConfiguration"`
+	Configuration *struct {
+		Token       string             `xml:"token,attr"`
+		Encoding    string             `xml:"Encoding"`
+		Resolution  *VideoResolution   `xml:"Resolution"`
+		Quality     *float64           `xml:"Quality"`
+		RateControl *VideoRateControl  `xml:"RateControl"`
+		H264        *H264Configuration `xml:"H264"`
+	} `xml:"Configuration"`
+	ForcePersistence bool `xml:"ForcePersistence"`
+}
+
+// SetVideoEncoderConfigurationResponse represents SetVideoEncoderConfiguration response
+type SetVideoEncoderConfigurationResponse struct {
+	XMLName xml.Name `xml:"http://www.onvif.org/ver10/media/wsdl SetVideoEncoderConfigurationResponse"`
+}
+
 // GetVideoSourcesResponse represents GetVideoSources response
 type GetVideoSourcesResponse struct {
 	XMLName      xml.Name      `xml:"http://www.onvif.org/ver10/media/wsdl GetVideoSourcesResponse"`
@@ -170,8 +201,23 @@ type VideoSource struct {
 
 // Media service handlers
 
+// mediaMutex guards the profiles' video encoder settings, which
+// SetVideoEncoderConfiguration changes
+var mediaMutex sync.RWMutex
+
+// Limits enforced by SetVideoEncoderConfiguration. Resolution and frame rate
+// are further limited by the profile's video source.
+var (
+	videoEncoderEncodings    = []string{"JPEG", "H264", "MPEG4"}
+	videoEncoderQualityRange = FloatRange{Min: 0, Max: 100}
+	videoEncoderBitrateRange = FloatRange{Min: 32, Max: 16384} // kbps
+)
+
 // HandleGetProfiles handles GetProfiles request
 func (s *Server) HandleGetProfiles(body interface{}) (interface{}, error) {
+	mediaMutex.RLock()
+	defer mediaMutex.RUnlock()
+
 	profiles := make([]MediaProfile, len(s.config.Profiles))
 
 	for i, profileCfg := range s.config.Profiles {
@@ -191,31 +237,7 @@ func (s *Server) HandleGetProfiles(body interface{}) (interface{}, error) {
 					Height: profileCfg.VideoSource.Bounds.Height,
 				},
 			},
-			VideoEncoderConfiguration: &VideoEncoderConfiguration{
-				Token:    profileCfg.Token + "_encoder",
-				Name:     profileCfg.Name + " Encoder",
-				UseCount: 1,
-				Encoding: profileCfg.VideoEncoder.Encoding,
-				Resolution: VideoResolution{
-					Width:  profileCfg.VideoEncoder.Resolution.Width,
-					Height: profileCfg.VideoEncoder.Resolution.Height,
-				},
-				Quality: profileCfg.VideoEncoder.Quality,
-				RateControl: &VideoRateControl{
-					FrameRateLimit:   profileCfg.VideoEncoder.Framerate,
-					EncodingInterval: 1,
-					BitrateLimit:     profileCfg.VideoEncoder.Bitrate,
-				},
-				SessionTimeout: "PT60S",
-			},
-		}
-
-		// Add H264 configuration if encoding is H264
-		if profileCfg.VideoEncoder.Encoding == "H264" {
-			profile.VideoEncoderConfiguration.H264 = &H264Configuration{
-				GovLength:   profileCfg.VideoEncoder.GovLength,
-				H264Profile: "Main",
-			}
+			VideoEncoderConfiguration: videoEncoderConfiguration(&s.config.Profiles[i]),
 		}
 
 		// Add audio configuration if present
@@ -258,20 +280,198 @@ func (s *Server) HandleGetProfiles(body interface{}) (interface{}, error) {
 	}, nil
 }
 
-// HandleGetStreamURI handles GetStreamURI request
+// videoEncoderConfigurationToken returns the token of a profile's video
+// encoder configuration
+func videoEncoderConfigurationToken(profileToken string) string {
+	return profileToken + "_encoder"
+}
+
+// videoEncoderConfiguration builds the video encoder configuration of a profile
+func videoEncoderConfiguration(profileCfg *ProfileConfig) *VideoEncoderConfiguration {
+	config := &VideoEncoderConfiguration{
+		Token:    videoEncoderConfigurationToken(profileCfg.Token),
+		Name:     profileCfg.Name + " Encoder",
+		UseCount: 1,
+		Encoding: profileCfg.VideoEncoder.Encoding,
+		Resolution: VideoResolution{
+			Width:  profileCfg.VideoEncoder.Resolution.Width,
+			Height: profileCfg.VideoEncoder.Resolution.Height,
+		},
+		Quality: profileCfg.VideoEncoder.Quality,
+		RateControl: &VideoRateControl{
+			FrameRateLimit:   profileCfg.VideoEncoder.Framerate,
+			EncodingInterval: 1,
+			BitrateLimit:     profileCfg.VideoEncoder.Bitrate,
+		},
+		SessionTimeout: "PT60S",
+	}
+
+	// Add H264 configuration if encoding is H264
+	if profileCfg.VideoEncoder.Encoding == "H264" {
+		config.H264 = &H264Configuration{
+			GovLength:   profileCfg.VideoEncoder.GovLength,
+			H264Profile: "Main",
+		}
+	}
+
+	return config
+}
+
+// HandleGetVideoEncoderConfiguration handles GetVideoEncoderConfiguration
+// request. Each profile has one video encoder configuration, whose token is
+// the profile token followed by "_encoder".
+func (s *Server) HandleGetVideoEncoderConfiguration(body interface{}) (interface{}, error) {
+	var req struct {
+		ConfigurationToken string `xml:"ConfigurationToken"`
+	}
+
+	if err := unmarshalBody(body, &req); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
+	}
+
+	mediaMutex.RLock()
+	defer mediaMutex.RUnlock()
+
+	profileCfg := s.videoEncoderProfile(req.ConfigurationToken)
+	if profileCfg == nil {
+		return nil, fmt.Errorf("%w: %s", ErrConfigNotFound, req.ConfigurationToken)
+	}
+
+	return &GetVideoEncoderConfigurationResponse{
+		Configuration: videoEncoderConfiguration(profileCfg),
+	}, nil
+}
+
+// HandleSetVideoEncoderConfiguration handles SetVideoEncoderConfiguration
+// request. The requested settings are checked against the profile's video
+// source and stored in the profile, so later GetProfiles and
+// GetVideoEncoderConfiguration requests reflect them.
+func (s *Server) HandleSetVideoEncoderConfiguration(body interface{}) (interface{}, error) {
+	var req SetVideoEncoderConfigurationRequest
+	if err := unmarshalBody(body, &req); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
+	}
+	if req.Configuration == nil {
+		return nil, fmt.Errorf("%w: configuration is required", ErrInvalidArgs)
+	}
+	config := req.Configuration
+
+	mediaMutex.Lock()
+	defer mediaMutex.Unlock()
+
+	profileCfg := s.videoEncoderProfile(config.Token)
+	if profileCfg == nil {
+		return nil, fmt.Errorf("%w: %s", ErrConfigNotFound, config.Token)
+	}
+
+	// Validate into a copy so a rejected request changes nothing
+	encoder := profileCfg.VideoEncoder
+	source := profileCfg.VideoSource
+
+	if config.Encoding != "" {
+		if !slices.Contains(videoEncoderEncodings, config.Encoding) {
+			return nil, fmt.Errorf("%w: unsupported encoding %s", ErrConfigModify, config.Encoding)
+		}
+		encoder.Encoding = config.Encoding
+	}
+	if config.H264 != nil && encoder.Encoding != "H264" {
+		return nil, fmt.Errorf("%w: H264 settings given for %s encoding", ErrConfigModify, encoder.Encoding)
+	}
+	if config.Resolution != nil {
+		width, height := config.Resolution.Width, config.Resolution.Height
+		if width <= 0 || height <= 0 || width > source.Resolution.Width || height > source.Resolution.Height {
+			return nil, fmt.Errorf("%w: resolution %dx%d exceeds video source %dx%d",
+				ErrConfigModify, width, height, source.Resolution.Width, source.Resolution.Height)
+		}
+		encoder.Resolution = Resolution{Width: width, Height: height}
+	}
+	if config.Quality != nil {
+		if !inRange(*config.Quality, videoEncoderQualityRange) {
+			return nil, fmt.Errorf("%w: quality %g out of range", ErrConfigModify, *config.Quality)
+		}
+		encoder.Quality = *config.Quality
+	}
+	if rate := config.RateControl; rate != nil {
+		if rate.FrameRateLimit <= 0 || rate.FrameRateLimit > source.Framerate {
+			return nil, fmt.Errorf("%w: frame rate %d exceeds video source frame rate %d",
+				ErrConfigModify, rate.FrameRateLimit, source.Framerate)
+		}
+		if !inRange(float64(rate.BitrateLimit), videoEncoderBitrateRange) {
+			return nil, fmt.Errorf("%w: bitrate %d kbps out of range", ErrConfigModify, rate.BitrateLimit)
+		}
+		encoder.Framerate = rate.FrameRateLimit
+		encoder.Bitrate = rate.BitrateLimit
+	}
+	if config.H264 != nil {
+		if config.H264.GovLength <= 0 {
+			return nil, fmt.Errorf("%w: GOV length %d must be positive", ErrConfigModify, config.H264.GovLength)
+		}
+		encoder.GovLength = config.H264.GovLength
+	}
+
+	profileCfg.VideoEncoder = encoder
+
+	return &SetVideoEncoderConfigurationResponse{}, nil
+}
+
+// videoEncoderProfile returns the profile owning a video encoder
+// configuration token, or nil. The caller must hold mediaMutex.
+func (s *Server) videoEncoderProfile(configurationToken string) *ProfileConfig {
+	for i := range s.config.Profiles {
+		if videoEncoderConfigurationToken(s.config.Profiles[i].Token) == configurationToken {
+			return &s.config.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// inRange reports whether value lies within r
+func inRange(value float64, r FloatRange) bool {
+	return value >= r.Min && value <= r.Max
+}
+
+// Stream types and transport protocols of a GetStreamUri StreamSetup
+const (
+	StreamTypeUnicast   = "RTP-Unicast"
+	StreamTypeMulticast = "RTP-Multicast"
+
+	TransportUDP  = "UDP"
+	TransportTCP  = "TCP"
+	TransportRTSP = "RTSP"
+	TransportHTTP = "HTTP"
+)
+
+// defaultTransports are the unicast protocols of a profile without Transports
+var defaultTransports = []string{TransportUDP, TransportTCP, TransportRTSP, TransportHTTP}
+
+// HandleGetStreamURI handles GetStreamUri request. The requested stream type
+// and transport protocol are echoed in the URI: RTSP, the default, returns
+// the plain RTSP URI, other protocols add a transport query parameter, and
+// RTP-Multicast adds the profile's multicast group.
 func (s *Server) HandleGetStreamURI(body interface{}) (interface{}, error) {
 	var req struct {
+		StreamSetup struct {
+			Stream    string `xml:"Stream"`
+			Transport struct {
+				Protocol string `xml:"Protocol"`
+			} `xml:"Transport"`
+		} `xml:"StreamSetup"`
 		ProfileToken string `xml:"ProfileToken"`
 	}
 
 	if err := unmarshalBody(body, &req); err != nil {
-		return nil, fmt.Errorf("invalid request: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
 	}
 
 	// Find the stream configuration for this profile
 	streamCfg, ok := s.streams[req.ProfileToken]
 	if !ok {
-		return nil, fmt.Errorf("profile not found: %s", req.ProfileToken)
+		return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, req.ProfileToken)
+	}
+
+	query, err := s.streamSetupQuery(req.ProfileToken, req.StreamSetup.Stream, req.StreamSetup.Transport.Protocol)
+	if err != nil {
+		return nil, err
 	}
 
 	// Build RTSP URI
@@ -282,7 +482,14 @@ func (s *Server) HandleGetStreamURI(body interface{}) (interface{}, error) {
 		if host == "0.0.0.0" || host == "" {
 			host = "localhost"
 		}
-		uri = fmt.Sprintf("rtsp://%s:8554%s", host, streamCfg.RTSPPath)
+		uri = fmt.Sprintf("rtsp://%s:%d%s", host, s.config.rtspPort(), streamCfg.RTSPPath)
+	}
+	if query != "" {
+		separator := "?"
+		if strings.Contains(uri, "?") {
+			separator = "&"
+		}
+		uri += separator + query
 	}
 
 	return &GetStreamURIResponse{
@@ -295,6 +502,58 @@ func (s *Server) HandleGetStreamURI(body interface{}) (interface{}, error) {
 	}, nil
 }
 
+// streamSetupQuery checks a requested stream type and transport protocol
+// against a profile and returns the URI query echoing them
+func (s *Server) streamSetupQuery(profileToken, stream, protocol string) (string, error) {
+	var profile *ProfileConfig
+	for i := range s.config.Profiles {
+		if s.config.Profiles[i].Token == profileToken {
+			profile = &s.config.Profiles[i]
+			break
+		}
+	}
+	if profile == nil {
+		return "", fmt.Errorf("%w: %s", ErrProfileNotFound, profileToken)
+	}
+
+	if protocol == "" {
+		protocol = TransportRTSP
+	}
+
+	query := url.Values{}
+	switch stream {
+	case "", StreamTypeUnicast:
+		transports := profile.Transports
+		if len(transports) == 0 {
+			transports = defaultTransports
+		}
+		if !slices.Contains(transports, protocol) {
+			return "", fmt.Errorf("%w: %s does not offer %s", ErrInvalidStreamSetup, profileToken, protocol)
+		}
+	case StreamTypeMulticast:
+		if profile.Multicast == nil {
+			return "", fmt.Errorf("%w: %s does not offer %s", ErrInvalidStreamSetup, profileToken, stream)
+		}
+		if protocol != TransportUDP && protocol != TransportRTSP {
+			return "", fmt.Errorf("%w: %s over %s", ErrInvalidStreamSetup, stream, protocol)
+		}
+		ttl := profile.Multicast.TTL
+		if ttl == 0 {
+			ttl = 1
+		}
+		query.Set("multicast", profile.Multicast.Address)
+		query.Set("port", strconv.Itoa(profile.Multicast.Port))
+		query.Set("ttl", strconv.Itoa(ttl))
+	default:
+		return "", fmt.Errorf("%w: unknown stream type %s", ErrInvalidStreamSetup, stream)
+	}
+
+	if protocol != TransportRTSP {
+		query.Set("transport", strings.ToLower(protocol))
+	}
+	return query.Encode(), nil
+}
+
 // HandleGetSnapshotURI handles GetSnapshotURI request
 func (s *Server) HandleGetSnapshotURI(body interface{}) (interface{}, error) {
 	var req struct {
@@ -302,7 +561,7 @@ func (s *Server) HandleGetSnapshotURI(body interface{}) (interface{}, error) {
 	}
 
 	if err := unmarshalBody(body, &req); err != nil {
-		return nil, fmt.Errorf("invalid request: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
 	}
 
 	// Find the profile
@@ -315,20 +574,15 @@ func (s *Server) HandleGetSnapshotURI(body interface{}) (interface{}, error) {
 	}
 
 	if profileCfg == nil {
-		return nil, fmt.Errorf("profile not found: %s", req.ProfileToken)
+		return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, req.ProfileToken)
 	}
 
 	if !profileCfg.Snapshot.Enabled {
-		return nil, fmt.Errorf("snapshot not supported for profile: %s", req.ProfileToken)
+		return nil, fmt.Errorf("%w: snapshot not supported for profile: %s", ErrActionNotSupported, req.ProfileToken)
 	}
 
 	// Build snapshot URI
-	host := s.config.Host
-	if host == "0.0.0.0" || host == "" {
-		host = "localhost"
-	}
-	uri := fmt.Sprintf("http://%s:%d%s/snapshot?profile=%s",
-		host, s.config.Port, s.config.BasePath, req.ProfileToken)
+	uri := fmt.Sprintf("%s/snapshot?profile=%s", s.serviceBaseURL(), req.ProfileToken)
 
 	return &GetSnapshotURIResponse{
 		MediaUri: MediaUri{
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"context"
+	"encoding/xml"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x524a/onvif-go"
+	"github.com/0x524a/onvif-go/server/soap"
+)
+
+func getVideoEncoderConfiguration(t *testing.T, url, token string) *VideoEncoderConfiguration {
+	t.Helper()
+
+	data := postSOAP(t, url, `<trt:GetVideoEncoderConfiguration><trt:ConfigurationToken>`+token+`</trt:ConfigurationToken></trt:GetVideoEncoderConfiguration>`)
+
+	var envelope struct {
+		Body struct {
+			Response GetVideoEncoderConfigurationResponse `xml:"GetVideoEncoderConfigurationResponse"`
+		} `xml:"Body"`
+	}
+	if err := xml.Unmarshal([]byte(data), &envelope); err != nil {
+		t.Fatalf("failed to decode configuration: %v", err)
+	}
+
+	return envelope.Body.Response.Configuration
+}
+
+func TestGetVideoEncoderConfiguration(t *testing.T) {
+	srv, server := newTestServer(t, func(srv *Server, h *soap.Handler) {
+		h.RegisterHandler("GetVideoEncoderConfiguration", srv.HandleGetVideoEncoderConfiguration)
+	})
+	defer server.Close()
+
+	srv.config.Profiles[1].VideoEncoder.Encoding = "JPEG"
+
+	config := getVideoEncoderConfiguration(t, server.URL, "profile_0_encoder")
+	if config == nil || config.Token != "profile_0_encoder" || config.Encoding != "H264" {
+		t.Fatalf("unexpected configuration: %+v", config)
+	}
+	if config.Resolution != (VideoResolution{Width: 1920, Height: 1080}) || config.RateControl == nil || config.RateControl.BitrateLimit != 4096 {
+		t.Errorf("unexpected encoder settings: %+v", config)
+	}
+	if config.H264 == nil || config.H264.GovLength != 30 {
+		t.Errorf("expected H264 settings, got %+v", config.H264)
+	}
+
+	config = getVideoEncoderConfiguration(t, server.URL, "profile_1_encoder")
+	if config == nil || config.Encoding != "JPEG" || config.H264 != nil {
+		t.Errorf("expected a JPEG configuration without H264 settings, got %+v", config)
+	}
+
+	data, status := postSOAPStatus(t, server.URL, `<trt:GetVideoEncoderConfiguration><trt:ConfigurationToken>profile_0</trt:ConfigurationToken></trt:GetVideoEncoderConfiguration>`)
+	if status != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", status)
+	}
+	if codes := faultCodes(t, data); len(codes) != 3 || codes[2] != "ter:NoConfig" {
+		t.Errorf("expected ter:NoConfig fault, got %v", codes)
+	}
+}
+
+func TestSetVideoEncoderConfiguration(t *testing.T) {
+	_, server := newTestServer(t, func(srv *Server, h *soap.Handler) {
+		h.RegisterHandler("GetVideoEncoderConfiguration", srv.HandleGetVideoEncoderConfiguration)
+		h.RegisterHandler("SetVideoEncoderConfiguration", srv.HandleSetVideoEncoderConfiguration)
+	})
+	defer server.Close()
+
+	postSOAP(t, server.URL, `<trt:SetVideoEncoderConfiguration>
+<trt:Configuration token="profile_0_encoder">
+<tt:Name>Main</tt:Name>
+<tt:Encoding>H264</tt:Encoding>
+<tt:Resolution><tt:Width>1280</tt:Width><tt:Height>720</tt:Height></tt:Resolution>
+<tt:RateControl><tt:FrameRateLimit>15</tt:FrameRateLimit><tt:EncodingInterval>1</tt:EncodingInterval><tt:BitrateLimit>2048</tt:BitrateLimit></tt:RateControl>
+<tt:H264><tt:GovLength>60</tt:GovLength><tt:H264Profile>Main</tt:H264Profile></tt:H264>
+</trt:Configuration>
+<trt:ForcePersistence>true</trt:ForcePersistence>
+</trt:SetVideoEncoderConfiguration>`)
+
+	config := getVideoEncoderConfiguration(t, server.URL, "profile_0_encoder")
+	if config == nil || config.Resolution != (VideoResolution{Width: 1280, Height: 720}) {
+		t.Fatalf("resolution was not stored: %+v", config)
+	}
+	if config.RateControl == nil || config.RateControl.FrameRateLimit != 15 || config.RateControl.BitrateLimit != 2048 {
+		t.Errorf("rate control was not stored: %+v", config.RateControl)
+	}
+	if config.H264 == nil || config.H264.GovLength != 60 {
+		t.Errorf("GOV length was not stored: %+v", config.H264)
+	}
+	if config.Quality != 80 {
+		t.Errorf("omitted quality should be kept, got %g", config.Quality)
+	}
+
+	invalid := []struct {
+		name          string
+		configuration string
+		subcode       string
+	}{
+		{"unknown token", `<trt:Configuration token="missing"><tt:Encoding>H264</tt:Encoding></trt:Configuration>`, "ter:NoConfig"},
+		{"encoding", `<trt:Configuration token="profile_0_encoder"><tt:Encoding>H266</tt:Encoding></trt:Configuration>`, "ter:ConfigModify"},
+		{"resolution", `<trt:Configuration token="profile_0_encoder"><tt:Resolution><tt:Width>3840</tt:Width><tt:Height>2160</tt:Height></tt:Resolution></trt:Configuration>`, "ter:ConfigModify"},
+		{"frame rate", `<trt:Configuration token="profile_0_encoder"><tt:RateControl><tt:FrameRateLimit>60</tt:FrameRateLimit><tt:BitrateLimit>2048</tt:BitrateLimit></tt:RateControl></trt:Configuration>`, "ter:ConfigModify"},
+		{"bitrate", `<trt:Configuration token="profile_0_encoder"><tt:RateControl><tt:FrameRateLimit>15</tt:FrameRateLimit><tt:BitrateLimit>0</tt:BitrateLimit></tt:RateControl></trt:Configuration>`, "ter:ConfigModify"},
+		{"H264 settings for JPEG", `<trt:Configuration token="profile_0_encoder"><tt:Encoding>JPEG</tt:Encoding><tt:H264><tt:GovLength>30</tt:GovLength></tt:H264></trt:Configuration>`, "ter:ConfigModify"},
+	}
+	for _, tt := range invalid {
+		data, status := postSOAPStatus(t, server.URL, `<trt:SetVideoEncoderConfiguration>`+tt.configuration+`</trt:SetVideoEncoderConfiguration>`)
+		if status != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", tt.name, status)
+		}
+		if codes := faultCodes(t, data); len(codes) != 3 || codes[2] != tt.subcode {
+			t.Errorf("%s: expected %s fault, got %v", tt.name, tt.subcode, codes)
+		}
+	}
+
+	config = getVideoEncoderConfiguration(t, server.URL, "profile_0_encoder")
+	if config.Encoding != "H264" || config.Resolution.Width != 1280 || config.RateControl.FrameRateLimit != 15 {
+		t.Errorf("rejected requests changed the configuration: %+v", config)
+	}
+}
+
+func TestSetVideoEncoderConfigurationResizesStreams(t *testing.T) {
+	srv, server := newTestServer(t, func(srv *Server, h *soap.Handler) {
+		h.RegisterHandler("SetVideoEncoderConfiguration", srv.HandleSetVideoEncoderConfiguration)
+	})
+	defer server.Close()
+
+	// Without a snapshot resolution snapshots follow the encoder
+	srv.config.Profiles[0].Snapshot.Resolution = Resolution{}
+	stream := newRTSPServer(srv).streams[srv.streams["profile_0"].RTSPPath]
+
+	before, err := stream.load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if before.width != 1920 || before.height != 1072 {
+		t.Fatalf("expected a 1920x1072 stream, got %dx%d", before.width, before.height)
+	}
+
+	postSOAP(t, server.URL, `<trt:SetVideoEncoderConfiguration>
+<trt:Configuration token="profile_0_encoder">
+<tt:Resolution><tt:Width>640</tt:Width><tt:Height>480</tt:Height></tt:Resolution>
+</trt:Configuration>
+<trt:ForcePersistence>true</trt:ForcePersistence>
+</trt:SetVideoEncoderConfiguration>`)
+
+	rec := httptest.NewRecorder()
+	srv.handleSnapshot(rec, httptest.NewRequest(http.MethodGet, "/onvif/snapshot?profile=profile_0", nil))
+	img, err := jpeg.Decode(rec.Body)
+	if err != nil {
+		t.Fatalf("snapshot is not a JPEG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 640 || b.Dy() != 480 {
+		t.Errorf("expected a 640x480 snapshot, got %dx%d", b.Dx(), b.Dy())
+	}
+
+	after, err := stream.load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if after.width != 640 || after.height != 480 {
+		t.Errorf("expected the stream to be re-encoded at 640x480, got %dx%d", after.width, after.height)
+	}
+	if len(after.frames) != rtspLoopFrames {
+		t.Errorf("expected %d frames, got %d", rtspLoopFrames, len(after.frames))
+	}
+}
+
+func TestGetStreamURITransport(t *testing.T) {
+	srv, server := newTestServer(t, func(srv *Server, h *soap.Handler) {
+		h.RegisterHandler("GetStreamUri", srv.HandleGetStreamURI)
+	})
+	defer server.Close()
+
+	srv.config.Profiles[0].Multicast = &MulticastConfig{Address: "239.255.0.1", Port: 5004}
+	srv.config.Profiles[1].Transports = []string{TransportRTSP}
+
+	getStreamURI := func(profileToken, stream, protocol string) (string, []string) {
+		t.Helper()
+
+		data, status := postSOAPStatus(t, server.URL, `<trt:GetStreamUri>
+<trt:StreamSetup><tt:Stream>`+stream+`</tt:Stream><tt:Transport><tt:Protocol>`+protocol+`</tt:Protocol></tt:Transport></trt:StreamSetup>
+<trt:ProfileToken>`+profileToken+`</trt:ProfileToken>
+</trt:GetStreamUri>`)
+		if status != http.StatusOK {
+			return "", faultCodes(t, data)
+		}
+
+		var envelope struct {
+			Body struct {
+				Response GetStreamURIResponse `xml:"GetStreamUriResponse"`
+			} `xml:"Body"`
+		}
+		if err := xml.Unmarshal([]byte(data), &envelope); err != nil {
+			t.Fatalf("failed to decode stream URI: %v", err)
+		}
+		return envelope.Body.Response.MediaUri.Uri, nil
+	}
+
+	base := srv.streams["profile_0"].StreamURI
+	tests := []struct {
+		stream   string
+		protocol string
+		want     string
+	}{
+		{"RTP-Unicast", "RTSP", base},
+		{"", "", base},
+		{"RTP-Unicast", "TCP", base + "?transport=tcp"},
+		{"RTP-Unicast", "HTTP", base + "?transport=http"},
+		{"RTP-Multicast", "UDP", base + "?multicast=239.255.0.1&port=5004&transport=udp&ttl=1"},
+		{"RTP-Multicast", "RTSP", base + "?multicast=239.255.0.1&port=5004&ttl=1"},
+	}
+	for _, tt := range tests {
+		if uri, codes := getStreamURI("profile_0", tt.stream, tt.protocol); uri != tt.want {
+			t.Errorf("%s/%s: expected %q, got %q (fault %v)", tt.stream, tt.protocol, tt.want, uri, codes)
+		}
+	}
+
+	unsupported := []struct {
+		profileToken string
+		stream       string
+		protocol     string
+	}{
+		{"profile_1", "RTP-Unicast", "TCP"},
+		{"profile_1", "RTP-Multicast", "UDP"},
+		{"profile_0", "RTP-Multicast", "TCP"},
+		{"profile_0", "RTP-Broadcast", "UDP"},
+	}
+	for _, tt := range unsupported {
+		if _, codes := getStreamURI(tt.profileToken, tt.stream, tt.protocol); len(codes) != 3 || codes[2] != "ter:InvalidStreamSetup" {
+			t.Errorf("%s %s/%s: expected ter:InvalidStreamSetup fault, got %v", tt.profileToken, tt.stream, tt.protocol, codes)
+		}
+	}
+	client, err := onvif.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	stream, err := client.GetStreamURI(context.Background(), "profile_0")
+	if err != nil || stream.URI != base {
+		t.Errorf("client GetStreamURI = %+v, %v", stream, err)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/0x524a/onvif-go"
 )
 
 // PTZ service SOAP message types
@@ -77,6 +79,7 @@ type GetStatusResponse struct {
 type PTZStatus struct {
 	Position   PTZVector      `xml:"Position"`
 	MoveStatus PTZMoveStatus  `xml:"MoveStatus"`
+	Error      string         `xml:"Error,omitempty"`
 	UTCTime    string         `xml:"UtcTime"`
 }
 
@@ -151,6 +154,54 @@ type SetPresetResponse struct {
 	PresetToken string   `xml:"PresetToken"`
 }
 
+// GetNodesResponse represents GetNodes response
+type GetNodesResponse struct {
+	XMLName xml.Name  `xml:"http://www.onvif.org/ver20/ptz/wsdl GetNodesResponse"`
+	PTZNode []PTZNode `xml:"PTZNode"`
+}
+
+// GetNodeRequest represents GetNode request
+type GetNodeRequest struct {
+	XMLName   xml.Name `xml:"http://www.onvif.org/ver20/ptz/wsdl GetNode"`
+	NodeToken string   `xml:"NodeToken"`
+}
+
+// GetNodeResponse represents GetNode response
+type GetNodeResponse struct {
+	XMLName xml.Name `xml:"http://www.onvif.org/ver20/ptz/wsdl GetNodeResponse"`
+	PTZNode PTZNode  `xml:"PTZNode"`
+}
+
+// PTZNode represents a PTZ node
+type PTZNode struct {
+	Token                  string    `xml:"token,attr"`
+	FixedHomePosition      bool      `xml:"FixedHomePosition,attr"`
+	Name                   string    `xml:"Name"`
+	SupportedPTZSpaces     PTZSpaces `xml:"SupportedPTZSpaces"`
+	MaximumNumberOfPresets int       `xml:"MaximumNumberOfPresets"`
+	HomeSupported          bool      `xml:"HomeSupported"`
+}
+
+// PTZSpaces represents the coordinate spaces a PTZ node supports
+type PTZSpaces struct {
+	AbsolutePanTiltPositionSpace    []Space2DDescription `xml:"AbsolutePanTiltPositionSpace,omitempty"`
+	AbsoluteZoomPositionSpace       []Space1DDescription `xml:"AbsoluteZoomPositionSpace,omitempty"`
+	RelativePanTiltTranslationSpace []Space2DDescription `xml:"RelativePanTiltTranslationSpace,omitempty"`
+	RelativeZoomTranslationSpace    []Space1DDescription `xml:"RelativeZoomTranslationSpace,omitempty"`
+	ContinuousPanTiltVelocitySpace  []Space2DDescription `xml:"ContinuousPanTiltVelocitySpace,omitempty"`
+	ContinuousZoomVelocitySpace     []Space1DDescription `xml:"ContinuousZoomVelocitySpace,omitempty"`
+}
+
+// Generic PTZ coordinate space URIs
+const (
+	panTiltPositionSpace    = "http://www.onvif.org/ver10/tptz/PanTiltSpaces/PositionGenericSpace"
+	zoomPositionSpace       = "http://www.onvif.org/ver10/tptz/ZoomSpaces/PositionGenericSpace"
+	panTiltTranslationSpace = "http://www.onvif.org/ver10/tptz/PanTiltSpaces/TranslationGenericSpace"
+	zoomTranslationSpace    = "http://www.onvif.org/ver10/tptz/ZoomSpaces/TranslationGenericSpace"
+	panTiltVelocitySpace    = "http://www.onvif.org/ver10/tptz/PanTiltSpaces/VelocityGenericSpace"
+	zoomVelocitySpace       = "http://www.onvif.org/ver10/tptz/ZoomSpaces/VelocityGenericSpace"
+)
+
 // GetConfigurationsResponse represents GetConfigurations response
 type GetConfigurationsResponse struct {
 	XMLName        xml.Name           `xml:"http://www.onvif.org/ver20/ptz/wsdl GetConfigurationsResponse"`
@@ -204,7 +255,7 @@ var ptzMutex sync.RWMutex
 func (s *Server) HandleContinuousMove(body interface{}) (interface{}, error) {
 	var req ContinuousMoveRequest
 	if err := unmarshalBody(body, &req); err != nil {
-		return nil, fmt.Errorf("invalid request: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
 	}
 
 	// Get PTZ state
@@ -213,22 +264,38 @@ func (s *Server) HandleContinuousMove(body interface{}) (interface{}, error) {
 
 	state, ok := s.ptzState[req.ProfileToken]
 	if !ok {
-		return nil, fmt.Errorf("PTZ not supported for profile: %s", req.ProfileToken)
+		return nil, s.ptzProfileError(req.ProfileToken)
 	}
 
-	// Set movement state
-	state.Moving = true
+	var timeout time.Duration
+	if req.Timeout != "" {
+		d, err := onvif.ParseDuration(req.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid timeout: %w", ErrInvalidArgs, err)
+		}
+		timeout = d
+	}
+
+	// Settle any move in progress before applying the new velocity
+	now := time.Now()
+	s.advancePTZ(req.ProfileToken, state, now)
+
+	state.Velocity = PTZSpeed{}
 	if req.Velocity.PanTilt != nil {
-		state.PanMoving = req.Velocity.PanTilt.X != 0 || req.Velocity.PanTilt.Y != 0
-		state.TiltMoving = state.PanMoving
+		state.Velocity.Pan = clamp(req.Velocity.PanTilt.X, -1, 1)
+		state.Velocity.Tilt = clamp(req.Velocity.PanTilt.Y, -1, 1)
 	}
 	if req.Velocity.Zoom != nil {
-		state.ZoomMoving = req.Velocity.Zoom.X != 0
+		state.Velocity.Zoom = clamp(req.Velocity.Zoom.X, -1, 1)
 	}
-	state.LastUpdate = time.Now()
 
-	// In a real implementation, this would start a background task to
-	// simulate movement and update position over time
+	state.MoveDeadline = time.Time{}
+	if timeout > 0 {
+		state.MoveDeadline = now.Add(timeout)
+	}
+	state.LastUpdate = now
+	state.Error = ""
+	state.setMovingFromVelocity()
 
 	return &ContinuousMoveResponse{}, nil
 }
@@ -237,7 +304,7 @@ func (s *Server) HandleContinuousMove(body interface{}) (interface{}, error) {
 func (s *Server) HandleAbsoluteMove(body interface{}) (interface{}, error) {
 	var req AbsoluteMoveRequest
 	if err := unmarshalBody(body, &req); err != nil {
-		return nil, fmt.Errorf("invalid request: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
 	}
 
 	// Get PTZ state
@@ -246,9 +313,12 @@ func (s *Server) HandleAbsoluteMove(body interface{}) (interface{}, error) {
 
 	state, ok := s.ptzState[req.ProfileToken]
 	if !ok {
-		return nil, fmt.Errorf("PTZ not supported for profile: %s", req.ProfileToken)
+		return nil, s.ptzProfileError(req.ProfileToken)
 	}
 
+	state.cancelContinuousMove()
+	state.Error = ""
+
 	// Update position
 	if req.Position.PanTilt != nil {
 		state.Position.Pan = req.Position.PanTilt.X
@@ -270,10 +340,13 @@ func (s *Server) HandleAbsoluteMove(body interface{}) (interface{}, error) {
 	go func() {
 		time.Sleep(500 * time.Millisecond)
 		ptzMutex.Lock()
-		state.Moving = false
-		state.PanMoving = false
-		state.TiltMoving = false
-		state.ZoomMoving = false
+		// A continuous move started since then owns the moving flags
+		if state.Velocity == (PTZSpeed{}) {
+			state.Moving = false
+			state.PanMoving = false
+			state.TiltMoving = false
+			state.ZoomMoving = false
+		}
 		ptzMutex.Unlock()
 	}()
 
@@ -284,7 +357,7 @@ func (s *Server) HandleAbsoluteMove(body interface{}) (interface{}, error) {
 func (s *Server) HandleRelativeMove(body interface{}) (interface{}, error) {
 	var req RelativeMoveRequest
 	if err := unmarshalBody(body, &req); err != nil {
-		return nil, fmt.Errorf("invalid request: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
 	}
 
 	// Get PTZ state
@@ -293,9 +366,13 @@ func (s *Server) HandleRelativeMove(body interface{}) (interface{}, error) {
 
 	state, ok := s.ptzState[req.ProfileToken]
 	if !ok {
-		return nil, fmt.Errorf("PTZ not supported for profile: %s", req.ProfileToken)
+		return nil, s.ptzProfileError(req.ProfileToken)
 	}
 
+	s.advancePTZ(req.ProfileToken, state, time.Now())
+	state.cancelContinuousMove()
+	state.Error = ""
+
 	// Update position relatively
 	if req.Translation.PanTilt != nil {
 		state.Position.Pan += req.Translation.PanTilt.X
@@ -317,10 +394,13 @@ func (s *Server) HandleRelativeMove(body interface{}) (interface{}, error) {
 	go func() {
 		time.Sleep(500 * time.Millisecond)
 		ptzMutex.Lock()
-		state.Moving = false
-		state.PanMoving = false
-		state.TiltMoving = false
-		state.ZoomMoving = false
+		// A continuous move started since then owns the moving flags
+		if state.Velocity == (PTZSpeed{}) {
+			state.Moving = false
+			state.PanMoving = false
+			state.TiltMoving = false
+			state.ZoomMoving = false
+		}
 		ptzMutex.Unlock()
 	}()
 
@@ -331,7 +411,7 @@ func (s *Server) HandleRelativeMove(body interface{}) (interface{}, error) {
 func (s *Server) HandleStop(body interface{}) (interface{}, error) {
 	var req StopRequest
 	if err := unmarshalBody(body, &req); err != nil {
-		return nil, fmt.Errorf("invalid request: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
 	}
 
 	// Get PTZ state
@@ -340,25 +420,28 @@ func (s *Server) HandleStop(body interface{}) (interface{}, error) {
 
 	state, ok := s.ptzState[req.ProfileToken]
 	if !ok {
-		return nil, fmt.Errorf("PTZ not supported for profile: %s", req.ProfileToken)
+		return nil, s.ptzProfileError(req.ProfileToken)
 	}
 
-	// Stop movement
-	if req.PanTilt {
+	// Bring the position up to date before halting
+	s.advancePTZ(req.ProfileToken, state, time.Now())
+
+	// Stop all if neither specified
+	stopAll := !req.PanTilt && !req.Zoom
+	if req.PanTilt || stopAll {
+		state.Velocity.Pan = 0
+		state.Velocity.Tilt = 0
 		state.PanMoving = false
 		state.TiltMoving = false
 	}
-	if req.Zoom {
+	if req.Zoom || stopAll {
+		state.Velocity.Zoom = 0
 		state.ZoomMoving = false
 	}
-	if !req.PanTilt && !req.Zoom {
-		// Stop all if neither specified
-		state.PanMoving = false
-		state.TiltMoving = false
-		state.ZoomMoving = false
+	if state.Velocity == (PTZSpeed{}) {
+		state.MoveDeadline = time.Time{}
 	}
 	state.Moving = state.PanMoving || state.TiltMoving || state.ZoomMoving
-	state.LastUpdate = time.Now()
 
 	return &StopResponse{}, nil
 }
@@ -367,35 +450,38 @@ func (s *Server) HandleStop(body interface{}) (interface{}, error) {
 func (s *Server) HandleGetStatus(body interface{}) (interface{}, error) {
 	var req GetStatusRequest
 	if err := unmarshalBody(body, &req); err != nil {
-		return nil, fmt.Errorf("invalid request: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
 	}
 
 	// Get PTZ state
-	ptzMutex.RLock()
-	defer ptzMutex.RUnlock()
+	ptzMutex.Lock()
+	defer ptzMutex.Unlock()
 
 	state, ok := s.ptzState[req.ProfileToken]
 	if !ok {
-		return nil, fmt.Errorf("PTZ not supported for profile: %s", req.ProfileToken)
+		return nil, s.ptzProfileError(req.ProfileToken)
 	}
 
+	s.advancePTZ(req.ProfileToken, state, time.Now())
+
 	// Build status response
 	status := &PTZStatus{
 		Position: PTZVector{
 			PanTilt: &Vector2D{
 				X:     state.Position.Pan,
 				Y:     state.Position.Tilt,
-				Space: "http://www.onvif.org/ver10/tptz/PanTiltSpaces/PositionGenericSpace",
+				Space: panTiltPositionSpace,
 			},
 			Zoom: &Vector1D{
 				X:     state.Position.Zoom,
-				Space: "http://www.onvif.org/ver10/tptz/ZoomSpaces/PositionGenericSpace",
+				Space: zoomPositionSpace,
 			},
 		},
 		MoveStatus: PTZMoveStatus{
 			PanTilt: getMoveStatusString(state.PanMoving || state.TiltMoving),
 			Zoom:    getMoveStatusString(state.ZoomMoving),
 		},
+		Error:   state.Error,
 		UTCTime: time.Now().UTC().Format(time.RFC3339),
 	}
 
@@ -404,11 +490,96 @@ func (s *Server) HandleGetStatus(body interface{}) (interface{}, error) {
 	}, nil
 }
 
+// HandleGetNodes handles GetNodes request
+func (s *Server) HandleGetNodes(body interface{}) (interface{}, error) {
+	var nodes []PTZNode
+	seen := make(map[string]bool)
+	for i := range s.config.Profiles {
+		cfg := s.config.Profiles[i].PTZ
+		if cfg == nil || seen[cfg.NodeToken] {
+			continue
+		}
+		seen[cfg.NodeToken] = true
+		nodes = append(nodes, ptzNode(cfg))
+	}
+
+	return &GetNodesResponse{PTZNode: nodes}, nil
+}
+
+// HandleGetNode handles GetNode request
+func (s *Server) HandleGetNode(body interface{}) (interface{}, error) {
+	var req GetNodeRequest
+	if err := unmarshalBody(body, &req); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
+	}
+
+	for i := range s.config.Profiles {
+		if cfg := s.config.Profiles[i].PTZ; cfg != nil && cfg.NodeToken == req.NodeToken {
+			return &GetNodeResponse{PTZNode: ptzNode(cfg)}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrNodeNotFound, req.NodeToken)
+}
+
+// ptzNode describes the node of a PTZ configuration. Absolute positions
+// span the configured ranges, relative translations span their width in
+// either direction and continuous velocities are normalized to -1..1, each
+// only when the node supports that kind of move.
+func ptzNode(cfg *PTZConfig) PTZNode {
+	node := PTZNode{
+		Token:                  cfg.NodeToken,
+		Name:                   cfg.NodeToken,
+		MaximumNumberOfPresets: len(cfg.Presets),
+		HomeSupported:          cfg.HomeSupported,
+	}
+
+	spaces := &node.SupportedPTZSpaces
+	if cfg.SupportsAbsolute {
+		spaces.AbsolutePanTiltPositionSpace = []Space2DDescription{{
+			URI:    panTiltPositionSpace,
+			XRange: FloatRange{Min: cfg.PanRange.Min, Max: cfg.PanRange.Max},
+			YRange: FloatRange{Min: cfg.TiltRange.Min, Max: cfg.TiltRange.Max},
+		}}
+		spaces.AbsoluteZoomPositionSpace = []Space1DDescription{{
+			URI:    zoomPositionSpace,
+			XRange: FloatRange{Min: cfg.ZoomRange.Min, Max: cfg.ZoomRange.Max},
+		}}
+	}
+	if cfg.SupportsRelative {
+		pan := cfg.PanRange.Max - cfg.PanRange.Min
+		tilt := cfg.TiltRange.Max - cfg.TiltRange.Min
+		zoom := cfg.ZoomRange.Max - cfg.ZoomRange.Min
+		spaces.RelativePanTiltTranslationSpace = []Space2DDescription{{
+			URI:    panTiltTranslationSpace,
+			XRange: FloatRange{Min: -pan, Max: pan},
+			YRange: FloatRange{Min: -tilt, Max: tilt},
+		}}
+		spaces.RelativeZoomTranslationSpace = []Space1DDescription{{
+			URI:    zoomTranslationSpace,
+			XRange: FloatRange{Min: -zoom, Max: zoom},
+		}}
+	}
+	if cfg.SupportsContinuous {
+		spaces.ContinuousPanTiltVelocitySpace = []Space2DDescription{{
+			URI:    panTiltVelocitySpace,
+			XRange: FloatRange{Min: -1, Max: 1},
+			YRange: FloatRange{Min: -1, Max: 1},
+		}}
+		spaces.ContinuousZoomVelocitySpace = []Space1DDescription{{
+			URI:    zoomVelocitySpace,
+			XRange: FloatRange{Min: -1, Max: 1},
+		}}
+	}
+
+	return node
+}
+
 // HandleGetPresets handles GetPresets request
 func (s *Server) HandleGetPresets(body interface{}) (interface{}, error) {
 	var req GetPresetsRequest
 	if err := unmarshalBody(body, &req); err != nil {
-		return nil, fmt.Errorf("invalid request: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
 	}
 
 	// Find the profile configuration
@@ -421,7 +592,7 @@ func (s *Server) HandleGetPresets(body interface{}) (interface{}, error) {
 	}
 
 	if profileCfg == nil || profileCfg.PTZ == nil {
-		return nil, fmt.Errorf("PTZ not supported for profile: %s", req.ProfileToken)
+		return nil, s.ptzProfileError(req.ProfileToken)
 	}
 
 	// Build presets response
@@ -451,7 +622,7 @@ func (s *Server) HandleGetPresets(body interface{}) (interface{}, error) {
 func (s *Server) HandleGotoPreset(body interface{}) (interface{}, error) {
 	var req GotoPresetRequest
 	if err := unmarshalBody(body, &req); err != nil {
-		return nil, fmt.Errorf("invalid request: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArgs, err)
 	}
 
 	// Find the profile configuration
@@ -464,7 +635,7 @@ func (s *Server) HandleGotoPreset(body interface{}) (interface{}, error) {
 	}
 
 	if profileCfg == nil || profileCfg.PTZ == nil {
-		return nil, fmt.Errorf("PTZ not supported for profile: %s", req.ProfileToken)
+		return nil, s.ptzProfileError(req.ProfileToken)
 	}
 
 	// Find the preset
@@ -477,7 +648,7 @@ func (s *Server) HandleGotoPreset(body interface{}) (interface{}, error) {
 	}
 
 	if presetPos == nil {
-		return nil, fmt.Errorf("preset not found: %s", req.PresetToken)
+		return nil, fmt.Errorf("%w: %s", ErrPresetNotFound, req.PresetToken)
 	}
 
 	// Get PTZ state and move to preset
@@ -485,6 +656,8 @@ func (s *Server) HandleGotoPreset(body interface{}) (interface{}, error) {
 	defer ptzMutex.Unlock()
 
 	state := s.ptzState[req.ProfileToken]
+	state.cancelContinuousMove()
+	state.Error = ""
 	state.Position = *presetPos
 	state.Moving = true
 	state.PanMoving = true
@@ -496,10 +669,13 @@ func (s *Server) HandleGotoPreset(body interface{}) (interface{}, error) {
 	go func() {
 		time.Sleep(1 * time.Second)
 		ptzMutex.Lock()
-		state.Moving = false
-		state.PanMoving = false
-		state.TiltMoving = false
-		state.ZoomMoving = false
+		// A continuous move started since then owns the moving flags
+		if state.Velocity == (PTZSpeed{}) {
+			state.Moving = false
+			state.PanMoving = false
+			state.TiltMoving = false
+			state.ZoomMoving = false
+		}
 		ptzMutex.Unlock()
 	}()
 
@@ -508,6 +684,101 @@ func (s *Server) HandleGotoPreset(body interface{}) (interface{}, error) {
 
 // Helper functions
 
+// ptzFullSpeedSweep is how long a continuous move at velocity 1 and default
+// speed 1 takes to cross the whole configured range of an axis
+const ptzFullSpeedSweep = 10 * time.Second
+
+// advancePTZ integrates an active continuous move up to now and ends the move
+// once its timeout has elapsed. Each axis moves at its requested velocity
+// scaled by the node's default speed for that axis. An axis that reaches a
+// limit of its configured range stops there and goes IDLE, and the status
+// Error reports it until the next move. The caller must hold ptzMutex.
+func (s *Server) advancePTZ(profileToken string, state *PTZState, now time.Time) {
+	if state.Velocity == (PTZSpeed{}) {
+		return
+	}
+
+	end := now
+	expired := !state.MoveDeadline.IsZero() && !now.Before(state.MoveDeadline)
+	if expired {
+		end = state.MoveDeadline
+	}
+
+	if elapsed := end.Sub(state.LastUpdate); elapsed > 0 {
+		if cfg := s.ptzConfig(profileToken); cfg != nil {
+			fraction := elapsed.Seconds() / ptzFullSpeedSweep.Seconds()
+			state.stepAxis("pan", &state.Position.Pan, &state.Velocity.Pan, cfg.DefaultSpeed.Pan, fraction, cfg.PanRange)
+			state.stepAxis("tilt", &state.Position.Tilt, &state.Velocity.Tilt, cfg.DefaultSpeed.Tilt, fraction, cfg.TiltRange)
+			state.stepAxis("zoom", &state.Position.Zoom, &state.Velocity.Zoom, cfg.DefaultSpeed.Zoom, fraction, cfg.ZoomRange)
+			state.setMovingFromVelocity()
+			if state.Velocity == (PTZSpeed{}) {
+				state.MoveDeadline = time.Time{}
+			}
+		}
+		state.LastUpdate = end
+	}
+
+	if expired {
+		state.cancelContinuousMove()
+		state.Moving = false
+		state.PanMoving = false
+		state.TiltMoving = false
+		state.ZoomMoving = false
+	}
+}
+
+// ptzConfig returns the PTZ configuration of a profile, or nil if it has none
+func (s *Server) ptzConfig(profileToken string) *PTZConfig {
+	for i := range s.config.Profiles {
+		if s.config.Profiles[i].Token == profileToken {
+			return s.config.Profiles[i].PTZ
+		}
+	}
+	return nil
+}
+
+// stepAxis moves *value by *velocity, scaled by the axis default speed, over
+// fraction of a full sweep of r. Reaching a limit of r stops the axis: its
+// velocity is cleared and the limit is recorded in the state's Error. An
+// unset default speed counts as 1.
+func (st *PTZState) stepAxis(axis string, value, velocity *float64, defaultSpeed, fraction float64, r Range) {
+	if *velocity == 0 {
+		return
+	}
+	if defaultSpeed <= 0 {
+		defaultSpeed = 1
+	}
+
+	next := *value + *velocity*defaultSpeed*fraction*(r.Max-r.Min)
+	if next > r.Min && next < r.Max {
+		*value = next
+		return
+	}
+
+	*value = clamp(next, r.Min, r.Max)
+	*velocity = 0
+
+	message := axis + " limit reached"
+	if st.Error != "" {
+		message = st.Error + "; " + message
+	}
+	st.Error = message
+}
+
+// setMovingFromVelocity derives the moving flags from the current velocity
+func (st *PTZState) setMovingFromVelocity() {
+	st.PanMoving = st.Velocity.Pan != 0
+	st.TiltMoving = st.Velocity.Tilt != 0
+	st.ZoomMoving = st.Velocity.Zoom != 0
+	st.Moving = st.PanMoving || st.TiltMoving || st.ZoomMoving
+}
+
+// cancelContinuousMove clears any active continuous move
+func (st *PTZState) cancelContinuousMove() {
+	st.Velocity = PTZSpeed{}
+	st.MoveDeadline = time.Time{}
+}
+
 func getMoveStatusString(moving bool) string {
 	if moving {
 		return "MOVING"
@@ -0,0 +1,239 @@
+package server
+
+import (
+	"context"
+	"encoding/xml"
+	"math"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0x524a/onvif-go"
+	"github.com/0x524a/onvif-go/server/soap"
+)
+
+func newPTZTestServer(t *testing.T) (*Server, *httptest.Server) {
+	t.Helper()
+
+	return newTestServer(t, func(srv *Server, h *soap.Handler) {
+		h.RegisterHandler("ContinuousMove", srv.HandleContinuousMove)
+		h.RegisterHandler("Stop", srv.HandleStop)
+		h.RegisterHandler("GetStatus", srv.HandleGetStatus)
+	})
+}
+
+func getPTZStatus(t *testing.T, url string) *PTZStatus {
+	t.Helper()
+
+	data := postSOAP(t, url, `<tptz:GetStatus><tptz:ProfileToken>profile_0</tptz:ProfileToken></tptz:GetStatus>`)
+
+	var envelope struct {
+		Body struct {
+			Response GetStatusResponse `xml:"GetStatusResponse"`
+		} `xml:"Body"`
+	}
+	if err := xml.Unmarshal([]byte(data), &envelope); err != nil {
+		t.Fatalf("failed to decode status: %v", err)
+	}
+
+	return envelope.Body.Response.PTZStatus
+}
+
+// rewind moves the PTZ clock back by d, as if d had elapsed since the last update
+func rewind(srv *Server, d time.Duration) {
+	ptzMutex.Lock()
+	defer ptzMutex.Unlock()
+
+	state := srv.ptzState["profile_0"]
+	state.LastUpdate = state.LastUpdate.Add(-d)
+	if !state.MoveDeadline.IsZero() {
+		state.MoveDeadline = state.MoveDeadline.Add(-d)
+	}
+}
+
+func TestContinuousMoveAndStop(t *testing.T) {
+	srv, server := newPTZTestServer(t)
+	defer server.Close()
+
+	postSOAP(t, server.URL, `<tptz:ContinuousMove>
+<tptz:ProfileToken>profile_0</tptz:ProfileToken>
+<tptz:Velocity><tt:PanTilt x="0.5" y="0"/></tptz:Velocity>
+</tptz:ContinuousMove>`)
+
+	status := getPTZStatus(t, server.URL)
+	if status.MoveStatus.PanTilt != "MOVING" {
+		t.Errorf("expected PanTilt MOVING, got %q", status.MoveStatus.PanTilt)
+	}
+	if status.MoveStatus.Zoom != "IDLE" {
+		t.Errorf("expected Zoom IDLE, got %q", status.MoveStatus.Zoom)
+	}
+
+	// Half velocity at the node's 0.5 default speed over a 360 degree range
+	// for 2s of a 10s full sweep
+	rewind(srv, 2*time.Second)
+	status = getPTZStatus(t, server.URL)
+	if math.Abs(status.Position.PanTilt.X-18) > 1 {
+		t.Errorf("expected pan near 18, got %v", status.Position.PanTilt.X)
+	}
+
+	postSOAP(t, server.URL, `<tptz:Stop><tptz:ProfileToken>profile_0</tptz:ProfileToken></tptz:Stop>`)
+
+	status = getPTZStatus(t, server.URL)
+	if status.MoveStatus.PanTilt != "IDLE" {
+		t.Errorf("expected PanTilt IDLE after Stop, got %q", status.MoveStatus.PanTilt)
+	}
+
+	stopped := status.Position.PanTilt.X
+	rewind(srv, 5*time.Second)
+	status = getPTZStatus(t, server.URL)
+	if status.Position.PanTilt.X != stopped {
+		t.Errorf("position changed after Stop: %v -> %v", stopped, status.Position.PanTilt.X)
+	}
+}
+
+func TestContinuousMoveTimeout(t *testing.T) {
+	srv, server := newPTZTestServer(t)
+	defer server.Close()
+
+	postSOAP(t, server.URL, `<tptz:ContinuousMove>
+<tptz:ProfileToken>profile_0</tptz:ProfileToken>
+<tptz:Velocity><tt:Zoom x="1"/></tptz:Velocity>
+<tptz:Timeout>PT1S</tptz:Timeout>
+</tptz:ContinuousMove>`)
+
+	rewind(srv, 3*time.Second)
+
+	status := getPTZStatus(t, server.URL)
+	if status.MoveStatus.Zoom != "IDLE" {
+		t.Errorf("expected Zoom IDLE after timeout, got %q", status.MoveStatus.Zoom)
+	}
+	// Only the 1s before the timeout counts: a tenth of the 0..1 range at
+	// the node's 0.5 default speed
+	if math.Abs(status.Position.Zoom.X-0.05) > 0.01 {
+		t.Errorf("expected zoom near 0.05, got %v", status.Position.Zoom.X)
+	}
+	if status.Error != "" {
+		t.Errorf("expected no error after a timeout, got %q", status.Error)
+	}
+}
+
+func TestContinuousMoveClampsToRange(t *testing.T) {
+	srv, server := newPTZTestServer(t)
+	defer server.Close()
+
+	postSOAP(t, server.URL, `<tptz:ContinuousMove>
+<tptz:ProfileToken>profile_0</tptz:ProfileToken>
+<tptz:Velocity><tt:PanTilt x="1" y="-1"/></tptz:Velocity>
+</tptz:ContinuousMove>`)
+
+	rewind(srv, time.Minute)
+
+	status := getPTZStatus(t, server.URL)
+	if status.Position.PanTilt.X != 180 {
+		t.Errorf("expected pan clamped to 180, got %v", status.Position.PanTilt.X)
+	}
+	if status.Position.PanTilt.Y != -90 {
+		t.Errorf("expected tilt clamped to -90, got %v", status.Position.PanTilt.Y)
+	}
+	if status.MoveStatus.PanTilt != "IDLE" {
+		t.Errorf("expected PanTilt IDLE at the limits, got %q", status.MoveStatus.PanTilt)
+	}
+	if status.Error != "pan limit reached; tilt limit reached" {
+		t.Errorf("unexpected error %q", status.Error)
+	}
+
+	postSOAP(t, server.URL, `<tptz:ContinuousMove>
+<tptz:ProfileToken>profile_0</tptz:ProfileToken>
+<tptz:Velocity><tt:PanTilt x="-1" y="0"/></tptz:Velocity>
+</tptz:ContinuousMove>`)
+
+	status = getPTZStatus(t, server.URL)
+	if status.MoveStatus.PanTilt != "MOVING" || status.Error != "" {
+		t.Errorf("expected a new move to clear the error, got %q, %q", status.MoveStatus.PanTilt, status.Error)
+	}
+}
+
+func TestContinuousMoveStopsAxisAtLimit(t *testing.T) {
+	srv, server := newPTZTestServer(t)
+	defer server.Close()
+
+	postSOAP(t, server.URL, `<tptz:ContinuousMove>
+<tptz:ProfileToken>profile_0</tptz:ProfileToken>
+<tptz:Velocity><tt:PanTilt x="0.1" y="0"/><tt:Zoom x="1"/></tptz:Velocity>
+</tptz:ContinuousMove>`)
+
+	// Zoom crosses its whole 0..1 range in 20s at the 0.5 default speed
+	rewind(srv, 30*time.Second)
+
+	status := getPTZStatus(t, server.URL)
+	if status.Position.Zoom.X != 1 || status.MoveStatus.Zoom != "IDLE" {
+		t.Errorf("expected zoom IDLE at 1, got %v %q", status.Position.Zoom.X, status.MoveStatus.Zoom)
+	}
+	if status.MoveStatus.PanTilt != "MOVING" {
+		t.Errorf("expected pan to keep moving, got %q", status.MoveStatus.PanTilt)
+	}
+	if status.Error != "zoom limit reached" {
+		t.Errorf("unexpected error %q", status.Error)
+	}
+	// 0.1 velocity at 0.5 default speed over a 360 degree range for 30s
+	if math.Abs(status.Position.PanTilt.X-54) > 1 {
+		t.Errorf("expected pan near 54, got %v", status.Position.PanTilt.X)
+	}
+}
+
+func TestGetNodes(t *testing.T) {
+	config := DefaultConfig()
+	config.RequireAuth = false
+	config.SimulatedMotionInterval = 0
+	config.Profiles[0].PTZ.HomeSupported = true
+	config.Profiles[0].PTZ.SupportsRelative = false
+
+	_, addr := startConfiguredServer(t, config)
+	client, err := onvif.NewClient("http://" + addr + "/onvif/device_service")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	ctx := context.Background()
+	if err := client.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	node, err := client.GetNode(ctx, "ptz_node_0")
+	if err != nil {
+		t.Fatalf("GetNode failed: %v", err)
+	}
+	if node.Token != "ptz_node_0" || node.MaximumNumberOfPresets != 2 || !node.HomeSupported {
+		t.Errorf("unexpected node: %+v", node)
+	}
+
+	spaces := node.SupportedPTZSpaces
+	if spaces == nil || len(spaces.AbsolutePanTiltPositionSpace) != 1 || len(spaces.AbsoluteZoomPositionSpace) != 1 {
+		t.Fatalf("expected absolute position spaces, got %+v", spaces)
+	}
+	if r := spaces.AbsolutePanTiltPositionSpace[0]; r.XRange == nil || r.XRange.Min != -180 || r.XRange.Max != 180 || r.YRange == nil || r.YRange.Min != -90 {
+		t.Errorf("unexpected pan/tilt space: %+v", r)
+	}
+	if r := spaces.AbsoluteZoomPositionSpace[0]; r.XRange == nil || r.XRange.Max != 1 {
+		t.Errorf("unexpected zoom space: %+v", r)
+	}
+	if len(spaces.RelativePanTiltTranslationSpace) != 0 {
+		t.Errorf("relative moves are disabled but a translation space was reported: %+v", spaces.RelativePanTiltTranslationSpace)
+	}
+
+	if _, err := client.GetNode(ctx, "missing"); err == nil {
+		t.Error("expected an unknown node to fail")
+	}
+
+	data := postSOAP(t, "http://"+addr+"/onvif/ptz_service", `<tptz:GetNodes/>`)
+	var envelope struct {
+		Body struct {
+			Response GetNodesResponse `xml:"GetNodesResponse"`
+		} `xml:"Body"`
+	}
+	if err := xml.Unmarshal([]byte(data), &envelope); err != nil {
+		t.Fatalf("failed to decode nodes: %v", err)
+	}
+	if nodes := envelope.Body.Response.PTZNode; len(nodes) != 2 || nodes[0].Token != "ptz_node_0" || nodes[1].Token != "ptz_node_2" {
+		t.Errorf("unexpected nodes: %+v", nodes)
+	}
+}
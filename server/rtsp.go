@@ -0,0 +1,645 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultRTSPPort is the port advertised in stream URIs when Config.RTSPPort is unset
+	defaultRTSPPort = 8554
+
+	// rtspLoopFrames is the number of pre-encoded frames each stream loops over
+	rtspLoopFrames = 30
+
+	// rtpMaxPayload keeps RTP packets below a typical Ethernet MTU
+	rtpMaxPayload = 1400
+
+	// rtpJPEGPayloadType is the static RTP payload type for JPEG (RFC 3551)
+	rtpJPEGPayloadType = 26
+
+	// rtpJPEGClockRate is the RTP clock rate for video payloads
+	rtpJPEGClockRate = 90000
+
+	// rtpJPEGMaxDimension is the largest width or height RFC 2435 can describe
+	rtpJPEGMaxDimension = 2040
+)
+
+// rtspServer serves each profile's stream as a looping test pattern using
+// RTP/JPEG (RFC 2435) interleaved on the RTSP connection. Only TCP transport
+// is offered; clients asking for UDP get 461 and retry over TCP.
+type rtspServer struct {
+	listener net.Listener
+	streams  map[string]*rtspStream // RTSP path -> stream
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// rtspStream holds the encoded frames for one profile
+type rtspStream struct {
+	profile *ProfileConfig
+
+	mu   sync.Mutex
+	loop *rtspLoop // Encoded for the last settings load saw
+}
+
+// rtspEncoding is the size, frame rate and JPEG quality a stream is encoded at
+type rtspEncoding struct {
+	width     int
+	height    int
+	framerate int
+	quality   int
+}
+
+// rtspLoop is the looping test pattern encoded at one rtspEncoding
+type rtspLoop struct {
+	rtspEncoding
+	frames []*rtpJPEGFrame
+}
+
+// rtpJPEGFrame is a JPEG image split into the parts RFC 2435 transmits
+type rtpJPEGFrame struct {
+	quantTables []byte // luma then chroma table, 64 bytes each
+	scan        []byte // entropy coded data between SOS and EOI
+}
+
+// newRTSPServer creates an RTSP server for the configured streams
+func newRTSPServer(s *Server) *rtspServer {
+	rs := &rtspServer{
+		streams: make(map[string]*rtspStream),
+		conns:   make(map[net.Conn]struct{}),
+	}
+
+	for i := range s.config.Profiles {
+		profile := &s.config.Profiles[i]
+		streamCfg, ok := s.streams[profile.Token]
+		if !ok {
+			continue
+		}
+
+		rs.streams[streamCfg.RTSPPath] = &rtspStream{profile: profile}
+	}
+
+	return rs
+}
+
+// listen binds the RTSP listener and starts accepting connections
+func (rs *rtspServer) listen(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for RTSP on %s: %w", addr, err)
+	}
+	rs.listener = listener
+
+	rs.wg.Add(1)
+	go rs.acceptLoop()
+
+	return nil
+}
+
+// Close stops accepting connections, drops active sessions and waits for them to end
+func (rs *rtspServer) Close() error {
+	rs.mu.Lock()
+	rs.closed = true
+	for conn := range rs.conns {
+		_ = conn.Close()
+	}
+	rs.mu.Unlock()
+
+	var err error
+	if rs.listener != nil {
+		err = rs.listener.Close()
+	}
+	rs.wg.Wait()
+
+	return err
+}
+
+func (rs *rtspServer) acceptLoop() {
+	defer rs.wg.Done()
+
+	for {
+		conn, err := rs.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		rs.mu.Lock()
+		if rs.closed {
+			rs.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		rs.conns[conn] = struct{}{}
+		rs.mu.Unlock()
+
+		rs.wg.Add(1)
+		go func() {
+			defer rs.wg.Done()
+			rs.serveConn(conn)
+
+			rs.mu.Lock()
+			delete(rs.conns, conn)
+			rs.mu.Unlock()
+		}()
+	}
+}
+
+// rtspSession tracks the state of a single RTSP connection
+type rtspSession struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+
+	id      string
+	stream  *rtspStream
+	channel byte
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func (rs *rtspServer) serveConn(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	session := &rtspSession{conn: conn}
+	defer session.stopPlaying()
+
+	reader := bufio.NewReader(conn)
+	for {
+		first, err := reader.Peek(1)
+		if err != nil {
+			return
+		}
+
+		// Discard interleaved RTCP receiver reports from the client
+		if first[0] == '$' {
+			header := make([]byte, 4)
+			if _, err := io.ReadFull(reader, header); err != nil {
+				return
+			}
+			if _, err := reader.Discard(int(binary.BigEndian.Uint16(header[2:]))); err != nil {
+				return
+			}
+			continue
+		}
+
+		method, uri, headers, err := readRTSPRequest(reader)
+		if err != nil {
+			return
+		}
+
+		if !rs.handleRequest(session, method, uri, headers) {
+			return
+		}
+	}
+}
+
+// readRTSPRequest reads a request line and headers, discarding any body
+func readRTSPRequest(reader *bufio.Reader) (string, string, textproto.MIMEHeader, error) {
+	tp := textproto.NewReader(reader)
+
+	line, err := tp.ReadLine()
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	parts := strings.Fields(line)
+	if len(parts) != 3 || !strings.HasPrefix(parts[2], "RTSP/") {
+		return "", "", nil, fmt.Errorf("malformed RTSP request line: %q", line)
+	}
+
+	headers, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	if length, _ := strconv.Atoi(headers.Get("Content-Length")); length > 0 {
+		if _, err := reader.Discard(length); err != nil {
+			return "", "", nil, err
+		}
+	}
+
+	return parts[0], parts[1], headers, nil
+}
+
+// handleRequest answers one RTSP request and reports whether to keep the connection
+func (rs *rtspServer) handleRequest(session *rtspSession, method, uri string, headers textproto.MIMEHeader) bool {
+	cseq := headers.Get("CSeq")
+
+	switch method {
+	case "OPTIONS":
+		return session.respond(200, "OK", cseq, []string{
+			"Public", "OPTIONS, DESCRIBE, SETUP, PLAY, TEARDOWN, GET_PARAMETER",
+		}, "")
+
+	case "DESCRIBE":
+		stream := rs.lookup(uri)
+		if stream == nil {
+			return session.respond(404, "Not Found", cseq, nil, "")
+		}
+		sdp := stream.sdp(session.conn.LocalAddr())
+		return session.respond(200, "OK", cseq, []string{
+			"Content-Base", strings.TrimSuffix(uri, "/") + "/",
+			"Content-Type", "application/sdp",
+		}, sdp)
+
+	case "SETUP":
+		stream := rs.lookup(uri)
+		if stream == nil {
+			return session.respond(404, "Not Found", cseq, nil, "")
+		}
+
+		transport := headers.Get("Transport")
+		if !strings.Contains(transport, "RTP/AVP/TCP") {
+			return session.respond(461, "Unsupported Transport", cseq, nil, "")
+		}
+
+		session.stopPlaying()
+		session.stream = stream
+		session.channel = interleavedChannel(transport)
+		if session.id == "" {
+			session.id = newRTSPSessionID()
+		}
+
+		return session.respond(200, "OK", cseq, []string{
+			"Transport", fmt.Sprintf("RTP/AVP/TCP;unicast;interleaved=%d-%d", session.channel, session.channel+1),
+			"Session", session.id + ";timeout=60",
+		}, "")
+
+	case "PLAY":
+		if session.stream == nil {
+			return session.respond(455, "Method Not Valid in This State", cseq, nil, "")
+		}
+		loop, err := session.stream.load()
+		if err != nil {
+			return session.respond(500, "Internal Server Error", cseq, nil, "")
+		}
+		if !session.respond(200, "OK", cseq, []string{
+			"Session", session.id,
+			"Range", "npt=0.000-",
+		}, "") {
+			return false
+		}
+		session.startPlaying(loop)
+		return true
+
+	case "GET_PARAMETER":
+		return session.respond(200, "OK", cseq, []string{"Session", session.id}, "")
+
+	case "TEARDOWN":
+		session.stopPlaying()
+		session.respond(200, "OK", cseq, []string{"Session", session.id}, "")
+		return false
+
+	default:
+		return session.respond(405, "Method Not Allowed", cseq, []string{
+			"Allow", "OPTIONS, DESCRIBE, SETUP, PLAY, TEARDOWN, GET_PARAMETER",
+		}, "")
+	}
+}
+
+// lookup finds the stream for a request URI, ignoring any track suffix
+func (rs *rtspServer) lookup(uri string) *rtspStream {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil
+	}
+
+	path := strings.TrimSuffix(u.Path, "/")
+	if stream, ok := rs.streams[path]; ok {
+		return stream
+	}
+
+	if idx := strings.LastIndex(path, "/"); idx > 0 {
+		return rs.streams[path[:idx]]
+	}
+
+	return nil
+}
+
+// respond writes an RTSP response; headers are name/value pairs
+func (session *rtspSession) respond(code int, reason, cseq string, headers []string, body string) bool {
+	var b strings.Builder
+	fmt.Fprintf(&b, "RTSP/1.0 %d %s\r\n", code, reason)
+	fmt.Fprintf(&b, "CSeq: %s\r\n", cseq)
+	for i := 0; i+1 < len(headers); i += 2 {
+		if headers[i+1] != "" {
+			fmt.Fprintf(&b, "%s: %s\r\n", headers[i], headers[i+1])
+		}
+	}
+	if body != "" {
+		fmt.Fprintf(&b, "Content-Length: %d\r\n", len(body))
+	}
+	b.WriteString("\r\n")
+	b.WriteString(body)
+
+	session.writeMu.Lock()
+	defer session.writeMu.Unlock()
+
+	_ = session.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	_, err := io.WriteString(session.conn, b.String())
+	return err == nil
+}
+
+// startPlaying begins sending the loop's frames on the interleaved channel
+func (session *rtspSession) startPlaying(loop *rtspLoop) {
+	session.stopPlaying()
+
+	session.stop = make(chan struct{})
+	session.done = make(chan struct{})
+	go session.play(loop, session.channel, session.stop, session.done)
+}
+
+// stopPlaying halts an active PLAY and waits for the sender to exit
+func (session *rtspSession) stopPlaying() {
+	if session.stop == nil {
+		return
+	}
+
+	close(session.stop)
+	<-session.done
+	session.stop = nil
+	session.done = nil
+}
+
+func (session *rtspSession) play(loop *rtspLoop, channel byte, stop, done chan struct{}) {
+	defer close(done)
+
+	// Random SSRC, sequence and timestamp origins (RFC 3550 §5.1)
+	var seed [10]byte
+	_, _ = rand.Read(seed[:])
+	ssrc := binary.BigEndian.Uint32(seed[:4])
+	seq := binary.BigEndian.Uint16(seed[4:6])
+	timestamp := binary.BigEndian.Uint32(seed[6:])
+
+	interval := time.Second / time.Duration(loop.framerate)
+	step := uint32(rtpJPEGClockRate / loop.framerate)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for i := 0; ; i++ {
+		frame := loop.frames[i%len(loop.frames)]
+		for _, packet := range frame.packetize(loop.width, loop.height, &seq, timestamp, ssrc) {
+			if !session.writeInterleaved(channel, packet) {
+				return
+			}
+		}
+		timestamp += step
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeInterleaved frames an RTP packet for the RTSP connection (RFC 2326 §10.12)
+func (session *rtspSession) writeInterleaved(channel byte, packet []byte) bool {
+	header := []byte{'$', channel, 0, 0}
+	binary.BigEndian.PutUint16(header[2:], uint16(len(packet)))
+
+	session.writeMu.Lock()
+	defer session.writeMu.Unlock()
+
+	_ = session.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	if _, err := session.conn.Write(header); err != nil {
+		return false
+	}
+	_, err := session.conn.Write(packet)
+	return err == nil
+}
+
+// sdp describes the stream as a single RTP/JPEG video track
+func (stream *rtspStream) sdp(local net.Addr) string {
+	host := "0.0.0.0"
+	if tcp, ok := local.(*net.TCPAddr); ok {
+		host = tcp.IP.String()
+	}
+
+	var b strings.Builder
+	b.WriteString("v=0\r\n")
+	fmt.Fprintf(&b, "o=- %d 1 IN IP4 %s\r\n", time.Now().Unix(), host)
+	fmt.Fprintf(&b, "s=%s\r\n", stream.profile.Name)
+	b.WriteString("c=IN IP4 0.0.0.0\r\n")
+	b.WriteString("t=0 0\r\n")
+	fmt.Fprintf(&b, "m=video 0 RTP/AVP %d\r\n", rtpJPEGPayloadType)
+	fmt.Fprintf(&b, "a=framerate:%d\r\n", currentRTSPEncoding(stream.profile).framerate)
+	b.WriteString("a=control:trackID=0\r\n")
+
+	return b.String()
+}
+
+// load returns the looping test pattern for the profile's current encoder
+// settings. It is rendered and encoded on first use and again whenever
+// SetVideoEncoderConfiguration has changed the settings since.
+func (stream *rtspStream) load() (*rtspLoop, error) {
+	encoding := currentRTSPEncoding(stream.profile)
+
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+
+	if stream.loop != nil && stream.loop.rtspEncoding == encoding {
+		return stream.loop, nil
+	}
+
+	loop := &rtspLoop{rtspEncoding: encoding}
+	for i := 0; i < rtspLoopFrames; i++ {
+		img := renderTestPattern(encoding.width, encoding.height, i, rtspLoopFrames)
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: encoding.quality}); err != nil {
+			return nil, err
+		}
+
+		frame, err := parseJPEGForRTP(buf.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		loop.frames = append(loop.frames, frame)
+	}
+	stream.loop = loop
+
+	return loop, nil
+}
+
+// currentRTSPEncoding returns the encoding for a profile's video encoder
+// settings as they are now
+func currentRTSPEncoding(profile *ProfileConfig) rtspEncoding {
+	mediaMutex.RLock()
+	defer mediaMutex.RUnlock()
+
+	encoding := rtspEncoding{
+		framerate: profile.VideoEncoder.Framerate,
+		quality:   int(profile.VideoEncoder.Quality),
+	}
+	encoding.width, encoding.height = rtspFrameSize(profile)
+	if encoding.framerate <= 0 {
+		encoding.framerate = 25
+	}
+	if encoding.quality < 1 || encoding.quality > 100 {
+		encoding.quality = jpeg.DefaultQuality
+	}
+
+	return encoding
+}
+
+// parseJPEGForRTP extracts the quantization tables and scan data from a
+// baseline 4:2:0 JPEG as produced by image/jpeg
+func parseJPEGForRTP(data []byte) (*rtpJPEGFrame, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errors.New("not a JPEG image")
+	}
+
+	frame := &rtpJPEGFrame{}
+	tables := make(map[byte][]byte)
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, fmt.Errorf("expected marker at offset %d", pos)
+		}
+		marker := data[pos+1]
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		segment := pos + 4
+		end := pos + 2 + length
+		if end > len(data) {
+			return nil, errors.New("truncated JPEG segment")
+		}
+
+		switch marker {
+		case 0xDB: // DQT
+			for p := segment; p < end; p += 65 {
+				if data[p]>>4 != 0 || p+65 > end {
+					return nil, errors.New("only 8-bit quantization tables are supported")
+				}
+				tables[data[p]&0x0F] = data[p+1 : p+65]
+			}
+		case 0xDA: // SOS
+			scan := data[end:]
+			if n := len(scan); n >= 2 && scan[n-2] == 0xFF && scan[n-1] == 0xD9 {
+				scan = scan[:n-2]
+			}
+			frame.scan = scan
+
+			if len(tables[0]) != 64 || len(tables[1]) != 64 {
+				return nil, errors.New("missing quantization tables")
+			}
+			frame.quantTables = append(append([]byte{}, tables[0]...), tables[1]...)
+			return frame, nil
+		}
+
+		pos = end
+	}
+
+	return nil, errors.New("JPEG has no scan data")
+}
+
+// packetize splits a frame into RTP packets with RFC 2435 headers. The
+// quantization tables travel in-band (Q=255) on the first fragment.
+func (frame *rtpJPEGFrame) packetize(width, height int, seq *uint16, timestamp, ssrc uint32) [][]byte {
+	var packets [][]byte
+
+	for offset := 0; offset < len(frame.scan); {
+		packet := make([]byte, 12, 12+8+4+len(frame.quantTables)+rtpMaxPayload)
+
+		// RTP header
+		packet[0] = 0x80
+		packet[1] = rtpJPEGPayloadType
+		binary.BigEndian.PutUint16(packet[2:], *seq)
+		binary.BigEndian.PutUint32(packet[4:], timestamp)
+		binary.BigEndian.PutUint32(packet[8:], ssrc)
+		*seq++
+
+		// JPEG header: type-specific, fragment offset, type 1 (4:2:0), Q, size in 8px blocks
+		packet = append(packet, 0, byte(offset>>16), byte(offset>>8), byte(offset),
+			1, 255, byte(width/8), byte(height/8))
+
+		if offset == 0 {
+			packet = append(packet, 0, 0, byte(len(frame.quantTables)>>8), byte(len(frame.quantTables)))
+			packet = append(packet, frame.quantTables...)
+		}
+
+		room := rtpMaxPayload - (len(packet) - 12)
+		end := offset + room
+		if end >= len(frame.scan) {
+			end = len(frame.scan)
+			packet[1] |= 0x80 // marker: last packet of the frame
+		}
+
+		packet = append(packet, frame.scan[offset:end]...)
+		packets = append(packets, packet)
+		offset = end
+	}
+
+	return packets
+}
+
+// rtspFrameSize picks the test pattern size for a profile, within the limits of
+// RFC 2435 and aligned to whole 4:2:0 macroblocks. Callers
+// hold mediaMutex.
+func rtspFrameSize(profile *ProfileConfig) (int, int) {
+	width, height := profile.VideoEncoder.Resolution.Width, profile.VideoEncoder.Resolution.Height
+	if width <= 0 || height <= 0 {
+		width, height = profile.VideoSource.Resolution.Width, profile.VideoSource.Resolution.Height
+	}
+	if width <= 0 || height <= 0 {
+		width, height = 640, 480
+	}
+
+	for width > rtpJPEGMaxDimension || height > rtpJPEGMaxDimension {
+		width /= 2
+		height /= 2
+	}
+
+	width -= width % 16
+	height -= height % 16
+	if width < 16 {
+		width = 16
+	}
+	if height < 16 {
+		height = 16
+	}
+
+	return width, height
+}
+
+// interleavedChannel returns the RTP channel requested in a Transport header
+func interleavedChannel(transport string) byte {
+	for _, part := range strings.Split(transport, ";") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(part), "interleaved=")
+		if !ok {
+			continue
+		}
+		first, _, _ := strings.Cut(value, "-")
+		if n, err := strconv.Atoi(first); err == nil && n >= 0 && n < 255 {
+			return byte(n)
+		}
+	}
+
+	return 0
+}
+
+// newRTSPSessionID returns a random session identifier
+func newRTSPSessionID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
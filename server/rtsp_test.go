@@ -0,0 +1,244 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image/jpeg"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRTSPServer(t *testing.T) *rtspServer {
+	t.Helper()
+
+	config := DefaultConfig()
+	config.Profiles = config.Profiles[:1]
+	config.Profiles[0].VideoEncoder.Resolution = Resolution{Width: 320, Height: 240}
+
+	srv, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	rs := newRTSPServer(srv)
+	if err := rs.listen("127.0.0.1:0"); err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	t.Cleanup(func() { _ = rs.Close() })
+
+	return rs
+}
+
+type rtspTestClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	cseq   int
+}
+
+func dialRTSP(t *testing.T, rs *rtspServer) *rtspTestClient {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", rs.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	return &rtspTestClient{t: t, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+// do sends a request and returns the status code, headers and body
+func (c *rtspTestClient) do(method, uri string, headers ...string) (int, textproto.MIMEHeader, string) {
+	c.t.Helper()
+
+	c.cseq++
+	req := fmt.Sprintf("%s %s RTSP/1.0\r\nCSeq: %d\r\n", method, uri, c.cseq)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req += headers[i] + ": " + headers[i+1] + "\r\n"
+	}
+	req += "\r\n"
+	if _, err := io.WriteString(c.conn, req); err != nil {
+		c.t.Fatalf("write failed: %v", err)
+	}
+
+	tp := textproto.NewReader(c.reader)
+	line, err := tp.ReadLine()
+	if err != nil {
+		c.t.Fatalf("read status failed: %v", err)
+	}
+	var code int
+	if _, err := fmt.Sscanf(line, "RTSP/1.0 %d", &code); err != nil {
+		c.t.Fatalf("malformed status line %q", line)
+	}
+
+	respHeaders, err := tp.ReadMIMEHeader()
+	if err != nil {
+		c.t.Fatalf("read headers failed: %v", err)
+	}
+	if got := respHeaders.Get("CSeq"); got != fmt.Sprint(c.cseq) {
+		c.t.Errorf("expected CSeq %d, got %q", c.cseq, got)
+	}
+
+	var body []byte
+	var length int
+	if _, err := fmt.Sscan(respHeaders.Get("Content-Length"), &length); err == nil && length > 0 {
+		body = make([]byte, length)
+		if _, err := io.ReadFull(c.reader, body); err != nil {
+			c.t.Fatalf("read body failed: %v", err)
+		}
+	}
+
+	return code, respHeaders, string(body)
+}
+
+func TestRTSPSession(t *testing.T) {
+	rs := newTestRTSPServer(t)
+	client := dialRTSP(t, rs)
+	uri := "rtsp://" + rs.listener.Addr().String() + "/stream0"
+
+	if code, headers, _ := client.do("OPTIONS", uri); code != 200 || !strings.Contains(headers.Get("Public"), "PLAY") {
+		t.Fatalf("OPTIONS: unexpected %d %v", code, headers)
+	}
+
+	code, _, sdp := client.do("DESCRIBE", uri, "Accept", "application/sdp")
+	if code != 200 {
+		t.Fatalf("DESCRIBE: unexpected status %d", code)
+	}
+	if !strings.Contains(sdp, "m=video 0 RTP/AVP 26") {
+		t.Errorf("SDP missing JPEG video track:\n%s", sdp)
+	}
+
+	if code, _, _ := client.do("DESCRIBE", "rtsp://"+rs.listener.Addr().String()+"/missing"); code != 404 {
+		t.Errorf("DESCRIBE unknown stream: expected 404, got %d", code)
+	}
+
+	if code, _, _ := client.do("SETUP", uri+"/trackID=0", "Transport", "RTP/AVP;unicast;client_port=5000-5001"); code != 461 {
+		t.Errorf("SETUP over UDP: expected 461, got %d", code)
+	}
+
+	code, headers, _ := client.do("SETUP", uri+"/trackID=0", "Transport", "RTP/AVP/TCP;unicast;interleaved=2-3")
+	if code != 200 {
+		t.Fatalf("SETUP: unexpected status %d", code)
+	}
+	if !strings.Contains(headers.Get("Transport"), "interleaved=2-3") {
+		t.Errorf("SETUP: unexpected transport %q", headers.Get("Transport"))
+	}
+	session, _, _ := strings.Cut(headers.Get("Session"), ";")
+
+	if code, _, _ := client.do("PLAY", uri, "Session", session); code != 200 {
+		t.Fatalf("PLAY: unexpected status %d", code)
+	}
+
+	// Read packets until the end of the first frame
+	for i := 0; ; i++ {
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(client.reader, header); err != nil {
+			t.Fatalf("read interleaved header failed: %v", err)
+		}
+		if header[0] != '$' || header[1] != 2 {
+			t.Fatalf("unexpected interleaved header %v", header)
+		}
+		packet := make([]byte, binary.BigEndian.Uint16(header[2:]))
+		if _, err := io.ReadFull(client.reader, packet); err != nil {
+			t.Fatalf("read packet failed: %v", err)
+		}
+
+		if packet[0]>>6 != 2 || packet[1]&0x7F != rtpJPEGPayloadType {
+			t.Fatalf("unexpected RTP header %v", packet[:2])
+		}
+		jpegHeader := packet[12:20]
+		if jpegHeader[4] != 1 || jpegHeader[6] != 320/8 || jpegHeader[7] != 240/8 {
+			t.Fatalf("unexpected RTP/JPEG header %v", jpegHeader)
+		}
+		if packet[1]&0x80 != 0 {
+			break
+		}
+		if i > 1000 {
+			t.Fatal("no end-of-frame marker received")
+		}
+	}
+
+	client.do("TEARDOWN", uri, "Session", session)
+}
+
+func TestRTPJPEGPacketize(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, renderTestPattern(640, 480, 3, 10), &jpeg.Options{Quality: 80}); err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	frame, err := parseJPEGForRTP(buf.Bytes())
+	if err != nil {
+		t.Fatalf("parseJPEGForRTP failed: %v", err)
+	}
+	if len(frame.quantTables) != 128 {
+		t.Fatalf("expected 128 bytes of quantization tables, got %d", len(frame.quantTables))
+	}
+
+	var seq uint16 = 65534
+	packets := frame.packetize(640, 480, &seq, 1000, 42)
+	if len(packets) < 2 {
+		t.Fatalf("expected the frame to span several packets, got %d", len(packets))
+	}
+
+	// Reassemble the scan from the fragment offsets
+	var scan []byte
+	for i, packet := range packets {
+		if len(packet)-12 > rtpMaxPayload {
+			t.Errorf("packet %d payload too large: %d", i, len(packet)-12)
+		}
+
+		payload := packet[20:]
+		offset := int(packet[13])<<16 | int(packet[14])<<8 | int(packet[15])
+		if offset == 0 {
+			if length := binary.BigEndian.Uint16(payload[2:4]); length != 128 {
+				t.Errorf("expected in-band table length 128, got %d", length)
+			}
+			payload = payload[4+128:]
+		}
+		if offset != len(scan) {
+			t.Fatalf("packet %d: expected offset %d, got %d", i, len(scan), offset)
+		}
+		scan = append(scan, payload...)
+
+		last := i == len(packets)-1
+		if marker := packet[1]&0x80 != 0; marker != last {
+			t.Errorf("packet %d: marker %v, want %v", i, marker, last)
+		}
+	}
+
+	if !bytes.Equal(scan, frame.scan) {
+		t.Error("reassembled scan does not match the encoded frame")
+	}
+	if seq != uint16(65534+len(packets)) {
+		t.Errorf("sequence number did not wrap correctly: %d", seq)
+	}
+}
+
+func TestRTSPFrameSize(t *testing.T) {
+	tests := []struct {
+		width, height int
+		wantW, wantH  int
+	}{
+		{1920, 1080, 1920, 1072},
+		{3840, 2160, 1920, 1072},
+		{0, 0, 640, 480},
+		{100, 50, 96, 48},
+	}
+
+	for _, tt := range tests {
+		profile := &ProfileConfig{VideoEncoder: VideoEncoderConfig{Resolution: Resolution{Width: tt.width, Height: tt.height}}}
+		w, h := rtspFrameSize(profile)
+		if w != tt.wantW || h != tt.wantH {
+			t.Errorf("%dx%d: expected %dx%d, got %dx%d", tt.width, tt.height, tt.wantW, tt.wantH, w, h)
+		}
+	}
+}
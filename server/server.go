@@ -2,10 +2,14 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/0x524a/onvif-go"
 	"github.com/0x524a/onvif-go/server/soap"
 )
 
@@ -16,25 +20,56 @@ func New(config *Config) (*Server, error) {
 	}
 
 	server := &Server{
-		config:       config,
-		streams:      make(map[string]*StreamConfig),
-		ptzState:     make(map[string]*PTZState),
-		imagingState: make(map[string]*ImagingState),
-		systemTime:   time.Now(),
+		config:        config,
+		streams:       make(map[string]*StreamConfig),
+		ptzState:      make(map[string]*PTZState),
+		imagingState:  make(map[string]*ImagingState),
+		subscriptions: make(map[string]*eventSubscription),
+	}
+
+	// Start the system clock at the current time
+	now := time.Now()
+	server.systemTime = now
+	server.systemTimeAt = now
+	server.dateTimeType = config.DateTimeType
+	if server.dateTimeType == "" {
+		server.dateTimeType = DateTimeTypeNTP
+	}
+	if server.dateTimeType != DateTimeTypeNTP && server.dateTimeType != DateTimeTypeManual {
+		return nil, fmt.Errorf("invalid date time type: %s", config.DateTimeType)
+	}
+	server.timeZone = config.TimeZone
+	if server.timeZone == "" {
+		server.timeZone = "UTC0"
+	}
+	location, err := onvif.ParsePOSIXTZ(server.timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone: %w", err)
+	}
+	server.timeLocation = location
+	server.daylightSavings = config.DaylightSavings
+
+	if err := validateFaultInjection(config.FaultInjection); err != nil {
+		return nil, err
+	}
+
+	// Seed the user accounts with the configured administrator
+	if config.Username != "" {
+		server.users = []User{{Username: config.Username, Password: config.Password, UserLevel: UserLevelAdministrator}}
 	}
 
 	// Initialize streams for each profile
 	for i := range config.Profiles {
 		profile := &config.Profiles[i]
 		streamPath := fmt.Sprintf("/stream%d", i)
-		
+
 		host := config.Host
 		if host == "0.0.0.0" || host == "" {
 			host = "localhost"
 		}
-		
-		streamURI := fmt.Sprintf("rtsp://%s:8554%s", host, streamPath)
-		
+
+		streamURI := fmt.Sprintf("rtsp://%s:%d%s", host, config.rtspPort(), streamPath)
+
 		server.streams[profile.Token] = &StreamConfig{
 			ProfileToken: profile.Token,
 			RTSPPath:     streamPath,
@@ -104,15 +139,19 @@ func (s *Server) Start(ctx context.Context) error {
 	// Register service handlers
 	s.registerDeviceService(mux)
 	s.registerMediaService(mux)
-	
+
 	if s.config.SupportPTZ {
 		s.registerPTZService(mux)
 	}
-	
+
 	if s.config.SupportImaging {
 		s.registerImagingService(mux)
 	}
 
+	if s.config.SupportEvents {
+		s.registerEventsService(mux)
+	}
+
 	// Add snapshot endpoint
 	mux.HandleFunc(s.config.BasePath+"/snapshot", s.handleSnapshot)
 
@@ -125,6 +164,35 @@ func (s *Server) Start(ctx context.Context) error {
 		WriteTimeout: s.config.Timeout,
 	}
 
+	// Bind before returning control so address errors surface here
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	// Start the RTSP server so advertised stream URIs play
+	var rtsp *rtspServer
+	if s.config.EnableRTSP {
+		rtsp = newRTSPServer(s)
+		if err := rtsp.listen(fmt.Sprintf("%s:%d", s.config.Host, s.config.rtspPort())); err != nil {
+			_ = listener.Close()
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	s.httpServer = httpServer
+	s.listenAddr = listener.Addr()
+	s.rtsp = rtsp
+	s.mu.Unlock()
+
+	// Fire simulated motion events until the server stops
+	if s.config.SupportEvents && s.config.SimulatedMotionInterval > 0 {
+		motionCtx, stopMotion := context.WithCancel(context.Background())
+		defer stopMotion()
+		go s.simulateMotion(motionCtx, s.config.SimulatedMotionInterval)
+	}
+
 	// Start server in goroutine
 	errChan := make(chan error, 1)
 	go func() {
@@ -137,6 +205,12 @@ func (s *Server) Start(ctx context.Context) error {
 		if s.config.SupportImaging {
 			fmt.Printf("📷 Imaging Service: http://%s%s/imaging_service\n", addr, s.config.BasePath)
 		}
+		if s.config.SupportEvents {
+			fmt.Printf("🔔 Events Service: http://%s%s/events_service\n", addr, s.config.BasePath)
+		}
+		if rtsp != nil {
+			fmt.Printf("📺 RTSP Server: rtsp://%s:%d\n", s.config.Host, s.config.rtspPort())
+		}
 		fmt.Printf("\n🌐 Virtual Camera Profiles:\n")
 		for i, profile := range s.config.Profiles {
 			stream := s.streams[profile.Token]
@@ -148,53 +222,118 @@ func (s *Server) Start(ctx context.Context) error {
 		}
 		fmt.Printf("\n✅ Server is ready!\n\n")
 
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			errChan <- err
+		err := httpServer.Serve(listener)
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
 		}
+		errChan <- err
 	}()
 
-	// Wait for context cancellation or error
+	// Wait for context cancellation, Shutdown or a serve error
 	select {
 	case <-ctx.Done():
 		fmt.Println("\n🛑 Shutting down server...")
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
-		return httpServer.Shutdown(shutdownCtx)
+		if err := s.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+		return <-errChan
 	case err := <-errChan:
+		// Serve returned on its own or because Shutdown was called; make
+		// sure the RTSP listener goes with it
+		if rtsp != nil {
+			_ = rtsp.Close()
+		}
 		return err
 	}
 }
 
+// Shutdown gracefully stops a running server: it closes the listeners, waits
+// for in-flight requests to finish or ctx to expire, and ends RTSP sessions.
+// Start returns nil once the server has stopped. Shutdown is a no-op if the
+// server is not running.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	httpServer, rtsp := s.httpServer, s.rtsp
+	s.httpServer, s.listenAddr, s.rtsp = nil, nil, nil
+	s.mu.Unlock()
+
+	if httpServer == nil {
+		return nil
+	}
+
+	err := httpServer.Shutdown(ctx)
+	if rtsp != nil {
+		if rtspErr := rtsp.Close(); err == nil && rtspErr != nil && !errors.Is(rtspErr, net.ErrClosed) {
+			err = rtspErr
+		}
+	}
+
+	return err
+}
+
+// serviceBaseURL returns the base URL advertised in service XAddrs. While the
+// server is running it uses the bound port, so a configured port of 0
+// advertises the port actually chosen.
+func (s *Server) serviceBaseURL() string {
+	port := s.config.Port
+
+	s.mu.Lock()
+	if addr, ok := s.listenAddr.(*net.TCPAddr); ok {
+		port = addr.Port
+	}
+	s.mu.Unlock()
+
+	return s.config.baseURL(s.config.advertisedHost(), port)
+}
+
+// newSOAPHandler creates a SOAP handler that authenticates requests against
+// the server's user accounts when authentication is required
+func (s *Server) newSOAPHandler() *soap.Handler {
+	handler := soap.NewHandler(s.config.Username, s.config.Password, s.config.RequireAuth)
+	handler.SetPasswordLookup(s.userPassword)
+	return handler
+}
+
 // registerDeviceService registers the device service handler
 func (s *Server) registerDeviceService(mux *http.ServeMux) {
-	handler := soap.NewHandler(s.config.Username, s.config.Password)
+	handler := s.newSOAPHandler()
 
 	// Register device service handlers
 	handler.RegisterHandler("GetDeviceInformation", s.HandleGetDeviceInformation)
 	handler.RegisterHandler("GetCapabilities", s.HandleGetCapabilities)
 	handler.RegisterHandler("GetSystemDateAndTime", s.HandleGetSystemDateAndTime)
+	handler.RegisterHandler("SetSystemDateAndTime", s.HandleSetSystemDateAndTime)
 	handler.RegisterHandler("GetServices", s.HandleGetServices)
 	handler.RegisterHandler("SystemReboot", s.HandleSystemReboot)
+	handler.RegisterHandler("GetUsers", s.HandleGetUsers)
+	handler.RegisterHandler("CreateUsers", s.HandleCreateUsers)
+	handler.RegisterHandler("DeleteUsers", s.HandleDeleteUsers)
+	handler.RegisterHandler("SetUser", s.HandleSetUser)
 
-	mux.Handle(s.config.BasePath+"/device_service", handler)
+	mux.Handle(s.config.BasePath+"/device_service", s.injectFaults(handler))
 }
 
 // registerMediaService registers the media service handler
 func (s *Server) registerMediaService(mux *http.ServeMux) {
-	handler := soap.NewHandler(s.config.Username, s.config.Password)
+	handler := s.newSOAPHandler()
 
 	// Register media service handlers
 	handler.RegisterHandler("GetProfiles", s.HandleGetProfiles)
 	handler.RegisterHandler("GetStreamURI", s.HandleGetStreamURI)
+	handler.RegisterHandler("GetStreamUri", s.HandleGetStreamURI)
 	handler.RegisterHandler("GetSnapshotURI", s.HandleGetSnapshotURI)
 	handler.RegisterHandler("GetVideoSources", s.HandleGetVideoSources)
+	handler.RegisterHandler("GetVideoEncoderConfiguration", s.HandleGetVideoEncoderConfiguration)
+	handler.RegisterHandler("SetVideoEncoderConfiguration", s.HandleSetVideoEncoderConfiguration)
 
-	mux.Handle(s.config.BasePath+"/media_service", handler)
+	mux.Handle(s.config.BasePath+"/media_service", s.injectFaults(handler))
 }
 
 // registerPTZService registers the PTZ service handler
 func (s *Server) registerPTZService(mux *http.ServeMux) {
-	handler := soap.NewHandler(s.config.Username, s.config.Password)
+	handler := s.newSOAPHandler()
 
 	// Register PTZ service handlers
 	handler.RegisterHandler("ContinuousMove", s.HandleContinuousMove)
@@ -204,13 +343,15 @@ func (s *Server) registerPTZService(mux *http.ServeMux) {
 	handler.RegisterHandler("GetStatus", s.HandleGetStatus)
 	handler.RegisterHandler("GetPresets", s.HandleGetPresets)
 	handler.RegisterHandler("GotoPreset", s.HandleGotoPreset)
+	handler.RegisterHandler("GetNodes", s.HandleGetNodes)
+	handler.RegisterHandler("GetNode", s.HandleGetNode)
 
-	mux.Handle(s.config.BasePath+"/ptz_service", handler)
+	mux.Handle(s.config.BasePath+"/ptz_service", s.injectFaults(handler))
 }
 
 // registerImagingService registers the imaging service handler
 func (s *Server) registerImagingService(mux *http.ServeMux) {
-	handler := soap.NewHandler(s.config.Username, s.config.Password)
+	handler := s.newSOAPHandler()
 
 	// Register imaging service handlers
 	handler.RegisterHandler("GetImagingSettings", s.HandleGetImagingSettings)
@@ -218,7 +359,18 @@ func (s *Server) registerImagingService(mux *http.ServeMux) {
 	handler.RegisterHandler("GetOptions", s.HandleGetOptions)
 	handler.RegisterHandler("Move", s.HandleMove)
 
-	mux.Handle(s.config.BasePath+"/imaging_service", handler)
+	mux.Handle(s.config.BasePath+"/imaging_service", s.injectFaults(handler))
+}
+
+// registerEventsService registers the events service and pull point handlers
+func (s *Server) registerEventsService(mux *http.ServeMux) {
+	handler := s.newSOAPHandler()
+
+	// Register events service handlers
+	handler.RegisterHandler("CreatePullPointSubscription", s.HandleCreatePullPointSubscription)
+
+	mux.Handle(s.config.BasePath+"/events_service", s.injectFaults(handler))
+	mux.Handle(s.config.BasePath+subscriptionPathSegment, s.injectFaults(http.HandlerFunc(s.handleSubscription)))
 }
 
 // handleSnapshot handles HTTP snapshot requests
@@ -249,13 +401,18 @@ func (s *Server) handleSnapshot(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// In a real implementation, this would capture a frame from the video source
-	// For now, return a placeholder response
+	// Render a test card in place of a captured frame
+	data, err := renderSnapshot(profileCfg, time.Now())
+	if err != nil {
+		http.Error(w, "Failed to encode snapshot", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "image/jpeg")
-	w.Header().Set("Content-Length", "0")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Cache-Control", "no-store")
 	w.WriteHeader(http.StatusOK)
-
-	// TODO: Generate or capture actual JPEG snapshot
+	_, _ = w.Write(data)
 }
 
 // GetConfig returns the server configuration
@@ -273,7 +430,7 @@ func (s *Server) GetStreamConfig(profileToken string) (*StreamConfig, bool) {
 func (s *Server) UpdateStreamURI(profileToken, uri string) error {
 	stream, ok := s.streams[profileToken]
 	if !ok {
-		return fmt.Errorf("profile not found: %s", profileToken)
+		return fmt.Errorf("%w: %s", ErrProfileNotFound, profileToken)
 	}
 	stream.StreamURI = uri
 	return nil
@@ -311,6 +468,8 @@ func (s *Server) ServerInfo() string {
 	info += fmt.Sprintf("\nServer Address: %s:%d\n", s.config.Host, s.config.Port)
 	info += fmt.Sprintf("Base Path: %s\n", s.config.BasePath)
 	info += fmt.Sprintf("\nProfiles (%d):\n", len(s.config.Profiles))
+	mediaMutex.RLock()
+	defer mediaMutex.RUnlock()
 	for i, profile := range s.config.Profiles {
 		info += fmt.Sprintf("  [%d] %s (%s)\n", i+1, profile.Name, profile.Token)
 		info += fmt.Sprintf("      Video: %dx%d @ %dfps (%s)\n",
@@ -0,0 +1,288 @@
+package server
+
+import (
+	"context"
+	"encoding/xml"
+	"image/jpeg"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0x524a/onvif-go/server/soap"
+)
+
+// newTestServer starts a SOAP endpoint backed by a server built from the
+// default configuration with authentication disabled
+func newTestServer(t *testing.T, register func(*Server, *soap.Handler)) (*Server, *httptest.Server) {
+	t.Helper()
+
+	config := DefaultConfig()
+	config.RequireAuth = false
+
+	srv, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	handler := soap.NewHandler(config.Username, config.Password, config.RequireAuth)
+	register(srv, handler)
+
+	return srv, httptest.NewServer(handler)
+}
+
+// postSOAPStatus wraps body in a SOAP envelope, posts it and returns the
+// response body and HTTP status
+func postSOAPStatus(t *testing.T, url, body string) (string, int) {
+	t.Helper()
+
+	envelope := `<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"
+  xmlns:trt="http://www.onvif.org/ver10/media/wsdl"
+  xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"
+  xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl"
+  xmlns:tev="http://www.onvif.org/ver10/events/wsdl"
+  xmlns:wsnt="http://docs.oasis-open.org/wsn/b-2"
+  xmlns:tt="http://www.onvif.org/ver10/schema">
+<s:Body>` + body + `</s:Body>
+</s:Envelope>`
+
+	resp, err := http.Post(url, "application/soap+xml", strings.NewReader(envelope))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+
+	return string(data), resp.StatusCode
+}
+
+// postSOAP is postSOAPStatus for requests that must succeed
+func postSOAP(t *testing.T, url, body string) string {
+	t.Helper()
+
+	data, status := postSOAPStatus(t, url, body)
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", status, data)
+	}
+
+	return data
+}
+
+// faultCodes returns the fault code followed by its nested subcodes
+func faultCodes(t *testing.T, data string) []string {
+	t.Helper()
+
+	type code struct {
+		Value   string `xml:"Value"`
+		Subcode *code  `xml:"Subcode"`
+	}
+	var envelope struct {
+		Body struct {
+			Fault struct {
+				Code code `xml:"Code"`
+			} `xml:"Fault"`
+		} `xml:"Body"`
+	}
+	if err := xml.Unmarshal([]byte(data), &envelope); err != nil {
+		t.Fatalf("failed to decode fault: %v", err)
+	}
+
+	var codes []string
+	for c := &envelope.Body.Fault.Code; c != nil; c = c.Subcode {
+		codes = append(codes, c.Value)
+	}
+	return codes
+}
+
+func TestHandlerFaultSubcodes(t *testing.T) {
+	_, server := newTestServer(t, func(srv *Server, h *soap.Handler) {
+		h.RegisterHandler("GetStreamURI", srv.HandleGetStreamURI)
+		h.RegisterHandler("GetStatus", srv.HandleGetStatus)
+		h.RegisterHandler("GotoPreset", srv.HandleGotoPreset)
+		h.RegisterHandler("GetImagingSettings", srv.HandleGetImagingSettings)
+	})
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			"unknown profile",
+			`<trt:GetStreamURI><trt:ProfileToken>missing</trt:ProfileToken></trt:GetStreamURI>`,
+			[]string{"env:Sender", "ter:InvalidArgVal", "ter:NoProfile"},
+		},
+		{
+			"unknown PTZ profile",
+			`<tptz:GetStatus><tptz:ProfileToken>missing</tptz:ProfileToken></tptz:GetStatus>`,
+			[]string{"env:Sender", "ter:InvalidArgVal", "ter:NoProfile"},
+		},
+		{
+			"profile without PTZ",
+			`<tptz:GetStatus><tptz:ProfileToken>profile_1</tptz:ProfileToken></tptz:GetStatus>`,
+			[]string{"env:Sender", "ter:InvalidArgVal", "ter:NoPTZProfile"},
+		},
+		{
+			"unknown preset",
+			`<tptz:GotoPreset><tptz:ProfileToken>profile_0</tptz:ProfileToken><tptz:PresetToken>missing</tptz:PresetToken></tptz:GotoPreset>`,
+			[]string{"env:Sender", "ter:InvalidArgVal", "ter:NoToken"},
+		},
+		{
+			"unknown video source",
+			`<timg:GetImagingSettings><timg:VideoSourceToken>missing</timg:VideoSourceToken></timg:GetImagingSettings>`,
+			[]string{"env:Sender", "ter:InvalidArgVal", "ter:NoSource"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, status := postSOAPStatus(t, server.URL, tt.body)
+			if status != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", status)
+			}
+			if got := faultCodes(t, data); strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("expected codes %v, got %v", tt.want, got)
+			}
+			if !strings.Contains(data, "missing") && !strings.Contains(data, "profile_1") {
+				t.Errorf("fault detail does not name the token:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestHandleSnapshot(t *testing.T) {
+	srv, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	sizes := make(map[string]int)
+	for _, token := range []string{"profile_0", "profile_1"} {
+		rec := httptest.NewRecorder()
+		srv.handleSnapshot(rec, httptest.NewRequest(http.MethodGet, "/onvif/snapshot?profile="+token, nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: unexpected status %d", token, rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "image/jpeg" {
+			t.Errorf("%s: unexpected content type %q", token, ct)
+		}
+
+		sizes[token] = rec.Body.Len()
+		img, err := jpeg.Decode(rec.Body)
+		if err != nil {
+			t.Fatalf("%s: response is not a JPEG: %v", token, err)
+		}
+
+		want := srv.config.Profiles[0].Snapshot.Resolution
+		if token == "profile_1" {
+			want = srv.config.Profiles[1].Snapshot.Resolution
+		}
+		if b := img.Bounds(); b.Dx() != want.Width || b.Dy() != want.Height {
+			t.Errorf("%s: expected %dx%d, got %dx%d", token, want.Width, want.Height, b.Dx(), b.Dy())
+		}
+	}
+
+	// Lower quality must shrink the image
+	srv.config.Profiles[0].Snapshot.Quality = 10
+	rec := httptest.NewRecorder()
+	srv.handleSnapshot(rec, httptest.NewRequest(http.MethodGet, "/onvif/snapshot?profile=profile_0", nil))
+	if rec.Body.Len() >= sizes["profile_0"] {
+		t.Errorf("expected quality 10 to be smaller than %d bytes, got %d", sizes["profile_0"], rec.Body.Len())
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleSnapshot(rec, httptest.NewRequest(http.MethodGet, "/onvif/snapshot?profile=missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown profile, got %d", rec.Code)
+	}
+}
+
+// startTestServer runs Start on a free local port and waits until it accepts connections
+func startTestServer(t *testing.T, ctx context.Context) (*Server, string, <-chan error) {
+	t.Helper()
+
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+	addr := probe.Addr().(*net.TCPAddr)
+	_ = probe.Close()
+
+	config := DefaultConfig()
+	config.Host = "127.0.0.1"
+	config.Port = addr.Port
+
+	srv, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- srv.Start(ctx) }()
+
+	for i := 0; i < 100; i++ {
+		if conn, err := net.Dial("tcp", addr.String()); err == nil {
+			_ = conn.Close()
+			return srv, addr.String(), done
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("server did not start")
+	return nil, "", nil
+}
+
+func waitStopped(t *testing.T, done <-chan error, addr string) {
+	t.Helper()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start returned error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start did not return after shutdown")
+	}
+
+	// The port must be free for the next server
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("listener leaked: %v", err)
+	}
+	_ = listener.Close()
+}
+
+func TestServerShutdown(t *testing.T) {
+	srv, addr, done := startTestServer(t, context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	waitStopped(t, done, addr)
+
+	// A second Shutdown is a no-op
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Errorf("second Shutdown failed: %v", err)
+	}
+}
+
+func TestServerStopsOnContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	_, addr, done := startTestServer(t, ctx)
+
+	cancel()
+	waitStopped(t, done, addr)
+}
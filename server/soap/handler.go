@@ -3,8 +3,10 @@ package soap
 import (
 	"bytes"
 	"crypto/sha1"
+	"crypto/subtle"
 	"encoding/base64"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,25 +16,42 @@ import (
 	originsoap "github.com/0x524a/onvif-go/internal/soap"
 )
 
+// SOAP 1.2 envelope and ONVIF error namespaces used in fault codes
+const (
+	envelopeNamespace = "http://www.w3.org/2003/05/soap-envelope"
+	errorNamespace    = "http://www.onvif.org/ver10/error"
+)
+
 // Handler handles incoming SOAP requests
 type Handler struct {
-	username string
-	password string
-	handlers map[string]MessageHandler
+	username       string
+	password       string
+	requireAuth    bool
+	handlers       map[string]MessageHandler
+	passwordLookup func(username string) (string, bool)
 }
 
 // MessageHandler is a function that handles a specific SOAP message
 type MessageHandler func(body interface{}) (interface{}, error)
 
-// NewHandler creates a new SOAP handler
-func NewHandler(username, password string) *Handler {
+// NewHandler creates a new SOAP handler. When requireAuth is true, every request
+// must carry a WS-Security UsernameToken digest matching username and password.
+func NewHandler(username, password string, requireAuth bool) *Handler {
 	return &Handler{
-		username: username,
-		password: password,
-		handlers: make(map[string]MessageHandler),
+		username:    username,
+		password:    password,
+		requireAuth: requireAuth,
+		handlers:    make(map[string]MessageHandler),
 	}
 }
 
+// SetPasswordLookup makes the handler authenticate requests against the
+// accounts known to lookup, which returns a user's password and whether the
+// user exists, instead of the username and password given to NewHandler
+func (h *Handler) SetPasswordLookup(lookup func(username string) (string, bool)) {
+	h.passwordLookup = lookup
+}
+
 // RegisterHandler registers a handler for a specific action/message type
 func (h *Handler) RegisterHandler(action string, handler MessageHandler) {
 	h.handlers[action] = handler
@@ -68,12 +87,10 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Authenticate if credentials are configured
-	if h.username != "" && h.password != "" {
-		if !h.authenticate(&envelope) {
-			h.sendFault(w, "Sender", "Authentication failed", "Invalid username or password")
-			return
-		}
+	// Authenticate if required
+	if h.requireAuth && !h.authenticate(&envelope) {
+		h.sendNotAuthorized(w)
+		return
 	}
 
 	// Find and execute handler
@@ -83,9 +100,25 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Capture the request element so handlers can decode their own message
+	var request struct {
+		Body struct {
+			Message RequestWrapper `xml:",any"`
+		} `xml:"Body"`
+	}
+	if err := xml.Unmarshal(body, &request); err != nil {
+		h.sendFault(w, "Sender", "Invalid SOAP body", err.Error())
+		return
+	}
+
 	// Execute handler
-	response, err := handler(envelope.Body.Content)
+	response, err := handler(&request.Body.Message)
 	if err != nil {
+		var soapErr *Error
+		if errors.As(err, &soapErr) {
+			h.sendError(w, soapErr, err.Error())
+			return
+		}
 		h.sendFault(w, "Receiver", "Handler error", err.Error())
 		return
 	}
@@ -103,7 +136,13 @@ func (h *Handler) authenticate(envelope *originsoap.Envelope) bool {
 	token := envelope.Header.Security.UsernameToken
 
 	// Check username
-	if token.Username != h.username {
+	password := h.password
+	if h.passwordLookup != nil {
+		var ok bool
+		if password, ok = h.passwordLookup(token.Username); !ok {
+			return false
+		}
+	} else if token.Username != h.username {
 		return false
 	}
 
@@ -117,11 +156,11 @@ func (h *Handler) authenticate(envelope *originsoap.Envelope) bool {
 	hash := sha1.New()
 	hash.Write(nonce)
 	hash.Write([]byte(token.Created))
-	hash.Write([]byte(h.password))
+	hash.Write([]byte(password))
 	expectedDigest := base64.StdEncoding.EncodeToString(hash.Sum(nil))
 
 	// Compare digests
-	return token.Password.Password == expectedDigest
+	return subtle.ConstantTimeCompare([]byte(token.Password.Password), []byte(expectedDigest)) == 1
 }
 
 // extractAction extracts the action/message type from the SOAP body
@@ -182,18 +221,61 @@ func (h *Handler) sendResponse(w http.ResponseWriter, response interface{}) {
 
 // sendFault sends a SOAP fault response
 func (h *Handler) sendFault(w http.ResponseWriter, code, reason, detail string) {
-	fault := &originsoap.Fault{
-		Code:   code,
-		Reason: reason,
+	writeFault(w, http.StatusInternalServerError, &Fault{
+		Code:   FaultCode{Value: "env:" + code},
+		Reason: FaultReason{Text: reason},
 		Detail: detail,
-	}
+	})
+}
 
-	envelope := &originsoap.Envelope{
-		Body: originsoap.Body{
-			Fault: fault,
+// sendNotAuthorized sends the ONVIF ter:NotAuthorized fault. Sender faults map
+// to HTTP 400 in the SOAP 1.2 HTTP binding.
+func (h *Handler) sendNotAuthorized(w http.ResponseWriter) {
+	writeFault(w, http.StatusBadRequest, &Fault{
+		Code: FaultCode{
+			Value:   "env:Sender",
+			Subcode: &FaultCode{Value: "ter:NotAuthorized"},
 		},
+		Reason: FaultReason{Text: "Sender not Authorized"},
+		Detail: "The action requested requires authorization and the sender is not authorized",
+	})
+}
+
+// sendError sends the fault described by a handler error
+func (h *Handler) sendError(w http.ResponseWriter, soapErr *Error, detail string) {
+	WriteError(w, soapErr, detail)
+}
+
+// WriteError writes soapErr as a SOAP fault response, the way handler errors
+// are sent. Sender faults map to HTTP 400 and Receiver faults to HTTP 500.
+func WriteError(w http.ResponseWriter, soapErr *Error, detail string) {
+	status := http.StatusInternalServerError
+	if soapErr.Code == "Sender" {
+		status = http.StatusBadRequest
+	}
+
+	code := FaultCode{Value: "env:" + soapErr.Code}
+	parent := &code
+	for _, subcode := range soapErr.Subcodes {
+		parent.Subcode = &FaultCode{Value: subcode}
+		parent = parent.Subcode
 	}
 
+	writeFault(w, status, &Fault{
+		Code:   code,
+		Reason: FaultReason{Text: soapErr.Reason},
+		Detail: detail,
+	})
+}
+
+// writeFault marshals a fault into a SOAP envelope and writes it with status
+func writeFault(w http.ResponseWriter, status int, fault *Fault) {
+	fault.EnvNS = envelopeNamespace
+	fault.TerNS = errorNamespace
+
+	envelope := &faultEnvelope{}
+	envelope.Body.Fault = fault
+
 	// Marshal to XML
 	body, err := xml.MarshalIndent(envelope, "", "  ")
 	if err != nil {
@@ -206,10 +288,53 @@ func (h *Handler) sendFault(w http.ResponseWriter, code, reason, detail string)
 
 	// Send fault response
 	w.Header().Set("Content-Type", "application/soap+xml; charset=utf-8")
-	w.WriteHeader(http.StatusInternalServerError)
+	w.WriteHeader(status)
 	_, _ = w.Write(xmlBody)
 }
 
+// faultEnvelope is a SOAP envelope carrying only a fault
+type faultEnvelope struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2003/05/soap-envelope Envelope"`
+	Body    struct {
+		Fault *Fault
+	} `xml:"http://www.w3.org/2003/05/soap-envelope Body"`
+}
+
+// Fault represents a SOAP 1.2 fault with optional ONVIF subcodes
+type Fault struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2003/05/soap-envelope Fault"`
+	EnvNS   string      `xml:"xmlns:env,attr"`
+	TerNS   string      `xml:"xmlns:ter,attr"`
+	Code    FaultCode   `xml:"Code"`
+	Reason  FaultReason `xml:"Reason"`
+	Detail  string      `xml:"Detail,omitempty"`
+}
+
+// FaultCode represents a fault code value and its nested subcode
+type FaultCode struct {
+	Value   string     `xml:"Value"`
+	Subcode *FaultCode `xml:"Subcode,omitempty"`
+}
+
+// FaultReason represents a human readable fault reason
+type FaultReason struct {
+	Text string `xml:"Text"`
+}
+
+// Error is a handler error reported as a SOAP fault with ONVIF subcodes.
+// Handlers may return it directly or wrapped; the full error text becomes the
+// fault Detail.
+type Error struct {
+	Code     string   // "Sender" or "Receiver"
+	Subcodes []string // ONVIF subcodes, outermost first, e.g. "ter:InvalidArgVal", "ter:NoProfile"
+	Reason   string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return e.Reason
+}
+
 // RequestWrapper wraps incoming SOAP request structures
 type RequestWrapper struct {
 	XMLName xml.Name
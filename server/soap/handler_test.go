@@ -0,0 +1,176 @@
+package soap
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	originsoap "github.com/0x524a/onvif-go/internal/soap"
+)
+
+type echoResponse struct {
+	XMLName xml.Name `xml:"EchoResponse"`
+	Value   string   `xml:"Value"`
+}
+
+func newTestServer(requireAuth bool) *httptest.Server {
+	handler := NewHandler("admin", "secret", requireAuth)
+	handler.RegisterHandler("Echo", func(body interface{}) (interface{}, error) {
+		return &echoResponse{Value: "ok"}, nil
+	})
+	return httptest.NewServer(handler)
+}
+
+type echoRequest struct {
+	XMLName xml.Name `xml:"tds:Echo"`
+	Xmlns   string   `xml:"xmlns:tds,attr"`
+}
+
+func TestHandler_RequireAuth(t *testing.T) {
+	server := newTestServer(true)
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		wantErr  bool
+	}{
+		{"valid credentials", "admin", "secret", false},
+		{"wrong password", "admin", "wrong", true},
+		{"wrong username", "other", "secret", true},
+		{"no credentials", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := originsoap.NewClient(http.DefaultClient, tt.username, tt.password)
+			req := echoRequest{Xmlns: "http://www.onvif.org/ver10/device/wsdl"}
+
+			var resp echoResponse
+			err := client.Call(context.Background(), server.URL, "", req, &resp)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected authentication error")
+				}
+				if !strings.Contains(err.Error(), "status 400") {
+					t.Errorf("expected HTTP 400, got %v", err)
+				}
+				if !strings.Contains(err.Error(), "ter:NotAuthorized") {
+					t.Errorf("expected ter:NotAuthorized fault, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Value != "ok" {
+				t.Errorf("expected ok, got %q", resp.Value)
+			}
+		})
+	}
+}
+
+func TestHandler_AuthDisabled(t *testing.T) {
+	server := newTestServer(false)
+	defer server.Close()
+
+	client := originsoap.NewClient(http.DefaultClient, "admin", "wrong")
+	req := echoRequest{Xmlns: "http://www.onvif.org/ver10/device/wsdl"}
+
+	var resp echoResponse
+	if err := client.Call(context.Background(), server.URL, "", req, &resp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Value != "ok" {
+		t.Errorf("expected ok, got %q", resp.Value)
+	}
+}
+
+func TestHandler_NotAuthorizedFault(t *testing.T) {
+	server := newTestServer(true)
+	defer server.Close()
+
+	body := `<?xml version="1.0"?><Envelope xmlns="http://www.w3.org/2003/05/soap-envelope"><Body><Echo/></Body></Envelope>`
+	resp, err := http.Post(server.URL, "application/soap+xml", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", resp.StatusCode)
+	}
+
+	var envelope struct {
+		Body struct {
+			Fault struct {
+				Code struct {
+					Value   string `xml:"Value"`
+					Subcode struct {
+						Value string `xml:"Value"`
+					} `xml:"Subcode"`
+				} `xml:"Code"`
+			} `xml:"Fault"`
+		} `xml:"Body"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		t.Fatalf("failed to decode fault: %v", err)
+	}
+
+	code := envelope.Body.Fault.Code
+	if code.Value != "env:Sender" {
+		t.Errorf("expected env:Sender, got %q", code.Value)
+	}
+	if code.Subcode.Value != "ter:NotAuthorized" {
+		t.Errorf("expected ter:NotAuthorized, got %q", code.Subcode.Value)
+	}
+}
+
+func TestHandler_ErrorFault(t *testing.T) {
+	handler := NewHandler("admin", "secret", false)
+	handler.RegisterHandler("Echo", func(body interface{}) (interface{}, error) {
+		noProfile := &Error{Code: "Sender", Subcodes: []string{"ter:InvalidArgVal", "ter:NoProfile"}, Reason: "profile not found"}
+		return nil, fmt.Errorf("%w: missing", noProfile)
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	body := `<?xml version="1.0"?><Envelope xmlns="http://www.w3.org/2003/05/soap-envelope"><Body><Echo/></Body></Envelope>`
+	resp, err := http.Post(server.URL, "application/soap+xml", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", resp.StatusCode)
+	}
+
+	var envelope struct {
+		Body struct {
+			Fault Fault `xml:"Fault"`
+		} `xml:"Body"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		t.Fatalf("failed to decode fault: %v", err)
+	}
+
+	fault := envelope.Body.Fault
+	if fault.Code.Value != "env:Sender" || fault.Code.Subcode == nil || fault.Code.Subcode.Subcode == nil {
+		t.Fatalf("expected two nested subcodes, got %+v", fault.Code)
+	}
+	if got := fault.Code.Subcode.Value + " " + fault.Code.Subcode.Subcode.Value; got != "ter:InvalidArgVal ter:NoProfile" {
+		t.Errorf("unexpected subcodes %q", got)
+	}
+	if fault.Reason.Text != "profile not found" {
+		t.Errorf("unexpected reason %q", fault.Reason.Text)
+	}
+	if fault.Detail != "profile not found: missing" {
+		t.Errorf("unexpected detail %q", fault.Detail)
+	}
+}
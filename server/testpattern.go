@@ -0,0 +1,197 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"strings"
+	"time"
+)
+
+// testPatternBars are the colour bars drawn across the top of the test pattern
+var testPatternBars = []color.RGBA{
+	{R: 192, G: 192, B: 192, A: 255}, // gray
+	{R: 192, G: 192, B: 0, A: 255},   // yellow
+	{R: 0, G: 192, B: 192, A: 255},   // cyan
+	{R: 0, G: 192, B: 0, A: 255},     // green
+	{R: 192, G: 0, B: 192, A: 255},   // magenta
+	{R: 192, G: 0, B: 0, A: 255},     // red
+	{R: 0, G: 0, B: 192, A: 255},     // blue
+}
+
+// renderTestPattern draws colour bars over a grayscale ramp with a white bar
+// sweeping across the frame. frame selects the sweep position out of frames
+// steps, so consecutive frames show visible motion.
+func renderTestPattern(width, height, frame, frames int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	barsHeight := height * 2 / 3
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var c color.RGBA
+			if y < barsHeight {
+				c = testPatternBars[x*len(testPatternBars)/width]
+			} else {
+				level := uint8(x * 255 / width)
+				c = color.RGBA{R: level, G: level, B: level, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	if frames > 0 {
+		barWidth := width / 32
+		if barWidth < 2 {
+			barWidth = 2
+		}
+		start := (frame % frames) * (width - barWidth) / frames
+		white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+		for y := 0; y < height; y++ {
+			for x := start; x < start+barWidth && x < width; x++ {
+				img.SetRGBA(x, y, white)
+			}
+		}
+	}
+
+	return img
+}
+
+// renderSnapshot encodes a JPEG test card for a profile showing its name, the
+// capture time and the resolution, at the snapshot resolution and quality
+func renderSnapshot(profile *ProfileConfig, now time.Time) ([]byte, error) {
+	mediaMutex.RLock()
+	encoderResolution := profile.VideoEncoder.Resolution
+	mediaMutex.RUnlock()
+
+	width, height := profile.Snapshot.Resolution.Width, profile.Snapshot.Resolution.Height
+	if width <= 0 || height <= 0 {
+		width, height = encoderResolution.Width, encoderResolution.Height
+	}
+	if width <= 0 || height <= 0 {
+		width, height = 640, 480
+	}
+
+	img := renderTestPattern(width, height, int(now.Unix()%30), 30)
+
+	lines := []string{
+		profile.Name,
+		now.UTC().Format("2006-01-02 15:04:05 UTC"),
+		fmt.Sprintf("%dx%d", width, height),
+	}
+
+	// Grow the text with the frame so it stays legible at high resolutions
+	scale := height / 180
+	if scale < 1 {
+		scale = 1
+	}
+	margin := 4 * scale
+	lineHeight := (glyphHeight + 2) * scale
+
+	boxWidth := 0
+	for _, line := range lines {
+		if w := len([]rune(line)) * (glyphWidth + 1) * scale; w > boxWidth {
+			boxWidth = w
+		}
+	}
+	box := image.Rect(0, 0, boxWidth+2*margin, len(lines)*lineHeight+2*margin).Add(image.Pt(margin, margin))
+	draw.Draw(img, box, image.NewUniform(color.RGBA{A: 255}), image.Point{}, draw.Src)
+
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	for i, line := range lines {
+		drawText(img, box.Min.X+margin, box.Min.Y+margin+i*lineHeight, scale, line, white)
+	}
+
+	quality := int(profile.Snapshot.Quality)
+	if quality < 1 || quality > 100 {
+		quality = jpeg.DefaultQuality
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Glyph cell size of the built-in bitmap font
+const (
+	glyphWidth  = 5
+	glyphHeight = 7
+)
+
+// drawText renders text in the built-in upper case bitmap font with its top
+// left corner at (x, y), each font pixel drawn as a scale x scale square
+func drawText(img *image.RGBA, x, y, scale int, text string, c color.RGBA) {
+	for _, r := range strings.ToUpper(text) {
+		glyph, ok := font5x7[r]
+		if !ok {
+			glyph = font5x7['?']
+		}
+
+		for row, bits := range glyph {
+			for col := 0; col < glyphWidth; col++ {
+				if bits&(1<<(glyphWidth-1-col)) == 0 {
+					continue
+				}
+				px := image.Rect(0, 0, scale, scale).Add(image.Pt(x+col*scale, y+row*scale))
+				draw.Draw(img, px, image.NewUniform(c), image.Point{}, draw.Src)
+			}
+		}
+
+		x += (glyphWidth + 1) * scale
+	}
+}
+
+// font5x7 is a minimal 5x7 bitmap font; each row's low five bits are pixels,
+// most significant on the left
+var font5x7 = map[rune][glyphHeight]uint8{
+	'A': {0b01110, 0b10001, 0b10001, 0b11111, 0b10001, 0b10001, 0b10001},
+	'B': {0b11110, 0b10001, 0b10001, 0b11110, 0b10001, 0b10001, 0b11110},
+	'C': {0b01110, 0b10001, 0b10000, 0b10000, 0b10000, 0b10001, 0b01110},
+	'D': {0b11100, 0b10010, 0b10001, 0b10001, 0b10001, 0b10010, 0b11100},
+	'E': {0b11111, 0b10000, 0b10000, 0b11110, 0b10000, 0b10000, 0b11111},
+	'F': {0b11111, 0b10000, 0b10000, 0b11110, 0b10000, 0b10000, 0b10000},
+	'G': {0b01110, 0b10001, 0b10000, 0b10111, 0b10001, 0b10001, 0b01111},
+	'H': {0b10001, 0b10001, 0b10001, 0b11111, 0b10001, 0b10001, 0b10001},
+	'I': {0b01110, 0b00100, 0b00100, 0b00100, 0b00100, 0b00100, 0b01110},
+	'J': {0b00111, 0b00010, 0b00010, 0b00010, 0b00010, 0b10010, 0b01100},
+	'K': {0b10001, 0b10010, 0b10100, 0b11000, 0b10100, 0b10010, 0b10001},
+	'L': {0b10000, 0b10000, 0b10000, 0b10000, 0b10000, 0b10000, 0b11111},
+	'M': {0b10001, 0b11011, 0b10101, 0b10101, 0b10001, 0b10001, 0b10001},
+	'N': {0b10001, 0b10001, 0b11001, 0b10101, 0b10011, 0b10001, 0b10001},
+	'O': {0b01110, 0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b01110},
+	'P': {0b11110, 0b10001, 0b10001, 0b11110, 0b10000, 0b10000, 0b10000},
+	'Q': {0b01110, 0b10001, 0b10001, 0b10001, 0b10101, 0b10010, 0b01101},
+	'R': {0b11110, 0b10001, 0b10001, 0b11110, 0b10100, 0b10010, 0b10001},
+	'S': {0b01111, 0b10000, 0b10000, 0b01110, 0b00001, 0b00001, 0b11110},
+	'T': {0b11111, 0b00100, 0b00100, 0b00100, 0b00100, 0b00100, 0b00100},
+	'U': {0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b01110},
+	'V': {0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b01010, 0b00100},
+	'W': {0b10001, 0b10001, 0b10001, 0b10101, 0b10101, 0b10101, 0b01010},
+	'X': {0b10001, 0b10001, 0b01010, 0b00100, 0b01010, 0b10001, 0b10001},
+	'Y': {0b10001, 0b10001, 0b10001, 0b01010, 0b00100, 0b00100, 0b00100},
+	'Z': {0b11111, 0b00001, 0b00010, 0b00100, 0b01000, 0b10000, 0b11111},
+	'0': {0b01110, 0b10001, 0b10011, 0b10101, 0b11001, 0b10001, 0b01110},
+	'1': {0b00100, 0b01100, 0b00100, 0b00100, 0b00100, 0b00100, 0b01110},
+	'2': {0b01110, 0b10001, 0b00001, 0b00010, 0b00100, 0b01000, 0b11111},
+	'3': {0b11111, 0b00010, 0b00100, 0b00010, 0b00001, 0b10001, 0b01110},
+	'4': {0b00010, 0b00110, 0b01010, 0b10010, 0b11111, 0b00010, 0b00010},
+	'5': {0b11111, 0b10000, 0b11110, 0b00001, 0b00001, 0b10001, 0b01110},
+	'6': {0b00110, 0b01000, 0b10000, 0b11110, 0b10001, 0b10001, 0b01110},
+	'7': {0b11111, 0b00001, 0b00010, 0b00100, 0b01000, 0b01000, 0b01000},
+	'8': {0b01110, 0b10001, 0b10001, 0b01110, 0b10001, 0b10001, 0b01110},
+	'9': {0b01110, 0b10001, 0b10001, 0b01111, 0b00001, 0b00010, 0b01100},
+	' ': {},
+	'-': {0, 0, 0, 0b11111, 0, 0, 0},
+	'_': {0, 0, 0, 0, 0, 0, 0b11111},
+	':': {0, 0b01100, 0b01100, 0, 0b01100, 0b01100, 0},
+	'.': {0, 0, 0, 0, 0, 0b01100, 0b01100},
+	'/': {0, 0b00001, 0b00010, 0b00100, 0b01000, 0b10000, 0},
+	'(': {0b00010, 0b00100, 0b01000, 0b01000, 0b01000, 0b00100, 0b00010},
+	')': {0b01000, 0b00100, 0b00010, 0b00010, 0b00010, 0b00100, 0b01000},
+	'?': {0b01110, 0b10001, 0b00001, 0b00010, 0b00100, 0, 0b00100},
+}
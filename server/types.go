@@ -1,10 +1,14 @@
 package server
 
 import (
-	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/0x524a/onvif-go"
+	"github.com/0x524a/onvif-go/server/soap"
 )
 
 // Config represents the ONVIF server configuration
@@ -19,8 +23,9 @@ type Config struct {
 	DeviceInfo DeviceInfo
 
 	// Authentication
-	Username string
-	Password string
+	Username    string
+	Password    string
+	RequireAuth bool // Reject requests without a valid WS-Security UsernameToken
 
 	// Camera profiles (supports multi-lens cameras)
 	Profiles []ProfileConfig
@@ -29,6 +34,38 @@ type Config struct {
 	SupportPTZ     bool
 	SupportImaging bool
 	SupportEvents  bool
+
+	// Advertised in GetServices/GetCapabilities only; the server does not
+	// answer requests for these services
+	SupportMedia2    bool
+	SupportAnalytics bool
+
+	// System date and time reported by GetSystemDateAndTime
+	DateTimeType    string // DateTimeTypeNTP or DateTimeTypeManual (default: NTP)
+	TimeZone        string // POSIX TZ string, e.g. "CET-1CEST,M3.5.0,M10.5.0/3" (default: "UTC0")
+	DaylightSavings bool   // Reported daylight saving indicator
+
+	// Events
+	SimulatedMotionInterval time.Duration // Toggle tns1:VideoSource/MotionAlarm at this interval (0 disables)
+
+	// Streaming
+	EnableRTSP bool // Serve a test pattern at each advertised RTSP URI
+	RTSPPort   int  // RTSP port (default: 8554)
+
+	// FaultInjection makes SOAP operations misbehave, keyed by operation name
+	// such as "GetProfiles". The "*" entry applies to operations without
+	// their own entry.
+	FaultInjection map[string]FaultInjection
+}
+
+// FaultInjection describes how the server misbehaves for an operation, to
+// test client timeouts and retries. Latency is applied first; a request
+// that is then neither failed nor faulted is answered normally.
+type FaultInjection struct {
+	Latency       time.Duration // Fixed delay before answering
+	RandomLatency time.Duration // Additional delay, uniformly random up to this
+	ErrorPercent  float64       // Percentage of requests (0-100) answered with a bare HTTP 500
+	Fault         *soap.Error   // SOAP fault sent instead of the response, e.g. ErrProfileNotFound
 }
 
 // DeviceInfo contains device identification information
@@ -50,6 +87,8 @@ type ProfileConfig struct {
 	AudioEncoder *AudioEncoderConfig // Audio encoder configuration (optional)
 	PTZ          *PTZConfig          // PTZ configuration (optional)
 	Snapshot     SnapshotConfig      // Snapshot configuration
+	Transports   []string            // Unicast protocols offered by GetStreamUri: UDP, TCP, RTSP, HTTP (default: all)
+	Multicast    *MulticastConfig    // Group offered by GetStreamUri for RTP-Multicast (optional; no multicast if nil)
 }
 
 // VideoSourceConfig represents video source configuration
@@ -88,15 +127,16 @@ type AudioEncoderConfig struct {
 
 // PTZConfig represents PTZ configuration
 type PTZConfig struct {
-	NodeToken         string    // PTZ node token
-	PanRange          Range     // Pan range in degrees
-	TiltRange         Range     // Tilt range in degrees
-	ZoomRange         Range     // Zoom range
-	DefaultSpeed      PTZSpeed  // Default speed
+	NodeToken          string   // PTZ node token
+	PanRange           Range    // Pan range in degrees
+	TiltRange          Range    // Tilt range in degrees
+	ZoomRange          Range    // Zoom range
+	DefaultSpeed       PTZSpeed // Default speed
 	SupportsContinuous bool     // Supports continuous move
 	SupportsAbsolute   bool     // Supports absolute move
 	SupportsRelative   bool     // Supports relative move
-	Presets           []Preset  // Predefined presets
+	HomeSupported      bool     // Reported by GetNode; whether the node has a settable home position
+	Presets            []Preset // Predefined presets
 }
 
 // SnapshotConfig represents snapshot configuration
@@ -106,6 +146,13 @@ type SnapshotConfig struct {
 	Quality    float64    // JPEG quality (0-100)
 }
 
+// MulticastConfig represents the RTP multicast group of a profile
+type MulticastConfig struct {
+	Address string // IPv4 group address, e.g. "239.255.0.1"
+	Port    int    // RTP port
+	TTL     int    // Time to live (default: 1)
+}
+
 // Resolution represents video resolution
 type Resolution struct {
 	Width  int
@@ -160,17 +207,39 @@ type Server struct {
 	streams      map[string]*StreamConfig // Profile token -> stream config
 	ptzState     map[string]*PTZState     // Profile token -> PTZ state
 	imagingState map[string]*ImagingState // Video source token -> imaging state
-	systemTime   time.Time
+
+	timeMu          sync.Mutex     // Guards the system clock below
+	systemTime      time.Time      // Device time at systemTimeAt; the clock runs on from there
+	systemTimeAt    time.Time      // When systemTime was set
+	dateTimeType    string         // DateTimeTypeNTP or DateTimeTypeManual
+	timeZone        string         // POSIX TZ string
+	timeLocation    *time.Location // Parsed timeZone
+	daylightSavings bool
+
+	mu         sync.Mutex   // Guards the running listeners below
+	httpServer *http.Server // Set while Start is serving
+	listenAddr net.Addr     // Bound HTTP address, set while Start is serving
+	rtsp       *rtspServer  // Set while Start is serving with EnableRTSP
+
+	eventsMu           sync.Mutex                    // Guards the event subscriptions below
+	subscriptions      map[string]*eventSubscription // Subscription ID -> pull point
+	nextSubscriptionID int
+
+	usersMu sync.Mutex // Guards the user accounts below
+	users   []User     // In creation order, seeded with the configured user
 }
 
 // PTZState represents the current PTZ state
 type PTZState struct {
-	Position   PTZPosition
-	Moving     bool
-	PanMoving  bool
-	TiltMoving bool
-	ZoomMoving bool
-	LastUpdate time.Time
+	Position     PTZPosition
+	Velocity     PTZSpeed  // Active continuous move velocity
+	MoveDeadline time.Time // When the continuous move times out (zero means no timeout)
+	Moving       bool
+	PanMoving    bool
+	TiltMoving   bool
+	ZoomMoving   bool
+	Error        string // Why the last move stopped early, e.g. "pan limit reached"
+	LastUpdate   time.Time
 }
 
 // ImagingState represents the current imaging settings state
@@ -195,8 +264,8 @@ type BacklightCompensation struct {
 
 // ExposureSettings represents exposure settings
 type ExposureSettings struct {
-	Mode         string  // AUTO, MANUAL
-	Priority     string  // LowNoise, FrameRate
+	Mode         string // AUTO, MANUAL
+	Priority     string // LowNoise, FrameRate
 	MinExposure  float64
 	MaxExposure  float64
 	MinGain      float64
@@ -207,7 +276,7 @@ type ExposureSettings struct {
 
 // FocusSettings represents focus settings
 type FocusSettings struct {
-	AutoFocusMode string  // AUTO, MANUAL
+	AutoFocusMode string // AUTO, MANUAL
 	DefaultSpeed  float64
 	NearLimit     float64
 	FarLimit      float64
@@ -216,7 +285,7 @@ type FocusSettings struct {
 
 // WhiteBalanceSettings represents white balance settings
 type WhiteBalanceSettings struct {
-	Mode   string  // AUTO, MANUAL
+	Mode   string // AUTO, MANUAL
 	CrGain float64
 	CbGain float64
 }
@@ -243,6 +312,7 @@ func DefaultConfig() *Config {
 		},
 		Username:       "admin",
 		Password:       "admin",
+		RequireAuth:    true,
 		SupportPTZ:     true,
 		SupportImaging: true,
 		SupportEvents:  false,
@@ -348,36 +418,31 @@ func DefaultConfig() *Config {
 				},
 			},
 		},
+		SimulatedMotionInterval: 10 * time.Second,
 	}
 }
 
-// ServiceEndpoints returns the service endpoint URLs
+// ServiceEndpoints returns the service endpoint URLs for the enabled services
 func (c *Config) ServiceEndpoints(host string) map[string]string {
 	if host == "" {
-		host = c.Host
-		if host == "0.0.0.0" || host == "" {
-			host = "localhost"
-		}
+		host = c.advertisedHost()
 	}
 
-	baseURL := ""
-	if c.Port == 80 {
-		baseURL = "http://" + host + c.BasePath
-	} else {
-		// Import fmt at the top to use Sprintf
-		baseURL = fmt.Sprintf("http://%s:%d%s", host, c.Port, c.BasePath)
-	}
+	baseURL := c.baseURL(host, c.Port)
 
 	endpoints := map[string]string{
-		"device":  baseURL + "/device_service",
-		"media":   baseURL + "/media_service",
-		"imaging": baseURL + "/imaging_service",
+		"device": baseURL + "/device_service",
+		"media":  baseURL + "/media_service",
 	}
 
 	if c.SupportPTZ {
 		endpoints["ptz"] = baseURL + "/ptz_service"
 	}
 
+	if c.SupportImaging {
+		endpoints["imaging"] = baseURL + "/imaging_service"
+	}
+
 	if c.SupportEvents {
 		endpoints["events"] = baseURL + "/events_service"
 	}
@@ -385,6 +450,32 @@ func (c *Config) ServiceEndpoints(host string) map[string]string {
 	return endpoints
 }
 
+// advertisedHost returns the host clients should use to reach the server,
+// substituting localhost for wildcard bind addresses
+func (c *Config) advertisedHost() string {
+	if c.Host == "0.0.0.0" || c.Host == "" {
+		return "localhost"
+	}
+	return c.Host
+}
+
+// baseURL returns the HTTP URL of BasePath on host and port, omitting the
+// default port
+func (c *Config) baseURL(host string, port int) string {
+	if port == 80 {
+		return "http://" + host + c.BasePath
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(port)) + c.BasePath
+}
+
+// rtspPort returns the configured RTSP port or the default
+func (c *Config) rtspPort() int {
+	if c.RTSPPort > 0 {
+		return c.RTSPPort
+	}
+	return defaultRTSPPort
+}
+
 // ToONVIFProfile converts a ProfileConfig to an ONVIF Profile
 func (p *ProfileConfig) ToONVIFProfile() *onvif.Profile {
 	profile := &onvif.Profile{
@@ -402,7 +493,7 @@ func (p *ProfileConfig) ToONVIFProfile() *onvif.Profile {
 			},
 		},
 		VideoEncoderConfiguration: &onvif.VideoEncoderConfiguration{
-			Token:    p.Token + "_encoder",
+			Token:    videoEncoderConfigurationToken(p.Token),
 			Name:     p.Name + " Encoder",
 			Encoding: p.VideoEncoder.Encoding,
 			Resolution: &onvif.VideoResolution{
@@ -0,0 +1,273 @@
+package onvif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Location returns the device time zone as a *time.Location, including its
+// daylight saving rules. It returns time.UTC when the device reports no time zone.
+func (s *SystemDateAndTime) Location() (*time.Location, error) {
+	if s.TimeZone == nil || strings.TrimSpace(s.TimeZone.TZ) == "" {
+		return time.UTC, nil
+	}
+
+	return ParsePOSIXTZ(s.TimeZone.TZ)
+}
+
+// ParsePOSIXTZ parses a POSIX TZ string such as "CST-8", "EST5EDT" or
+// "PST8PDT,M3.2.0,M11.1.0" into a *time.Location. Note that POSIX offsets are
+// west of UTC, so "CST-8" is UTC+8. When a daylight zone is given without
+// rules, the US rules (M3.2.0,M11.1.0) apply. A bare "UTC" or "GMT", which
+// many devices send, is taken as UTC+0.
+func ParsePOSIXTZ(tz string) (*time.Location, error) {
+	tz = strings.TrimSpace(tz)
+
+	rule := tz
+	if tz == "UTC" || tz == "GMT" {
+		rule = tz + "0"
+	}
+
+	p := posixTZParser{s: rule}
+	stdName, stdOffset, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("invalid POSIX TZ %q: %w", tz, err)
+	}
+
+	// Build a TZif blob with no transitions whose footer carries the TZ rule;
+	// the time package then applies the rule to every instant.
+	loc, err := time.LoadLocationFromTZData(tz, buildTZif(stdName, stdOffset, rule))
+	if err != nil {
+		return nil, fmt.Errorf("invalid POSIX TZ %q: %w", tz, err)
+	}
+
+	return loc, nil
+}
+
+// posixTZParser validates a POSIX TZ string and extracts the standard zone
+type posixTZParser struct {
+	s   string
+	pos int
+}
+
+// parse returns the standard zone name and its offset east of UTC in seconds
+func (p *posixTZParser) parse() (string, int, error) {
+	stdName, err := p.name()
+	if err != nil {
+		return "", 0, err
+	}
+
+	stdOffset, err := p.offset()
+	if err != nil {
+		return "", 0, err
+	}
+
+	if p.done() {
+		return stdName, -stdOffset, nil
+	}
+
+	if _, err := p.name(); err != nil {
+		return "", 0, fmt.Errorf("daylight zone: %w", err)
+	}
+
+	// Optional daylight offset
+	if !p.done() && p.peek() != ',' {
+		if _, err := p.offset(); err != nil {
+			return "", 0, fmt.Errorf("daylight offset: %w", err)
+		}
+	}
+
+	if p.done() {
+		return stdName, -stdOffset, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		if p.done() || p.peek() != ',' {
+			return "", 0, fmt.Errorf("expected ',' at position %d", p.pos)
+		}
+		p.pos++
+		if err := p.rule(); err != nil {
+			return "", 0, err
+		}
+	}
+
+	if !p.done() {
+		return "", 0, fmt.Errorf("unexpected %q at position %d", p.s[p.pos:], p.pos)
+	}
+
+	return stdName, -stdOffset, nil
+}
+
+func (p *posixTZParser) done() bool { return p.pos >= len(p.s) }
+
+func (p *posixTZParser) peek() byte { return p.s[p.pos] }
+
+// name parses a zone abbreviation: three or more letters, or <...> quoted
+func (p *posixTZParser) name() (string, error) {
+	if p.done() {
+		return "", fmt.Errorf("missing zone name")
+	}
+
+	if p.peek() == '<' {
+		end := strings.IndexByte(p.s[p.pos:], '>')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quoted zone name")
+		}
+		name := p.s[p.pos+1 : p.pos+end]
+		p.pos += end + 1
+		if len(name) < 3 {
+			return "", fmt.Errorf("zone name %q is too short", name)
+		}
+		return name, nil
+	}
+
+	start := p.pos
+	for !p.done() && (p.peek() >= 'A' && p.peek() <= 'Z' || p.peek() >= 'a' && p.peek() <= 'z') {
+		p.pos++
+	}
+	if p.pos-start < 3 {
+		return "", fmt.Errorf("zone name %q is too short", p.s[start:p.pos])
+	}
+
+	return p.s[start:p.pos], nil
+}
+
+// offset parses [+-]hh[:mm[:ss]] and returns seconds west of UTC
+func (p *posixTZParser) offset() (int, error) {
+	sign := 1
+	if !p.done() && (p.peek() == '+' || p.peek() == '-') {
+		if p.peek() == '-' {
+			sign = -1
+		}
+		p.pos++
+	}
+
+	secs, err := p.clock(24)
+	if err != nil {
+		return 0, err
+	}
+
+	return sign * secs, nil
+}
+
+// clock parses hh[:mm[:ss]] with hh up to maxHour and returns seconds
+func (p *posixTZParser) clock(maxHour int) (int, error) {
+	total := 0
+	for i, limit := range []int{maxHour, 59, 59} {
+		if i > 0 {
+			if p.done() || p.peek() != ':' {
+				break
+			}
+			p.pos++
+		}
+
+		n, err := p.number(limit)
+		if err != nil {
+			return 0, err
+		}
+		total += n * []int{3600, 60, 1}[i]
+	}
+
+	return total, nil
+}
+
+// number parses a decimal number no greater than max
+func (p *posixTZParser) number(max int) (int, error) {
+	start := p.pos
+	for !p.done() && p.peek() >= '0' && p.peek() <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, fmt.Errorf("expected number at position %d", start)
+	}
+
+	n, err := strconv.Atoi(p.s[start:p.pos])
+	if err != nil || n > max {
+		return 0, fmt.Errorf("number %q out of range", p.s[start:p.pos])
+	}
+
+	return n, nil
+}
+
+// rule parses a transition date (Jn, n or Mm.w.d) with an optional /time
+func (p *posixTZParser) rule() error {
+	if p.done() {
+		return fmt.Errorf("missing transition rule")
+	}
+
+	switch p.peek() {
+	case 'J':
+		p.pos++
+		n, err := p.number(365)
+		if err != nil {
+			return err
+		}
+		if n < 1 {
+			return fmt.Errorf("julian day %d out of range", n)
+		}
+	case 'M':
+		p.pos++
+		for i, limits := range [][2]int{{1, 12}, {1, 5}, {0, 6}} {
+			if i > 0 {
+				if p.done() || p.peek() != '.' {
+					return fmt.Errorf("expected '.' at position %d", p.pos)
+				}
+				p.pos++
+			}
+			n, err := p.number(limits[1])
+			if err != nil {
+				return err
+			}
+			if n < limits[0] {
+				return fmt.Errorf("rule field %d out of range", n)
+			}
+		}
+	default:
+		if _, err := p.number(365); err != nil {
+			return err
+		}
+	}
+
+	if !p.done() && p.peek() == '/' {
+		p.pos++
+		if _, err := p.offset(); err != nil {
+			return fmt.Errorf("transition time: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// buildTZif encodes a version 2 TZif file with a single zone and no
+// transitions, carrying tz in its footer
+func buildTZif(name string, offset int, tz string) []byte {
+	var buf bytes.Buffer
+
+	header := func() {
+		buf.WriteString("TZif2")
+		buf.Write(make([]byte, 15))
+		for _, n := range []uint32{0, 0, 0, 0, 1, uint32(len(name) + 1)} { // isut, isstd, leap, time, type, char
+			_ = binary.Write(&buf, binary.BigEndian, n)
+		}
+	}
+	data := func() {
+		_ = binary.Write(&buf, binary.BigEndian, int32(offset))
+		buf.WriteByte(0) // isdst
+		buf.WriteByte(0) // abbreviation index
+		buf.WriteString(name)
+		buf.WriteByte(0)
+	}
+
+	// Version 1 block followed by the version 2 block and footer
+	header()
+	data()
+	header()
+	data()
+	buf.WriteString("\n" + tz + "\n")
+
+	return buf.Bytes()
+}
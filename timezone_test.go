@@ -0,0 +1,90 @@
+package onvif
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePOSIXTZ(t *testing.T) {
+	tests := []struct {
+		tz         string
+		at         time.Time
+		wantName   string
+		wantOffset int
+	}{
+		{"CST-8", time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC), "CST", 8 * 3600},
+		{"UTC0", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "UTC", 0},
+		{"UTC", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "UTC", 0},
+		{"GMT", time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), "GMT", 0},
+		{"PST8PDT,M3.2.0,M11.1.0", time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC), "PST", -8 * 3600},
+		{"PST8PDT,M3.2.0,M11.1.0", time.Date(2024, 7, 15, 12, 0, 0, 0, time.UTC), "PDT", -7 * 3600},
+		{"CET-1CEST,M3.5.0,M10.5.0/3", time.Date(2024, 7, 15, 12, 0, 0, 0, time.UTC), "CEST", 2 * 3600},
+		{"<+0530>-5:30", time.Date(2024, 7, 15, 12, 0, 0, 0, time.UTC), "+0530", 5*3600 + 30*60},
+		{"EST5EDT", time.Date(2024, 7, 15, 12, 0, 0, 0, time.UTC), "EDT", -4 * 3600},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tz, func(t *testing.T) {
+			loc, err := ParsePOSIXTZ(tt.tz)
+			if err != nil {
+				t.Fatalf("ParsePOSIXTZ(%q) error = %v", tt.tz, err)
+			}
+
+			name, offset := tt.at.In(loc).Zone()
+			if name != tt.wantName || offset != tt.wantOffset {
+				t.Errorf("Zone() = %s %d, want %s %d", name, offset, tt.wantName, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestParsePOSIXTZ_DSTTransition(t *testing.T) {
+	loc, err := ParsePOSIXTZ("PST8PDT,M3.2.0,M11.1.0")
+	if err != nil {
+		t.Fatalf("ParsePOSIXTZ() error = %v", err)
+	}
+
+	// 2024-03-10 02:00 PST is the switch to PDT (10:00 UTC)
+	before := time.Date(2024, 3, 10, 9, 59, 0, 0, time.UTC).In(loc)
+	after := time.Date(2024, 3, 10, 10, 0, 0, 0, time.UTC).In(loc)
+	if name, _ := before.Zone(); name != "PST" {
+		t.Errorf("Expected PST before transition, got %s", name)
+	}
+	if name, _ := after.Zone(); name != "PDT" || after.Hour() != 3 {
+		t.Errorf("Expected 03:00 PDT after transition, got %s", after)
+	}
+}
+
+func TestParsePOSIXTZ_Invalid(t *testing.T) {
+	for _, tz := range []string{"", "8", "AB-1", "CST", "CST-8,", "PST8PDT,M3.2.0", "PST8PDT,M13.2.0,M11.1.0", "CST-99"} {
+		if _, err := ParsePOSIXTZ(tz); err == nil {
+			t.Errorf("ParsePOSIXTZ(%q) expected error", tz)
+		}
+	}
+}
+
+func TestSystemDateAndTimeLocation(t *testing.T) {
+	sdt := &SystemDateAndTime{}
+	loc, err := sdt.Location()
+	if err != nil || loc != time.UTC {
+		t.Errorf("Location() without time zone = %v, %v; want UTC", loc, err)
+	}
+
+	sdt.TimeZone = &TimeZone{TZ: "CST-8"}
+	loc, err = sdt.Location()
+	if err != nil {
+		t.Fatalf("Location() error = %v", err)
+	}
+	if _, offset := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).In(loc).Zone(); offset != 8*3600 {
+		t.Errorf("Expected UTC+8, got offset %d", offset)
+	}
+	// As sent by the virtual server and onviftest.SystemDateAndTime
+	sdt.TimeZone = &TimeZone{TZ: "UTC"}
+	loc, err = sdt.Location()
+	if err != nil {
+		t.Fatalf("Location() with TZ UTC error = %v", err)
+	}
+	if _, offset := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).In(loc).Zone(); offset != 0 {
+		t.Errorf("Expected UTC+0, got offset %d", offset)
+	}
+}
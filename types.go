@@ -4,115 +4,189 @@ import "time"
 
 // DeviceInformation contains basic device information
 type DeviceInformation struct {
-	Manufacturer    string
-	Model           string
-	FirmwareVersion string
-	SerialNumber    string
-	HardwareID      string
+	Manufacturer    string `json:"manufacturer"`
+	Model           string `json:"model"`
+	FirmwareVersion string `json:"firmware_version"`
+	SerialNumber    string `json:"serial_number"`
+	HardwareID      string `json:"hardware_id"`
+
+	// Extension holds vendor-specific elements of the response by local name,
+	// such as a country code or build date. Nil if there are none.
+	Extension map[string]string `json:"extension,omitempty"`
 }
 
 // Capabilities represents the device capabilities
 type Capabilities struct {
-	Analytics *AnalyticsCapabilities
-	Device    *DeviceCapabilities
-	Events    *EventCapabilities
-	Imaging   *ImagingCapabilities
-	Media     *MediaCapabilities
-	PTZ       *PTZCapabilities
-	Extension *CapabilitiesExtension
+	Analytics *AnalyticsCapabilities `json:"analytics,omitempty"`
+	Device    *DeviceCapabilities    `json:"device,omitempty"`
+	Events    *EventCapabilities     `json:"events,omitempty"`
+	Imaging   *ImagingCapabilities   `json:"imaging,omitempty"`
+	Media     *MediaCapabilities     `json:"media,omitempty"`
+	PTZ       *PTZCapabilities       `json:"ptz,omitempty"`
+	Extension *CapabilitiesExtension `json:"extension,omitempty"`
 }
 
 // AnalyticsCapabilities represents analytics service capabilities
 type AnalyticsCapabilities struct {
-	XAddr                  string
-	RuleSupport            bool
-	AnalyticsModuleSupport bool
+	XAddr                  string `json:"xaddr"`
+	RuleSupport            bool   `json:"rule_support"`
+	AnalyticsModuleSupport bool   `json:"analytics_module_support"`
 }
 
 // DeviceCapabilities represents device service capabilities
 type DeviceCapabilities struct {
-	XAddr    string
-	Network  *NetworkCapabilities
-	System   *SystemCapabilities
-	IO       *IOCapabilities
-	Security *SecurityCapabilities
+	XAddr    string                `json:"xaddr"`
+	Network  *NetworkCapabilities  `json:"network,omitempty"`
+	System   *SystemCapabilities   `json:"system,omitempty"`
+	IO       *IOCapabilities       `json:"io,omitempty"`
+	Security *SecurityCapabilities `json:"security,omitempty"`
 }
 
 // EventCapabilities represents event service capabilities
 type EventCapabilities struct {
-	XAddr                         string
-	WSSubscriptionPolicySupport   bool
-	WSPullPointSupport            bool
-	WSPausableSubscriptionSupport bool
+	XAddr                         string `json:"xaddr"`
+	WSSubscriptionPolicySupport   bool   `json:"ws_subscription_policy_support"`
+	WSPullPointSupport            bool   `json:"ws_pull_point_support"`
+	WSPausableSubscriptionSupport bool   `json:"ws_pausable_subscription_support"`
 }
 
 // ImagingCapabilities represents imaging service capabilities
 type ImagingCapabilities struct {
-	XAddr string
+	XAddr string `json:"xaddr"`
 }
 
 // MediaCapabilities represents media service capabilities
 type MediaCapabilities struct {
-	XAddr                 string
-	StreamingCapabilities *StreamingCapabilities
+	XAddr                 string                 `json:"xaddr"`
+	StreamingCapabilities *StreamingCapabilities `json:"streaming_capabilities,omitempty"`
 }
 
 // PTZCapabilities represents PTZ service capabilities
 type PTZCapabilities struct {
-	XAddr string
+	XAddr string `json:"xaddr"`
 }
 
 // NetworkCapabilities represents network capabilities
 type NetworkCapabilities struct {
-	IPFilter          bool
-	ZeroConfiguration bool
-	IPVersion6        bool
-	DynDNS            bool
-	Extension         *NetworkCapabilitiesExtension
+	IPFilter          bool                          `json:"ip_filter"`
+	ZeroConfiguration bool                          `json:"zero_configuration"`
+	IPVersion6        bool                          `json:"ip_version6"`
+	DynDNS            bool                          `json:"dyn_dns"`
+	Extension         *NetworkCapabilitiesExtension `json:"extension,omitempty"`
 }
 
 // SystemCapabilities represents system capabilities
 type SystemCapabilities struct {
-	DiscoveryResolve  bool
-	DiscoveryBye      bool
-	RemoteDiscovery   bool
-	SystemBackup      bool
-	SystemLogging     bool
-	FirmwareUpgrade   bool
-	SupportedVersions []string
-	Extension         *SystemCapabilitiesExtension
+	DiscoveryResolve  bool                         `json:"discovery_resolve"`
+	DiscoveryBye      bool                         `json:"discovery_bye"`
+	RemoteDiscovery   bool                         `json:"remote_discovery"`
+	SystemBackup      bool                         `json:"system_backup"`
+	SystemLogging     bool                         `json:"system_logging"`
+	FirmwareUpgrade   bool                         `json:"firmware_upgrade"`
+	SupportedVersions []string                     `json:"supported_versions,omitempty"`
+	Extension         *SystemCapabilitiesExtension `json:"extension,omitempty"`
 }
 
 // IOCapabilities represents I/O capabilities
 type IOCapabilities struct {
-	InputConnectors int
-	RelayOutputs    int
-	Extension       *IOCapabilitiesExtension
+	InputConnectors int                      `json:"input_connectors"`
+	RelayOutputs    int                      `json:"relay_outputs"`
+	Extension       *IOCapabilitiesExtension `json:"extension,omitempty"`
 }
 
 // SecurityCapabilities represents security capabilities
 type SecurityCapabilities struct {
-	TLS11                bool
-	TLS12                bool
-	OnboardKeyGeneration bool
-	AccessPolicyConfig   bool
-	X509Token            bool
-	SAMLToken            bool
-	KerberosToken        bool
-	RELToken             bool
-	Extension            *SecurityCapabilitiesExtension
+	TLS11                bool                           `json:"tls11"`
+	TLS12                bool                           `json:"tls12"`
+	OnboardKeyGeneration bool                           `json:"onboard_key_generation"`
+	AccessPolicyConfig   bool                           `json:"access_policy_config"`
+	X509Token            bool                           `json:"x509_token"`
+	SAMLToken            bool                           `json:"saml_token"`
+	KerberosToken        bool                           `json:"kerberos_token"`
+	RELToken             bool                           `json:"rel_token"`
+	Extension            *SecurityCapabilitiesExtension `json:"extension,omitempty"`
 }
 
 // StreamingCapabilities represents streaming capabilities
 type StreamingCapabilities struct {
-	RTPMulticast bool
-	RTP_TCP      bool
-	RTP_RTSP_TCP bool
-	Extension    *StreamingCapabilitiesExtension
+	RTPMulticast bool                            `json:"rtp_multicast"`
+	RTP_TCP      bool                            `json:"rtp_tcp"`
+	RTP_RTSP_TCP bool                            `json:"rtp_rtsp_tcp"`
+	Extension    *StreamingCapabilitiesExtension `json:"extension,omitempty"`
+}
+
+// CapabilitiesExtension represents capabilities of services only advertised in the extension
+type CapabilitiesExtension struct {
+	DeviceIO  *DeviceIOCapabilities  `json:"device_io,omitempty"`
+	Recording *RecordingCapabilities `json:"recording,omitempty"`
+	Search    *SearchCapabilities    `json:"search,omitempty"`
+	Replay    *ReplayCapabilities    `json:"replay,omitempty"`
+}
+
+// DeviceIOCapabilities represents DeviceIO service capabilities
+type DeviceIOCapabilities struct {
+	XAddr        string `json:"xaddr"`
+	VideoSources int    `json:"video_sources"`
+	VideoOutputs int    `json:"video_outputs"`
+	AudioSources int    `json:"audio_sources"`
+	AudioOutputs int    `json:"audio_outputs"`
+	RelayOutputs int    `json:"relay_outputs"`
+}
+
+// RecordingCapabilities represents recording service capabilities
+type RecordingCapabilities struct {
+	XAddr              string `json:"xaddr"`
+	ReceiverSource     bool   `json:"receiver_source"`
+	MediaProfileSource bool   `json:"media_profile_source"`
+	DynamicRecordings  bool   `json:"dynamic_recordings"`
+	DynamicTracks      bool   `json:"dynamic_tracks"`
+	MaxStringLength    int    `json:"max_string_length"`
+}
+
+// SearchCapabilities represents search service capabilities
+type SearchCapabilities struct {
+	XAddr          string `json:"xaddr"`
+	MetadataSearch bool   `json:"metadata_search"`
+}
+
+// ReplayCapabilities represents replay service capabilities
+type ReplayCapabilities struct {
+	XAddr string `json:"xaddr"`
+}
+
+// MediaServiceCapabilities represents the capabilities reported by the media service
+type MediaServiceCapabilities struct {
+	SnapshotURI             bool `json:"snapshot_uri"`
+	Rotation                bool `json:"rotation"`
+	VideoSourceMode         bool `json:"video_source_mode"`
+	OSD                     bool `json:"osd"`
+	TemporaryOSDText        bool `json:"temporary_osd_text"`
+	EXICompression          bool `json:"exi_compression"`
+	MaximumNumberOfProfiles int  `json:"maximum_number_of_profiles"`
+	RTPMulticast            bool `json:"rtp_multicast"`
+	RTP_TCP                 bool `json:"rtp_tcp"`
+	RTP_RTSP_TCP            bool `json:"rtp_rtsp_tcp"`
+	NonAggregateControl     bool `json:"non_aggregate_control"`
+	NoRTSPStreaming         bool `json:"no_rtsp_streaming"`
+}
+
+// PTZServiceCapabilities represents the capabilities reported by the PTZ service
+type PTZServiceCapabilities struct {
+	EFlip                       bool `json:"e_flip"`
+	Reverse                     bool `json:"reverse"`
+	GetCompatibleConfigurations bool `json:"get_compatible_configurations"`
+	MoveStatus                  bool `json:"move_status"`
+	StatusPosition              bool `json:"status_position"`
+}
+
+// ImagingServiceCapabilities represents the capabilities reported by the imaging service
+type ImagingServiceCapabilities struct {
+	ImageStabilization bool `json:"image_stabilization"`
+	Presets            bool `json:"presets"`
+	AdaptablePreset    bool `json:"adaptable_preset"`
 }
 
 // Extension types
-type CapabilitiesExtension struct{}
 type NetworkCapabilitiesExtension struct{}
 type SystemCapabilitiesExtension struct{}
 type IOCapabilitiesExtension struct{}
@@ -121,197 +195,267 @@ type StreamingCapabilitiesExtension struct{}
 
 // Profile represents a media profile
 type Profile struct {
-	Token                     string
-	Name                      string
-	VideoSourceConfiguration  *VideoSourceConfiguration
-	AudioSourceConfiguration  *AudioSourceConfiguration
-	VideoEncoderConfiguration *VideoEncoderConfiguration
-	AudioEncoderConfiguration *AudioEncoderConfiguration
-	PTZConfiguration          *PTZConfiguration
-	MetadataConfiguration     *MetadataConfiguration
-	Extension                 *ProfileExtension
+	Token                       string                       `json:"token"`
+	Name                        string                       `json:"name"`
+	VideoSourceConfiguration    *VideoSourceConfiguration    `json:"video_source_configuration,omitempty"`
+	AudioSourceConfiguration    *AudioSourceConfiguration    `json:"audio_source_configuration,omitempty"`
+	VideoEncoderConfiguration   *VideoEncoderConfiguration   `json:"video_encoder_configuration,omitempty"`
+	AudioEncoderConfiguration   *AudioEncoderConfiguration   `json:"audio_encoder_configuration,omitempty"`
+	VideoAnalyticsConfiguration *VideoAnalyticsConfiguration `json:"video_analytics_configuration,omitempty"`
+	PTZConfiguration            *PTZConfiguration            `json:"ptz_configuration,omitempty"`
+	MetadataConfiguration       *MetadataConfiguration       `json:"metadata_configuration,omitempty"`
+	Extension                   *ProfileExtension            `json:"extension,omitempty"`
 }
 
 // VideoSourceConfiguration represents video source configuration
 type VideoSourceConfiguration struct {
-	Token       string
-	Name        string
-	UseCount    int
-	SourceToken string
-	Bounds      *IntRectangle
+	Token       string        `json:"token"`
+	Name        string        `json:"name"`
+	UseCount    int           `json:"use_count"`
+	SourceToken string        `json:"source_token"`
+	Bounds      *IntRectangle `json:"bounds,omitempty"`
 }
 
 // AudioSourceConfiguration represents audio source configuration
 type AudioSourceConfiguration struct {
-	Token       string
-	Name        string
-	UseCount    int
-	SourceToken string
+	Token       string `json:"token"`
+	Name        string `json:"name"`
+	UseCount    int    `json:"use_count"`
+	SourceToken string `json:"source_token"`
 }
 
 // VideoEncoderConfiguration represents video encoder configuration
 type VideoEncoderConfiguration struct {
-	Token          string
-	Name           string
-	UseCount       int
-	Encoding       string // JPEG, MPEG4, H264
-	Resolution     *VideoResolution
-	Quality        float64
-	RateControl    *VideoRateControl
-	MPEG4          *MPEG4Configuration
-	H264           *H264Configuration
-	Multicast      *MulticastConfiguration
-	SessionTimeout time.Duration
+	Token          string                  `json:"token"`
+	Name           string                  `json:"name"`
+	UseCount       int                     `json:"use_count"`
+	Encoding       string                  `json:"encoding"` // JPEG, MPEG4, H264
+	Resolution     *VideoResolution        `json:"resolution,omitempty"`
+	Quality        float64                 `json:"quality"`
+	RateControl    *VideoRateControl       `json:"rate_control,omitempty"`
+	MPEG4          *MPEG4Configuration     `json:"mpeg4,omitempty"`
+	H264           *H264Configuration      `json:"h264,omitempty"`
+	H265           *H265Configuration      `json:"h265,omitempty"`
+	Multicast      *MulticastConfiguration `json:"multicast,omitempty"`
+	SessionTimeout time.Duration           `json:"session_timeout"`
+}
+
+// VideoEncoderConfigurationOptions lists the values a video encoder
+// configuration accepts, per encoding. Encodings the device does not offer
+// are nil.
+type VideoEncoderConfigurationOptions struct {
+	QualityRange *FloatRange          `json:"quality_range,omitempty"`
+	JPEG         *VideoEncoderOptions `json:"jpeg,omitempty"`
+	MPEG4        *VideoEncoderOptions `json:"mpeg4,omitempty"`
+	H264         *VideoEncoderOptions `json:"h264,omitempty"`
+}
+
+// VideoEncoderOptions describes the values accepted for one video encoding
+type VideoEncoderOptions struct {
+	ResolutionsAvailable  []*VideoResolution `json:"resolutions_available,omitempty"`
+	FrameRateRange        *IntRange          `json:"frame_rate_range,omitempty"`
+	EncodingIntervalRange *IntRange          `json:"encoding_interval_range,omitempty"`
+	BitrateRange          *IntRange          `json:"bitrate_range,omitempty"` // kbps, from the options extension
 }
 
 // AudioEncoderConfiguration represents audio encoder configuration
 type AudioEncoderConfiguration struct {
-	Token          string
-	Name           string
-	UseCount       int
-	Encoding       string // G711, G726, AAC
-	Bitrate        int
-	SampleRate     int
-	Multicast      *MulticastConfiguration
-	SessionTimeout time.Duration
+	Token          string                  `json:"token"`
+	Name           string                  `json:"name"`
+	UseCount       int                     `json:"use_count"`
+	Encoding       string                  `json:"encoding"`    // G711, G726, AAC
+	Bitrate        int                     `json:"bitrate"`     // kbps
+	SampleRate     int                     `json:"sample_rate"` // kHz
+	Multicast      *MulticastConfiguration `json:"multicast,omitempty"`
+	SessionTimeout time.Duration           `json:"session_timeout"`
+}
+
+// AudioEncoderConfigurationOptions lists the audio encodings a configuration accepts
+type AudioEncoderConfigurationOptions struct {
+	Options []*AudioEncoderConfigurationOption `json:"options,omitempty"`
+}
+
+// AudioEncoderConfigurationOption describes one supported audio encoding
+type AudioEncoderConfigurationOption struct {
+	Encoding       string `json:"encoding"`                   // G711, G726, AAC
+	BitrateList    []int  `json:"bitrate_list,omitempty"`     // Supported bitrates in kbps
+	SampleRateList []int  `json:"sample_rate_list,omitempty"` // Supported sample rates in kHz
 }
 
 // PTZConfiguration represents PTZ configuration
 type PTZConfiguration struct {
-	Token                                  string
-	Name                                   string
-	UseCount                               int
-	NodeToken                              string
-	DefaultAbsolutePantTiltPositionSpace   string
-	DefaultAbsoluteZoomPositionSpace       string
-	DefaultRelativePanTiltTranslationSpace string
-	DefaultRelativeZoomTranslationSpace    string
-	DefaultContinuousPanTiltVelocitySpace  string
-	DefaultContinuousZoomVelocitySpace     string
-	DefaultPTZSpeed                        *PTZSpeed
-	DefaultPTZTimeout                      time.Duration
-	PanTiltLimits                          *PanTiltLimits
-	ZoomLimits                             *ZoomLimits
+	Token                                  string         `json:"token"`
+	Name                                   string         `json:"name"`
+	UseCount                               int            `json:"use_count"`
+	NodeToken                              string         `json:"node_token"`
+	DefaultAbsolutePantTiltPositionSpace   string         `json:"default_absolute_pant_tilt_position_space"`
+	DefaultAbsoluteZoomPositionSpace       string         `json:"default_absolute_zoom_position_space"`
+	DefaultRelativePanTiltTranslationSpace string         `json:"default_relative_pan_tilt_translation_space"`
+	DefaultRelativeZoomTranslationSpace    string         `json:"default_relative_zoom_translation_space"`
+	DefaultContinuousPanTiltVelocitySpace  string         `json:"default_continuous_pan_tilt_velocity_space"`
+	DefaultContinuousZoomVelocitySpace     string         `json:"default_continuous_zoom_velocity_space"`
+	DefaultPTZSpeed                        *PTZSpeed      `json:"default_ptz_speed,omitempty"`
+	DefaultPTZTimeout                      time.Duration  `json:"default_ptz_timeout"`
+	PanTiltLimits                          *PanTiltLimits `json:"pan_tilt_limits,omitempty"`
+	ZoomLimits                             *ZoomLimits    `json:"zoom_limits,omitempty"`
+}
+
+// VideoAnalyticsConfiguration represents video analytics configuration: the
+// analytics modules and rules run on a profile's video
+type VideoAnalyticsConfiguration struct {
+	Token            string           `json:"token"`
+	Name             string           `json:"name"`
+	UseCount         int              `json:"use_count"`
+	AnalyticsModules []AnalyticsEntry `json:"analytics_modules,omitempty"`
+	Rules            []AnalyticsEntry `json:"rules,omitempty"`
+}
+
+// AnalyticsEntry names an analytics module or rule and its type, such as
+// tt:CellMotionEngine or tt:LineDetector
+type AnalyticsEntry struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
 }
 
 // MetadataConfiguration represents metadata configuration
 type MetadataConfiguration struct {
-	Token          string
-	Name           string
-	UseCount       int
-	PTZStatus      *PTZFilter
-	Events         *EventSubscription
-	Analytics      bool
-	Multicast      *MulticastConfiguration
-	SessionTimeout time.Duration
+	Token          string                  `json:"token"`
+	Name           string                  `json:"name"`
+	UseCount       int                     `json:"use_count"`
+	PTZStatus      *PTZFilter              `json:"ptz_status,omitempty"`
+	Events         *EventSubscription      `json:"events,omitempty"`
+	Analytics      bool                    `json:"analytics"`
+	Multicast      *MulticastConfiguration `json:"multicast,omitempty"`
+	SessionTimeout time.Duration           `json:"session_timeout"`
 }
 
 // VideoResolution represents video resolution
 type VideoResolution struct {
-	Width  int
-	Height int
+	Width  int `json:"width"`
+	Height int `json:"height"`
 }
 
 // VideoRateControl represents video rate control
 type VideoRateControl struct {
-	FrameRateLimit   int
-	EncodingInterval int
-	BitrateLimit     int
+	FrameRateLimit   int `json:"frame_rate_limit"`
+	EncodingInterval int `json:"encoding_interval"`
+	BitrateLimit     int `json:"bitrate_limit"`
 }
 
 // MPEG4Configuration represents MPEG4 configuration
 type MPEG4Configuration struct {
-	GovLength    int
-	MPEG4Profile string
+	GovLength    int    `json:"gov_length"`
+	MPEG4Profile string `json:"mpeg4_profile"`
 }
 
 // H264Configuration represents H264 configuration
 type H264Configuration struct {
-	GovLength   int
-	H264Profile string
+	GovLength   int    `json:"gov_length"`
+	H264Profile string `json:"h264_profile"`
+}
+
+// H265Configuration represents H265 configuration
+type H265Configuration struct {
+	GovLength   int    `json:"gov_length"`
+	H265Profile string `json:"h265_profile"` // Main, Main10
 }
 
 // MulticastConfiguration represents multicast configuration
 type MulticastConfiguration struct {
-	Address   *IPAddress
-	Port      int
-	TTL       int
-	AutoStart bool
+	Address   *IPAddress `json:"address,omitempty"`
+	Port      int        `json:"port"`
+	TTL       int        `json:"ttl"`
+	AutoStart bool       `json:"auto_start"`
 }
 
 // IPAddress represents an IP address
 type IPAddress struct {
-	Type        string // IPv4 or IPv6
-	Address     string
-	IPv4Address string
-	IPv6Address string
+	Type        string `json:"type"` // IPv4 or IPv6
+	Address     string `json:"address"`
+	IPv4Address string `json:"ipv4_address"`
+	IPv6Address string `json:"ipv6_address"`
+}
+
+// Rectangle represents a rectangle by its edges in normalized image
+// coordinates, from 0 at the top and left to 1 at the bottom and right
+type Rectangle struct {
+	Bottom float64 `json:"bottom"`
+	Top    float64 `json:"top"`
+	Right  float64 `json:"right"`
+	Left   float64 `json:"left"`
 }
 
 // IntRectangle represents a rectangle with integer coordinates
 type IntRectangle struct {
-	X      int
-	Y      int
-	Width  int
-	Height int
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
 }
 
 // PTZSpeed represents PTZ speed
 type PTZSpeed struct {
-	PanTilt *Vector2D
-	Zoom    *Vector1D
+	PanTilt *Vector2D `json:"pan_tilt,omitempty"`
+	Zoom    *Vector1D `json:"zoom,omitempty"`
 }
 
 // Vector2D represents a 2D vector
 type Vector2D struct {
-	X     float64
-	Y     float64
-	Space string
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+	Space string  `json:"space"`
 }
 
 // Vector1D represents a 1D vector
 type Vector1D struct {
-	X     float64
-	Space string
+	X     float64 `json:"x"`
+	Space string  `json:"space"`
 }
 
 // PanTiltLimits represents pan/tilt limits
 type PanTiltLimits struct {
-	Range *Space2DDescription
+	Range *Space2DDescription `json:"range,omitempty"`
 }
 
 // ZoomLimits represents zoom limits
 type ZoomLimits struct {
-	Range *Space1DDescription
+	Range *Space1DDescription `json:"range,omitempty"`
 }
 
 // Space2DDescription represents 2D space description
 type Space2DDescription struct {
-	URI    string
-	XRange *FloatRange
-	YRange *FloatRange
+	URI    string      `json:"uri"`
+	XRange *FloatRange `json:"x_range,omitempty"`
+	YRange *FloatRange `json:"y_range,omitempty"`
 }
 
 // Space1DDescription represents 1D space description
 type Space1DDescription struct {
-	URI    string
-	XRange *FloatRange
+	URI    string      `json:"uri"`
+	XRange *FloatRange `json:"x_range,omitempty"`
 }
 
 // FloatRange represents a float range
 type FloatRange struct {
-	Min float64
-	Max float64
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// IntRange represents an integer range
+type IntRange struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
 }
 
 // PTZFilter represents PTZ filter
 type PTZFilter struct {
-	Status   bool
-	Position bool
+	Status   bool `json:"status"`
+	Position bool `json:"position"`
 }
 
 // EventSubscription represents event subscription
 type EventSubscription struct {
-	Filter *FilterType
+	Filter *FilterType `json:"filter,omitempty"`
 }
 
 // FilterType represents filter type
@@ -320,18 +464,21 @@ type FilterType struct {
 }
 
 // ProfileExtension represents profile extension
-type ProfileExtension struct{}
+type ProfileExtension struct {
+	AudioOutputConfiguration  *AudioOutputConfiguration  `json:"audio_output_configuration,omitempty"`
+	AudioDecoderConfiguration *AudioDecoderConfiguration `json:"audio_decoder_configuration,omitempty"`
+}
 
 // StreamSetup represents stream setup parameters
 type StreamSetup struct {
-	Stream    string // RTP-Unicast, RTP-Multicast
-	Transport *Transport
+	Stream    string     `json:"stream"` // RTP-Unicast, RTP-Multicast
+	Transport *Transport `json:"transport,omitempty"`
 }
 
 // Transport represents transport parameters
 type Transport struct {
-	Protocol string // UDP, TCP, RTSP, HTTP
-	Tunnel   *Tunnel
+	Protocol string  `json:"protocol"` // UDP, TCP, RTSP, HTTP
+	Tunnel   *Tunnel `json:"tunnel,omitempty"`
 }
 
 // Tunnel represents tunnel parameters
@@ -339,300 +486,622 @@ type Tunnel struct{}
 
 // MediaURI represents a media URI
 type MediaURI struct {
-	URI                 string
-	InvalidAfterConnect bool
-	InvalidAfterReboot  bool
-	Timeout             time.Duration
+	URI                 string        `json:"uri"`
+	InvalidAfterConnect bool          `json:"invalid_after_connect"`
+	InvalidAfterReboot  bool          `json:"invalid_after_reboot"`
+	Timeout             time.Duration `json:"timeout"`
+}
+
+// ProfileWithURIs is a media profile with its RTSP stream and snapshot URIs,
+// as returned by GetProfilesWithURIs. A URI whose request failed is nil, with
+// the failure in the matching error field.
+type ProfileWithURIs struct {
+	Profile     *Profile  `json:"profile,omitempty"`
+	StreamURI   *MediaURI `json:"stream_uri,omitempty"`
+	SnapshotURI *MediaURI `json:"snapshot_uri,omitempty"`
+	StreamErr   error     `json:"-"`
+	SnapshotErr error     `json:"-"`
 }
 
 // PTZStatus represents PTZ status
 type PTZStatus struct {
-	Position   *PTZVector
-	MoveStatus *PTZMoveStatus
-	Error      string
-	UTCTime    time.Time
+	Position   *PTZVector     `json:"position,omitempty"`
+	MoveStatus *PTZMoveStatus `json:"move_status,omitempty"`
+	Error      string         `json:"error"`
+	UTCTime    time.Time      `json:"utc_time"`
 }
 
 // PTZVector represents PTZ position
 type PTZVector struct {
-	PanTilt *Vector2D
-	Zoom    *Vector1D
+	PanTilt *Vector2D `json:"pan_tilt,omitempty"`
+	Zoom    *Vector1D `json:"zoom,omitempty"`
 }
 
 // PTZMoveStatus represents PTZ movement status
 type PTZMoveStatus struct {
-	PanTilt string // IDLE, MOVING, UNKNOWN
-	Zoom    string // IDLE, MOVING, UNKNOWN
+	PanTilt string `json:"pan_tilt"` // IDLE, MOVING, UNKNOWN
+	Zoom    string `json:"zoom"`     // IDLE, MOVING, UNKNOWN
 }
 
 // PTZPreset represents a PTZ preset
 type PTZPreset struct {
-	Token       string
-	Name        string
-	PTZPosition *PTZVector
+	Token       string     `json:"token"`
+	Name        string     `json:"name"`
+	PTZPosition *PTZVector `json:"ptz_position,omitempty"`
+}
+
+// PTZNode represents a PTZ node (a physical PTZ device or mechanism)
+type PTZNode struct {
+	Token                  string     `json:"token"`
+	Name                   string     `json:"name"`
+	FixedHomePosition      bool       `json:"fixed_home_position"`
+	GeoMove                bool       `json:"geo_move"`
+	MaximumNumberOfPresets int        `json:"maximum_number_of_presets"`
+	HomeSupported          bool       `json:"home_supported"`
+	AuxiliaryCommands      []string   `json:"auxiliary_commands,omitempty"`
+	SupportedPTZSpaces     *PTZSpaces `json:"supported_ptz_spaces,omitempty"`
+}
+
+// PTZSpaces lists the position and translation spaces a PTZ node supports
+type PTZSpaces struct {
+	AbsolutePanTiltPositionSpace    []*Space2DDescription `json:"absolute_pan_tilt_position_space,omitempty"`
+	AbsoluteZoomPositionSpace       []*Space1DDescription `json:"absolute_zoom_position_space,omitempty"`
+	RelativePanTiltTranslationSpace []*Space2DDescription `json:"relative_pan_tilt_translation_space,omitempty"`
+	RelativeZoomTranslationSpace    []*Space1DDescription `json:"relative_zoom_translation_space,omitempty"`
+}
+
+// PresetTour represents a PTZ preset tour (guard tour)
+type PresetTour struct {
+	Token             string                       `json:"token"`
+	Name              string                       `json:"name"`
+	Status            *PresetTourStatus            `json:"status,omitempty"`
+	AutoStart         bool                         `json:"auto_start"`
+	StartingCondition *PresetTourStartingCondition `json:"starting_condition,omitempty"`
+	TourSpots         []*TourSpot                  `json:"tour_spots,omitempty"`
+}
+
+// PresetTourStatus represents the current state of a preset tour
+type PresetTourStatus struct {
+	State           string    `json:"state"` // Idle, Touring, Paused, Extended
+	CurrentTourSpot *TourSpot `json:"current_tour_spot,omitempty"`
+}
+
+// PresetTourStartingCondition represents how a preset tour runs
+type PresetTourStartingCondition struct {
+	RecurringTime     int           `json:"recurring_time"`
+	RecurringDuration time.Duration `json:"recurring_duration"`
+	Direction         string        `json:"direction"` // Forward, Backward
+	RandomPresetOrder bool          `json:"random_preset_order"`
+}
+
+// TourSpot represents a stop on a preset tour
+type TourSpot struct {
+	PresetToken string        `json:"preset_token"`
+	StayTime    time.Duration `json:"stay_time"`
+	Speed       *PTZSpeed     `json:"speed,omitempty"`
 }
 
 // ImagingSettings represents imaging settings
 type ImagingSettings struct {
-	BacklightCompensation *BacklightCompensation
-	Brightness            *float64
-	ColorSaturation       *float64
-	Contrast              *float64
-	Exposure              *Exposure
-	Focus                 *FocusConfiguration
-	IrCutFilter           *string
-	Sharpness             *float64
-	WideDynamicRange      *WideDynamicRange
-	WhiteBalance          *WhiteBalance
-	Extension             *ImagingSettingsExtension
-}
+	BacklightCompensation *BacklightCompensation    `json:"backlight_compensation,omitempty"`
+	Brightness            *float64                  `json:"brightness,omitempty"`
+	ColorSaturation       *float64                  `json:"color_saturation,omitempty"`
+	Contrast              *float64                  `json:"contrast,omitempty"`
+	Exposure              *Exposure                 `json:"exposure,omitempty"`
+	Focus                 *FocusConfiguration       `json:"focus,omitempty"`
+	IrCutFilter           *IrCutFilterMode          `json:"ir_cut_filter,omitempty"`
+	Sharpness             *float64                  `json:"sharpness,omitempty"`
+	WideDynamicRange      *WideDynamicRange         `json:"wide_dynamic_range,omitempty"`
+	WhiteBalance          *WhiteBalance             `json:"white_balance,omitempty"`
+	Extension             *ImagingSettingsExtension `json:"extension,omitempty"`
+}
+
+// IrCutFilterMode is the state of the IR cut filter
+type IrCutFilterMode string
+
+// IR cut filter modes
+const (
+	IrCutFilterOn   IrCutFilterMode = "ON"   // Filter in place, day mode
+	IrCutFilterOff  IrCutFilterMode = "OFF"  // Filter removed, night mode
+	IrCutFilterAuto IrCutFilterMode = "AUTO" // Switched by the device according to the light level
+)
+
+// ExposureMode selects automatic or manual exposure
+type ExposureMode string
+
+// Exposure modes
+const (
+	ExposureModeAuto   ExposureMode = "AUTO"
+	ExposureModeManual ExposureMode = "MANUAL"
+)
+
+// WhiteBalanceMode selects automatic or manual white balance
+type WhiteBalanceMode string
+
+// White balance modes
+const (
+	WhiteBalanceModeAuto   WhiteBalanceMode = "AUTO"
+	WhiteBalanceModeManual WhiteBalanceMode = "MANUAL"
+)
+
+// WDRMode turns wide dynamic range on or off
+type WDRMode string
+
+// Wide dynamic range modes
+const (
+	WDRModeOn  WDRMode = "ON"
+	WDRModeOff WDRMode = "OFF"
+)
 
 // BacklightCompensation represents backlight compensation
 type BacklightCompensation struct {
-	Mode  string // OFF, ON
-	Level float64
+	Mode  string  `json:"mode"` // OFF, ON
+	Level float64 `json:"level"`
 }
 
 // Exposure represents exposure settings
 type Exposure struct {
-	Mode            string // AUTO, MANUAL
-	Priority        string // LowNoise, FrameRate
-	MinExposureTime float64
-	MaxExposureTime float64
-	MinGain         float64
-	MaxGain         float64
-	MinIris         float64
-	MaxIris         float64
-	ExposureTime    float64
-	Gain            float64
-	Iris            float64
+	Mode            ExposureMode `json:"mode"`
+	Priority        string       `json:"priority"`         // LowNoise, FrameRate
+	Window          *Rectangle   `json:"window,omitempty"` // Region auto exposure meters on
+	MinExposureTime float64      `json:"min_exposure_time"`
+	MaxExposureTime float64      `json:"max_exposure_time"`
+	MinGain         float64      `json:"min_gain"`
+	MaxGain         float64      `json:"max_gain"`
+	MinIris         float64      `json:"min_iris"`
+	MaxIris         float64      `json:"max_iris"`
+	ExposureTime    float64      `json:"exposure_time"`
+	Gain            float64      `json:"gain"`
+	Iris            float64      `json:"iris"`
 }
 
 // FocusConfiguration represents focus configuration
 type FocusConfiguration struct {
-	AutoFocusMode string // AUTO, MANUAL
-	DefaultSpeed  float64
-	NearLimit     float64
-	FarLimit      float64
+	AutoFocusMode string  `json:"auto_focus_mode"` // AUTO, MANUAL
+	DefaultSpeed  float64 `json:"default_speed"`
+	NearLimit     float64 `json:"near_limit"`
+	FarLimit      float64 `json:"far_limit"`
 }
 
 // WideDynamicRange represents WDR settings
 type WideDynamicRange struct {
-	Mode  string // OFF, ON
-	Level float64
+	Mode  WDRMode `json:"mode"`
+	Level float64 `json:"level"`
 }
 
 // WhiteBalance represents white balance settings
 type WhiteBalance struct {
-	Mode   string // AUTO, MANUAL
-	CrGain float64
-	CbGain float64
+	Mode   WhiteBalanceMode `json:"mode"`
+	CrGain float64          `json:"cr_gain"`
+	CbGain float64          `json:"cb_gain"`
 }
 
 // ImagingSettingsExtension represents imaging settings extension
-type ImagingSettingsExtension struct{}
+type ImagingSettingsExtension struct {
+	IrCutFilterAutoAdjustment []IrCutFilterAutoAdjustment `json:"ir_cut_filter_auto_adjustment,omitempty"`
+}
+
+// IR cut filter auto adjustment boundary types
+const (
+	IrCutFilterBoundaryCommon = "Common" // Applies to switching both ways
+	IrCutFilterBoundaryToOn   = "ToOn"   // Switching the filter in, to day mode
+	IrCutFilterBoundaryToOff  = "ToOff"  // Switching the filter out, to night mode
+)
+
+// IrCutFilterAutoAdjustment tunes when an IR cut filter in AUTO mode switches
+type IrCutFilterAutoAdjustment struct {
+	BoundaryType   string        `json:"boundary_type"`             // One of the IrCutFilterBoundary constants
+	BoundaryOffset *float64      `json:"boundary_offset,omitempty"` // -1 (darker) to 1 (brighter) relative to the device default
+	ResponseTime   time.Duration `json:"response_time"`             // Delay before switching, 0 for the device default
+}
 
 // HostnameInformation represents hostname configuration
 type HostnameInformation struct {
-	FromDHCP bool
-	Name     string
+	FromDHCP bool   `json:"from_dhcp"`
+	Name     string `json:"name"`
 }
 
 // DNSInformation represents DNS configuration
 type DNSInformation struct {
-	FromDHCP     bool
-	SearchDomain []string
-	DNSFromDHCP  []IPAddress
-	DNSManual    []IPAddress
+	FromDHCP     bool        `json:"from_dhcp"`
+	SearchDomain []string    `json:"search_domain,omitempty"`
+	DNSFromDHCP  []IPAddress `json:"dns_from_dhcp,omitempty"`
+	DNSManual    []IPAddress `json:"dns_manual,omitempty"`
 }
 
 // NTPInformation represents NTP configuration
 type NTPInformation struct {
-	FromDHCP    bool
-	NTPFromDHCP []NetworkHost
-	NTPManual   []NetworkHost
+	FromDHCP    bool          `json:"from_dhcp"`
+	NTPFromDHCP []NetworkHost `json:"ntp_from_dhcp,omitempty"`
+	NTPManual   []NetworkHost `json:"ntp_manual,omitempty"`
 }
 
 // NetworkHost represents a network host
 type NetworkHost struct {
-	Type        string // IPv4, IPv6, DNS
-	IPv4Address string
-	IPv6Address string
-	DNSname     string
+	Type        string `json:"type"` // IPv4, IPv6, DNS
+	IPv4Address string `json:"ipv4_address"`
+	IPv6Address string `json:"ipv6_address"`
+	DNSname     string `json:"dns_name"`
 }
 
 // NetworkInterface represents a network interface
 type NetworkInterface struct {
-	Token   string
-	Enabled bool
-	Info    NetworkInterfaceInfo
-	IPv4    *IPv4NetworkInterface
-	IPv6    *IPv6NetworkInterface
+	Token   string                `json:"token"`
+	Enabled bool                  `json:"enabled"`
+	Info    NetworkInterfaceInfo  `json:"info"`
+	IPv4    *IPv4NetworkInterface `json:"ipv4,omitempty"`
+	IPv6    *IPv6NetworkInterface `json:"ipv6,omitempty"`
 }
 
 // NetworkInterfaceInfo represents network interface info
 type NetworkInterfaceInfo struct {
-	Name      string
-	HwAddress string
-	MTU       int
+	Name      string `json:"name"`
+	HwAddress string `json:"hw_address"`
+	MTU       int    `json:"mtu"`
 }
 
 // IPv4NetworkInterface represents IPv4 configuration
 type IPv4NetworkInterface struct {
-	Enabled bool
-	Config  IPv4Configuration
+	Enabled bool              `json:"enabled"`
+	Config  IPv4Configuration `json:"config"`
 }
 
 // IPv6NetworkInterface represents IPv6 configuration
 type IPv6NetworkInterface struct {
-	Enabled bool
-	Config  IPv6Configuration
+	Enabled bool              `json:"enabled"`
+	Config  IPv6Configuration `json:"config"`
 }
 
 // IPv4Configuration represents IPv4 configuration
 type IPv4Configuration struct {
-	Manual []PrefixedIPv4Address
-	DHCP   bool
+	Manual []PrefixedIPv4Address `json:"manual,omitempty"`
+	DHCP   bool                  `json:"dhcp"`
 }
 
 // IPv6Configuration represents IPv6 configuration
 type IPv6Configuration struct {
-	Manual []PrefixedIPv6Address
-	DHCP   bool
+	Manual []PrefixedIPv6Address `json:"manual,omitempty"`
+	DHCP   bool                  `json:"dhcp"`
 }
 
 // PrefixedIPv4Address represents an IPv4 address with prefix
 type PrefixedIPv4Address struct {
-	Address      string
-	PrefixLength int
+	Address      string `json:"address"`
+	PrefixLength int    `json:"prefix_length"`
 }
 
 // PrefixedIPv6Address represents an IPv6 address with prefix
 type PrefixedIPv6Address struct {
-	Address      string
-	PrefixLength int
+	Address      string `json:"address"`
+	PrefixLength int    `json:"prefix_length"`
+}
+
+// IPAddressFilter represents the device's IP address filter, which allows or
+// denies access to the listed addresses
+type IPAddressFilter struct {
+	Type        string                `json:"type"` // IPAddressFilterAllow or IPAddressFilterDeny
+	IPv4Address []PrefixedIPv4Address `json:"ipv4_address,omitempty"`
+	IPv6Address []PrefixedIPv6Address `json:"ipv6_address,omitempty"`
 }
 
+// IP address filter types
+const (
+	IPAddressFilterAllow = "Allow" // Only the listed addresses may connect
+	IPAddressFilterDeny  = "Deny"  // The listed addresses may not connect
+)
+
 // Scope represents a device scope
 type Scope struct {
-	ScopeDef  string
-	ScopeItem string
+	ScopeDef  string `json:"scope_def"` // ScopeDefFixed or ScopeDefConfigurable
+	ScopeItem string `json:"scope_item"`
+}
+
+// Scope definitions
+const (
+	ScopeDefFixed        = "Fixed"        // Set by the device, cannot be changed or removed
+	ScopeDefConfigurable = "Configurable" // Can be replaced with SetScopes or removed with RemoveScopes
+)
+
+// IsConfigurable reports whether the scope can be changed or removed
+func (s *Scope) IsConfigurable() bool {
+	return s.ScopeDef == ScopeDefConfigurable
+}
+
+// BackupFile represents a device configuration backup file
+type BackupFile struct {
+	Name string `json:"name"`
+	Data []byte `json:"data,omitempty"`
+}
+
+// FirmwareUpgradeInfo contains the parameters returned by StartFirmwareUpgrade
+type FirmwareUpgradeInfo struct {
+	UploadURI        string        `json:"upload_uri"`
+	UploadDelay      time.Duration `json:"upload_delay"`       // Wait before uploading
+	ExpectedDownTime time.Duration `json:"expected_down_time"` // Expected downtime after the upload
+}
+
+// Certificate represents an NVT certificate
+type Certificate struct {
+	CertificateID string `json:"certificate_id"`
+	Data          []byte `json:"data,omitempty"` // DER encoded certificate
+}
+
+// CertificateStatus represents the enabled state of a certificate
+type CertificateStatus struct {
+	CertificateID string `json:"certificate_id"`
+	Status        bool   `json:"status"`
+}
+
+// Recording represents a recording stored on the device
+type Recording struct {
+	Token                string                      `json:"token"`
+	Source               *RecordingSourceInformation `json:"source,omitempty"`
+	Content              string                      `json:"content"`
+	MaximumRetentionTime string                      `json:"maximum_retention_time"`
+	Tracks               []*RecordingTrack           `json:"tracks,omitempty"`
+}
+
+// RecordingSourceInformation describes the source a recording was made from
+type RecordingSourceInformation struct {
+	SourceID    string `json:"source_id"`
+	Name        string `json:"name"`
+	Location    string `json:"location"`
+	Description string `json:"description"`
+	Address     string `json:"address"`
+}
+
+// RecordingTrack represents a track within a recording
+type RecordingTrack struct {
+	Token       string `json:"token"`
+	TrackType   string `json:"track_type"`
+	Description string `json:"description"`
+}
+
+// RecordingSearchScope limits a recording search
+type RecordingSearchScope struct {
+	IncludedSources            []string `json:"included_sources,omitempty"`
+	IncludedRecordings         []string `json:"included_recordings,omitempty"`
+	RecordingInformationFilter string   `json:"recording_information_filter"`
+}
+
+// RecordingInformation represents a recording found by a search
+type RecordingInformation struct {
+	RecordingToken    string                      `json:"recording_token"`
+	Source            *RecordingSourceInformation `json:"source,omitempty"`
+	EarliestRecording time.Time                   `json:"earliest_recording"`
+	LatestRecording   time.Time                   `json:"latest_recording"`
+	Content           string                      `json:"content"`
+	RecordingStatus   string                      `json:"recording_status"`
+}
+
+// FindRecordingResult represents the results of a recording search
+type FindRecordingResult struct {
+	SearchState          string                  `json:"search_state"`
+	RecordingInformation []*RecordingInformation `json:"recording_information,omitempty"`
+}
+
+// SystemDateAndTime represents the device's clock configuration
+type SystemDateAndTime struct {
+	DateTimeType    string    `json:"date_time_type"` // Manual, NTP
+	DaylightSavings bool      `json:"daylight_savings"`
+	TimeZone        *TimeZone `json:"time_zone,omitempty"`
+	UTCDateTime     time.Time `json:"utc_date_time"`
+	// LocalDateTime is the device's local wall clock as reported; its location is UTC
+	LocalDateTime time.Time `json:"local_date_time"`
+}
+
+// TimeZone represents a device time zone
+type TimeZone struct {
+	TZ string `json:"tz"` // POSIX TZ string, e.g. "CST-8" or "PST8PDT,M3.2.0,M11.1.0"
+}
+
+// GeoLocation represents the geographic location of a device entity
+type GeoLocation struct {
+	Entity    string  `json:"entity"` // e.g. VideoSource, AudioSource; empty for the device itself
+	Token     string  `json:"token"`
+	Fixed     bool    `json:"fixed"`
+	Lon       float64 `json:"lon"`
+	Lat       float64 `json:"lat"`
+	Elevation float64 `json:"elevation"`
 }
 
 // User represents a user account
 type User struct {
-	Username  string
-	Password  string
-	UserLevel string // Administrator, Operator, User
-}
+	Username  string            `json:"username"`
+	Password  string            `json:"password,omitempty"`
+	UserLevel string            `json:"user_level"`          // Administrator, Operator, User, Anonymous, Extended
+	Extension map[string]string `json:"extension,omitempty"` // Extension children by local name, e.g. the detail of an Extended level
+}
+
+// User levels
+const (
+	UserLevelAdministrator = "Administrator"
+	UserLevelOperator      = "Operator"
+	UserLevelUser          = "User"
+	UserLevelAnonymous     = "Anonymous"
+	UserLevelExtended      = "Extended"
+)
+
+// RemoteUser represents the credentials a device uses towards a remote service
+type RemoteUser struct {
+	Username           string `json:"username"`
+	Password           string `json:"password,omitempty"`   // Write-only; never returned by the device
+	UseDerivedPassword bool   `json:"use_derived_password"` // Derive the password sent to the remote service from Password
+}
+
+// StorageConfiguration represents a storage target the device records to,
+// such as a NAS share or an SD card
+type StorageConfiguration struct {
+	Token      string             `json:"token"`
+	Type       string             `json:"type"`                 // NFS, CIFS, CDMI, FTP
+	LocalPath  string             `json:"local_path,omitempty"` // Local path on the device, for local storage
+	StorageURI string             `json:"storage_uri,omitempty"`
+	User       *StorageCredential `json:"user,omitempty"`
+}
+
+// StorageCredential represents the credentials the device uses to access a
+// storage target
+type StorageCredential struct {
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"` // Usually not returned by the device
+}
+
+// Storage types
+const (
+	StorageTypeNFS  = "NFS"
+	StorageTypeCIFS = "CIFS"
+	StorageTypeCDMI = "CDMI"
+	StorageTypeFTP  = "FTP"
+)
 
 // VideoSource represents a video source
 type VideoSource struct {
-	Token      string
-	Framerate  float64
-	Resolution *VideoResolution
-	Imaging    *ImagingSettings
+	Token      string           `json:"token"`
+	Framerate  float64          `json:"framerate"`
+	Resolution *VideoResolution `json:"resolution,omitempty"`
+	Imaging    *ImagingSettings `json:"imaging,omitempty"`
 }
 
 // AudioSource represents an audio source
 type AudioSource struct {
-	Token    string
-	Channels int
+	Token    string `json:"token"`
+	Channels int    `json:"channels"`
 }
 
 // AudioOutput represents an audio output
 type AudioOutput struct {
-	Token string
+	Token string `json:"token"`
+}
+
+// AudioOutputConfiguration represents audio output configuration
+type AudioOutputConfiguration struct {
+	Token       string `json:"token"`
+	Name        string `json:"name"`
+	UseCount    int    `json:"use_count"`
+	OutputToken string `json:"output_token"`
+	SendPrimacy string `json:"send_primacy"`
+	OutputLevel int    `json:"output_level"`
+}
+
+// AudioDecoderConfiguration represents audio decoder configuration
+type AudioDecoderConfiguration struct {
+	Token    string `json:"token"`
+	Name     string `json:"name"`
+	UseCount int    `json:"use_count"`
 }
 
 // ImagingOptions represents available imaging options
 type ImagingOptions struct {
-	BacklightCompensation *BacklightCompensationOptions
-	Brightness            *FloatRange
-	ColorSaturation       *FloatRange
-	Contrast              *FloatRange
-	Exposure              *ExposureOptions
-	Focus                 *FocusOptions
-	IrCutFilterModes      []string
-	Sharpness             *FloatRange
-	WideDynamicRange      *WideDynamicRangeOptions
-	WhiteBalance          *WhiteBalanceOptions
+	BacklightCompensation *BacklightCompensationOptions `json:"backlight_compensation,omitempty"`
+	Brightness            *FloatRange                   `json:"brightness,omitempty"`
+	ColorSaturation       *FloatRange                   `json:"color_saturation,omitempty"`
+	Contrast              *FloatRange                   `json:"contrast,omitempty"`
+	Exposure              *ExposureOptions              `json:"exposure,omitempty"`
+	Focus                 *FocusOptions                 `json:"focus,omitempty"`
+	IrCutFilterModes      []string                      `json:"ir_cut_filter_modes,omitempty"`
+	Sharpness             *FloatRange                   `json:"sharpness,omitempty"`
+	WideDynamicRange      *WideDynamicRangeOptions      `json:"wide_dynamic_range,omitempty"`
+	WhiteBalance          *WhiteBalanceOptions          `json:"white_balance,omitempty"`
 }
 
 // BacklightCompensationOptions represents backlight compensation options
 type BacklightCompensationOptions struct {
-	Mode  []string
-	Level *FloatRange
+	Mode  []string    `json:"mode,omitempty"`
+	Level *FloatRange `json:"level,omitempty"`
 }
 
 // ExposureOptions represents exposure options
 type ExposureOptions struct {
-	Mode            []string
-	Priority        []string
-	MinExposureTime *FloatRange
-	MaxExposureTime *FloatRange
-	MinGain         *FloatRange
-	MaxGain         *FloatRange
-	MinIris         *FloatRange
-	MaxIris         *FloatRange
-	ExposureTime    *FloatRange
-	Gain            *FloatRange
-	Iris            *FloatRange
+	Mode            []string    `json:"mode,omitempty"`
+	Priority        []string    `json:"priority,omitempty"`
+	MinExposureTime *FloatRange `json:"min_exposure_time,omitempty"`
+	MaxExposureTime *FloatRange `json:"max_exposure_time,omitempty"`
+	MinGain         *FloatRange `json:"min_gain,omitempty"`
+	MaxGain         *FloatRange `json:"max_gain,omitempty"`
+	MinIris         *FloatRange `json:"min_iris,omitempty"`
+	MaxIris         *FloatRange `json:"max_iris,omitempty"`
+	ExposureTime    *FloatRange `json:"exposure_time,omitempty"`
+	Gain            *FloatRange `json:"gain,omitempty"`
+	Iris            *FloatRange `json:"iris,omitempty"`
 }
 
 // FocusOptions represents focus options
 type FocusOptions struct {
-	AutoFocusModes []string
-	DefaultSpeed   *FloatRange
-	NearLimit      *FloatRange
-	FarLimit       *FloatRange
+	AutoFocusModes []string    `json:"auto_focus_modes,omitempty"`
+	DefaultSpeed   *FloatRange `json:"default_speed,omitempty"`
+	NearLimit      *FloatRange `json:"near_limit,omitempty"`
+	FarLimit       *FloatRange `json:"far_limit,omitempty"`
 }
 
 // WideDynamicRangeOptions represents WDR options
 type WideDynamicRangeOptions struct {
-	Mode  []string
-	Level *FloatRange
+	Mode  []string    `json:"mode,omitempty"`
+	Level *FloatRange `json:"level,omitempty"`
 }
 
 // WhiteBalanceOptions represents white balance options
 type WhiteBalanceOptions struct {
-	Mode   []string
-	YrGain *FloatRange
-	YbGain *FloatRange
+	Mode   []string    `json:"mode,omitempty"`
+	YrGain *FloatRange `json:"yr_gain,omitempty"`
+	YbGain *FloatRange `json:"yb_gain,omitempty"`
 }
 
 // MoveOptions represents imaging move options
 type MoveOptions struct {
-	Absolute   *AbsoluteFocusOptions
-	Relative   *RelativeFocusOptions
-	Continuous *ContinuousFocusOptions
+	Absolute   *AbsoluteFocusOptions   `json:"absolute,omitempty"`
+	Relative   *RelativeFocusOptions   `json:"relative,omitempty"`
+	Continuous *ContinuousFocusOptions `json:"continuous,omitempty"`
 }
 
 // AbsoluteFocusOptions represents absolute focus options
 type AbsoluteFocusOptions struct {
-	Position FloatRange
-	Speed    FloatRange
+	Position FloatRange `json:"position"`
+	Speed    FloatRange `json:"speed"`
 }
 
 // RelativeFocusOptions represents relative focus options
 type RelativeFocusOptions struct {
-	Distance FloatRange
-	Speed    FloatRange
+	Distance FloatRange `json:"distance"`
+	Speed    FloatRange `json:"speed"`
 }
 
 // ContinuousFocusOptions represents continuous focus options
 type ContinuousFocusOptions struct {
-	Speed FloatRange
+	Speed FloatRange `json:"speed"`
 }
 
 // ImagingStatus represents imaging status
 type ImagingStatus struct {
-	FocusStatus *FocusStatus
+	FocusStatus *FocusStatus `json:"focus_status,omitempty"`
 }
 
 // FocusStatus represents focus status
 type FocusStatus struct {
-	Position   float64
-	MoveStatus string
-	Error      string
+	Position   float64 `json:"position"`
+	MoveStatus string  `json:"move_status"`
+	Error      string  `json:"error"`
+}
+
+// Mask represents a privacy mask drawn over a video source
+type Mask struct {
+	Token              string  `json:"token"`
+	ConfigurationToken string  `json:"configuration_token"` // Video source configuration the mask applies to
+	Polygon            []Point `json:"polygon,omitempty"`   // Normalized coordinates in the range [-1, 1]
+	Type               string  `json:"type"`                // One of the MaskType constants
+	Color              *Color  `json:"color,omitempty"`     // Fill for MaskTypeColor
+	Enabled            bool    `json:"enabled"`
+}
+
+// Point represents a normalized point on the image
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Color represents a color in the given color space
+type Color struct {
+	X          float64 `json:"x"`
+	Y          float64 `json:"y"`
+	Z          float64 `json:"z"`
+	Colorspace string  `json:"colorspace,omitempty"`
 }